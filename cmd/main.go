@@ -2,10 +2,14 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -18,12 +22,26 @@ import (
 	"github.com/popeskul/qna-go/internal/db"
 	"github.com/popeskul/qna-go/internal/db/postgres"
 	"github.com/popeskul/qna-go/internal/hash"
+	"github.com/popeskul/qna-go/internal/idempotency"
+	"github.com/popeskul/qna-go/internal/live"
+	"github.com/popeskul/qna-go/internal/lockout"
 	"github.com/popeskul/qna-go/internal/logger"
+	"github.com/popeskul/qna-go/internal/mail"
+	"github.com/popeskul/qna-go/internal/notify"
+	"github.com/popeskul/qna-go/internal/oidc"
+	"github.com/popeskul/qna-go/internal/password"
 	"github.com/popeskul/qna-go/internal/repository"
+	"github.com/popeskul/qna-go/internal/repository/admin"
+	"github.com/popeskul/qna-go/internal/repository/attempts"
+	idempotencyRepo "github.com/popeskul/qna-go/internal/repository/idempotency"
 	"github.com/popeskul/qna-go/internal/repository/sessions"
 	"github.com/popeskul/qna-go/internal/server"
 	"github.com/popeskul/qna-go/internal/services"
+	"github.com/popeskul/qna-go/internal/services/account"
+	"github.com/popeskul/qna-go/internal/services/exports"
 	"github.com/popeskul/qna-go/internal/token"
+	"github.com/popeskul/qna-go/internal/transport/graphql"
+	"github.com/popeskul/qna-go/internal/transport/grpc"
 	"github.com/popeskul/qna-go/internal/transport/rest"
 
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
@@ -39,6 +57,7 @@ import (
 // @securityDefinitions.apikey ApiKeyAuth
 // @in header
 // @name Authorization
+// @description Type "Bearer" followed by a space and the access token or the API key.
 func main() {
 	log := logger.GetLogger()
 
@@ -51,25 +70,31 @@ func main() {
 		log.Fatal(err)
 	}
 
-	db, err := postgres.NewPostgresConnection(db.ConfigDB{
+	dbConfig := db.ConfigDB{
 		Host:     cfg.DB.Host,
 		Port:     cfg.DB.Port,
 		User:     cfg.DB.User,
 		DBName:   cfg.DB.DBName,
 		Password: cfg.DB.Password,
 		SSLMode:  cfg.DB.SSLMode,
-	})
+	}
+	db, err := postgres.NewPostgresConnection(dbConfig)
 	if err != nil {
 		log.Fatal("Error connecting to database: ", err)
 	}
 	defer db.Close()
 
-	tokenManager, err := token.NewPasetoManager(cfg.TokenSymmetricKey)
+	tokenManager, err := newTokenManager(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	hashManager, err := newHashManager(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	hashManager, err := hash.NewHash(cfg.HashSalt)
+	passwords, err := newPasswordValidator(cfg.Password)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -87,13 +112,68 @@ func main() {
 	}
 	cache := cache.New(d)
 
+	loginGuard, err := newLoginGuard(cfg.Lockout, db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	idempotencyKeys, sweepInterval, err := newIdempotencyKeys(cfg.Idempotency, db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sweepCtx, stopSweep := context.WithCancel(context.Background())
+	defer stopSweep()
+	go idempotencyKeys.Sweep(sweepCtx, sweepInterval, func(err error) {
+		log.Errorf("%s: [IDEMPOTENCY] - sweep: %s", time.Now().Format(time.RFC3339), err)
+	})
+
+	accountConfig, err := newAccountConfig(cfg.Account)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	exportConfig, err := newExportConfig(cfg.Export)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	repo := repository.NewRepository(db)
-	service := services.NewService(repo, tokenManager, hashManager, cache, sessionManager)
-	handlers := rest.NewHandler(service, store, log)
+	service := services.NewService(repo, tokenManager, hashManager, passwords, cache, sessionManager, loginGuard, newOIDCProviders(cfg.OIDC),
+		mail.NewLogSender(log), accountConfig, exportConfig)
+
+	if err = bootstrapAdmin(service.Admin, cfg.Admin.BootstrapEmail, log); err != nil {
+		log.Fatal(err)
+	}
+
+	graphQL, err := graphql.NewExecutor(service, graphql.Config{
+		MaxDepth:      cfg.GraphQL.MaxDepth,
+		MaxComplexity: cfg.GraphQL.MaxComplexity,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	liveConfig, err := newLiveConfig(cfg.Live)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	events, err := notify.NewPostgres(dbConfig.String(), log)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer events.Close()
+
+	handlers := rest.NewHandler(service, store, log, graphQL, live.NewManager(liveConfig), events, idempotencyKeys)
+	router, err := handlers.Init(cfg.Server.TrustedProxies)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	srv := server.NewServer(&http.Server{
 		Addr:           fmt.Sprintf(":%d", cfg.Server.Port),
-		Handler:        handlers.Init(),
+		Handler:        router,
 		ReadTimeout:    10 * time.Second,
 		WriteTimeout:   10 * time.Second,
 		MaxHeaderBytes: 1 << 20,
@@ -105,6 +185,19 @@ func main() {
 
 	log.Println("Starting server on port 8080")
 
+	grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPC.Port))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	grpcServer := grpc.NewServer(service, log)
+
+	go func() {
+		log.Fatal(grpcServer.Serve(grpcListener))
+	}()
+
+	log.Printf("Starting gRPC server on port %d", cfg.GRPC.Port)
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
 	<-quit
@@ -114,6 +207,9 @@ func main() {
 		log.Fatal("Failed to shutdown server: ", err)
 	}
 
+	grpcServer.GracefulStop()
+	stopSweep()
+
 	if err = db.Close(); err != nil {
 		log.Fatal("Failed to close database: ", err)
 	}
@@ -133,12 +229,287 @@ func initConfig() (*config.Config, error) {
 	}
 	cfg.DB.Password = os.Getenv("DB_PASSWORD")
 	cfg.TokenSymmetricKey = os.Getenv("TOKEN_SYMMETRIC_KEY")
+	cfg.Token.PrivateKey = os.Getenv("TOKEN_PRIVATE_KEY")
+	if keys := os.Getenv("TOKEN_VERIFICATION_KEYS"); keys != "" {
+		cfg.Token.VerificationKeys = strings.Split(keys, ",")
+	}
 	cfg.HashSalt = os.Getenv("HASH_SALT")
 	cfg.Session.Secret = os.Getenv("SESSION_SECRET")
+	if email := os.Getenv("ADMIN_BOOTSTRAP_EMAIL"); email != "" {
+		cfg.Admin.BootstrapEmail = email
+	}
+	for name, provider := range cfg.OIDC {
+		provider.ClientSecret = os.Getenv("OIDC_" + strings.ToUpper(name) + "_CLIENT_SECRET")
+		cfg.OIDC[name] = provider
+	}
 
 	return cfg, nil
 }
 
+// bootstrapAdmin assigns the admin role to the user with the email from config while there is no admin.
+func bootstrapAdmin(admins services.Admin, email string, log *logger.Logger) error {
+	if email == "" {
+		return nil
+	}
+
+	err := admins.BootstrapAdmin(context.Background(), email)
+	switch {
+	case err == nil:
+		log.Printf("The admin role is assigned to %s", email)
+	case errors.Is(err, admin.ErrAdminExists):
+	case errors.Is(err, admin.ErrUserNotFound):
+		log.Warnf("The admin bootstrap is skipped: there is no enabled user with the email %s, sign up and restart", email)
+	default:
+		return err
+	}
+
+	return nil
+}
+
+// newHashManager creates the password hash manager with the algorithm from config.
+func newHashManager(cfg *config.Config) (*hash.Manager, error) {
+	var hasher hash.Hasher
+	switch cfg.Hash.Algorithm {
+	case "", "argon2id":
+		params := hash.DefaultArgon2Params
+		if cfg.Hash.Argon2Memory != 0 {
+			params.Memory = cfg.Hash.Argon2Memory
+		}
+		if cfg.Hash.Argon2Iterations != 0 {
+			params.Iterations = cfg.Hash.Argon2Iterations
+		}
+		if cfg.Hash.Argon2Parallelism != 0 {
+			params.Parallelism = cfg.Hash.Argon2Parallelism
+		}
+		hasher = hash.NewArgon2id(params)
+	case "bcrypt":
+		cost := cfg.Hash.BcryptCost
+		if cost == 0 {
+			cost = hash.DefaultBcryptCost
+		}
+		hasher = hash.NewBcrypt(cost)
+	default:
+		return nil, fmt.Errorf("unknown hash algorithm: %q", cfg.Hash.Algorithm)
+	}
+
+	return hash.NewHash(cfg.HashSalt, hash.WithHasher(hasher))
+}
+
+// newPasswordValidator creates the password validator with the policy and the breached passwords from config.
+func newPasswordValidator(cfg config.PasswordPolicy) (*password.Validator, error) {
+	policy := password.Policy{
+		MinLength:          cfg.MinLength,
+		MaxLength:          cfg.MaxLength,
+		RequireUpper:       cfg.RequireUpper,
+		RequireLower:       cfg.RequireLower,
+		RequireDigit:       cfg.RequireDigit,
+		RequireSymbol:      cfg.RequireSymbol,
+		ForbidPersonalInfo: cfg.ForbidPersonalInfo,
+	}
+
+	if cfg.BreachedList == "" {
+		return password.NewValidator(policy, nil), nil
+	}
+
+	breaches, err := password.NewBreachList(cfg.BreachedList)
+	if err != nil {
+		return nil, err
+	}
+
+	return password.NewValidator(policy, breaches), nil
+}
+
+// newAccountConfig creates the account management config with the deletion policy from config.
+func newAccountConfig(cfg config.Account) (account.Config, error) {
+	policy := account.DeletionPolicy(cfg.DeletionPolicy)
+	switch policy {
+	case "", account.DeleteData, account.AnonymizeData:
+	default:
+		return account.Config{}, fmt.Errorf("unknown account deletion policy: %q", cfg.DeletionPolicy)
+	}
+
+	var ttl time.Duration
+	if cfg.EmailChangeTTL != "" {
+		d, err := time.ParseDuration(cfg.EmailChangeTTL)
+		if err != nil {
+			return account.Config{}, err
+		}
+		ttl = d
+	}
+
+	return account.Config{
+		DeletionPolicy:  policy,
+		ConfirmEmailURL: cfg.ConfirmEmailURL,
+		EmailChangeTTL:  ttl,
+	}, nil
+}
+
+// newLiveConfig creates the live sessions config, the settings missing in config are the defaults.
+func newLiveConfig(cfg config.Live) (live.Config, error) {
+	liveConfig := live.Config{
+		MaxPoints:  cfg.MaxPoints,
+		MaxPlayers: cfg.MaxPlayers,
+	}
+
+	if cfg.QuestionTime != "" {
+		d, err := time.ParseDuration(cfg.QuestionTime)
+		if err != nil {
+			return live.Config{}, err
+		}
+		liveConfig.QuestionTime = d
+	}
+
+	return liveConfig, nil
+}
+
+// newExportConfig creates the data exports config, the durations missing in config are the defaults.
+func newExportConfig(cfg config.Export) (exports.Config, error) {
+	durations := make([]time.Duration, 3)
+	for i, s := range []string{cfg.LinkTTL, cfg.Retention, cfg.Timeout} {
+		if s == "" {
+			continue
+		}
+
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return exports.Config{}, err
+		}
+		durations[i] = d
+	}
+
+	return exports.Config{
+		LinkTTL:   durations[0],
+		Retention: durations[1],
+		Timeout:   durations[2],
+		Workers:   cfg.Workers,
+	}, nil
+}
+
+// newTokenManager creates the token manager of the type from config.
+func newTokenManager(cfg *config.Config) (token.Manager, error) {
+	opts := []token.Option{token.WithIssuer(cfg.Token.Issuer), token.WithAudience(cfg.Token.Audience)}
+	if cfg.Token.Leeway != "" {
+		leeway, err := time.ParseDuration(cfg.Token.Leeway)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, token.WithLeeway(leeway))
+	}
+
+	if cfg.Token.Type == "" || cfg.Token.Type == "paseto-local" {
+		return token.NewPasetoManager(cfg.TokenSymmetricKey, opts...)
+	}
+
+	signing, err := token.ParsePrivateKey(cfg.Token.KeyID, cfg.Token.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("token private key: %w", err)
+	}
+
+	verification := make([]token.Key, 0, len(cfg.Token.VerificationKeys))
+	for _, s := range cfg.Token.VerificationKeys {
+		id, encoded, ok := strings.Cut(strings.TrimSpace(s), ":")
+		if !ok {
+			return nil, fmt.Errorf("token verification key %q: %w", s, token.ErrInvalidKey)
+		}
+
+		key, err := token.ParsePublicKey(id, encoded)
+		if err != nil {
+			return nil, fmt.Errorf("token verification key %q: %w", id, err)
+		}
+		verification = append(verification, key)
+	}
+
+	keys, err := token.NewKeyRing(signing, verification...)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Token.Type {
+	case "paseto-public":
+		return token.NewPasetoPublicManager(keys, opts...)
+	case "jwt-ed25519":
+		return token.NewJWTEd25519Maker(keys, opts...)
+	default:
+		return nil, fmt.Errorf("unknown token type: %q", cfg.Token.Type)
+	}
+}
+
+// newOIDCProviders creates the clients of the identity providers from config.
+func newOIDCProviders(cfg map[string]config.OIDCProvider) map[string]*oidc.Provider {
+	providers := make(map[string]*oidc.Provider, len(cfg))
+	for name, p := range cfg {
+		providers[name] = oidc.NewProvider(oidc.Config{
+			IssuerURL:    p.IssuerURL,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+			TrustEmail:   p.TrustEmail,
+		}, nil)
+	}
+
+	return providers
+}
+
+// newLoginGuard creates the sign in brute-force protection with the store from config.
+func newLoginGuard(cfg config.Lockout, db *sql.DB) (*lockout.Guard, error) {
+	var store lockout.Store
+	switch cfg.Store {
+	case "memory":
+		store = lockout.NewMemoryStore()
+	case "postgres":
+		store = attempts.NewRepoAttempts(db)
+	default:
+		return nil, fmt.Errorf("unknown lockout store: %q", cfg.Store)
+	}
+
+	durations := make([]time.Duration, 4)
+	for i, s := range []string{cfg.Window, cfg.BaseDelay, cfg.MaxDelay, cfg.Duration} {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, err
+		}
+		durations[i] = d
+	}
+
+	return lockout.NewGuard(store, lockout.Config{
+		MaxAttempts:   cfg.MaxAttempts,
+		MaxIPAttempts: cfg.MaxIPAttempts,
+		Window:        durations[0],
+		BaseDelay:     durations[1],
+		MaxDelay:      durations[2],
+		Duration:      durations[3],
+	}), nil
+}
+
+// newIdempotencyKeys creates the idempotency keys of the unsafe requests with the store from config
+// and returns the interval of the sweep of the expired keys.
+func newIdempotencyKeys(cfg config.Idempotency, db *sql.DB) (*idempotency.Keys, time.Duration, error) {
+	var store idempotency.Store
+	switch cfg.Store {
+	case "memory":
+		store = idempotency.NewMemoryStore()
+	case "postgres":
+		store = idempotencyRepo.NewRepoIdempotency(db)
+	default:
+		return nil, 0, fmt.Errorf("unknown idempotency store: %q", cfg.Store)
+	}
+
+	ttl, err := time.ParseDuration(cfg.TTL)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sweepInterval := idempotency.DefaultSweepInterval
+	if cfg.SweepInterval != "" {
+		if sweepInterval, err = time.ParseDuration(cfg.SweepInterval); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return idempotency.NewKeys(store, ttl), sweepInterval, nil
+}
+
 // runMigration run the migration for the database.
 func runMigration(cfg *config.Config) error {
 	migrationPath := "file://schema"
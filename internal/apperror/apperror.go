@@ -0,0 +1,130 @@
+// Package apperror defines the errors of the application the transports show to the clients.
+// An error has a stable code the clients can rely on, the HTTP status and a message that is safe to show,
+// the cause is kept for the logs only. The errors of the services and the repositories are mapped
+// to the application errors by the rules of the transport.
+package apperror
+
+import (
+	"errors"
+	"net/http"
+)
+
+// The codes of the generic errors, the rules define the codes of the specific ones.
+const (
+	CodeInvalidRequest = "invalid_request"
+	CodeUnauthorized   = "unauthorized"
+	CodeForbidden      = "forbidden"
+	CodeNotFound       = "not_found"
+	CodeConflict       = "conflict"
+	CodeInternal       = "internal"
+)
+
+var (
+	ErrInvalidRequest = New(CodeInvalidRequest, http.StatusBadRequest, "the request is invalid")
+	ErrUnauthorized   = New(CodeUnauthorized, http.StatusUnauthorized, "authentication is required")
+	ErrForbidden      = New(CodeForbidden, http.StatusForbidden, "you are not allowed to perform this action")
+	ErrNotFound       = New(CodeNotFound, http.StatusNotFound, "the resource is not found")
+	ErrConflict       = New(CodeConflict, http.StatusConflict, "the request conflicts with the current state")
+	ErrInternal       = New(CodeInternal, http.StatusInternalServerError, "internal server error")
+)
+
+// Error is the error of the application.
+type Error struct {
+	Code    string
+	Status  int
+	Message string
+	// Details are the additional members of the response, e.g. the violations of the password policy.
+	Details map[string]interface{}
+	// Err is the cause of the error, it is logged and never shown to the client.
+	Err error
+}
+
+// New creates a new Error without the cause.
+func New(code string, status int, message string) *Error {
+	return &Error{
+		Code:    code,
+		Status:  status,
+		Message: message,
+	}
+}
+
+// Error returns the message and the cause of the error.
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+
+	return e.Message + ": " + e.Err.Error()
+}
+
+// Unwrap returns the cause of the error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an Error with the same code.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of the error caused by err.
+func (e *Error) Wrap(err error) *Error {
+	cp := *e
+	cp.Err = err
+
+	return &cp
+}
+
+// WithDetails returns a copy of the error with the details added to its own.
+func (e *Error) WithDetails(details map[string]interface{}) *Error {
+	cp := *e
+	cp.Details = make(map[string]interface{}, len(e.Details)+len(details))
+	for k, v := range e.Details {
+		cp.Details[k] = v
+	}
+	for k, v := range details {
+		cp.Details[k] = v
+	}
+
+	return &cp
+}
+
+// Invalid returns ErrInvalidRequest caused by the error of parsing the request, its message is shown as the reason.
+func Invalid(err error) *Error {
+	return ErrInvalidRequest.Wrap(err).WithDetails(map[string]interface{}{"reason": err.Error()})
+}
+
+// Rule maps the errors matching Err with errors.Is to the Error with the code and the status.
+// The message of Err is shown unless Message is set, so Err must be a sentinel with a safe message.
+type Rule struct {
+	Err     error
+	Code    string
+	Status  int
+	Message string
+}
+
+// Map returns err if it is an Error, the Error of the first rule err matches caused by err
+// or ErrInternal caused by err.
+func Map(err error, rules []Rule) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	for _, rule := range rules {
+		if !errors.Is(err, rule.Err) {
+			continue
+		}
+
+		message := rule.Message
+		if message == "" {
+			message = rule.Err.Error()
+		}
+
+		return New(rule.Code, rule.Status, message).Wrap(err)
+	}
+
+	return ErrInternal.Wrap(err)
+}
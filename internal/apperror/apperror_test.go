@@ -0,0 +1,97 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+var (
+	errMissing = errors.New("item not found")
+	errTaken   = errors.New("name is taken")
+)
+
+var rules = []Rule{
+	{Err: errMissing, Code: "item_not_found", Status: http.StatusNotFound},
+	{Err: errTaken, Code: "name_taken", Status: http.StatusConflict, Message: "the name is already used"},
+}
+
+func TestMap(t *testing.T) {
+	custom := New("custom", http.StatusTeapot, "custom error")
+
+	tests := []struct {
+		name    string
+		err     error
+		code    string
+		status  int
+		message string
+	}{
+		{name: "sentinel", err: errMissing, code: "item_not_found", status: http.StatusNotFound, message: "item not found"},
+		{name: "wrapped sentinel", err: fmt.Errorf("get item: %w", errMissing), code: "item_not_found", status: http.StatusNotFound, message: "item not found"},
+		{name: "rule message", err: errTaken, code: "name_taken", status: http.StatusConflict, message: "the name is already used"},
+		{name: "application error", err: fmt.Errorf("handle: %w", custom), code: "custom", status: http.StatusTeapot, message: "custom error"},
+		{name: "unknown error", err: errors.New("pq: connection refused"), code: CodeInternal, status: http.StatusInternalServerError, message: "internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Map(tt.err, rules)
+
+			if got.Code != tt.code || got.Status != tt.status || got.Message != tt.message {
+				t.Errorf("Map() = %s %d %q, want %s %d %q", got.Code, got.Status, got.Message, tt.code, tt.status, tt.message)
+			}
+			if !errors.Is(got, tt.err) && !errors.Is(tt.err, got) {
+				t.Errorf("Map() lost the cause %v", tt.err)
+			}
+		})
+	}
+}
+
+func TestError_Is(t *testing.T) {
+	err := fmt.Errorf("handle: %w", ErrNotFound.Wrap(errMissing))
+
+	if !errors.Is(err, ErrNotFound) {
+		t.Error("the wrapped error must match the error with the same code")
+	}
+	if errors.Is(err, ErrConflict) {
+		t.Error("the error must not match the error with another code")
+	}
+	if !errors.Is(err, errMissing) {
+		t.Error("the error must match its cause")
+	}
+}
+
+func TestError_Wrap(t *testing.T) {
+	err := ErrInternal.Wrap(errMissing)
+
+	if ErrInternal.Err != nil {
+		t.Error("Wrap must not change the original error")
+	}
+	if err.Error() != "internal server error: item not found" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+}
+
+func TestError_WithDetails(t *testing.T) {
+	err := ErrInvalidRequest.WithDetails(map[string]interface{}{"field": "title"}).
+		WithDetails(map[string]interface{}{"reason": "required"})
+
+	if len(ErrInvalidRequest.Details) != 0 {
+		t.Error("WithDetails must not change the original error")
+	}
+	if err.Details["field"] != "title" || err.Details["reason"] != "required" {
+		t.Errorf("Details = %v", err.Details)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	err := Invalid(errors.New("invalid character 'b'"))
+
+	if err.Code != CodeInvalidRequest || err.Status != http.StatusBadRequest {
+		t.Errorf("Invalid() = %s %d", err.Code, err.Status)
+	}
+	if err.Details["reason"] != "invalid character 'b'" {
+		t.Errorf("Details = %v", err.Details)
+	}
+}
@@ -14,15 +14,157 @@ type Config struct {
 	DB     Postgres
 	Server struct {
 		Port int `mapstructure:"port"`
+		// TrustedProxies are the addresses or CIDRs of the proxies whose X-Forwarded-For is trusted
+		// for the client IP, none by default.
+		TrustedProxies []string `mapstructure:"trusted_proxies"`
 	} `mapstructure:"server"`
+	// GRPC is the gRPC server, it listens on its own port next to the rest server.
+	GRPC struct {
+		Port int `mapstructure:"port"`
+	} `mapstructure:"grpc"`
 	TokenSymmetricKey string `mapstructure:"token_symmetric_key"`
 	Cache             struct {
 		TTL string `mapstructure:"ttl"`
 	}
-	HashSalt          string `mapstructure:"hash_salt"`
-	Session           struct {
+	// HashSalt is the pepper mixed into the password hashes, it is loaded from HASH_SALT.
+	HashSalt string         `mapstructure:"hash_salt"`
+	Hash     Hash           `mapstructure:"hash"`
+	Password PasswordPolicy `mapstructure:"password"`
+	Session  struct {
 		Secret string `mapstructure:"secret"`
 	} `mapstructure:"session"`
+	Lockout     Lockout     `mapstructure:"lockout"`
+	Idempotency Idempotency `mapstructure:"idempotency"`
+	Account     Account     `mapstructure:"account"`
+	Admin       Admin       `mapstructure:"admin"`
+	Export      Export      `mapstructure:"export"`
+	Token       Token       `mapstructure:"token"`
+	GraphQL     GraphQL     `mapstructure:"graphql"`
+	Live        Live        `mapstructure:"live"`
+	// OIDC are the identity providers for the single sign-on by their names.
+	OIDC map[string]OIDCProvider `mapstructure:"oidc"`
+}
+
+// OIDCProvider represents an OpenID Connect identity provider config.
+// ClientSecret is loaded from OIDC_<NAME>_CLIENT_SECRET environment variable.
+type OIDCProvider struct {
+	IssuerURL    string   `mapstructure:"issuer_url"`
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	RedirectURL  string   `mapstructure:"redirect_url"`
+	Scopes       []string `mapstructure:"scopes"`
+	// TrustEmail links the first sign in to the existing user with the same verified email.
+	TrustEmail bool `mapstructure:"trust_email"`
+}
+
+// Token represents access token config.
+type Token struct {
+	// Type is the format of the token: paseto-local, paseto-public or jwt-ed25519.
+	// paseto-local uses TokenSymmetricKey, the others sign tokens with the Ed25519 PrivateKey.
+	Type  string `mapstructure:"type"`
+	KeyID string `mapstructure:"key_id"`
+	// Issuer and Audience are set in the created tokens and required in the verified ones.
+	Issuer   string `mapstructure:"issuer"`
+	Audience string `mapstructure:"audience"`
+	// Leeway is the allowed clock skew for exp and nbf claims.
+	Leeway string `mapstructure:"leeway"`
+	// PrivateKey is the base64 encoded Ed25519 seed or private key.
+	PrivateKey string
+	// VerificationKeys are the public keys of the previous signing keys in the form "id:base64",
+	// they are kept until the tokens signed by them are expired.
+	VerificationKeys []string `mapstructure:"verification_keys"`
+}
+
+// Hash represents password hashing config.
+type Hash struct {
+	// Algorithm of the new hashes: argon2id or bcrypt. The hashes of the other algorithm
+	// or with other parameters are still verified and replaced on the next sign in.
+	Algorithm  string `mapstructure:"algorithm"`
+	BcryptCost int    `mapstructure:"bcrypt_cost"`
+	// Argon2Memory is the memory of Argon2id in KiB.
+	Argon2Memory      uint32 `mapstructure:"argon2_memory"`
+	Argon2Iterations  uint32 `mapstructure:"argon2_iterations"`
+	Argon2Parallelism uint8  `mapstructure:"argon2_parallelism"`
+}
+
+// PasswordPolicy represents the policy of the passwords chosen on sign up and password change.
+type PasswordPolicy struct {
+	MinLength     int  `mapstructure:"min_length"`
+	MaxLength     int  `mapstructure:"max_length"`
+	RequireUpper  bool `mapstructure:"require_upper"`
+	RequireLower  bool `mapstructure:"require_lower"`
+	RequireDigit  bool `mapstructure:"require_digit"`
+	RequireSymbol bool `mapstructure:"require_symbol"`
+	// ForbidPersonalInfo rejects the passwords containing the name or the email of the user.
+	ForbidPersonalInfo bool `mapstructure:"forbid_personal_info"`
+	// BreachedList is the path to the SHA-1 hashes of breached passwords: a directory of
+	// Pwned Passwords range files or a single file with "HASH:COUNT" lines. Empty disables the check.
+	BreachedList string `mapstructure:"breached_list"`
+}
+
+// Account represents the account management config.
+type Account struct {
+	// DeletionPolicy is what happens with the tests, the passages and the refresh tokens
+	// of the deleted account: delete or anonymize.
+	DeletionPolicy string `mapstructure:"deletion_policy"`
+	// ConfirmEmailURL is the page the email change confirmation link leads to.
+	ConfirmEmailURL string `mapstructure:"confirm_email_url"`
+	EmailChangeTTL  string `mapstructure:"email_change_ttl"`
+}
+
+// Admin represents the user management config.
+type Admin struct {
+	// BootstrapEmail is the email of the user who gets the admin role on start while there is no admin,
+	// it can be overridden by ADMIN_BOOTSTRAP_EMAIL.
+	BootstrapEmail string `mapstructure:"bootstrap_email"`
+}
+
+// GraphQL represents the limits of the GraphQL queries.
+type GraphQL struct {
+	MaxDepth int `mapstructure:"max_depth"`
+	// MaxComplexity is the maximum estimated number of the objects returned for the query.
+	MaxComplexity int `mapstructure:"max_complexity"`
+}
+
+// Live represents the live quiz sessions config.
+type Live struct {
+	// QuestionTime is how long the players can answer the question.
+	QuestionTime string `mapstructure:"question_time"`
+	// MaxPoints is the score of the instant correct answer.
+	MaxPoints  int `mapstructure:"max_points"`
+	MaxPlayers int `mapstructure:"max_players"`
+}
+
+// Export represents the data exports config.
+type Export struct {
+	// LinkTTL is how long the download link of the archive works.
+	LinkTTL string `mapstructure:"link_ttl"`
+	// Retention is how long the archive is kept.
+	Retention string `mapstructure:"retention"`
+	Workers   int    `mapstructure:"workers"`
+	Timeout   string `mapstructure:"timeout"`
+}
+
+// Lockout represents sign in brute-force protection config.
+type Lockout struct {
+	// Store is where the attempts are kept: memory or postgres.
+	Store         string `mapstructure:"store"`
+	MaxAttempts   int    `mapstructure:"max_attempts"`
+	MaxIPAttempts int    `mapstructure:"max_ip_attempts"`
+	Window        string `mapstructure:"window"`
+	BaseDelay     string `mapstructure:"base_delay"`
+	MaxDelay      string `mapstructure:"max_delay"`
+	Duration      string `mapstructure:"duration"`
+}
+
+// Idempotency represents the Idempotency-Key config of the unsafe requests.
+type Idempotency struct {
+	// Store is where the keys and the responses are kept: memory or postgres.
+	Store string `mapstructure:"store"`
+	// TTL is how long the response is replayed to the retries.
+	TTL string `mapstructure:"ttl"`
+	// SweepInterval is how often the expired keys are deleted.
+	SweepInterval string `mapstructure:"sweep_interval"`
 }
 
 // Postgres represents postgres config.
@@ -44,9 +44,11 @@ func TestNew(t *testing.T) {
 					SSLMode:  "disable",
 				},
 				Server: struct {
-					Port int `mapstructure:"port"`
+					Port           int      `mapstructure:"port"`
+					TrustedProxies []string `mapstructure:"trusted_proxies"`
 				}{
-					Port: 8080,
+					Port:           8080,
+					TrustedProxies: []string{},
 				},
 			},
 			error: false,
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditAction is the action of an operator recorded in the audit log.
+type AuditAction string
+
+const (
+	AuditViewUser      AuditAction = "user.view"
+	AuditUpdateRole    AuditAction = "user.update_role"
+	AuditDisableUser   AuditAction = "user.disable"
+	AuditEnableUser    AuditAction = "user.enable"
+	AuditForceLogout   AuditAction = "user.force_logout"
+	AuditResetPassword AuditAction = "user.reset_password"
+	AuditImpersonate   AuditAction = "user.impersonate"
+	AuditDeleteUser    AuditAction = "user.delete"
+	// AuditBootstrapAdmin is recorded without the actor when the first admin is assigned from the config.
+	AuditBootstrapAdmin AuditAction = "user.bootstrap_admin"
+)
+
+// AdminUser describe the user as the operators see it.
+type AdminUser struct {
+	ID             int        `json:"id" db:"id"`
+	Name           string     `json:"name" db:"name"`
+	Email          string     `json:"email" db:"email"`
+	Role           Role       `json:"role" db:"role"`
+	Confirmed      bool       `json:"confirmed" db:"confirmed"`
+	Locale         string     `json:"locale" db:"locale"`
+	Timezone       string     `json:"timezone" db:"timezone"`
+	DisabledAt     *time.Time `json:"disabled_at,omitempty" db:"disabled_at"`
+	DisabledReason string     `json:"disabled_reason,omitempty" db:"disabled_reason"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	// Sessions is the number of the refresh tokens which are not expired.
+	Sessions  int       `json:"sessions" db:"sessions"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SearchUsersRequest is the query of the users search. Query matches the name or the email.
+type SearchUsersRequest struct {
+	Query    string `form:"q"`
+	Role     Role   `form:"role"`
+	Disabled *bool  `form:"disabled"`
+	Limit    int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Offset   int    `form:"offset" binding:"omitempty,min=0"`
+}
+
+// AdminActionRequest is the body of the admin actions which need the reason.
+type AdminActionRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// ForceLogoutResponse is returned when the sessions of the user are wiped.
+type ForceLogoutResponse struct {
+	Sessions int64 `json:"sessions"`
+}
+
+// ResetPasswordResponse is returned once when the password of the user is reset.
+type ResetPasswordResponse struct {
+	TemporaryPassword string `json:"temporary_password"`
+}
+
+// ImpersonationResponse is the access token to act as the user. It has no refresh token.
+type ImpersonationResponse struct {
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// AuditEntry describe an action of an operator.
+type AuditEntry struct {
+	ID           int64           `json:"id" db:"id"`
+	ActorID      *int            `json:"actor_id" db:"actor_id"`
+	Action       AuditAction     `json:"action" db:"action"`
+	TargetUserID *int            `json:"target_user_id" db:"target_user_id"`
+	Details      json.RawMessage `json:"details" db:"details" swaggertype:"object"`
+	IP           string          `json:"ip" db:"ip"`
+	CreatedAt    time.Time       `json:"created_at" db:"created_at"`
+}
+
+// AuditLogRequest is the query of the audit log.
+type AuditLogRequest struct {
+	ActorID      int         `form:"actor_id"`
+	TargetUserID int         `form:"target_user_id"`
+	Action       AuditAction `form:"action"`
+	Limit        int         `form:"limit" binding:"omitempty,min=1,max=100"`
+	Offset       int         `form:"offset" binding:"omitempty,min=0"`
+}
@@ -0,0 +1,224 @@
+package domain
+
+import "errors"
+
+// ErrBatchRolledBack is the error of the operation rolled back because another operation of the atomic batch failed.
+var ErrBatchRolledBack = errors.New("the operation was rolled back because another operation of the batch failed")
+
+// BatchMode selects how the operations of a batch are applied.
+type BatchMode string
+
+const (
+	// BatchAtomic applies all the operations in one transaction, a failed operation rolls back the others.
+	BatchAtomic BatchMode = "atomic"
+	// BatchPartial applies the operations that can be applied and reports the failed ones.
+	BatchPartial BatchMode = "partial"
+)
+
+// MaxBatchSize limits the number of the operations in a batch.
+const MaxBatchSize = 500
+
+// Valid check if the mode is known.
+func (m BatchMode) Valid() bool {
+	return m == BatchAtomic || m == BatchPartial
+}
+
+// TestUpdate replaces the title of the test. Version is required like If-Match, Any updates any version
+// like If-Match: * and leaves Version AnyVersion.
+type TestUpdate struct {
+	ID      int    `json:"id" binding:"required,min=1"`
+	Version int    `json:"version,omitempty" binding:"required_without=Any,excluded_with=Any,omitempty,min=1"`
+	Any     bool   `json:"any,omitempty"`
+	Title   string `json:"title" binding:"required,notblank,min=3,max=255"`
+}
+
+// TestDelete deletes the test. Version is required like If-Match, Any deletes any version
+// like If-Match: * and leaves Version AnyVersion.
+type TestDelete struct {
+	ID      int  `json:"id" binding:"required,min=1"`
+	Version int  `json:"version,omitempty" binding:"required_without=Any,excluded_with=Any,omitempty,min=1"`
+	Any     bool `json:"any,omitempty"`
+}
+
+// TestBatchRequest is the body of the batch of the tests. The tests are created, then updated, then deleted.
+type TestBatchRequest struct {
+	Mode   BatchMode     `json:"mode" binding:"omitempty,valid"`
+	Create []TestRequest `json:"create" binding:"max=500,dive"`
+	Update []TestUpdate  `json:"update" binding:"max=500,dive"`
+	Delete []TestDelete  `json:"delete" binding:"max=500,dive"`
+}
+
+// Batch returns the batch with the operations of the request.
+func (r TestBatchRequest) Batch() TestBatch {
+	batch := TestBatch{
+		Mode:   r.Mode,
+		Create: make([]Test, len(r.Create)),
+		Update: r.Update,
+		Delete: r.Delete,
+	}
+	if batch.Mode == "" {
+		batch.Mode = BatchAtomic
+	}
+
+	for i, request := range r.Create {
+		batch.Create[i] = request.Test()
+	}
+
+	return batch
+}
+
+// TestBatch are the operations on the tests applied at once.
+type TestBatch struct {
+	Mode   BatchMode
+	Create []Test
+	Update []TestUpdate
+	Delete []TestDelete
+}
+
+// Size is the number of the operations.
+func (b TestBatch) Size() int {
+	return len(b.Create) + len(b.Update) + len(b.Delete)
+}
+
+// TestResult is the result of an operation on a test, Err is nil if the operation succeeded.
+// The result of a delete has only the ID of the test.
+type TestResult struct {
+	Test Test
+	Err  error
+}
+
+// TestBatchResult are the results of the operations in the order of the batch.
+type TestBatchResult struct {
+	Create []TestResult
+	Update []TestResult
+	Delete []TestResult
+}
+
+// Failed is the number of the failed operations.
+func (r TestBatchResult) Failed() int {
+	failed := 0
+	for _, results := range [][]TestResult{r.Create, r.Update, r.Delete} {
+		for _, result := range results {
+			if result.Err != nil {
+				failed++
+			}
+		}
+	}
+
+	return failed
+}
+
+// RollBack fails the succeeded operations with ErrBatchRolledBack, only the IDs of the existing tests are kept.
+func (r TestBatchResult) RollBack() {
+	for _, results := range [][]TestResult{r.Create, r.Update, r.Delete} {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i] = TestResult{Test: Test{ID: results[i].Test.ID}, Err: ErrBatchRolledBack}
+			}
+		}
+	}
+	for i := range r.Create {
+		r.Create[i].Test = Test{}
+	}
+}
+
+// QuestionRequest is the body of a question.
+type QuestionRequest struct {
+	Body string `json:"body" binding:"required,notblank,max=10000"`
+}
+
+// QuestionUpdate replaces the body of the question.
+type QuestionUpdate struct {
+	ID   int    `json:"id" binding:"required,min=1"`
+	Body string `json:"body" binding:"required,notblank,max=10000"`
+}
+
+// QuestionDelete deletes the question with its answers.
+type QuestionDelete struct {
+	ID int `json:"id" binding:"required,min=1"`
+}
+
+// QuestionBatchRequest is the body of the batch of the questions of a test.
+// The questions are created, then updated, then deleted.
+type QuestionBatchRequest struct {
+	Mode   BatchMode         `json:"mode" binding:"omitempty,valid"`
+	Create []QuestionRequest `json:"create" binding:"max=500,dive"`
+	Update []QuestionUpdate  `json:"update" binding:"max=500,dive"`
+	Delete []QuestionDelete  `json:"delete" binding:"max=500,dive"`
+}
+
+// Batch returns the batch with the operations of the request on the questions of the test.
+func (r QuestionBatchRequest) Batch(testID int) QuestionBatch {
+	batch := QuestionBatch{
+		TestID: testID,
+		Mode:   r.Mode,
+		Create: make([]Question, len(r.Create)),
+		Update: r.Update,
+		Delete: r.Delete,
+	}
+	if batch.Mode == "" {
+		batch.Mode = BatchAtomic
+	}
+
+	for i, request := range r.Create {
+		batch.Create[i] = Question{TestID: testID, Body: request.Body}
+	}
+
+	return batch
+}
+
+// QuestionBatch are the operations on the questions of a test applied at once.
+type QuestionBatch struct {
+	TestID int
+	Mode   BatchMode
+	Create []Question
+	Update []QuestionUpdate
+	Delete []QuestionDelete
+}
+
+// Size is the number of the operations.
+func (b QuestionBatch) Size() int {
+	return len(b.Create) + len(b.Update) + len(b.Delete)
+}
+
+// QuestionResult is the result of an operation on a question, Err is nil if the operation succeeded.
+// The result of a delete has only the ID of the question.
+type QuestionResult struct {
+	Question Question
+	Err      error
+}
+
+// QuestionBatchResult are the results of the operations in the order of the batch.
+type QuestionBatchResult struct {
+	Create []QuestionResult
+	Update []QuestionResult
+	Delete []QuestionResult
+}
+
+// Failed is the number of the failed operations.
+func (r QuestionBatchResult) Failed() int {
+	failed := 0
+	for _, results := range [][]QuestionResult{r.Create, r.Update, r.Delete} {
+		for _, result := range results {
+			if result.Err != nil {
+				failed++
+			}
+		}
+	}
+
+	return failed
+}
+
+// RollBack fails the succeeded operations with ErrBatchRolledBack, only the IDs of the existing questions are kept.
+func (r QuestionBatchResult) RollBack() {
+	for _, results := range [][]QuestionResult{r.Create, r.Update, r.Delete} {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i] = QuestionResult{Question: Question{ID: results[i].Question.ID}, Err: ErrBatchRolledBack}
+			}
+		}
+	}
+	for i := range r.Create {
+		r.Create[i].Question = Question{}
+	}
+}
@@ -0,0 +1,80 @@
+package domain
+
+import "time"
+
+// ExportStatus describe the progress of the data export.
+type ExportStatus string
+
+const (
+	ExportPending ExportStatus = "pending"
+	ExportRunning ExportStatus = "running"
+	ExportReady   ExportStatus = "ready"
+	ExportFailed  ExportStatus = "failed"
+)
+
+// DataExport describe the archive with all data of the user.
+// DownloadURL is set when the archive is ready, the link stops working at LinkExpiresAt
+// and the archive itself is deleted at ExpiresAt.
+type DataExport struct {
+	ID            int          `json:"id" db:"id"`
+	UserID        int          `json:"-" db:"user_id"`
+	Status        ExportStatus `json:"status" db:"status"`
+	Error         string       `json:"error,omitempty" db:"error"`
+	DownloadURL   string       `json:"download_url,omitempty"`
+	LinkExpiresAt *time.Time   `json:"link_expires_at,omitempty"`
+	ExpiresAt     *time.Time   `json:"expires_at,omitempty" db:"expires_at"`
+	CompletedAt   *time.Time   `json:"completed_at,omitempty" db:"completed_at"`
+	CreatedAt     time.Time    `json:"created_at" db:"created_at"`
+	LinkSecret    []byte       `json:"-" db:"link_secret"`
+}
+
+// UserData is everything stored about the user, every field is a file in the export archive.
+type UserData struct {
+	Profile       Profile
+	Tests         []Test
+	Questions     []Question
+	Answers       []Answer
+	Passages      []TestPassage
+	Memberships   []TestMember
+	Sessions      []ExportedSession
+	Identities    []LinkedIdentity
+	APIKeys       []APIKey
+	OAuthClients  []OAuthClient
+	OAuthConsents []ExportedConsent
+}
+
+// Question describe a question of the test.
+type Question struct {
+	ID        int    `json:"id" db:"id"`
+	TestID    int    `json:"test_id" db:"test_id"`
+	Body      string `json:"body" db:"body"`
+	CreatedAt string `json:"created_at" db:"created_at"`
+	UpdatedAt string `json:"updated_at" db:"updated_at"`
+}
+
+// Answer describe an answer option of the question.
+type Answer struct {
+	ID         int    `json:"id" db:"id"`
+	QuestionID int    `json:"question_id" db:"question_id"`
+	Title      string `json:"title" db:"title"`
+	Correct    bool   `json:"correct" db:"correct"`
+	CreatedAt  string `json:"created_at" db:"created_at"`
+	UpdatedAt  string `json:"updated_at" db:"updated_at"`
+}
+
+// ExportedSession describe a refresh session of the user without the token.
+type ExportedSession struct {
+	ID        int64     `json:"id"`
+	ClientID  string    `json:"client_id,omitempty"`
+	Scopes    []string  `json:"scopes,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ExportedConsent describe the scopes the user granted to an OAuth2 client.
+type ExportedConsent struct {
+	ClientID  string    `json:"client_id"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
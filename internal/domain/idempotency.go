@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// IdempotencyKey is the key the client sent with an unsafe request. The response is kept
+// for the retries with the same key, it is nil while the request is in progress.
+type IdempotencyKey struct {
+	// Scope separates the keys of the users, the keys of the anonymous requests share a scope.
+	Scope string
+	Key   string
+	// Fingerprint is the hash of the request, the retries must send the same request.
+	Fingerprint string
+	Response    *IdempotentResponse
+	ExpiresAt   time.Time
+}
+
+// IdempotentResponse is the response replayed to the retries.
+type IdempotentResponse struct {
+	Status int
+	// Header are the headers describing the body, the created resource and the cookies.
+	Header map[string][]string
+	Body   []byte
+}
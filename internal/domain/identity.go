@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// LinkedIdentity describe the account of a user at an external identity provider.
+type LinkedIdentity struct {
+	ID          int        `json:"id" db:"id"`
+	UserID      int        `json:"user_id" db:"user_id"`
+	Provider    string     `json:"provider" db:"provider"`
+	Subject     string     `json:"subject" db:"subject"`
+	Email       string     `json:"email" db:"email"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty" db:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// OIDCLoginState keeps the authorization request until the identity provider redirects back.
+// UserID is set when the identity is linked to the signed-in user instead of signing in.
+type OIDCLoginState struct {
+	State        string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	UserID       int
+	ExpiresAt    time.Time
+}
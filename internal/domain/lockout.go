@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// LoginAttempts describe failed sign in attempts for an email or an IP address.
+type LoginAttempts struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+}
+
+// LockoutEvent is recorded every time a key is locked out.
+type LockoutEvent struct {
+	Key         string
+	Failures    int
+	LockedUntil time.Time
+	CreatedAt   time.Time
+}
@@ -0,0 +1,39 @@
+package domain
+
+// MemberRole describe what a collaborator can do with a test.
+type MemberRole string
+
+const (
+	MemberEditor   MemberRole = "editor"
+	MemberReviewer MemberRole = "reviewer"
+	MemberViewer   MemberRole = "viewer"
+)
+
+// Valid check if the member role is one of the known roles.
+func (r MemberRole) Valid() bool {
+	switch r {
+	case MemberEditor, MemberReviewer, MemberViewer:
+		return true
+	}
+	return false
+}
+
+// TestMember describe a user invited to collaborate on a test.
+type TestMember struct {
+	TestID    int        `json:"test_id" db:"test_id"`
+	UserID    int        `json:"user_id" db:"user_id"`
+	Role      MemberRole `json:"role" db:"role"`
+	CreatedAt string     `json:"created_at" db:"created_at"`
+	UpdatedAt string     `json:"updated_at" db:"updated_at"`
+}
+
+// SaveMemberRequest is the body of the invite member request, the test is taken from the path.
+type SaveMemberRequest struct {
+	UserID int        `json:"user_id" binding:"required,min=1"`
+	Role   MemberRole `json:"role" binding:"required,valid"`
+}
+
+// Member returns the member of the test with the fields of the request.
+func (r SaveMemberRequest) Member(testID int) TestMember {
+	return TestMember{TestID: testID, UserID: r.UserID, Role: r.Role}
+}
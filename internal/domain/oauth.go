@@ -0,0 +1,111 @@
+package domain
+
+import "time"
+
+// OAuthClient describe a third-party app registered to act on behalf of the users.
+// A confidential client authenticates with its secret, only the hash of the secret is stored.
+type OAuthClient struct {
+	ID           int       `json:"id" db:"id"`
+	ClientID     string    `json:"client_id" db:"client_id"`
+	SecretHash   string    `json:"-" db:"secret_hash"`
+	Name         string    `json:"name" db:"name"`
+	RedirectURIs []string  `json:"redirect_uris" db:"redirect_uris"`
+	Scopes       []string  `json:"scopes" db:"scopes"`
+	OwnerID      int       `json:"owner_id" db:"owner_id"`
+	Confidential bool      `json:"confidential" db:"confidential"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// CreateOAuthClientRequest is the body of the register OAuth2 client request.
+type CreateOAuthClientRequest struct {
+	Name         string   `json:"name" binding:"required,max=255"`
+	RedirectURIs []string `json:"redirect_uris" binding:"required,min=1"`
+	Scopes       []string `json:"scopes" binding:"required,min=1"`
+	Confidential bool     `json:"confidential"`
+}
+
+// RegisteredOAuthClient is returned once when the OAuth2 client is registered.
+type RegisteredOAuthClient struct {
+	OAuthClient
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// OAuthAuthorizationCode is the code issued to the client when the user approves the authorization request.
+type OAuthAuthorizationCode struct {
+	CodeHash      string
+	ClientID      string
+	UserID        int
+	RedirectURI   string
+	Scopes        []string
+	CodeChallenge string
+	ExpiresAt     time.Time
+}
+
+// OAuthConsent is the scopes the user already granted to the client.
+type OAuthConsent struct {
+	UserID   int
+	ClientID string
+	Scopes   []string
+}
+
+// AuthorizationRequest is the authorization request of the client from RFC 6749 with PKCE from RFC 7636.
+type AuthorizationRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type" binding:"required"`
+	ClientID            string `form:"client_id" json:"client_id" binding:"required"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri" binding:"required"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge" binding:"required"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method" binding:"required"`
+}
+
+// ConsentRequest is shown to the user on the consent screen.
+type ConsentRequest struct {
+	Client  OAuthClient          `json:"client"`
+	Scopes  []string             `json:"scopes"`
+	Request AuthorizationRequest `json:"request"`
+	// Granted is true if the user already granted the scopes, the client is redirected without asking.
+	Granted bool `json:"granted"`
+}
+
+// ConsentDecision is the answer of the user on the consent screen.
+type ConsentDecision struct {
+	AuthorizationRequest
+	Approve bool `json:"approve"`
+}
+
+// TokenRequest is the body of the token endpoint request.
+type TokenRequest struct {
+	GrantType    string `form:"grant_type" binding:"required"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	Scope        string `form:"scope"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+// TokenResponse is the successful response of the token endpoint.
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope"`
+}
+
+// Introspection is the response of the token introspection endpoint from RFC 7662.
+// Only Active is returned for tokens that are invalid or expired.
+type Introspection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	UserID    int    `json:"user_id,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	Issuer    string `json:"iss,omitempty"`
+	Audience  string `json:"aud,omitempty"`
+}
@@ -0,0 +1,29 @@
+package domain
+
+// TestPassage describe a result of a user passing a test.
+type TestPassage struct {
+	ID        int    `json:"id" db:"id"`
+	UserID    int    `json:"user_id" db:"user_id"`
+	TestID    int    `json:"test_id" db:"test_id"`
+	Passed    bool   `json:"passed" db:"passed"`
+	CreatedAt string `json:"created_at" db:"created_at"`
+	UpdatedAt string `json:"updated_at" db:"updated_at"`
+}
+
+// The types of the passage events.
+const (
+	// PassageFinished is sent when a user finishes the test.
+	PassageFinished = "finished"
+	// PassageScored is sent when the result of the passage is changed.
+	PassageScored = "scored"
+)
+
+// PassageEvent describe a new or changed result of the test sent to the authors.
+type PassageEvent struct {
+	Type      string `json:"type"`
+	PassageID int    `json:"passage_id"`
+	TestID    int    `json:"test_id"`
+	UserID    int    `json:"user_id"`
+	Passed    bool   `json:"passed"`
+	At        string `json:"at"`
+}
@@ -0,0 +1,50 @@
+package domain
+
+import "time"
+
+// Profile describe the account of the current user without the credentials.
+// PendingEmail is the new email waiting for the confirmation.
+type Profile struct {
+	ID           int       `json:"id" db:"id"`
+	Name         string    `json:"name" db:"name"`
+	Email        string    `json:"email" db:"email"`
+	PendingEmail string    `json:"pending_email,omitempty" db:"pending_email"`
+	AvatarURL    string    `json:"avatar_url" db:"avatar_url"`
+	Locale       string    `json:"locale" db:"locale"`
+	Timezone     string    `json:"timezone" db:"timezone"`
+	Role         Role      `json:"role" db:"role"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// UpdateProfileRequest is the body of the profile update request.
+// Only the fields present in the body are changed, an empty string clears the avatar, the locale and the timezone.
+// The email is changed after the user confirms the new address.
+type UpdateProfileRequest struct {
+	Name      *string `json:"name"`
+	Email     *string `json:"email"`
+	AvatarURL *string `json:"avatar_url"`
+	Locale    *string `json:"locale"`
+	Timezone  *string `json:"timezone"`
+}
+
+// ProfileUpdate is the validated change of the profile fields stored as they are.
+type ProfileUpdate struct {
+	Name      *string
+	AvatarURL *string
+	Locale    *string
+	Timezone  *string
+}
+
+// EmailChange keeps the new email of the user until it is confirmed.
+type EmailChange struct {
+	UserID    int
+	NewEmail  string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+// ConfirmEmailRequest is the query of the email change confirmation link.
+type ConfirmEmailRequest struct {
+	Token string `form:"token" binding:"required"`
+}
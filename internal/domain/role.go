@@ -0,0 +1,28 @@
+package domain
+
+// Role describe the role of a user.
+type Role string
+
+const (
+	RoleAdmin    Role = "admin"
+	RoleAuthor   Role = "author"
+	RoleReviewer Role = "reviewer"
+	RoleLearner  Role = "learner"
+)
+
+// DefaultRole is assigned to every new user.
+const DefaultRole = RoleAuthor
+
+// Valid check if the role is one of the known roles.
+func (r Role) Valid() bool {
+	switch r {
+	case RoleAdmin, RoleAuthor, RoleReviewer, RoleLearner:
+		return true
+	}
+	return false
+}
+
+// UpdateRoleRequest is the body of the assign role request.
+type UpdateRoleRequest struct {
+	Role Role `json:"role" binding:"required,valid"`
+}
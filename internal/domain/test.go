@@ -5,10 +5,35 @@ package domain
 // Test describe test entity.
 type Test struct {
 	ID        int    `json:"id" db:"id"`
-	Title     string `json:"title" db:"title" validate:"required,min=3,max=255"`
+	Title     string `json:"title" db:"title"`
 	AuthorID  int    `json:"author_id" db:"author_id"`
 	CreatedAt string `json:"created_at" db:"created_at"`
 	UpdatedAt string `json:"updated_at" db:"updated_at"`
+	// Version is incremented on every change of the test, the updates of a stale version are rejected.
+	Version int `json:"version" db:"version"`
+}
+
+// AnyVersion skips the version check of the update.
+const AnyVersion = 0
+
+// TestRequest is the body of the create and update test requests.
+type TestRequest struct {
+	Title string `json:"title" binding:"required,notblank,min=3,max=255"`
+}
+
+// Test returns the test with the fields of the request.
+func (r TestRequest) Test() Test {
+	return Test{Title: r.Title}
+}
+
+// TestPatch is the JSON Merge Patch (RFC 7396) of the mutable fields of a test, the nil fields are not changed.
+type TestPatch struct {
+	Title *string `json:"title" binding:"omitempty,notblank,min=3,max=255"`
+}
+
+// Empty check if the patch changes nothing.
+func (p TestPatch) Empty() bool {
+	return p.Title == nil
 }
 
 // ?
@@ -2,9 +2,13 @@ package domain
 
 import "time"
 
+// RefreshSession describe a refresh token. ClientID and Scopes are set for the sessions
+// of the OAuth2 clients, the sessions of the application itself have no client.
 type RefreshSession struct {
 	ID        int64
 	UserID    int64
 	Token     string
+	ClientID  string
+	Scopes    []string
 	ExpiresAt time.Time
 }
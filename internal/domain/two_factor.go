@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+// TOTP describe the TOTP second factor of a user.
+type TOTP struct {
+	UserID       int
+	Secret       string
+	Enabled      bool
+	LastUsedStep int64
+}
+
+// RecoveryCode describe a hashed one-time recovery code of a user.
+type RecoveryCode struct {
+	ID       int
+	UserID   int
+	CodeHash string
+}
+
+// TwoFactorChallenge is issued by sign-in when the user has to confirm it with a TOTP code.
+type TwoFactorChallenge struct {
+	Token     string
+	UserID    int
+	Attempts  int
+	ExpiresAt time.Time
+}
+
+// TwoFactorEnrollment is returned to the user to configure an authenticator app.
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// TwoFactorCodeRequest is the body of the requests confirmed with a TOTP or recovery code.
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorVerifyRequest is the body of the second step of sign-in.
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
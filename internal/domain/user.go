@@ -2,12 +2,60 @@
 // This place define basic auth domain: User.
 package domain
 
+import (
+	"errors"
+	"time"
+)
+
+// ErrUserDisabled is returned when the disabled user tries to get a credential.
+var ErrUserDisabled = errors.New("user is disabled")
+
 // User describe user entity.
 type User struct {
 	ID        int    `json:"id" db:"id"`
-	Name      string `json:"name" db:"name" validate:"required,min=3,max=255"`
-	Email     string `json:"email" db:"email" binding:"required" validate:"required,email,min=3,max=255"`
-	Password  string `json:"password" db:"password" binding:"required" validate:"required,min=6,max=255"`
+	Name      string `json:"name" db:"name"`
+	Email     string `json:"email" db:"email"`
+	Password  string `json:"password" db:"password"`
+	Role      Role   `json:"role" db:"role"`
 	CreatedAt string `json:"created_at" db:"created_at"`
 	UpdatedAt string `json:"updated_at" db:"updated_at"`
+
+	// DisabledAt is set when an operator disabled the user, the disabled user gets no new credentials.
+	DisabledAt *time.Time `json:"-" db:"disabled_at"`
+}
+
+// Disabled check if the user is disabled.
+func (u User) Disabled() bool {
+	return u.DisabledAt != nil
+}
+
+// SignUpRequest is the body of the sign up request.
+// The length and the strength of the password are checked by the password policy.
+type SignUpRequest struct {
+	Name     string `json:"name" binding:"required,notblank,min=3,max=255"`
+	Email    string `json:"email" binding:"required,email,max=255"`
+	Password string `json:"password" binding:"required"`
+}
+
+// User returns the user with the fields of the request.
+func (r SignUpRequest) User() User {
+	return User{Name: r.Name, Email: r.Email, Password: r.Password}
+}
+
+// SignInRequest is the body of the sign in request.
+type SignInRequest struct {
+	Email    string `json:"email" binding:"required,email,max=255"`
+	Password string `json:"password" binding:"required"`
+}
+
+// User returns the user with the credentials of the request.
+func (r SignInRequest) User() User {
+	return User{Email: r.Email, Password: r.Password}
+}
+
+// ChangePasswordRequest is the body of the change password request.
+// CurrentPassword is empty for a user signed up with an identity provider who sets the first password.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password" binding:"required"`
 }
@@ -0,0 +1,58 @@
+package hash
+
+import (
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultBcryptCost is the cost of the bcrypt hashes created before the cost was configurable.
+const DefaultBcryptCost = bcrypt.DefaultCost
+
+// Bcrypt hashes the passwords with bcrypt. The cost is encoded in the hash.
+type Bcrypt struct {
+	cost int
+}
+
+// NewBcrypt creates a new Bcrypt with the cost clamped to the range allowed by bcrypt.
+func NewBcrypt(cost int) *Bcrypt {
+	if cost < bcrypt.MinCost {
+		cost = bcrypt.MinCost
+	}
+	if cost > bcrypt.MaxCost {
+		cost = bcrypt.MaxCost
+	}
+
+	return &Bcrypt{cost: cost}
+}
+
+// Hash returns the bcrypt hash of the password.
+func (b *Bcrypt) Hash(password []byte) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword(password, b.cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hashed), nil
+}
+
+// Verify compares the password with the bcrypt hash.
+func (b *Bcrypt) Verify(password []byte, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), password)
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// Identify check if the hash is a bcrypt hash.
+func (b *Bcrypt) Identify(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// NeedsRehash check if the hash is created with other cost.
+func (b *Bcrypt) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != b.cost
+}
@@ -1,38 +1,128 @@
+// Package hash hashes the passwords and other secrets chosen by the users.
+// The algorithm and its parameters are encoded in the stored hash, so the hashes created
+// with other settings are still verified and can be upgraded on the next sign in.
 package hash
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
-	"golang.org/x/crypto/bcrypt"
 )
 
 var (
-	ErrHash = fmt.Errorf("error hashing")
+	ErrHash        = fmt.Errorf("error hashing")
+	ErrUnknownHash = fmt.Errorf("unknown hash format")
 )
 
+// Hasher is a password hashing algorithm.
+type Hasher interface {
+	// Hash returns the encoded hash of the password with the parameters of the hasher.
+	Hash(password []byte) (string, error)
+	// Verify compares the password with the encoded hash created with any parameters.
+	Verify(password []byte, encoded string) (bool, error)
+	// Identify check if the encoded hash is created by the algorithm of the hasher.
+	Identify(encoded string) bool
+	// NeedsRehash check if the encoded hash is created with other parameters than the hasher has.
+	NeedsRehash(encoded string) bool
+}
+
+// Manager hashes the passwords with the current hasher and verifies the hashes of all known algorithms.
 type Manager struct {
-	salt string
+	pepper  []byte
+	current Hasher
+	known   []Hasher
 }
 
-func NewHash(salt string) (*Manager, error) {
-	if salt == "" {
+// Option configures the Manager.
+type Option func(*Manager)
+
+// WithHasher sets the hasher of the new hashes, Argon2id with the default parameters is used without it.
+func WithHasher(h Hasher) Option {
+	return func(m *Manager) {
+		m.current = h
+	}
+}
+
+// NewHash creates a new Manager. The pepper is mixed into every password with HMAC-SHA256
+// and is kept out of the db, so the stolen hashes can't be cracked without it.
+func NewHash(pepper string, opts ...Option) (*Manager, error) {
+	if pepper == "" {
 		return &Manager{}, ErrHash
 	}
 
-	return &Manager{
-		salt: salt,
-	}, nil
+	m := &Manager{
+		pepper:  []byte(pepper),
+		current: NewArgon2id(DefaultArgon2Params),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	m.known = []Hasher{m.current, NewArgon2id(DefaultArgon2Params), NewBcrypt(DefaultBcryptCost)}
+
+	return m, nil
 }
 
+// HashPassword returns the encoded hash of the peppered password.
 func (h *Manager) HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := h.current.Hash(h.peppered(password))
 	if err != nil {
 		return "", ErrHash
 	}
 
-	return string(hashedPassword), nil
+	return hashedPassword, nil
 }
 
+// CheckPasswordHash check if the password matches the hash.
 func (h *Manager) CheckPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	ok, _ := h.VerifyPassword(password, hash)
+	return ok
+}
+
+// VerifyPassword check if the password matches the hash. If it does, needsRehash tells
+// that the hash is created with an outdated algorithm, parameters or without the pepper,
+// and should be replaced with HashPassword while the password is known.
+func (h *Manager) VerifyPassword(password, hash string) (ok bool, needsRehash bool) {
+	hasher, err := h.identify(hash)
+	if err != nil {
+		return false, false
+	}
+
+	if ok, _ = hasher.Verify(h.peppered(password), hash); ok {
+		return true, h.outdated(hash)
+	}
+
+	// bcrypt hashes created before the pepper was applied are verified with the plain password
+	if _, legacy := hasher.(*Bcrypt); legacy {
+		if ok, _ = hasher.Verify([]byte(password), hash); ok {
+			return true, true
+		}
+	}
+
+	return false, false
+}
+
+// outdated check if the hash isn't created by the current hasher with its parameters.
+func (h *Manager) outdated(hash string) bool {
+	return !h.current.Identify(hash) || h.current.NeedsRehash(hash)
+}
+
+func (h *Manager) identify(hash string) (Hasher, error) {
+	for _, hasher := range h.known {
+		if hasher.Identify(hash) {
+			return hasher, nil
+		}
+	}
+
+	return nil, ErrUnknownHash
+}
+
+// peppered returns the HMAC-SHA256 of the password keyed with the pepper. The base64 encoding
+// keeps the input of bcrypt shorter than its 72 bytes limit for passwords of any length.
+func (h *Manager) peppered(password string) []byte {
+	mac := hmac.New(sha256.New, h.pepper)
+	mac.Write([]byte(password))
+
+	return []byte(base64.StdEncoding.EncodeToString(mac.Sum(nil)))
 }
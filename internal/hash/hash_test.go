@@ -1,8 +1,11 @@
 package hash
 
 import (
-	"github.com/popeskul/qna-go/internal/util"
+	"strings"
 	"testing"
+
+	"github.com/popeskul/qna-go/internal/util"
+	"golang.org/x/crypto/bcrypt"
 )
 
 var (
@@ -35,3 +38,154 @@ func TestManager_HashPassword(t *testing.T) {
 		t.Error("password does not match")
 	}
 }
+
+func TestManager_Hashers(t *testing.T) {
+	fastArgon2 := Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+	tests := []struct {
+		name   string
+		hasher Hasher
+		prefix string
+	}{
+		{name: "argon2id", hasher: NewArgon2id(fastArgon2), prefix: "$argon2id$v=19$m=1024,t=1,p=1$"},
+		{name: "bcrypt", hasher: NewBcrypt(bcrypt.MinCost), prefix: "$2a$04$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, err := NewHash(salt, WithHasher(tt.hasher))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			hashedPassword, err := manager.HashPassword(password)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(hashedPassword, tt.prefix) {
+				t.Errorf("hash %q doesn't encode the parameters %q", hashedPassword, tt.prefix)
+			}
+
+			ok, needsRehash := manager.VerifyPassword(password, hashedPassword)
+			if !ok || needsRehash {
+				t.Errorf("VerifyPassword() = %v, %v, want true, false", ok, needsRehash)
+			}
+
+			if manager.CheckPasswordHash(util.RandomString(10), hashedPassword) {
+				t.Error("wrong password matches")
+			}
+		})
+	}
+}
+
+func TestManager_Pepper(t *testing.T) {
+	manager, err := NewHash(salt, WithHasher(NewBcrypt(bcrypt.MinCost)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hashedPassword, err := manager.HashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := NewHash("other-pepper", WithHasher(NewBcrypt(bcrypt.MinCost)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if other.CheckPasswordHash(password, hashedPassword) {
+		t.Error("hash must not match without the pepper it is created with")
+	}
+}
+
+func TestManager_NeedsRehash(t *testing.T) {
+	fastArgon2 := Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	strongerArgon2 := fastArgon2
+	strongerArgon2.Iterations = 2
+
+	legacy, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hashWith := func(h Hasher) string {
+		m, err := NewHash(salt, WithHasher(h))
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashed, err := m.HashPassword(password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hashed
+	}
+
+	tests := []struct {
+		name        string
+		current     Hasher
+		hash        string
+		needsRehash bool
+	}{
+		{
+			name:        "bcrypt without pepper",
+			current:     NewBcrypt(bcrypt.MinCost),
+			hash:        string(legacy),
+			needsRehash: true,
+		},
+		{
+			name:        "bcrypt to argon2id",
+			current:     NewArgon2id(fastArgon2),
+			hash:        hashWith(NewBcrypt(bcrypt.MinCost)),
+			needsRehash: true,
+		},
+		{
+			name:        "bcrypt cost raised",
+			current:     NewBcrypt(bcrypt.MinCost + 1),
+			hash:        hashWith(NewBcrypt(bcrypt.MinCost)),
+			needsRehash: true,
+		},
+		{
+			name:        "argon2id parameters raised",
+			current:     NewArgon2id(strongerArgon2),
+			hash:        hashWith(NewArgon2id(fastArgon2)),
+			needsRehash: true,
+		},
+		{
+			name:        "up to date",
+			current:     NewArgon2id(fastArgon2),
+			hash:        hashWith(NewArgon2id(fastArgon2)),
+			needsRehash: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, err := NewHash(salt, WithHasher(tt.current))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ok, needsRehash := manager.VerifyPassword(password, tt.hash)
+			if !ok {
+				t.Fatal("password does not match")
+			}
+			if needsRehash != tt.needsRehash {
+				t.Errorf("needsRehash = %v, want %v", needsRehash, tt.needsRehash)
+			}
+		})
+	}
+}
+
+func TestManager_UnknownHash(t *testing.T) {
+	manager, err := NewHash(salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, hash := range []string{"", "plain", "$argon2id$v=19$m=1,t=1$broken"} {
+		if manager.CheckPasswordHash(password, hash) {
+			t.Errorf("password matches %q", hash)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Package idempotency makes the retries of the unsafe requests safe.
+// The first request with a key reserves it, the response is stored with the key
+// and replayed to the retries until the key expires. A retry with another request
+// or while the first one is in progress is rejected.
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+var (
+	ErrKeyInProgress = errors.New("a request with the same idempotency key is in progress")
+	ErrKeyReused     = errors.New("the idempotency key was used with another request")
+)
+
+// DefaultSweepInterval is how often the expired keys are deleted by default.
+const DefaultSweepInterval = 10 * time.Minute
+
+// Store keeps the keys. It is implemented in memory and in Postgres.
+type Store interface {
+	// CreateIdempotencyKey saves the key unless there is an unexpired key with the same scope and key,
+	// the expired one is replaced. It returns the saved or the existing key and whether the key was saved.
+	CreateIdempotencyKey(ctx context.Context, key domain.IdempotencyKey, now time.Time) (domain.IdempotencyKey, bool, error)
+	// CompleteIdempotencyKey stores the response of the key.
+	CompleteIdempotencyKey(ctx context.Context, key domain.IdempotencyKey) error
+	DeleteIdempotencyKey(ctx context.Context, scope, key string) error
+	// DeleteExpiredIdempotencyKeys deletes the keys expired by now.
+	DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) error
+}
+
+// Keys reserves and completes the idempotency keys.
+type Keys struct {
+	store Store
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+// NewKeys creates a new Keys with the store, the keys are kept for ttl.
+func NewKeys(store Store, ttl time.Duration) *Keys {
+	return &Keys{
+		store: store,
+		ttl:   ttl,
+		now:   time.Now,
+	}
+}
+
+// Begin reserves the key for the request with the fingerprint. It returns the completed key
+// when the request was already processed, its response must be replayed, and nil when the
+// request must be processed and then completed or released.
+func (k *Keys) Begin(ctx context.Context, scope, key, fingerprint string) (*domain.IdempotencyKey, error) {
+	now := k.now()
+
+	saved, created, err := k.store.CreateIdempotencyKey(ctx, domain.IdempotencyKey{
+		Scope:       scope,
+		Key:         key,
+		Fingerprint: fingerprint,
+		ExpiresAt:   now.Add(k.ttl),
+	}, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if created {
+		return nil, nil
+	}
+
+	if saved.Fingerprint != fingerprint {
+		return nil, ErrKeyReused
+	}
+
+	if saved.Response == nil {
+		return nil, ErrKeyInProgress
+	}
+
+	return &saved, nil
+}
+
+// Complete stores the response of the request reserved by Begin.
+func (k *Keys) Complete(ctx context.Context, scope, key string, response domain.IdempotentResponse) error {
+	return k.store.CompleteIdempotencyKey(ctx, domain.IdempotencyKey{
+		Scope:    scope,
+		Key:      key,
+		Response: &response,
+	})
+}
+
+// Release forgets the key reserved by Begin, so the request can be retried, e.g. after a failure.
+func (k *Keys) Release(ctx context.Context, scope, key string) error {
+	return k.store.DeleteIdempotencyKey(ctx, scope, key)
+}
+
+// Sweep deletes the expired keys every interval until the context is done, so the requests don't wait for it.
+// The errors are passed to onError and the next sweep tries again.
+func (k *Keys) Sweep(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := k.store.DeleteExpiredIdempotencyKeys(ctx, k.now()); err != nil && ctx.Err() == nil {
+				onError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+func newTestKeys(ttl time.Duration) (*Keys, *time.Time) {
+	k := NewKeys(NewMemoryStore(), ttl)
+
+	now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	k.now = func() time.Time { return now }
+
+	return k, &now
+}
+
+func TestKeys_Begin(t *testing.T) {
+	ctx := context.Background()
+	k, now := newTestKeys(time.Hour)
+	response := domain.IdempotentResponse{Status: 201, Header: map[string][]string{"Location": {"/tests/1"}}, Body: []byte(`{"id":1}`)}
+
+	replay, err := k.Begin(ctx, "user:1", "key", "request")
+	if err != nil || replay != nil {
+		t.Fatalf("Begin() of new key = %v, %v, want nil, nil", replay, err)
+	}
+
+	if _, err = k.Begin(ctx, "user:1", "key", "request"); !errors.Is(err, ErrKeyInProgress) {
+		t.Fatalf("Begin() of key in progress error = %v, want %v", err, ErrKeyInProgress)
+	}
+
+	if err = k.Complete(ctx, "user:1", "key", response); err != nil {
+		t.Fatal(err)
+	}
+
+	replay, err = k.Begin(ctx, "user:1", "key", "request")
+	if err != nil || replay == nil || replay.Response.Status != 201 || string(replay.Response.Body) != `{"id":1}` {
+		t.Fatalf("Begin() of completed key = %+v, %v, want the response", replay, err)
+	}
+
+	if _, err = k.Begin(ctx, "user:1", "key", "other request"); !errors.Is(err, ErrKeyReused) {
+		t.Fatalf("Begin() with other request error = %v, want %v", err, ErrKeyReused)
+	}
+
+	if replay, err = k.Begin(ctx, "user:2", "key", "other request"); err != nil || replay != nil {
+		t.Fatalf("Begin() in other scope = %v, %v, want nil, nil", replay, err)
+	}
+
+	*now = now.Add(time.Hour)
+	if replay, err = k.Begin(ctx, "user:1", "key", "other request"); err != nil || replay != nil {
+		t.Fatalf("Begin() of expired key = %v, %v, want nil, nil", replay, err)
+	}
+}
+
+func TestKeys_Release(t *testing.T) {
+	ctx := context.Background()
+	k, _ := newTestKeys(time.Hour)
+
+	if _, err := k.Begin(ctx, "anonymous", "key", "request"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := k.Release(ctx, "anonymous", "key"); err != nil {
+		t.Fatal(err)
+	}
+
+	if replay, err := k.Begin(ctx, "anonymous", "key", "request"); err != nil || replay != nil {
+		t.Fatalf("Begin() of released key = %v, %v, want nil, nil", replay, err)
+	}
+}
+
+func TestKeys_Sweep(t *testing.T) {
+	store := NewMemoryStore()
+	k := NewKeys(store, time.Millisecond)
+
+	if _, err := k.Begin(context.Background(), "user:1", "key", "request"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		k.Sweep(ctx, time.Millisecond, func(err error) { t.Error(err) })
+		close(done)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		store.mu.Lock()
+		left := len(store.keys)
+		store.mu.Unlock()
+
+		if left == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the expired key is not swept")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,81 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+type memoryKey struct {
+	scope string
+	key   string
+}
+
+// MemoryStore keeps the keys in memory. It is suitable for a single instance.
+type MemoryStore struct {
+	mu   sync.Mutex
+	keys map[memoryKey]domain.IdempotencyKey
+}
+
+// NewMemoryStore creates a new empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		keys: make(map[memoryKey]domain.IdempotencyKey),
+	}
+}
+
+// CreateIdempotencyKey saves the key unless there is an unexpired key with the same scope and key,
+// the expired one is replaced.
+func (s *MemoryStore) CreateIdempotencyKey(_ context.Context, key domain.IdempotencyKey, now time.Time) (domain.IdempotencyKey, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := memoryKey{scope: key.Scope, key: key.Key}
+	if saved, ok := s.keys[id]; ok && saved.ExpiresAt.After(now) {
+		return saved, false, nil
+	}
+
+	s.keys[id] = key
+
+	return key, true, nil
+}
+
+// CompleteIdempotencyKey stores the response of the key, the missing key is ignored.
+func (s *MemoryStore) CompleteIdempotencyKey(_ context.Context, key domain.IdempotencyKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := memoryKey{scope: key.Scope, key: key.Key}
+	if saved, ok := s.keys[id]; ok {
+		saved.Response = key.Response
+		s.keys[id] = saved
+	}
+
+	return nil
+}
+
+// DeleteIdempotencyKey forgets the key.
+func (s *MemoryStore) DeleteIdempotencyKey(_ context.Context, scope, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys, memoryKey{scope: scope, key: key})
+
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys deletes the keys expired by now.
+func (s *MemoryStore) DeleteExpiredIdempotencyKeys(_ context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, saved := range s.keys {
+		if !saved.ExpiresAt.After(now) {
+			delete(s.keys, id)
+		}
+	}
+
+	return nil
+}
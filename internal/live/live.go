@@ -0,0 +1,181 @@
+// Package live runs the live quiz sessions: the host opens a room for a test,
+// the players join it with the PIN, the host advances the questions and the players
+// answer them in real time, the faster correct answers get more points.
+// Every room is run by its own goroutine that owns the state of the room,
+// the clients talk to it through the channels.
+package live
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+var (
+	ErrRoomNotFound    = errors.New("room not found")
+	ErrRoomStarted     = errors.New("the quiz has already started")
+	ErrRoomFull        = errors.New("the room is full")
+	ErrNicknameTaken   = errors.New("the nickname is already taken")
+	ErrInvalidNickname = errors.New("nickname must be from 1 to 32 characters")
+	ErrEmptyQuiz       = errors.New("the test has no questions")
+)
+
+const maxNicknameLength = 32
+
+// DefaultConfig is used for the settings missing in config.
+var DefaultConfig = Config{
+	QuestionTime: 20 * time.Second,
+	MaxPoints:    1000,
+	MaxPlayers:   100,
+}
+
+// Config describe the rules of the rooms.
+type Config struct {
+	// QuestionTime is how long the players can answer the question.
+	QuestionTime time.Duration
+	// MaxPoints is the score of the instant correct answer, the answer at the end of the time gets a half of it.
+	MaxPoints  int
+	MaxPlayers int
+}
+
+// Quiz is the test played in the room.
+type Quiz struct {
+	TestID    int
+	Title     string
+	Questions []Question
+}
+
+// Question is the question of the quiz with its answer options.
+type Question struct {
+	ID      int
+	Body    string
+	Answers []Answer
+}
+
+// Answer is the answer option, Correct is never sent to the players before the question ends.
+type Answer struct {
+	ID      int
+	Title   string
+	Correct bool
+}
+
+// NewQuiz creates the quiz of the test from its questions and answers.
+// Returns ErrEmptyQuiz if the test has no questions.
+func NewQuiz(test domain.Test, questions []domain.Question, answers []domain.Answer) (Quiz, error) {
+	if len(questions) == 0 {
+		return Quiz{}, ErrEmptyQuiz
+	}
+
+	byQuestion := make(map[int][]Answer, len(questions))
+	for _, a := range answers {
+		byQuestion[a.QuestionID] = append(byQuestion[a.QuestionID], Answer{ID: a.ID, Title: a.Title, Correct: a.Correct})
+	}
+
+	quiz := Quiz{TestID: test.ID, Title: test.Title, Questions: make([]Question, 0, len(questions))}
+	for _, q := range questions {
+		quiz.Questions = append(quiz.Questions, Question{ID: q.ID, Body: q.Body, Answers: byQuestion[q.ID]})
+	}
+
+	return quiz, nil
+}
+
+// Manager keeps the open rooms by their PINs.
+type Manager struct {
+	mu    sync.Mutex
+	rooms map[string]*Room
+	cfg   Config
+	now   func() time.Time
+}
+
+// NewManager creates a new Manager, the zero settings of cfg are the defaults.
+func NewManager(cfg Config) *Manager {
+	if cfg.QuestionTime == 0 {
+		cfg.QuestionTime = DefaultConfig.QuestionTime
+	}
+	if cfg.MaxPoints == 0 {
+		cfg.MaxPoints = DefaultConfig.MaxPoints
+	}
+	if cfg.MaxPlayers == 0 {
+		cfg.MaxPlayers = DefaultConfig.MaxPlayers
+	}
+
+	return &Manager{
+		rooms: make(map[string]*Room),
+		cfg:   cfg,
+		now:   time.Now,
+	}
+}
+
+// Open creates the room of the quiz, starts its goroutine and returns the client of the host.
+// The first message of the host is MessageRoom with the PIN of the room.
+func (m *Manager) Open(hostID int, quiz Quiz) (*Client, error) {
+	if len(quiz.Questions) == 0 {
+		return nil, ErrEmptyQuiz
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pin, err := m.newPIN()
+	if err != nil {
+		return nil, err
+	}
+
+	room := newRoom(pin, quiz, m.cfg, m.now, func() { m.remove(pin) })
+	host := newClient(room, hostID, "")
+	room.host = host
+	m.rooms[pin] = room
+
+	host.send <- Message{Type: MessageRoom, Payload: RoomPayload{PIN: pin, Title: quiz.Title}}
+	go room.run()
+
+	return host, nil
+}
+
+// Join adds the player to the room with the PIN while the quiz is not started.
+func (m *Manager) Join(pin string, userID int, nickname string) (*Client, error) {
+	if nickname == "" || len([]rune(nickname)) > maxNicknameLength {
+		return nil, ErrInvalidNickname
+	}
+
+	m.mu.Lock()
+	room, ok := m.rooms[pin]
+	m.mu.Unlock()
+	if !ok {
+		return nil, ErrRoomNotFound
+	}
+
+	client := newClient(room, userID, nickname)
+	if err := room.join(client); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+func (m *Manager) remove(pin string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.rooms, pin)
+}
+
+// newPIN returns the random 6 digit PIN that is not used by the open rooms.
+func (m *Manager) newPIN() (string, error) {
+	for {
+		n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+		if err != nil {
+			return "", err
+		}
+
+		pin := fmt.Sprintf("%06d", n.Int64())
+		if _, ok := m.rooms[pin]; !ok {
+			return pin, nil
+		}
+	}
+}
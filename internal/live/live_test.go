@@ -0,0 +1,310 @@
+package live
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+// clock is the time of the room that the tests move forward.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *clock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func newTestQuiz(t *testing.T) Quiz {
+	t.Helper()
+
+	quiz, err := NewQuiz(domain.Test{ID: 1, Title: "Go basics"},
+		[]domain.Question{{ID: 1, TestID: 1, Body: "2 + 2"}, {ID: 2, TestID: 1, Body: "3 + 3"}},
+		[]domain.Answer{
+			{ID: 1, QuestionID: 1, Title: "4", Correct: true},
+			{ID: 2, QuestionID: 1, Title: "5"},
+			{ID: 3, QuestionID: 2, Title: "6", Correct: true},
+			{ID: 4, QuestionID: 2, Title: "7"},
+		})
+	if err != nil {
+		t.Fatalf("error creating quiz: %v", err)
+	}
+
+	return quiz
+}
+
+// waitFor returns the first message of the type skipping the others.
+func waitFor(t *testing.T, c *Client, messageType string) Message {
+	t.Helper()
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case msg, ok := <-c.Messages():
+			if !ok {
+				t.Fatalf("the messages of %q are closed while waiting for %s", c.Nickname, messageType)
+			}
+			if msg.Type == messageType {
+				return msg
+			}
+		case <-timeout:
+			t.Fatalf("no %s message for %q", messageType, c.Nickname)
+		}
+	}
+}
+
+// waitClosed waits until the messages of the client are closed.
+func waitClosed(t *testing.T, c *Client) {
+	t.Helper()
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-c.Messages():
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("the messages of %q are not closed", c.Nickname)
+		}
+	}
+}
+
+func TestRoom_Game(t *testing.T) {
+	now := &clock{now: time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)}
+	manager := NewManager(Config{QuestionTime: 20 * time.Second, MaxPlayers: 2})
+	manager.now = now.Now
+
+	host, err := manager.Open(1, newTestQuiz(t))
+	if err != nil {
+		t.Fatalf("error opening room: %v", err)
+	}
+	pin := waitFor(t, host, MessageRoom).Payload.(RoomPayload).PIN
+
+	alice, err := manager.Join(pin, 2, "alice")
+	if err != nil {
+		t.Fatalf("error joining room: %v", err)
+	}
+
+	joinErrors := []struct {
+		name     string
+		pin      string
+		nickname string
+		err      error
+	}{
+		{name: "Error: nickname taken", pin: pin, nickname: "ALICE", err: ErrNicknameTaken},
+		{name: "Error: empty nickname", pin: pin, err: ErrInvalidNickname},
+		{name: "Error: unknown PIN", pin: "pin", nickname: "carol", err: ErrRoomNotFound},
+	}
+	for _, tt := range joinErrors {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := manager.Join(tt.pin, 3, tt.nickname); !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+
+	bob, err := manager.Join(pin, 3, "bob")
+	if err != nil {
+		t.Fatalf("error joining room: %v", err)
+	}
+
+	if _, err = manager.Join(pin, 4, "carol"); !errors.Is(err, ErrRoomFull) {
+		t.Errorf("expected %v, got %v", ErrRoomFull, err)
+	}
+
+	t.Run("Error: the player starts the quiz", func(t *testing.T) {
+		bob.Handle(ClientMessage{Type: CommandNext})
+		if msg := waitFor(t, bob, MessageError); msg.Payload.(ErrorPayload).Message != ErrNotHost.Error() {
+			t.Errorf("unexpected error: %v", msg)
+		}
+	})
+
+	host.Handle(ClientMessage{Type: CommandNext})
+	question := waitFor(t, alice, MessageQuestion).Payload.(QuestionPayload)
+	if question.Index != 0 || question.Total != 2 || len(question.Answers) != 2 {
+		t.Fatalf("unexpected question: %v", question)
+	}
+	waitFor(t, bob, MessageQuestion)
+
+	if _, err = manager.Join(pin, 4, "dave"); !errors.Is(err, ErrRoomStarted) {
+		t.Errorf("expected %v, got %v", ErrRoomStarted, err)
+	}
+
+	alice.Handle(ClientMessage{Type: CommandAnswer, AnswerID: 1})
+	waitFor(t, alice, MessageAnswerAccepted)
+
+	t.Run("Error: answer twice", func(t *testing.T) {
+		alice.Handle(ClientMessage{Type: CommandAnswer, AnswerID: 2})
+		if msg := waitFor(t, alice, MessageError); msg.Payload.(ErrorPayload).Message != ErrAlreadyAnswered.Error() {
+			t.Errorf("unexpected error: %v", msg)
+		}
+	})
+
+	t.Run("Error: unknown answer", func(t *testing.T) {
+		bob.Handle(ClientMessage{Type: CommandAnswer, AnswerID: 3})
+		if msg := waitFor(t, bob, MessageError); msg.Payload.(ErrorPayload).Message != ErrUnknownAnswer.Error() {
+			t.Errorf("unexpected error: %v", msg)
+		}
+	})
+
+	now.Add(5 * time.Second)
+	bob.Handle(ClientMessage{Type: CommandAnswer, AnswerID: 2})
+
+	// everybody has answered, the question ends without the host
+	result := waitFor(t, alice, MessageResult).Payload.(ResultPayload)
+	if result.You == nil || !result.You.Correct || result.You.Points != 1000 || result.You.Rank != 1 {
+		t.Errorf("unexpected result of alice: %+v", result.You)
+	}
+	if len(result.CorrectAnswerIDs) != 1 || result.CorrectAnswerIDs[0] != 1 {
+		t.Errorf("unexpected correct answers: %v", result.CorrectAnswerIDs)
+	}
+
+	if result = waitFor(t, bob, MessageResult).Payload.(ResultPayload); result.You.Correct || result.You.Rank != 2 {
+		t.Errorf("unexpected result of bob: %+v", result.You)
+	}
+
+	if result = waitFor(t, host, MessageResult).Payload.(ResultPayload); result.You != nil || len(result.Leaderboard) != 2 {
+		t.Errorf("unexpected result of host: %+v", result)
+	}
+
+	host.Handle(ClientMessage{Type: CommandNext})
+	waitFor(t, alice, MessageQuestion)
+	waitFor(t, bob, MessageQuestion)
+
+	now.Add(20 * time.Second)
+	bob.Handle(ClientMessage{Type: CommandAnswer, AnswerID: 3})
+	waitFor(t, bob, MessageAnswerAccepted)
+
+	// the host ends the question before alice answers
+	host.Handle(ClientMessage{Type: CommandNext})
+	if result = waitFor(t, bob, MessageResult).Payload.(ResultPayload); result.You.Points != 500 {
+		t.Errorf("expected 500 points at the end of the time, got %+v", result.You)
+	}
+
+	host.Handle(ClientMessage{Type: CommandNext})
+	leaderboard := waitFor(t, host, MessageFinished).Payload.(FinishedPayload).Leaderboard
+	expected := []Standing{{Rank: 1, Nickname: "alice", Score: 1000}, {Rank: 2, Nickname: "bob", Score: 500}}
+	if len(leaderboard) != 2 || leaderboard[0] != expected[0] || leaderboard[1] != expected[1] {
+		t.Errorf("expected leaderboard %v, got %v", expected, leaderboard)
+	}
+
+	waitClosed(t, host)
+	waitClosed(t, alice)
+	waitClosed(t, bob)
+
+	if _, err = manager.Join(pin, 4, "carol"); !errors.Is(err, ErrRoomNotFound) {
+		t.Errorf("the finished room is not removed: %v", err)
+	}
+}
+
+func TestRoom_QuestionTimeout(t *testing.T) {
+	manager := NewManager(Config{QuestionTime: 50 * time.Millisecond})
+
+	host, err := manager.Open(1, newTestQuiz(t))
+	if err != nil {
+		t.Fatalf("error opening room: %v", err)
+	}
+	pin := waitFor(t, host, MessageRoom).Payload.(RoomPayload).PIN
+
+	player, err := manager.Join(pin, 2, "alice")
+	if err != nil {
+		t.Fatalf("error joining room: %v", err)
+	}
+
+	host.Handle(ClientMessage{Type: CommandNext})
+	waitFor(t, player, MessageQuestion)
+
+	if result := waitFor(t, player, MessageResult).Payload.(ResultPayload); result.You.Correct || result.You.Score != 0 {
+		t.Errorf("unexpected result without answer: %+v", result.You)
+	}
+
+	player.Handle(ClientMessage{Type: CommandAnswer, AnswerID: 1})
+	if msg := waitFor(t, player, MessageError); msg.Payload.(ErrorPayload).Message != ErrNoQuestion.Error() {
+		t.Errorf("unexpected error: %v", msg)
+	}
+}
+
+func TestRoom_HostLeaves(t *testing.T) {
+	manager := NewManager(Config{})
+
+	host, err := manager.Open(1, newTestQuiz(t))
+	if err != nil {
+		t.Fatalf("error opening room: %v", err)
+	}
+	pin := waitFor(t, host, MessageRoom).Payload.(RoomPayload).PIN
+
+	player, err := manager.Join(pin, 2, "alice")
+	if err != nil {
+		t.Fatalf("error joining room: %v", err)
+	}
+
+	t.Run("Error: start without players", func(t *testing.T) {
+		player.Leave()
+		waitClosed(t, player)
+
+		host.Handle(ClientMessage{Type: CommandNext})
+		if msg := waitFor(t, host, MessageError); msg.Payload.(ErrorPayload).Message != ErrNoPlayers.Error() {
+			t.Errorf("unexpected error: %v", msg)
+		}
+	})
+
+	player, err = manager.Join(pin, 2, "alice")
+	if err != nil {
+		t.Fatalf("error joining room again: %v", err)
+	}
+
+	host.Leave()
+	if msg := waitFor(t, player, MessageClosed); msg.Payload.(ClosedPayload).Reason != hostLeftReason {
+		t.Errorf("unexpected message: %v", msg)
+	}
+	waitClosed(t, player)
+
+	if _, err = manager.Join(pin, 3, "bob"); !errors.Is(err, ErrRoomNotFound) {
+		t.Errorf("the closed room is not removed: %v", err)
+	}
+}
+
+func TestNewQuiz(t *testing.T) {
+	if _, err := NewQuiz(domain.Test{ID: 1}, nil, nil); !errors.Is(err, ErrEmptyQuiz) {
+		t.Errorf("expected %v, got %v", ErrEmptyQuiz, err)
+	}
+
+	if _, err := NewManager(Config{}).Open(1, Quiz{}); !errors.Is(err, ErrEmptyQuiz) {
+		t.Errorf("expected %v, got %v", ErrEmptyQuiz, err)
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		elapsed time.Duration
+		points  int
+	}{
+		{elapsed: 0, points: 1000},
+		{elapsed: 5 * time.Second, points: 875},
+		{elapsed: 10 * time.Second, points: 750},
+		{elapsed: 20 * time.Second, points: 500},
+		{elapsed: time.Minute, points: 500},
+		{elapsed: -time.Second, points: 1000},
+	}
+
+	for _, tt := range tests {
+		if points := score(tt.elapsed, 20*time.Second, 1000); points != tt.points {
+			t.Errorf("score(%v) = %d, expected %d", tt.elapsed, points, tt.points)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package live
+
+import "time"
+
+// The types of the messages sent to the clients.
+const (
+	// MessageRoom is sent to the host when the room is opened and to the player when it joined.
+	MessageRoom = "room"
+	// MessagePlayers is sent to everyone when the players join or leave.
+	MessagePlayers = "players"
+	// MessageQuestion starts the question.
+	MessageQuestion = "question"
+	// MessageAnswerAccepted confirms the answer to the player.
+	MessageAnswerAccepted = "answer_accepted"
+	// MessageAnswers is sent to the host when the players answer.
+	MessageAnswers = "answers"
+	// MessageResult ends the question with the correct answers and the leaderboard.
+	MessageResult = "result"
+	// MessageFinished ends the quiz with the final leaderboard.
+	MessageFinished = "finished"
+	// MessageClosed is sent when the host leaves the room.
+	MessageClosed = "closed"
+	MessageError  = "error"
+)
+
+// The types of the messages sent by the clients.
+const (
+	// CommandNext starts the quiz, ends the current question early or asks the next question. Only for the host.
+	CommandNext = "next"
+	// CommandEnd finishes the quiz. Only for the host.
+	CommandEnd = "end"
+	// CommandAnswer answers the current question. Only for the players.
+	CommandAnswer = "answer"
+)
+
+// Message is sent to the client.
+type Message struct {
+	Type    string      `json:"type"`
+	Payload interface{} `json:"payload,omitempty"`
+}
+
+// ClientMessage is sent by the client.
+type ClientMessage struct {
+	Type     string `json:"type"`
+	AnswerID int    `json:"answer_id,omitempty"`
+}
+
+type RoomPayload struct {
+	PIN      string `json:"pin"`
+	Title    string `json:"title"`
+	Nickname string `json:"nickname,omitempty"`
+}
+
+type PlayersPayload struct {
+	Players []string `json:"players"`
+}
+
+type QuestionPayload struct {
+	Index       int            `json:"index"`
+	Total       int            `json:"total"`
+	Body        string         `json:"body"`
+	Answers     []AnswerOption `json:"answers"`
+	TimeLimitMS int64          `json:"time_limit_ms"`
+	Deadline    time.Time      `json:"deadline"`
+}
+
+// AnswerOption is the answer of the question without the correctness.
+type AnswerOption struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+type AnswerAcceptedPayload struct {
+	AnswerID int `json:"answer_id"`
+}
+
+type AnswersPayload struct {
+	Answered int `json:"answered"`
+	Players  int `json:"players"`
+}
+
+type ResultPayload struct {
+	Index            int        `json:"index"`
+	CorrectAnswerIDs []int      `json:"correct_answer_ids"`
+	Leaderboard      []Standing `json:"leaderboard"`
+	// You is the result of the player, it is not sent to the host.
+	You *PlayerResult `json:"you,omitempty"`
+}
+
+type PlayerResult struct {
+	Correct bool `json:"correct"`
+	Points  int  `json:"points"`
+	Score   int  `json:"score"`
+	Rank    int  `json:"rank"`
+}
+
+type FinishedPayload struct {
+	Leaderboard []Standing `json:"leaderboard"`
+}
+
+type ClosedPayload struct {
+	Reason string `json:"reason"`
+}
+
+type ErrorPayload struct {
+	Message string `json:"message"`
+}
+
+// Standing is the place of the player in the leaderboard, the players with the same score share the rank.
+type Standing struct {
+	Rank     int    `json:"rank"`
+	Nickname string `json:"nickname"`
+	Score    int    `json:"score"`
+}
@@ -0,0 +1,491 @@
+package live
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotHost         = errors.New("only the host can do it")
+	ErrNotPlayer       = errors.New("only the players can answer")
+	ErrNoPlayers       = errors.New("nobody has joined the room yet")
+	ErrNoQuestion      = errors.New("there is no question to answer")
+	ErrAlreadyAnswered = errors.New("the question is already answered")
+	ErrUnknownAnswer   = errors.New("the answer is not an option of the question")
+	ErrUnknownCommand  = errors.New("unknown command")
+)
+
+const (
+	hostLeftReason     = "the host left the room"
+	sendBufferSize     = 32
+	commandsBufferSize = 64
+	// minPointsFactor is the part of MaxPoints for the correct answer at the end of the time.
+	minPointsFactor = 0.5
+)
+
+type state int
+
+const (
+	stateLobby state = iota
+	stateQuestion
+	stateResult
+)
+
+type commandKind int
+
+const (
+	commandJoin commandKind = iota
+	commandLeave
+	commandNext
+	commandEnd
+	commandAnswer
+	commandTimeout
+	commandUnknown
+)
+
+// command is handled by the goroutine of the room.
+type command struct {
+	kind     commandKind
+	client   *Client
+	answerID int
+	index    int
+	at       time.Time
+	reply    chan error
+}
+
+// Client is the host or the player connected to the room.
+type Client struct {
+	room     *Room
+	UserID   int
+	Nickname string
+	send     chan Message
+}
+
+func newClient(room *Room, userID int, nickname string) *Client {
+	return &Client{
+		room:     room,
+		UserID:   userID,
+		Nickname: nickname,
+		send:     make(chan Message, sendBufferSize),
+	}
+}
+
+// Messages returns the messages to the client. The channel is closed when the client
+// leaves the room, when the room is closed or when the client is too slow to read them.
+func (c *Client) Messages() <-chan Message {
+	return c.send
+}
+
+// Handle passes the message of the client to the room, the errors are sent back as MessageError.
+func (c *Client) Handle(msg ClientMessage) {
+	cmd := command{client: c, at: c.room.now()}
+	switch msg.Type {
+	case CommandNext:
+		cmd.kind = commandNext
+	case CommandEnd:
+		cmd.kind = commandEnd
+	case CommandAnswer:
+		cmd.kind = commandAnswer
+		cmd.answerID = msg.AnswerID
+	default:
+		cmd.kind = commandUnknown
+	}
+
+	c.room.post(cmd)
+}
+
+// Leave removes the client from the room, the room is closed when the host leaves.
+func (c *Client) Leave() {
+	c.room.post(command{kind: commandLeave, client: c})
+}
+
+// player is the state of the player in the room.
+type player struct {
+	score    int
+	answered bool
+	correct  bool
+	points   int
+}
+
+// Room is the live session of the quiz. Its state is owned by the run goroutine.
+type Room struct {
+	PIN      string
+	quiz     Quiz
+	cfg      Config
+	now      func() time.Time
+	commands chan command
+	done     chan struct{}
+	onClose  func()
+
+	host      *Client
+	players   map[*Client]*player
+	state     state
+	index     int
+	startedAt time.Time
+	timer     *time.Timer
+}
+
+func newRoom(pin string, quiz Quiz, cfg Config, now func() time.Time, onClose func()) *Room {
+	return &Room{
+		PIN:      pin,
+		quiz:     quiz,
+		cfg:      cfg,
+		now:      now,
+		commands: make(chan command, commandsBufferSize),
+		done:     make(chan struct{}),
+		onClose:  onClose,
+		players:  make(map[*Client]*player),
+		index:    -1,
+	}
+}
+
+// post passes the command to the room and reports false if the room is closed.
+func (r *Room) post(cmd command) bool {
+	select {
+	case r.commands <- cmd:
+		return true
+	case <-r.done:
+		return false
+	}
+}
+
+// join adds the player to the room and returns the error of the room.
+func (r *Room) join(c *Client) error {
+	reply := make(chan error, 1)
+	if !r.post(command{kind: commandJoin, client: c, reply: reply}) {
+		return ErrRoomNotFound
+	}
+
+	select {
+	case err := <-reply:
+		return err
+	case <-r.done:
+		return ErrRoomNotFound
+	}
+}
+
+func (r *Room) run() {
+	defer r.close()
+
+	for cmd := range r.commands {
+		if !r.handle(cmd) {
+			return
+		}
+	}
+}
+
+// handle handles the command and reports false if the room must be closed.
+func (r *Room) handle(cmd command) bool {
+	var err error
+	switch cmd.kind {
+	case commandJoin:
+		err = r.handleJoin(cmd.client)
+		cmd.reply <- err
+		return true
+	case commandLeave:
+		if cmd.client == r.host {
+			r.broadcast(Message{Type: MessageClosed, Payload: ClosedPayload{Reason: hostLeftReason}})
+			return false
+		}
+		r.removePlayer(cmd.client)
+		return true
+	case commandNext:
+		if cmd.client != r.host {
+			err = ErrNotHost
+			break
+		}
+		return r.next(cmd.client)
+	case commandEnd:
+		if cmd.client != r.host {
+			err = ErrNotHost
+			break
+		}
+		r.finish()
+		return false
+	case commandAnswer:
+		err = r.answer(cmd.client, cmd.answerID, cmd.at)
+	case commandTimeout:
+		if r.state == stateQuestion && cmd.index == r.index {
+			r.reveal()
+		}
+	default:
+		err = ErrUnknownCommand
+	}
+
+	if err != nil {
+		r.sendTo(cmd.client, Message{Type: MessageError, Payload: ErrorPayload{Message: err.Error()}})
+	}
+
+	return true
+}
+
+func (r *Room) handleJoin(c *Client) error {
+	if r.state != stateLobby {
+		return ErrRoomStarted
+	}
+
+	if len(r.players) >= r.cfg.MaxPlayers {
+		return ErrRoomFull
+	}
+
+	for other := range r.players {
+		if strings.EqualFold(other.Nickname, c.Nickname) {
+			return ErrNicknameTaken
+		}
+	}
+
+	r.players[c] = &player{}
+	r.sendTo(c, Message{Type: MessageRoom, Payload: RoomPayload{PIN: r.PIN, Title: r.quiz.Title, Nickname: c.Nickname}})
+	r.broadcastPlayers()
+
+	return nil
+}
+
+func (r *Room) removePlayer(c *Client) {
+	if _, ok := r.players[c]; !ok {
+		return
+	}
+
+	delete(r.players, c)
+	close(c.send)
+	r.broadcastPlayers()
+
+	if r.state == stateQuestion && len(r.players) > 0 && r.answered() == len(r.players) {
+		r.reveal()
+	}
+}
+
+// next starts the quiz, ends the current question or asks the next question.
+// Reports false when the last question is over and the room must be closed.
+func (r *Room) next(host *Client) bool {
+	switch r.state {
+	case stateLobby:
+		if len(r.players) == 0 {
+			r.sendTo(host, Message{Type: MessageError, Payload: ErrorPayload{Message: ErrNoPlayers.Error()}})
+			return true
+		}
+		r.ask(0)
+	case stateQuestion:
+		r.reveal()
+	case stateResult:
+		if r.index == len(r.quiz.Questions)-1 {
+			r.finish()
+			return false
+		}
+		r.ask(r.index + 1)
+	}
+
+	return true
+}
+
+// ask starts the question and the timer of the question.
+func (r *Room) ask(index int) {
+	r.state = stateQuestion
+	r.index = index
+	r.startedAt = r.now()
+
+	for _, p := range r.players {
+		p.answered, p.correct, p.points = false, false, 0
+	}
+
+	r.timer = time.AfterFunc(r.cfg.QuestionTime, func() {
+		r.post(command{kind: commandTimeout, index: index})
+	})
+
+	question := r.quiz.Questions[index]
+	options := make([]AnswerOption, 0, len(question.Answers))
+	for _, a := range question.Answers {
+		options = append(options, AnswerOption{ID: a.ID, Title: a.Title})
+	}
+
+	r.broadcast(Message{Type: MessageQuestion, Payload: QuestionPayload{
+		Index:       index,
+		Total:       len(r.quiz.Questions),
+		Body:        question.Body,
+		Answers:     options,
+		TimeLimitMS: r.cfg.QuestionTime.Milliseconds(),
+		Deadline:    r.startedAt.Add(r.cfg.QuestionTime),
+	}})
+}
+
+// answer records the answer of the player, the question ends when everybody has answered.
+func (r *Room) answer(c *Client, answerID int, at time.Time) error {
+	p, ok := r.players[c]
+	if !ok {
+		return ErrNotPlayer
+	}
+
+	if r.state != stateQuestion {
+		return ErrNoQuestion
+	}
+
+	if p.answered {
+		return ErrAlreadyAnswered
+	}
+
+	var option *Answer
+	for i, a := range r.quiz.Questions[r.index].Answers {
+		if a.ID == answerID {
+			option = &r.quiz.Questions[r.index].Answers[i]
+			break
+		}
+	}
+	if option == nil {
+		return ErrUnknownAnswer
+	}
+
+	p.answered = true
+	if option.Correct {
+		p.correct = true
+		p.points = score(at.Sub(r.startedAt), r.cfg.QuestionTime, r.cfg.MaxPoints)
+		p.score += p.points
+	}
+
+	r.sendTo(c, Message{Type: MessageAnswerAccepted, Payload: AnswerAcceptedPayload{AnswerID: answerID}})
+
+	answered := r.answered()
+	r.sendTo(r.host, Message{Type: MessageAnswers, Payload: AnswersPayload{Answered: answered, Players: len(r.players)}})
+
+	if answered == len(r.players) {
+		r.reveal()
+	}
+
+	return nil
+}
+
+// answered returns the number of the players who answered the current question.
+func (r *Room) answered() int {
+	answered := 0
+	for _, p := range r.players {
+		if p.answered {
+			answered++
+		}
+	}
+
+	return answered
+}
+
+// reveal ends the question and sends the correct answers and the leaderboard, every player gets its own result.
+func (r *Room) reveal() {
+	r.timer.Stop()
+	r.state = stateResult
+
+	correct := make([]int, 0)
+	for _, a := range r.quiz.Questions[r.index].Answers {
+		if a.Correct {
+			correct = append(correct, a.ID)
+		}
+	}
+
+	leaderboard := r.leaderboard()
+	result := ResultPayload{Index: r.index, CorrectAnswerIDs: correct, Leaderboard: leaderboard}
+	r.sendTo(r.host, Message{Type: MessageResult, Payload: result})
+
+	for c, p := range r.players {
+		personal := result
+		personal.You = &PlayerResult{Correct: p.correct, Points: p.points, Score: p.score, Rank: rankOf(leaderboard, c.Nickname)}
+		r.sendTo(c, Message{Type: MessageResult, Payload: personal})
+	}
+}
+
+func (r *Room) finish() {
+	r.broadcast(Message{Type: MessageFinished, Payload: FinishedPayload{Leaderboard: r.leaderboard()}})
+}
+
+// leaderboard returns the players by their scores.
+func (r *Room) leaderboard() []Standing {
+	standings := make([]Standing, 0, len(r.players))
+	for c, p := range r.players {
+		standings = append(standings, Standing{Nickname: c.Nickname, Score: p.score})
+	}
+
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Score != standings[j].Score {
+			return standings[i].Score > standings[j].Score
+		}
+		return standings[i].Nickname < standings[j].Nickname
+	})
+
+	for i := range standings {
+		standings[i].Rank = i + 1
+		if i > 0 && standings[i].Score == standings[i-1].Score {
+			standings[i].Rank = standings[i-1].Rank
+		}
+	}
+
+	return standings
+}
+
+func (r *Room) broadcastPlayers() {
+	nicknames := make([]string, 0, len(r.players))
+	for c := range r.players {
+		nicknames = append(nicknames, c.Nickname)
+	}
+	sort.Strings(nicknames)
+
+	r.broadcast(Message{Type: MessagePlayers, Payload: PlayersPayload{Players: nicknames}})
+}
+
+// broadcast sends the message to the host and all the players.
+func (r *Room) broadcast(msg Message) {
+	r.sendTo(r.host, msg)
+	for c := range r.players {
+		r.sendTo(c, msg)
+	}
+}
+
+// sendTo sends the message without blocking the room. The player that is too slow to read
+// the messages is removed from the room, the messages to the slow host are dropped.
+func (r *Room) sendTo(c *Client, msg Message) {
+	select {
+	case c.send <- msg:
+	default:
+		if c != r.host {
+			delete(r.players, c)
+			close(c.send)
+		}
+	}
+}
+
+// close stops the room, removes it from the manager and closes the channels of the clients.
+func (r *Room) close() {
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+
+	close(r.done)
+	r.onClose()
+
+	close(r.host.send)
+	for c := range r.players {
+		close(c.send)
+	}
+}
+
+// score returns the points for the correct answer given after elapsed:
+// MaxPoints for the instant answer down to the half of them at the end of the time.
+func score(elapsed, limit time.Duration, maxPoints int) int {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	if elapsed > limit {
+		elapsed = limit
+	}
+
+	factor := 1 - (1-minPointsFactor)*elapsed.Seconds()/limit.Seconds()
+
+	return int(math.Round(float64(maxPoints) * factor))
+}
+
+func rankOf(leaderboard []Standing, nickname string) int {
+	for _, s := range leaderboard {
+		if s.Nickname == nickname {
+			return s.Rank
+		}
+	}
+
+	return 0
+}
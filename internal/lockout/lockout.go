@@ -0,0 +1,199 @@
+// Package lockout protects sign in from brute-force attacks.
+// It counts failed attempts per email and per IP address, slows down
+// the next attempts with exponential backoff and temporarily locks
+// the key out when the threshold is reached.
+package lockout
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+var (
+	ErrTooManyAttempts = errors.New("too many sign in attempts, try again later")
+)
+
+// RetryError is returned when the attempt is rejected. It wraps ErrTooManyAttempts.
+type RetryError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RetryError) Error() string {
+	return ErrTooManyAttempts.Error()
+}
+
+func (e *RetryError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+// Store keeps the failed attempts. It is implemented in memory and in Postgres.
+// The failures are counted atomically, so the concurrent attempts don't lose each other's failures.
+type Store interface {
+	// GetLoginAttempts returns the attempts of the key or empty attempts if there are none.
+	GetLoginAttempts(ctx context.Context, key string) (domain.LoginAttempts, error)
+	// AddLoginFailure counts the failure at now and returns the attempts with it. The failures
+	// before since are forgotten, the count starts again.
+	AddLoginFailure(ctx context.Context, key string, now, since time.Time) (domain.LoginAttempts, error)
+	// LockLoginAttempts locks the key out until lockedUntil and forgets its failures.
+	LockLoginAttempts(ctx context.Context, key string, lockedUntil time.Time) error
+	DeleteLoginAttempts(ctx context.Context, key string) error
+	CreateLockoutEvent(ctx context.Context, event domain.LockoutEvent) error
+}
+
+// Config describe the limits of the Guard.
+type Config struct {
+	// MaxAttempts is the number of failures per email before it is locked out.
+	MaxAttempts int
+	// MaxIPAttempts is the number of failures per IP address before it is locked out.
+	MaxIPAttempts int
+	// Window is the time after the last failure when the failures are forgotten.
+	Window time.Duration
+	// BaseDelay is the delay after the first failure, it doubles with every next failure.
+	// Zero disables the backoff.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff delay.
+	MaxDelay time.Duration
+	// Duration is how long the key is locked out.
+	Duration time.Duration
+}
+
+// Guard checks and records sign in attempts.
+type Guard struct {
+	store Store
+	cfg   Config
+	now   func() time.Time
+}
+
+// NewGuard creates a new Guard with the store and limits.
+func NewGuard(store Store, cfg Config) *Guard {
+	return &Guard{
+		store: store,
+		cfg:   cfg,
+		now:   time.Now,
+	}
+}
+
+type clientIPKey struct{}
+
+// WithClientIP returns a copy of ctx with the IP address of the client.
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIP returns the IP address of the client stored by WithClientIP.
+func ClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+// Check returns *RetryError if the email or the IP address is locked out or has to wait for the backoff.
+// The same error is returned for existing and unknown emails.
+func (g *Guard) Check(ctx context.Context, email, ip string) error {
+	var wait time.Duration
+
+	for _, key := range g.keys(email, ip) {
+		attempts, err := g.store.GetLoginAttempts(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		if d := g.retryAfter(attempts); d > wait {
+			wait = d
+		}
+	}
+
+	if wait > 0 {
+		return &RetryError{RetryAfter: wait}
+	}
+
+	return nil
+}
+
+// Fail records a failed attempt for the email and the IP address and locks them out when the limit is reached.
+// The lockout is decided by the count returned by the store, the concurrent failures are all counted.
+func (g *Guard) Fail(ctx context.Context, email, ip string) error {
+	now := g.now()
+
+	for _, key := range g.keys(email, ip) {
+		attempts, err := g.store.AddLoginFailure(ctx, key, now, now.Add(-g.cfg.Window))
+		if err != nil {
+			return err
+		}
+
+		if limit := g.limit(key); limit > 0 && attempts.Failures >= limit {
+			lockedUntil := now.Add(g.cfg.Duration)
+
+			if err = g.store.CreateLockoutEvent(ctx, domain.LockoutEvent{
+				Key:         key,
+				Failures:    attempts.Failures,
+				LockedUntil: lockedUntil,
+				CreatedAt:   now,
+			}); err != nil {
+				return err
+			}
+
+			if err = g.store.LockLoginAttempts(ctx, key, lockedUntil); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Succeed forgets the failures of the email. The failures of the IP address are kept
+// so a valid account can't be used to reset the counter of an attacker.
+func (g *Guard) Succeed(ctx context.Context, email string) error {
+	return g.store.DeleteLoginAttempts(ctx, emailKey(email))
+}
+
+func (g *Guard) retryAfter(attempts domain.LoginAttempts) time.Duration {
+	now := g.now()
+
+	if attempts.LockedUntil.After(now) {
+		return attempts.LockedUntil.Sub(now)
+	}
+
+	if attempts.Failures == 0 || g.cfg.BaseDelay <= 0 {
+		return 0
+	}
+
+	delay := g.cfg.BaseDelay << (attempts.Failures - 1)
+	if delay <= 0 || (g.cfg.MaxDelay > 0 && delay > g.cfg.MaxDelay) {
+		delay = g.cfg.MaxDelay
+	}
+
+	if next := attempts.LastFailureAt.Add(delay); next.After(now) {
+		return next.Sub(now)
+	}
+
+	return 0
+}
+
+func (g *Guard) limit(key string) int {
+	if strings.HasPrefix(key, ipPrefix) {
+		return g.cfg.MaxIPAttempts
+	}
+	return g.cfg.MaxAttempts
+}
+
+func (g *Guard) keys(email, ip string) []string {
+	keys := []string{emailKey(email)}
+	if ip != "" {
+		keys = append(keys, ipPrefix+ip)
+	}
+	return keys
+}
+
+const (
+	emailPrefix = "email:"
+	ipPrefix    = "ip:"
+)
+
+func emailKey(email string) string {
+	return emailPrefix + strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,178 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestGuard(cfg Config) (*Guard, *MemoryStore, *time.Time) {
+	store := NewMemoryStore()
+	g := NewGuard(store, cfg)
+
+	now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	g.now = func() time.Time { return now }
+
+	return g, store, &now
+}
+
+func TestGuard_Lockout(t *testing.T) {
+	ctx := context.Background()
+	g, store, now := newTestGuard(Config{
+		MaxAttempts:   3,
+		MaxIPAttempts: 10,
+		Window:        time.Hour,
+		Duration:      15 * time.Minute,
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := g.Fail(ctx, "User@gmail.com", "10.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+		if err := g.Check(ctx, "user@gmail.com", "10.0.0.1"); err != nil {
+			t.Fatalf("Check() after %d failures error = %v", i+1, err)
+		}
+	}
+
+	if err := g.Fail(ctx, "user@gmail.com", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := g.Check(ctx, "user@gmail.com", "10.0.0.2")
+	var retry *RetryError
+	if !errors.As(err, &retry) || !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("Check() error = %v, want %v", err, ErrTooManyAttempts)
+	}
+	if retry.RetryAfter != 15*time.Minute {
+		t.Errorf("RetryAfter = %v, want %v", retry.RetryAfter, 15*time.Minute)
+	}
+
+	if err = g.Check(ctx, "other@gmail.com", "10.0.0.1"); err != nil {
+		t.Errorf("Check() for other email error = %v", err)
+	}
+
+	if events := store.LockoutEvents(); len(events) != 1 || events[0].Key != "email:user@gmail.com" {
+		t.Errorf("unexpected lockout events: %v", events)
+	}
+
+	*now = now.Add(16 * time.Minute)
+	if err = g.Check(ctx, "user@gmail.com", "10.0.0.1"); err != nil {
+		t.Errorf("Check() after lockout error = %v", err)
+	}
+}
+
+func TestGuard_ConcurrentFailures(t *testing.T) {
+	ctx := context.Background()
+	g, store, _ := newTestGuard(Config{
+		MaxAttempts:   10,
+		MaxIPAttempts: 100,
+		Window:        time.Hour,
+		Duration:      15 * time.Minute,
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := g.Fail(ctx, "user@gmail.com", "10.0.0.1"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := g.Check(ctx, "user@gmail.com", "10.0.0.2"); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("Check() error = %v, want %v", err, ErrTooManyAttempts)
+	}
+	if events := store.LockoutEvents(); len(events) != 1 || events[0].Failures != 10 {
+		t.Errorf("unexpected lockout events: %v", events)
+	}
+}
+
+func TestGuard_IPLockout(t *testing.T) {
+	ctx := context.Background()
+	g, _, _ := newTestGuard(Config{
+		MaxAttempts:   5,
+		MaxIPAttempts: 3,
+		Window:        time.Hour,
+		Duration:      time.Minute,
+	})
+
+	for _, email := range []string{"a@gmail.com", "b@gmail.com", "c@gmail.com"} {
+		if err := g.Fail(ctx, email, "10.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := g.Check(ctx, "d@gmail.com", "10.0.0.1"); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("Check() error = %v, want %v", err, ErrTooManyAttempts)
+	}
+	if err := g.Check(ctx, "d@gmail.com", "10.0.0.2"); err != nil {
+		t.Errorf("Check() from other IP error = %v", err)
+	}
+}
+
+func TestGuard_Backoff(t *testing.T) {
+	ctx := context.Background()
+	g, _, now := newTestGuard(Config{
+		MaxAttempts: 10,
+		Window:      time.Hour,
+		BaseDelay:   time.Second,
+		MaxDelay:    3 * time.Second,
+		Duration:    time.Minute,
+	})
+
+	wantDelays := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	for i, want := range wantDelays {
+		if err := g.Fail(ctx, "user@gmail.com", ""); err != nil {
+			t.Fatal(err)
+		}
+
+		var retry *RetryError
+		if err := g.Check(ctx, "user@gmail.com", ""); !errors.As(err, &retry) || retry.RetryAfter != want {
+			t.Fatalf("Check() after %d failures error = %v, want retry after %v", i+1, err, want)
+		}
+
+		*now = now.Add(want)
+		if err := g.Check(ctx, "user@gmail.com", ""); err != nil {
+			t.Fatalf("Check() after waiting error = %v", err)
+		}
+	}
+
+	if err := g.Succeed(ctx, "user@gmail.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Fail(ctx, "user@gmail.com", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	var retry *RetryError
+	if err := g.Check(ctx, "user@gmail.com", ""); !errors.As(err, &retry) || retry.RetryAfter != time.Second {
+		t.Errorf("Check() after success and failure error = %v, want retry after %v", err, time.Second)
+	}
+}
+
+func TestGuard_Window(t *testing.T) {
+	ctx := context.Background()
+	g, _, now := newTestGuard(Config{
+		MaxAttempts: 2,
+		Window:      time.Minute,
+		Duration:    time.Minute,
+	})
+
+	if err := g.Fail(ctx, "user@gmail.com", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	*now = now.Add(2 * time.Minute)
+	if err := g.Fail(ctx, "user@gmail.com", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := g.Check(ctx, "user@gmail.com", ""); err != nil {
+		t.Errorf("Check() error = %v, old failure must be forgotten", err)
+	}
+}
@@ -0,0 +1,104 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+// maxMemoryEvents is the number of the last lockout events kept by MemoryStore.
+const maxMemoryEvents = 1000
+
+// MemoryStore keeps the attempts in memory. It is suitable for a single instance.
+type MemoryStore struct {
+	mu       sync.Mutex
+	attempts map[string]domain.LoginAttempts
+	events   []domain.LockoutEvent
+}
+
+// NewMemoryStore creates a new empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		attempts: make(map[string]domain.LoginAttempts),
+	}
+}
+
+// GetLoginAttempts returns the attempts of the key or empty attempts if there are none.
+func (s *MemoryStore) GetLoginAttempts(_ context.Context, key string) (domain.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if a, ok := s.attempts[key]; ok {
+		return a, nil
+	}
+
+	return domain.LoginAttempts{Key: key}, nil
+}
+
+// AddLoginFailure counts the failure of the key at now, the failures before since are forgotten.
+func (s *MemoryStore) AddLoginFailure(_ context.Context, key string, now, since time.Time) (domain.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a := s.attempts[key]
+	if a.LastFailureAt.Before(since) {
+		a.Failures = 0
+	}
+
+	a.Key = key
+	a.Failures++
+	a.LastFailureAt = now
+	s.attempts[key] = a
+
+	return a, nil
+}
+
+// LockLoginAttempts locks the key out until lockedUntil and forgets its failures.
+func (s *MemoryStore) LockLoginAttempts(_ context.Context, key string, lockedUntil time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a := s.attempts[key]
+	a.Key = key
+	a.Failures = 0
+	a.LockedUntil = lockedUntil
+	s.attempts[key] = a
+
+	return nil
+}
+
+// DeleteLoginAttempts forgets the attempts of the key.
+func (s *MemoryStore) DeleteLoginAttempts(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+
+	return nil
+}
+
+// CreateLockoutEvent records the lockout event keeping only the last maxMemoryEvents events.
+func (s *MemoryStore) CreateLockoutEvent(_ context.Context, event domain.LockoutEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = append(s.events, event)
+	if len(s.events) > maxMemoryEvents {
+		s.events = s.events[len(s.events)-maxMemoryEvents:]
+	}
+
+	return nil
+}
+
+// LockoutEvents returns the recorded lockout events.
+func (s *MemoryStore) LockoutEvents() []domain.LockoutEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := make([]domain.LockoutEvent, len(s.events))
+	copy(events, s.events)
+
+	return events
+}
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"net/url"
+	"strings"
+)
+
+// redacted replaces the values of the sensitive query parameters in the logs.
+const redacted = "REDACTED"
+
+// sensitiveQueryParams are the query parameters carrying credentials: the access token of WebSocket,
+// the authorization code of the identity provider, the signature of the download URL and the OAuth secrets.
+var sensitiveQueryParams = []string{"access_token", "code", "token", "signature", "client_secret"}
+
+// RedactURL returns the URL to log with the values of the sensitive query parameters redacted.
+func RedactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+
+	query := u.Query()
+	for _, param := range sensitiveQueryParams {
+		if _, ok := query[param]; ok {
+			query.Set(param, redacted)
+		}
+	}
+
+	redactedURL := *u
+	redactedURL.RawQuery = query.Encode()
+
+	return redactedURL.String()
+}
+
+// RedactPath is RedactURL for the path with the query of the request line.
+func RedactPath(path string) string {
+	if !strings.Contains(path, "?") {
+		return path
+	}
+
+	u, err := url.Parse(path)
+	if err != nil {
+		// the query can't be parsed, so it can't be redacted param by param
+		return strings.SplitN(path, "?", 2)[0]
+	}
+
+	return RedactURL(u)
+}
@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestRedactURL(t *testing.T) {
+	cases := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{name: "without query", url: "/api/v1/tests/1", want: "/api/v1/tests/1"},
+		{name: "access token", url: "/api/v1/live/tests/1?access_token=secret", want: "/api/v1/live/tests/1?access_token=REDACTED"},
+		{name: "other params kept", url: "/api/v1/auth/oidc/company/callback?code=secret&state=s", want: "/api/v1/auth/oidc/company/callback?code=REDACTED&state=s"},
+		{name: "every value", url: "/download?signature=a&signature=b&expires=1", want: "/download?expires=1&signature=REDACTED"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := RedactURL(u); got != tt.want {
+				t.Errorf("RedactURL() = %s, want %s", got, tt.want)
+			}
+			if got := RedactPath(tt.url); got != tt.want {
+				t.Errorf("RedactPath() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if got := RedactPath("/live?access_token=%zz"); got != "/live" {
+		t.Errorf("RedactPath() with invalid query = %s, want /live", got)
+	}
+}
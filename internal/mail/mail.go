@@ -0,0 +1,49 @@
+// Package mail sends the emails to the users.
+// There is no mail server configured yet, so the messages are written to the log by LogSender.
+package mail
+
+import "context"
+
+// Message is an email to one recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers the messages.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SenderFunc is an adapter to use the function as Sender.
+type SenderFunc func(ctx context.Context, msg Message) error
+
+// Send calls f(ctx, msg).
+func (f SenderFunc) Send(ctx context.Context, msg Message) error {
+	return f(ctx, msg)
+}
+
+// Logger is the part of the application logger used by LogSender.
+type Logger interface {
+	Infof(format string, args ...interface{})
+}
+
+// LogSender writes the messages to the log instead of sending them.
+type LogSender struct {
+	logger Logger
+}
+
+// NewLogSender creates a new instance of LogSender.
+func NewLogSender(logger Logger) *LogSender {
+	return &LogSender{
+		logger: logger,
+	}
+}
+
+// Send writes the message to the log.
+func (s *LogSender) Send(_ context.Context, msg Message) error {
+	s.logger.Infof("mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+
+	return nil
+}
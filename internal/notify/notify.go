@@ -0,0 +1,105 @@
+// Package notify delivers the results of the tests to the subscribed authors.
+// Postgres listens to the events announced by the trigger on test_passages on every instance,
+// Hub delivers them to the subscribers of the instance.
+package notify
+
+import (
+	"context"
+	"sync"
+
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+// subscriptionBuffer is the number of events a subscriber can fall behind before it is dropped.
+const subscriptionBuffer = 16
+
+// Broker delivers the passage events to the subscribers of the test.
+type Broker interface {
+	Subscribe(testID int) *Subscription
+}
+
+// Subscription receives the events of the test until it is closed.
+type Subscription struct {
+	hub    *Hub
+	testID int
+	events chan domain.PassageEvent
+}
+
+// Events returns the channel of the events, it is closed when the subscription is closed
+// or when the subscriber falls behind.
+func (s *Subscription) Events() <-chan domain.PassageEvent {
+	return s.events
+}
+
+// Close unsubscribes from the test.
+func (s *Subscription) Close() {
+	s.hub.unsubscribe(s)
+}
+
+// Hub keeps the subscriptions of the instance in memory.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[int]map[*Subscription]struct{}
+}
+
+// NewHub creates a new Hub without subscriptions.
+func NewHub() *Hub {
+	return &Hub{
+		subs: make(map[int]map[*Subscription]struct{}),
+	}
+}
+
+// Publish sends the event to the subscribers of its test without blocking,
+// the subscribers that fall behind are dropped.
+func (h *Hub) Publish(_ context.Context, event domain.PassageEvent) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs[event.TestID] {
+		select {
+		case sub.events <- event:
+		default:
+			h.remove(sub)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe returns the subscription to the events of the test.
+func (h *Hub) Subscribe(testID int) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub := &Subscription{hub: h, testID: testID, events: make(chan domain.PassageEvent, subscriptionBuffer)}
+	if h.subs[testID] == nil {
+		h.subs[testID] = make(map[*Subscription]struct{})
+	}
+	h.subs[testID][sub] = struct{}{}
+
+	return sub
+}
+
+func (h *Hub) unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remove(sub)
+}
+
+// remove closes the events of the subscription once, h.mu must be held.
+func (h *Hub) remove(sub *Subscription) {
+	subs, ok := h.subs[sub.testID]
+	if !ok {
+		return
+	}
+	if _, ok = subs[sub]; !ok {
+		return
+	}
+
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subs, sub.testID)
+	}
+	close(sub.events)
+}
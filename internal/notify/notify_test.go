@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+func receive(t *testing.T, sub *Subscription) (domain.PassageEvent, bool) {
+	t.Helper()
+
+	select {
+	case event, ok := <-sub.Events():
+		return event, ok
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+
+	return domain.PassageEvent{}, false
+}
+
+func TestHub_Publish(t *testing.T) {
+	hub := NewHub()
+	ctx := context.Background()
+
+	first := hub.Subscribe(1)
+	second := hub.Subscribe(1)
+	other := hub.Subscribe(2)
+
+	event := domain.PassageEvent{Type: domain.PassageFinished, PassageID: 10, TestID: 1, UserID: 5, Passed: true}
+	if err := hub.Publish(ctx, event); err != nil {
+		t.Fatalf("error publishing: %v", err)
+	}
+
+	for _, sub := range []*Subscription{first, second} {
+		if got, ok := receive(t, sub); !ok || got != event {
+			t.Errorf("expected %+v, got %+v", event, got)
+		}
+	}
+
+	select {
+	case got := <-other.Events():
+		t.Errorf("the event of another test is delivered: %+v", got)
+	default:
+	}
+
+	t.Run("Success: closed subscription", func(t *testing.T) {
+		second.Close()
+		second.Close()
+
+		if _, ok := receive(t, second); ok {
+			t.Error("the events of the closed subscription are not closed")
+		}
+
+		if err := hub.Publish(ctx, event); err != nil {
+			t.Fatalf("error publishing: %v", err)
+		}
+		if _, ok := receive(t, first); !ok {
+			t.Error("the event is not delivered to the open subscription")
+		}
+	})
+
+	t.Run("Success: slow subscriber is dropped", func(t *testing.T) {
+		for i := 0; i <= subscriptionBuffer; i++ {
+			if err := hub.Publish(ctx, domain.PassageEvent{Type: domain.PassageScored, PassageID: i, TestID: 2}); err != nil {
+				t.Fatalf("error publishing: %v", err)
+			}
+		}
+
+		for i := 0; i < subscriptionBuffer; i++ {
+			if got, ok := receive(t, other); !ok || got.PassageID != i {
+				t.Fatalf("expected the event %d, got %+v", i, got)
+			}
+		}
+		if _, ok := receive(t, other); ok {
+			t.Error("the slow subscriber is not dropped")
+		}
+
+		other.Close()
+	})
+}
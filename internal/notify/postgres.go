@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/logger"
+)
+
+// Channel is the channel of the passage events notified by the trigger on test_passages.
+const Channel = "test_passages"
+
+const (
+	minReconnectInterval = 10 * time.Second
+	maxReconnectInterval = time.Minute
+)
+
+// Postgres delivers the events notified on Channel to the local subscribers.
+// The events notified while the listener is reconnecting are lost.
+type Postgres struct {
+	listener *pq.Listener
+	hub      *Hub
+	logger   *logger.Logger
+}
+
+// NewPostgres listens to Channel on the new connection with the dsn and returns a new Postgres.
+func NewPostgres(dsn string, log *logger.Logger) (*Postgres, error) {
+	listener := pq.NewListener(dsn, minReconnectInterval, maxReconnectInterval, func(_ pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Errorf("%s: [NOTIFY] - %s", time.Now().Format(time.RFC3339), err)
+		}
+	})
+
+	if err := listener.Listen(Channel); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	p := &Postgres{
+		listener: listener,
+		hub:      NewHub(),
+		logger:   log,
+	}
+	go p.run()
+
+	return p, nil
+}
+
+// Subscribe returns the subscription to the events of the test.
+func (p *Postgres) Subscribe(testID int) *Subscription {
+	return p.hub.Subscribe(testID)
+}
+
+// Close stops listening.
+func (p *Postgres) Close() error {
+	return p.listener.Close()
+}
+
+// run delivers the notifications to the local subscribers until the listener is closed.
+func (p *Postgres) run() {
+	for n := range p.listener.Notify {
+		// nil is sent after the reconnection
+		if n == nil {
+			continue
+		}
+
+		var event domain.PassageEvent
+		if err := json.Unmarshal([]byte(n.Extra), &event); err != nil {
+			p.logger.Errorf("%s: [NOTIFY] - %s", time.Now().Format(time.RFC3339), err)
+			continue
+		}
+
+		_ = p.hub.Publish(context.Background(), event)
+	}
+}
@@ -0,0 +1,158 @@
+package oidc_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/popeskul/qna-go/internal/oidc"
+	"github.com/popeskul/qna-go/internal/oidc/oidctest"
+)
+
+func TestProvider_AuthorizationCodeFlow(t *testing.T) {
+	ctx := context.Background()
+
+	idp, err := oidctest.NewServer("qna", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idp.Close()
+
+	idp.SetUser(oidctest.User{Subject: "42", Email: "user@example.com", EmailVerified: true, Name: "User"})
+
+	provider := oidc.NewProvider(oidc.Config{
+		IssuerURL:    idp.URL,
+		ClientID:     "qna",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost/callback",
+		Scopes:       []string{"email", "profile"},
+	}, nil)
+
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, state, err := oidctest.Authorize(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != "state-1" {
+		t.Fatalf("state = %q, want %q", state, "state-1")
+	}
+
+	if _, err = provider.Exchange(ctx, code, "wrong-verifier"); !errors.Is(err, oidc.ErrExchange) {
+		t.Fatalf("Exchange() with wrong verifier error = %v, want %v", err, oidc.ErrExchange)
+	}
+
+	code, _, err = oidctest.Authorize(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identity, err := provider.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+
+	want := oidc.Identity{Subject: "42", Email: "user@example.com", EmailVerified: true, Name: "User", Nonce: "nonce-1"}
+	if identity != want {
+		t.Errorf("identity = %+v, want %+v", identity, want)
+	}
+
+	if _, err = provider.Exchange(ctx, code, verifier); !errors.Is(err, oidc.ErrExchange) {
+		t.Errorf("Exchange() with used code error = %v, want %v", err, oidc.ErrExchange)
+	}
+}
+
+func TestProvider_VerifyIDToken(t *testing.T) {
+	ctx := context.Background()
+
+	idp, err := oidctest.NewServer("qna", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idp.Close()
+
+	idp.SetUser(oidctest.User{Subject: "42"})
+	idToken := requestIDToken(t, idp)
+
+	tests := []struct {
+		name     string
+		clientID string
+		token    string
+		err      error
+	}{
+		{name: "valid", clientID: "qna", token: idToken},
+		{name: "other audience", clientID: "other", token: idToken, err: oidc.ErrInvalidIDToken},
+		{name: "tampered", clientID: "qna", token: idToken[:len(idToken)-4] + "AAAA", err: oidc.ErrInvalidIDToken},
+		{name: "not a token", clientID: "qna", token: "not-a-token", err: oidc.ErrInvalidIDToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := oidc.NewProvider(oidc.Config{IssuerURL: idp.URL, ClientID: tt.clientID}, nil)
+
+			if _, err := provider.VerifyIDToken(ctx, tt.token); !errors.Is(err, tt.err) {
+				t.Errorf("VerifyIDToken() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+// requestIDToken runs the flow against the identity provider without the client and returns the raw ID token.
+func requestIDToken(t *testing.T, idp *oidctest.Server) string {
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authURL := idp.URL + "/authorize?" + url.Values{
+		"client_id":             {idp.ClientID},
+		"response_type":         {"code"},
+		"redirect_uri":          {"http://localhost/callback"},
+		"code_challenge":        {oidc.CodeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}.Encode()
+
+	code, _, err := oidctest.Authorize(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, idp.URL+"/token", strings.NewReader(url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {"http://localhost/callback"},
+		"code_verifier": {verifier},
+	}.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(idp.ClientID, idp.ClientSecret)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		t.Fatal(err)
+	}
+
+	return tokens.IDToken
+}
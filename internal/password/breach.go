@@ -0,0 +1,108 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const prefixLength = 5
+
+// BreachList checks the passwords against a local list of the SHA-1 hashes of breached passwords
+// in the k-anonymity format of Pwned Passwords. The list is either:
+//   - a directory of range files named by the first 5 hex chars of the hash with "SUFFIX:COUNT" lines,
+//     only the range file of the password is read;
+//   - a single file with "HASH:COUNT" lines, which is loaded into memory by ranges.
+type BreachList struct {
+	dir    string
+	ranges map[string]map[string]struct{}
+}
+
+// NewBreachList opens the breached password list at the path.
+func NewBreachList(path string) (*BreachList, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return &BreachList{dir: path}, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ranges := make(map[string]map[string]struct{})
+	err = readHashes(f, func(hash string) {
+		if len(hash) <= prefixLength {
+			return
+		}
+
+		prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+		if ranges[prefix] == nil {
+			ranges[prefix] = make(map[string]struct{})
+		}
+		ranges[prefix][suffix] = struct{}{}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &BreachList{ranges: ranges}, nil
+}
+
+// Breached check if the SHA-1 hash of the password is in the list.
+func (b *BreachList) Breached(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+
+	if b.ranges != nil {
+		_, ok := b.ranges[prefix][suffix]
+		return ok, nil
+	}
+
+	f, err := os.Open(filepath.Join(b.dir, prefix))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer f.Close()
+
+	found := false
+	err = readHashes(f, func(s string) {
+		if s == suffix {
+			found = true
+		}
+	})
+
+	return found, err
+}
+
+// readHashes calls fn with the uppercase hash of every "HASH[:COUNT]" line.
+func readHashes(r io.Reader, fn func(hash string)) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			line = line[:i]
+		}
+		fn(strings.ToUpper(line))
+	}
+
+	return scanner.Err()
+}
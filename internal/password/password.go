@@ -0,0 +1,180 @@
+// Package password checks the passwords chosen by the users against the password policy
+// and the list of breached passwords.
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+// Codes of the policy violations.
+const (
+	CodeTooShort         = "too_short"
+	CodeTooLong          = "too_long"
+	CodeMissingUpper     = "missing_upper"
+	CodeMissingLower     = "missing_lower"
+	CodeMissingDigit     = "missing_digit"
+	CodeMissingSymbol    = "missing_symbol"
+	CodeContainsPersonal = "contains_personal_info"
+	CodeBreached         = "breached"
+)
+
+const (
+	defaultMinLength = 8
+	defaultMaxLength = 128
+
+	// minPersonalInfoLength is the length of the shortest name or email checked in the password.
+	minPersonalInfoLength = 3
+)
+
+// Policy is the password policy. The zero values of the lengths mean the defaults.
+type Policy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// ForbidPersonalInfo rejects the passwords containing the name or the email of the user.
+	ForbidPersonalInfo bool
+}
+
+// DefaultPolicy follows NIST SP 800-63B: a minimal length without the composition rules.
+var DefaultPolicy = Policy{
+	MinLength:          defaultMinLength,
+	MaxLength:          defaultMaxLength,
+	ForbidPersonalInfo: true,
+}
+
+// Violation is a rule of the policy the password breaks.
+type Violation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when the password breaks the policy, it lists all broken rules.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, v.Message)
+	}
+
+	return "invalid password: " + strings.Join(messages, "; ")
+}
+
+// BreachChecker checks if the password is known from a data breach.
+type BreachChecker interface {
+	Breached(password string) (bool, error)
+}
+
+// Validator checks the passwords against the policy and the breached passwords.
+type Validator struct {
+	policy   Policy
+	breaches BreachChecker
+}
+
+// NewValidator creates a new Validator. The breached passwords aren't checked if breaches is nil.
+func NewValidator(policy Policy, breaches BreachChecker) *Validator {
+	if policy.MinLength == 0 {
+		policy.MinLength = defaultMinLength
+	}
+	if policy.MaxLength == 0 {
+		policy.MaxLength = defaultMaxLength
+	}
+
+	return &Validator{
+		policy:   policy,
+		breaches: breaches,
+	}
+}
+
+// Validate returns *ValidationError if the password of the user breaks the policy.
+// Other errors are returned if the breached passwords can't be checked.
+func (v *Validator) Validate(password string, user domain.User) error {
+	var violations []Violation
+	add := func(code, format string, args ...interface{}) {
+		violations = append(violations, Violation{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < v.policy.MinLength {
+		add(CodeTooShort, "password must be at least %d characters long", v.policy.MinLength)
+	}
+	if length > v.policy.MaxLength {
+		add(CodeTooLong, "password must be at most %d characters long", v.policy.MaxLength)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+
+	if v.policy.RequireUpper && !upper {
+		add(CodeMissingUpper, "password must contain an uppercase letter")
+	}
+	if v.policy.RequireLower && !lower {
+		add(CodeMissingLower, "password must contain a lowercase letter")
+	}
+	if v.policy.RequireDigit && !digit {
+		add(CodeMissingDigit, "password must contain a digit")
+	}
+	if v.policy.RequireSymbol && !symbol {
+		add(CodeMissingSymbol, "password must contain a symbol")
+	}
+
+	if v.policy.ForbidPersonalInfo && containsPersonalInfo(password, user) {
+		add(CodeContainsPersonal, "password must not contain the name or the email")
+	}
+
+	if len(violations) == 0 && v.breaches != nil {
+		breached, err := v.breaches.Breached(password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			add(CodeBreached, "password is found in a data breach, choose another one")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+
+	return nil
+}
+
+// containsPersonalInfo check if the password contains the name, the email or the local part of the email.
+func containsPersonalInfo(password string, user domain.User) bool {
+	password = strings.ToLower(password)
+
+	candidates := []string{user.Name, user.Email}
+	if at := strings.LastIndex(user.Email, "@"); at > 0 {
+		candidates = append(candidates, user.Email[:at])
+	}
+
+	for _, c := range candidates {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if utf8.RuneCountInString(c) >= minPersonalInfoLength && strings.Contains(password, c) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,146 @@
+package password
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+func TestValidator_Validate(t *testing.T) {
+	user := domain.User{Name: "alice", Email: "wonder@example.com"}
+	strict := Policy{
+		MinLength:          10,
+		MaxLength:          20,
+		RequireUpper:       true,
+		RequireLower:       true,
+		RequireDigit:       true,
+		RequireSymbol:      true,
+		ForbidPersonalInfo: true,
+	}
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		password string
+		codes    []string
+	}{
+		{
+			name:     "Success: default policy",
+			policy:   DefaultPolicy,
+			password: "correct horse battery",
+		},
+		{
+			name:     "Success: strict policy",
+			policy:   strict,
+			password: "Tr0ub4dor&3x",
+		},
+		{
+			name:     "Error: too short",
+			policy:   DefaultPolicy,
+			password: "short",
+			codes:    []string{CodeTooShort},
+		},
+		{
+			name:     "Error: all character classes missing",
+			policy:   strict,
+			password: "          ",
+			codes:    []string{CodeMissingUpper, CodeMissingLower, CodeMissingDigit},
+		},
+		{
+			name:     "Error: too long",
+			policy:   strict,
+			password: "Tr0ub4dor&3xTr0ub4dor&3x",
+			codes:    []string{CodeTooLong},
+		},
+		{
+			name:     "Error: contains name",
+			policy:   DefaultPolicy,
+			password: "my name is Alice",
+			codes:    []string{CodeContainsPersonal},
+		},
+		{
+			name:     "Error: contains email local part",
+			policy:   DefaultPolicy,
+			password: "wonder-wonder",
+			codes:    []string{CodeContainsPersonal},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewValidator(tt.policy, nil).Validate(tt.password, user)
+			if len(tt.codes) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Validate() error = %v, want *ValidationError", err)
+			}
+
+			if len(validationErr.Violations) != len(tt.codes) {
+				t.Fatalf("violations = %+v, want %v", validationErr.Violations, tt.codes)
+			}
+			for i, code := range tt.codes {
+				if validationErr.Violations[i].Code != code {
+					t.Errorf("violation %d = %s, want %s", i, validationErr.Violations[i].Code, code)
+				}
+			}
+		})
+	}
+}
+
+// sha1("password1") = E38AD214943DAAD1D64C102FAEC29DE4AFE9DA3D
+const (
+	breachedPassword = "password1"
+	breachedPrefix   = "E38AD"
+	breachedSuffix   = "214943DAAD1D64C102FAEC29DE4AFE9DA3D"
+)
+
+func TestBreachList(t *testing.T) {
+	dir := t.TempDir()
+
+	file := filepath.Join(dir, "hashes.txt")
+	if err := os.WriteFile(file, []byte(breachedPrefix+breachedSuffix+":2413945\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rangesDir := filepath.Join(dir, "ranges")
+	if err := os.Mkdir(rangesDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(rangesDir, breachedPrefix), []byte("0018A45C4D1DEF81644B54AB7F969B88D65:1\n"+breachedSuffix+":2413945\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, path := range map[string]string{"file": file, "ranges": rangesDir} {
+		t.Run(name, func(t *testing.T) {
+			list, err := NewBreachList(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			breached, err := list.Breached(breachedPassword)
+			if err != nil || !breached {
+				t.Errorf("Breached(%q) = %v, %v, want true", breachedPassword, breached, err)
+			}
+
+			breached, err = list.Breached("correct horse battery")
+			if err != nil || breached {
+				t.Errorf("Breached() = %v, %v, want false", breached, err)
+			}
+
+			err = NewValidator(DefaultPolicy, list).Validate(breachedPassword, domain.User{})
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Violations[0].Code != CodeBreached {
+				t.Errorf("Validate() error = %v, want breached", err)
+			}
+		})
+	}
+}
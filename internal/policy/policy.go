@@ -0,0 +1,167 @@
+// Package policy defines what each role is allowed to do.
+// Handlers ask the policy before touching a resource instead of comparing ids by hand.
+package policy
+
+import (
+	"errors"
+
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+var (
+	ErrForbidden = errors.New("you are not allowed to perform this action")
+)
+
+// Permission is an action on a kind of resource.
+type Permission string
+
+const (
+	CreateTest    Permission = "tests:create"
+	ReadTest      Permission = "tests:read"
+	UpdateTest    Permission = "tests:update"
+	DeleteTest    Permission = "tests:delete"
+	ViewResults   Permission = "tests:results"
+	ManageMembers Permission = "tests:members"
+	ManageUsers   Permission = "users:manage"
+	ManageAPIKeys Permission = "api-keys:manage"
+	// ManageOAuthClients allows registering the OAuth2 clients of third-party apps.
+	ManageOAuthClients Permission = "oauth-clients:manage"
+)
+
+// grantable are the permissions that can be delegated to an API key or an OAuth2 client.
+// ManageAPIKeys and ManageOAuthClients are not among them, so a delegated token can't create other credentials,
+// and neither is ManageUsers, so it can't escalate to the user management of the admins.
+var grantable = map[Permission]bool{
+	CreateTest:    true,
+	ReadTest:      true,
+	UpdateTest:    true,
+	DeleteTest:    true,
+	ViewResults:   true,
+	ManageMembers: true,
+}
+
+// Grantable check if the permission can be delegated to an API key or an OAuth2 client.
+func Grantable(perm Permission) bool {
+	return grantable[perm]
+}
+
+// Scope tells on which resources a permission is granted.
+type Scope int
+
+const (
+	// ScopeOwn grants the permission only on resources owned by the subject.
+	ScopeOwn Scope = iota + 1
+	// ScopeAny grants the permission on every resource.
+	ScopeAny
+)
+
+// Subject is the authenticated user who performs an action.
+type Subject struct {
+	UserID int
+	Role   domain.Role
+	// Scopes restrict the permissions of the role, e.g. for API keys and OAuth2 clients. Empty means no restriction.
+	Scopes []Permission
+}
+
+// InScope check if the permission is not restricted by the scopes of the subject.
+func (s Subject) InScope(perm Permission) bool {
+	if len(s.Scopes) == 0 {
+		return true
+	}
+
+	for _, scope := range s.Scopes {
+		if scope == perm {
+			return true
+		}
+	}
+
+	return false
+}
+
+var rolePermissions = map[domain.Role]map[Permission]Scope{
+	domain.RoleAdmin: {
+		CreateTest:         ScopeAny,
+		ReadTest:           ScopeAny,
+		UpdateTest:         ScopeAny,
+		DeleteTest:         ScopeAny,
+		ViewResults:        ScopeAny,
+		ManageMembers:      ScopeAny,
+		ManageUsers:        ScopeAny,
+		ManageAPIKeys:      ScopeOwn,
+		ManageOAuthClients: ScopeOwn,
+	},
+	domain.RoleAuthor: {
+		CreateTest:         ScopeOwn,
+		ReadTest:           ScopeOwn,
+		UpdateTest:         ScopeOwn,
+		DeleteTest:         ScopeOwn,
+		ViewResults:        ScopeOwn,
+		ManageMembers:      ScopeOwn,
+		ManageAPIKeys:      ScopeOwn,
+		ManageOAuthClients: ScopeOwn,
+	},
+	domain.RoleReviewer: {
+		ReadTest:           ScopeAny,
+		ViewResults:        ScopeAny,
+		ManageAPIKeys:      ScopeOwn,
+		ManageOAuthClients: ScopeOwn,
+	},
+	domain.RoleLearner: {
+		ManageAPIKeys:      ScopeOwn,
+		ManageOAuthClients: ScopeOwn,
+	},
+}
+
+// memberPermissions are granted on a single test to the users invited to it.
+// Deleting the test and managing its members stay with the owner.
+var memberPermissions = map[domain.MemberRole]map[Permission]bool{
+	domain.MemberEditor: {
+		ReadTest:    true,
+		UpdateTest:  true,
+		ViewResults: true,
+	},
+	domain.MemberReviewer: {
+		ReadTest:    true,
+		ViewResults: true,
+	},
+	domain.MemberViewer: {
+		ReadTest: true,
+	},
+}
+
+// Can check if the subject has the permission on at least its own resources.
+func Can(sub Subject, perm Permission) bool {
+	_, ok := rolePermissions[sub.Role][perm]
+	return ok && sub.InScope(perm)
+}
+
+// CanOn check if the subject has the permission on a resource owned by ownerID.
+func CanOn(sub Subject, perm Permission, ownerID int) bool {
+	if !sub.InScope(perm) {
+		return false
+	}
+
+	switch rolePermissions[sub.Role][perm] {
+	case ScopeAny:
+		return true
+	case ScopeOwn:
+		return sub.UserID != 0 && sub.UserID == ownerID
+	}
+	return false
+}
+
+// Authorize returns ErrForbidden if the subject can't perform perm on a resource owned by ownerID.
+func Authorize(sub Subject, perm Permission, ownerID int) error {
+	if !CanOn(sub, perm, ownerID) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// AuthorizeMember returns ErrForbidden if the membership doesn't grant perm on the test.
+func AuthorizeMember(member domain.TestMember, perm Permission) error {
+	if !memberPermissions[member.Role][perm] {
+		return ErrForbidden
+	}
+	return nil
+}
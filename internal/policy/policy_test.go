@@ -0,0 +1,160 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+func TestCanOn(t *testing.T) {
+	const ownerID = 7
+
+	tests := []struct {
+		name string
+		sub  Subject
+		perm Permission
+		want bool
+	}{
+		{
+			name: "Success: admin updates someone else's test",
+			sub:  Subject{UserID: 1, Role: domain.RoleAdmin},
+			perm: UpdateTest,
+			want: true,
+		},
+		{
+			name: "Success: author updates own test",
+			sub:  Subject{UserID: ownerID, Role: domain.RoleAuthor},
+			perm: UpdateTest,
+			want: true,
+		},
+		{
+			name: "Fail: author deletes someone else's test",
+			sub:  Subject{UserID: 1, Role: domain.RoleAuthor},
+			perm: DeleteTest,
+			want: false,
+		},
+		{
+			name: "Success: reviewer reads any test",
+			sub:  Subject{UserID: 1, Role: domain.RoleReviewer},
+			perm: ReadTest,
+			want: true,
+		},
+		{
+			name: "Fail: reviewer updates a test",
+			sub:  Subject{UserID: 1, Role: domain.RoleReviewer},
+			perm: UpdateTest,
+			want: false,
+		},
+		{
+			name: "Fail: learner reads a test",
+			sub:  Subject{UserID: ownerID, Role: domain.RoleLearner},
+			perm: ReadTest,
+			want: false,
+		},
+		{
+			name: "Fail: unknown role",
+			sub:  Subject{UserID: ownerID, Role: "root"},
+			perm: ReadTest,
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanOn(tt.sub, tt.perm, ownerID); got != tt.want {
+				t.Errorf("CanOn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCan(t *testing.T) {
+	if !Can(Subject{Role: domain.RoleAdmin}, ManageUsers) {
+		t.Error("admin must be able to manage users")
+	}
+	if Can(Subject{Role: domain.RoleAuthor}, ManageUsers) {
+		t.Error("author must not be able to manage users")
+	}
+	if !Can(Subject{Role: domain.RoleAuthor}, CreateTest) {
+		t.Error("author must be able to create tests")
+	}
+	if Can(Subject{Role: domain.RoleLearner}, CreateTest) {
+		t.Error("learner must not be able to create tests")
+	}
+}
+
+func TestAuthorizeMember(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    domain.MemberRole
+		perm    Permission
+		wantErr error
+	}{
+		{
+			name: "Success: editor updates the test",
+			role: domain.MemberEditor,
+			perm: UpdateTest,
+		},
+		{
+			name:    "Fail: editor deletes the test",
+			role:    domain.MemberEditor,
+			perm:    DeleteTest,
+			wantErr: ErrForbidden,
+		},
+		{
+			name: "Success: reviewer views results",
+			role: domain.MemberReviewer,
+			perm: ViewResults,
+		},
+		{
+			name:    "Fail: viewer views results",
+			role:    domain.MemberViewer,
+			perm:    ViewResults,
+			wantErr: ErrForbidden,
+		},
+		{
+			name:    "Fail: viewer manages members",
+			role:    domain.MemberViewer,
+			perm:    ManageMembers,
+			wantErr: ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := AuthorizeMember(domain.TestMember{UserID: 1, TestID: 1, Role: tt.role}, tt.perm)
+			if err != tt.wantErr {
+				t.Errorf("AuthorizeMember() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSubject_Scopes(t *testing.T) {
+	sub := Subject{UserID: 1, Role: domain.RoleAuthor, Scopes: []Permission{ReadTest}}
+
+	if !CanOn(sub, ReadTest, 1) {
+		t.Error("scoped subject must be able to read own test")
+	}
+	if CanOn(sub, UpdateTest, 1) {
+		t.Error("scoped subject must not be able to update own test")
+	}
+	if Can(sub, CreateTest) {
+		t.Error("scoped subject must not be able to create tests")
+	}
+
+	admin := Subject{UserID: 2, Role: domain.RoleAdmin, Scopes: []Permission{ReadTest}}
+	if Can(admin, ManageUsers) {
+		t.Error("scopes must restrict admin too")
+	}
+
+	if Grantable(ManageAPIKeys) {
+		t.Error("api keys must not be able to manage api keys")
+	}
+	if Grantable(ManageOAuthClients) {
+		t.Error("delegated tokens must not be able to manage oauth clients")
+	}
+	if Grantable(ManageUsers) {
+		t.Error("delegated tokens must not be able to manage users")
+	}
+}
@@ -0,0 +1,384 @@
+// Package admin is a struct that contains all functions for the user management and the audit log repository.
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/repository/user"
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrAdminExists  = errors.New("an admin already exists")
+)
+
+const adminUserColumns = `u.id, u.name, u.email, u.role, u.confirmed, u.locale, u.timezone, u.disabled_at, u.disabled_reason, u.deleted_at,
+	(SELECT count(*) FROM refresh_tokens r WHERE r.user_id = u.id AND r.expires_at > now()), u.created_at, u.updated_at`
+
+const auditColumns = "id, actor_id, action, target_user_id, details, ip, created_at"
+
+// RepositoryAdmin provides all the functions for the user management and the audit log repository.
+type RepositoryAdmin struct {
+	db *sql.DB
+}
+
+// NewRepoAdmin creates a new instance of RepositoryAdmin.
+func NewRepoAdmin(db *sql.DB) *RepositoryAdmin {
+	return &RepositoryAdmin{
+		db: db,
+	}
+}
+
+// SearchUsers returns the users matching the request ordered by id.
+func (r *RepositoryAdmin) SearchUsers(ctx context.Context, req domain.SearchUsersRequest) ([]domain.AdminUser, error) {
+	users := make([]domain.AdminUser, 0)
+
+	var disabled sql.NullBool
+	if req.Disabled != nil {
+		disabled = sql.NullBool{Bool: *req.Disabled, Valid: true}
+	}
+
+	searchUsersQuery := fmt.Sprintf(`SELECT %s FROM users u
+		WHERE ($1 = '' OR u.name ILIKE $1 ESCAPE '\' OR u.email ILIKE $1 ESCAPE '\')
+		AND ($2 = '' OR u.role = $2)
+		AND ($3::boolean IS NULL OR (u.disabled_at IS NOT NULL) = $3)
+		ORDER BY u.id LIMIT $4 OFFSET $5`, adminUserColumns)
+
+	rows, err := r.db.QueryContext(ctx, searchUsersQuery, likePattern(req.Query), string(req.Role), disabled, req.Limit, req.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		u, err := scanAdminUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+
+	return users, rows.Err()
+}
+
+// GetAdminUser returns the user by id.
+func (r *RepositoryAdmin) GetAdminUser(ctx context.Context, userID int) (domain.AdminUser, error) {
+	getUserQuery := fmt.Sprintf("SELECT %s FROM users u WHERE u.id = $1", adminUserColumns)
+
+	return scanAdminUser(r.db.QueryRowContext(ctx, getUserQuery, userID))
+}
+
+// ChangeUserRole assigns the role to the user and records the audit entry in one transaction.
+func (r *RepositoryAdmin) ChangeUserRole(ctx context.Context, userID int, role domain.Role, entry domain.AuditEntry) error {
+	return r.withAudit(ctx, entry, func(tx *sql.Tx) error {
+		updateRoleQuery := fmt.Sprintln("UPDATE users SET role = $1, updated_at = now() WHERE id = $2")
+		res, err := tx.ExecContext(ctx, updateRoleQuery, role, userID)
+		if err != nil {
+			return err
+		}
+
+		return requireUser(res)
+	})
+}
+
+// DisableUser disables the user with the reason, deletes the refresh tokens of the user and records the audit entry
+// in one transaction. Disabling the disabled user only changes the reason.
+func (r *RepositoryAdmin) DisableUser(ctx context.Context, userID int, reason string, entry domain.AuditEntry) error {
+	return r.withAudit(ctx, entry, func(tx *sql.Tx) error {
+		disableUserQuery := fmt.Sprintln("UPDATE users SET disabled_at = COALESCE(disabled_at, now()), disabled_reason = $1, updated_at = now() WHERE id = $2")
+		res, err := tx.ExecContext(ctx, disableUserQuery, reason, userID)
+		if err != nil {
+			return err
+		}
+
+		if err = requireUser(res); err != nil {
+			return err
+		}
+
+		_, err = deleteSessions(ctx, tx, userID)
+
+		return err
+	})
+}
+
+// EnableUser enables the disabled user and records the audit entry in one transaction.
+func (r *RepositoryAdmin) EnableUser(ctx context.Context, userID int, entry domain.AuditEntry) error {
+	return r.withAudit(ctx, entry, func(tx *sql.Tx) error {
+		enableUserQuery := fmt.Sprintln("UPDATE users SET disabled_at = NULL, disabled_reason = '', updated_at = now() WHERE id = $1")
+		res, err := tx.ExecContext(ctx, enableUserQuery, userID)
+		if err != nil {
+			return err
+		}
+
+		return requireUser(res)
+	})
+}
+
+// DeleteSessions deletes all refresh tokens of the user including the ones issued to the OAuth2 clients,
+// records the audit entry with the number of the deleted tokens in the sessions detail in one transaction
+// and returns how many were deleted.
+func (r *RepositoryAdmin) DeleteSessions(ctx context.Context, userID int, entry domain.AuditEntry) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	deleted, err := deleteSessions(ctx, tx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if entry.Details, err = setDetail(entry.Details, "sessions", deleted); err != nil {
+		return 0, err
+	}
+
+	if err = createAuditEntry(ctx, tx, entry); err != nil {
+		return 0, err
+	}
+
+	return deleted, tx.Commit()
+}
+
+// ResetPassword sets the password hash of the user, deletes the refresh tokens of the user
+// and records the audit entry in one transaction.
+func (r *RepositoryAdmin) ResetPassword(ctx context.Context, userID int, passwordHash string, entry domain.AuditEntry) error {
+	return r.withAudit(ctx, entry, func(tx *sql.Tx) error {
+		updatePasswordQuery := fmt.Sprintln("UPDATE users SET password = $1, updated_at = now() WHERE id = $2")
+		res, err := tx.ExecContext(ctx, updatePasswordQuery, passwordHash, userID)
+		if err != nil {
+			return err
+		}
+
+		if err = requireUser(res); err != nil {
+			return err
+		}
+
+		_, err = deleteSessions(ctx, tx, userID)
+
+		return err
+	})
+}
+
+// DeleteUser records the audit entry and deletes or anonymizes the user in one transaction.
+// The entry is written first, its target is set to null when the user is deleted.
+func (r *RepositoryAdmin) DeleteUser(ctx context.Context, userID int, anonymize bool, entry domain.AuditEntry) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	if err = createAuditEntry(ctx, tx, entry); err != nil {
+		return err
+	}
+
+	if anonymize {
+		err = user.AnonymizeUserTx(ctx, tx, userID)
+	} else {
+		err = user.DeleteUserTx(ctx, tx, userID)
+	}
+	if err != nil {
+		if errors.Is(err, user.ErrDeleteUser) {
+			return ErrUserNotFound
+		}
+
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// BootstrapAdmin assigns the admin role to the enabled user with the email and records the audit entry
+// in one transaction, if there is no admin yet. It returns the id of the user.
+func (r *RepositoryAdmin) BootstrapAdmin(ctx context.Context, email string, entry domain.AuditEntry) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	var adminExists bool
+	adminExistsQuery := fmt.Sprintln("SELECT EXISTS (SELECT 1 FROM users WHERE role = $1 AND deleted_at IS NULL)")
+	if err = tx.QueryRowContext(ctx, adminExistsQuery, domain.RoleAdmin).Scan(&adminExists); err != nil {
+		return 0, err
+	}
+
+	if adminExists {
+		return 0, ErrAdminExists
+	}
+
+	var userID int
+	bootstrapQuery := fmt.Sprintln(`UPDATE users SET role = $1, updated_at = now()
+		WHERE email = $2 AND deleted_at IS NULL AND disabled_at IS NULL RETURNING id`)
+	if err = tx.QueryRowContext(ctx, bootstrapQuery, domain.RoleAdmin, email).Scan(&userID); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrUserNotFound
+		}
+
+		return 0, err
+	}
+
+	entry.TargetUserID = &userID
+	if err = createAuditEntry(ctx, tx, entry); err != nil {
+		return 0, err
+	}
+
+	return userID, tx.Commit()
+}
+
+// CreateAuditEntry records the action of the operator which changes nothing else.
+func (r *RepositoryAdmin) CreateAuditEntry(ctx context.Context, entry domain.AuditEntry) error {
+	return createAuditEntry(ctx, r.db, entry)
+}
+
+// GetAuditLog returns the audit log entries matching the request, the latest first.
+func (r *RepositoryAdmin) GetAuditLog(ctx context.Context, req domain.AuditLogRequest) ([]domain.AuditEntry, error) {
+	entries := make([]domain.AuditEntry, 0)
+
+	auditLogQuery := fmt.Sprintf(`SELECT %s FROM audit_log
+		WHERE ($1 = 0 OR actor_id = $1) AND ($2 = 0 OR target_user_id = $2) AND ($3 = '' OR action = $3)
+		ORDER BY id DESC LIMIT $4 OFFSET $5`, auditColumns)
+
+	rows, err := r.db.QueryContext(ctx, auditLogQuery, req.ActorID, req.TargetUserID, string(req.Action), req.Limit, req.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			e            domain.AuditEntry
+			actorID      sql.NullInt64
+			targetUserID sql.NullInt64
+			details      []byte
+		)
+		if err = rows.Scan(&e.ID, &actorID, &e.Action, &targetUserID, &details, &e.IP, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.ActorID = nullInt(actorID)
+		e.TargetUserID = nullInt(targetUserID)
+		e.Details = details
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// withAudit runs the action and records the audit entry in one transaction, nothing is written if either fails.
+func (r *RepositoryAdmin) withAudit(ctx context.Context, entry domain.AuditEntry, action func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	if err = action(tx); err != nil {
+		return err
+	}
+
+	if err = createAuditEntry(ctx, tx, entry); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func createAuditEntry(ctx context.Context, db execer, entry domain.AuditEntry) error {
+	details := entry.Details
+	if len(details) == 0 {
+		details = []byte("{}")
+	}
+
+	_, err := db.ExecContext(ctx, "INSERT INTO audit_log (actor_id, action, target_user_id, details, ip) VALUES ($1, $2, $3, $4, $5)",
+		entry.ActorID, entry.Action, entry.TargetUserID, string(details), entry.IP)
+
+	return err
+}
+
+func deleteSessions(ctx context.Context, tx *sql.Tx, userID int) (int64, error) {
+	res, err := tx.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE user_id = $1", userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// requireUser returns ErrUserNotFound if the update has changed no user.
+func requireUser(res sql.Result) error {
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// setDetail returns the details with the key set to the value.
+func setDetail(details json.RawMessage, key string, value interface{}) (json.RawMessage, error) {
+	fields := make(map[string]interface{})
+	if len(details) > 0 {
+		if err := json.Unmarshal(details, &fields); err != nil {
+			return nil, err
+		}
+	}
+	fields[key] = value
+
+	return json.Marshal(fields)
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAdminUser(row scanner) (domain.AdminUser, error) {
+	var u domain.AdminUser
+
+	err := row.Scan(&u.ID, &u.Name, &u.Email, &u.Role, &u.Confirmed, &u.Locale, &u.Timezone, &u.DisabledAt, &u.DisabledReason,
+		&u.DeletedAt, &u.Sessions, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return u, ErrUserNotFound
+		}
+
+		return u, err
+	}
+
+	return u, nil
+}
+
+// likePattern returns the ILIKE pattern matching the query anywhere, the wildcards in the query match themselves.
+func likePattern(query string) string {
+	if query == "" {
+		return ""
+	}
+
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(query)
+
+	return "%" + escaped + "%"
+}
+
+func nullInt(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+
+	i := int(n.Int64)
+
+	return &i
+}
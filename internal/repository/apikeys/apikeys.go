@@ -0,0 +1,116 @@
+// Package apikeys is a struct that contains all functions for the API keys repository.
+package apikeys
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+)
+
+const apiKeyColumns = "id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at"
+
+// RepositoryAPIKeys provides all the functions for the API keys repository.
+type RepositoryAPIKeys struct {
+	db *sql.DB
+}
+
+// NewRepoAPIKeys creates a new instance of RepositoryAPIKeys.
+func NewRepoAPIKeys(db *sql.DB) *RepositoryAPIKeys {
+	return &RepositoryAPIKeys{
+		db: db,
+	}
+}
+
+// CreateAPIKey stores the API key and returns it with id and creation time.
+func (r *RepositoryAPIKeys) CreateAPIKey(ctx context.Context, key domain.APIKey) (domain.APIKey, error) {
+	createKeyQuery := fmt.Sprintln(`INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`)
+	err := r.db.QueryRowContext(ctx, createKeyQuery, key.UserID, key.Name, key.Prefix, key.KeyHash, pq.Array(key.Scopes), key.ExpiresAt).
+		Scan(&key.ID, &key.CreatedAt)
+
+	return key, err
+}
+
+// GetAPIKeysByUserID returns all API keys of the user including revoked ones.
+func (r *RepositoryAPIKeys) GetAPIKeysByUserID(ctx context.Context, userID int) ([]domain.APIKey, error) {
+	keys := make([]domain.APIKey, 0)
+	allKeysQuery := fmt.Sprintf("SELECT %s FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC", apiKeyColumns)
+
+	rows, err := r.db.QueryContext(ctx, allKeysQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+
+	return keys, rows.Err()
+}
+
+// GetAPIKeyByHash returns the API key by the hash of the key.
+func (r *RepositoryAPIKeys) GetAPIKeyByHash(ctx context.Context, keyHash string) (domain.APIKey, error) {
+	getKeyQuery := fmt.Sprintf("SELECT %s FROM api_keys WHERE key_hash = $1", apiKeyColumns)
+
+	k, err := scanAPIKey(r.db.QueryRowContext(ctx, getKeyQuery, keyHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return k, ErrAPIKeyNotFound
+		}
+
+		return k, err
+	}
+
+	return k, nil
+}
+
+// RevokeAPIKey revokes the API key of the user and returns error if any.
+func (r *RepositoryAPIKeys) RevokeAPIKey(ctx context.Context, userID, keyID int) error {
+	revokeKeyQuery := fmt.Sprintln("UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL")
+	res, err := r.db.ExecContext(ctx, revokeKeyQuery, keyID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// TouchAPIKey updates the last usage time of the API key.
+func (r *RepositoryAPIKeys) TouchAPIKey(ctx context.Context, keyID int) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE api_keys SET last_used_at = now() WHERE id = $1", keyID)
+
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIKey(row scanner) (domain.APIKey, error) {
+	var k domain.APIKey
+	err := row.Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, &k.KeyHash, pq.Array(&k.Scopes), &k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedAt)
+
+	return k, err
+}
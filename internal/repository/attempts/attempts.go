@@ -0,0 +1,73 @@
+// Package attempts is a struct that contains all functions for the sign in attempts repository.
+// It implements lockout.Store for deployments with several instances.
+package attempts
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+// RepositoryAttempts provides all the functions for the sign in attempts repository.
+type RepositoryAttempts struct {
+	db *sql.DB
+}
+
+// NewRepoAttempts creates a new instance of RepositoryAttempts.
+func NewRepoAttempts(db *sql.DB) *RepositoryAttempts {
+	return &RepositoryAttempts{
+		db: db,
+	}
+}
+
+// GetLoginAttempts returns the attempts of the key or empty attempts if there are none.
+func (r *RepositoryAttempts) GetLoginAttempts(ctx context.Context, key string) (domain.LoginAttempts, error) {
+	a := domain.LoginAttempts{Key: key}
+
+	getAttemptsQuery := fmt.Sprintln("SELECT failures, last_failure_at, locked_until FROM login_attempts WHERE key = $1")
+	err := r.db.QueryRowContext(ctx, getAttemptsQuery, key).Scan(&a.Failures, &a.LastFailureAt, &a.LockedUntil)
+	if err != nil && err != sql.ErrNoRows {
+		return a, err
+	}
+
+	return a, nil
+}
+
+// AddLoginFailure counts the failure of the key at now with one statement, the failures before since are forgotten.
+// It returns the attempts with the failure and error if any.
+func (r *RepositoryAttempts) AddLoginFailure(ctx context.Context, key string, now, since time.Time) (domain.LoginAttempts, error) {
+	a := domain.LoginAttempts{Key: key}
+
+	addFailureQuery := fmt.Sprintln(`INSERT INTO login_attempts (key, failures, last_failure_at, locked_until) VALUES ($1, 1, $2, $4)
+		ON CONFLICT (key) DO UPDATE SET last_failure_at = EXCLUDED.last_failure_at,
+		failures = CASE WHEN login_attempts.last_failure_at < $3 THEN 1 ELSE login_attempts.failures + 1 END
+		RETURNING failures, last_failure_at, locked_until`)
+	err := r.db.QueryRowContext(ctx, addFailureQuery, key, now, since, time.Time{}).Scan(&a.Failures, &a.LastFailureAt, &a.LockedUntil)
+
+	return a, err
+}
+
+// LockLoginAttempts locks the key out until lockedUntil, forgets its failures and returns error if any.
+func (r *RepositoryAttempts) LockLoginAttempts(ctx context.Context, key string, lockedUntil time.Time) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE login_attempts SET failures = 0, locked_until = $2 WHERE key = $1", key, lockedUntil)
+
+	return err
+}
+
+// DeleteLoginAttempts forgets the attempts of the key and returns error if any.
+func (r *RepositoryAttempts) DeleteLoginAttempts(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM login_attempts WHERE key = $1", key)
+
+	return err
+}
+
+// CreateLockoutEvent records the lockout event and returns error if any.
+func (r *RepositoryAttempts) CreateLockoutEvent(ctx context.Context, e domain.LockoutEvent) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO lockout_events (key, failures, locked_until, created_at) VALUES ($1, $2, $3, $4)",
+		e.Key, e.Failures, e.LockedUntil, e.CreatedAt)
+
+	return err
+}
@@ -0,0 +1,297 @@
+// Package exports is a struct that contains all functions for the data exports repository.
+package exports
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+var (
+	ErrExportNotFound  = errors.New("export not found")
+	ErrArchiveNotFound = errors.New("export archive not found or expired")
+)
+
+const exportColumns = "id, user_id, status, error, expires_at, completed_at, created_at, link_secret"
+
+// RepositoryExports provides all the functions for the data exports repository.
+type RepositoryExports struct {
+	db *sql.DB
+}
+
+// NewRepoExports creates a new instance of RepositoryExports.
+func NewRepoExports(db *sql.DB) *RepositoryExports {
+	return &RepositoryExports{
+		db: db,
+	}
+}
+
+// CreateExport creates the pending export of the user and returns it.
+func (r *RepositoryExports) CreateExport(ctx context.Context, userID int, linkSecret []byte) (domain.DataExport, error) {
+	createExportQuery := fmt.Sprintf("INSERT INTO data_exports (user_id, link_secret) VALUES ($1, $2) RETURNING %s", exportColumns)
+
+	return scanExport(r.db.QueryRowContext(ctx, createExportQuery, userID, linkSecret))
+}
+
+// GetExport returns the export by id.
+func (r *RepositoryExports) GetExport(ctx context.Context, exportID int) (domain.DataExport, error) {
+	getExportQuery := fmt.Sprintf("SELECT %s FROM data_exports WHERE id = $1", exportColumns)
+
+	return scanExport(r.db.QueryRowContext(ctx, getExportQuery, exportID))
+}
+
+// GetActiveExport returns the pending or running export of the user.
+func (r *RepositoryExports) GetActiveExport(ctx context.Context, userID int) (domain.DataExport, error) {
+	getExportQuery := fmt.Sprintf("SELECT %s FROM data_exports WHERE user_id = $1 AND status IN ($2, $3) ORDER BY created_at DESC LIMIT 1", exportColumns)
+
+	return scanExport(r.db.QueryRowContext(ctx, getExportQuery, userID, domain.ExportPending, domain.ExportRunning))
+}
+
+// StartExport marks the export as running.
+func (r *RepositoryExports) StartExport(ctx context.Context, exportID int) error {
+	return r.updateStatus(ctx, "UPDATE data_exports SET status = $1, updated_at = now() WHERE id = $2",
+		domain.ExportRunning, exportID)
+}
+
+// CompleteExport stores the archive of the export and marks it as ready until expiresAt.
+func (r *RepositoryExports) CompleteExport(ctx context.Context, exportID int, archive []byte, expiresAt time.Time) error {
+	return r.updateStatus(ctx, `UPDATE data_exports SET status = $1, archive = $2, expires_at = $3,
+		completed_at = now(), updated_at = now() WHERE id = $4`, domain.ExportReady, archive, expiresAt, exportID)
+}
+
+// FailExport marks the export as failed with the reason.
+func (r *RepositoryExports) FailExport(ctx context.Context, exportID int, reason string) error {
+	return r.updateStatus(ctx, "UPDATE data_exports SET status = $1, error = $2, completed_at = now(), updated_at = now() WHERE id = $3",
+		domain.ExportFailed, reason, exportID)
+}
+
+func (r *RepositoryExports) updateStatus(ctx context.Context, query string, args ...interface{}) error {
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrExportNotFound
+	}
+
+	return nil
+}
+
+// GetExportArchive returns the archive of the ready export which is not expired yet.
+func (r *RepositoryExports) GetExportArchive(ctx context.Context, exportID int) ([]byte, error) {
+	var archive []byte
+
+	getArchiveQuery := fmt.Sprintln("SELECT archive FROM data_exports WHERE id = $1 AND status = $2 AND expires_at > now()")
+	err := r.db.QueryRowContext(ctx, getArchiveQuery, exportID, domain.ExportReady).Scan(&archive)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrArchiveNotFound
+		}
+
+		return nil, err
+	}
+
+	return archive, nil
+}
+
+// DeleteExpiredExports deletes the expired archives and fails the exports which are not finished
+// in staleAfter, they were interrupted by the restart of the application.
+func (r *RepositoryExports) DeleteExpiredExports(ctx context.Context, staleAfter time.Duration) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM data_exports WHERE expires_at <= now()"); err != nil {
+		return err
+	}
+
+	_, err := r.db.ExecContext(ctx, `UPDATE data_exports SET status = $1, error = 'interrupted', completed_at = now(), updated_at = now()
+		WHERE status IN ($2, $3) AND updated_at < $4`,
+		domain.ExportFailed, domain.ExportPending, domain.ExportRunning, time.Now().Add(-staleAfter))
+
+	return err
+}
+
+// GetUserData returns everything stored about the user. The data is read in one snapshot.
+// The secrets are left out: the password, the refresh tokens and the hashes of the API keys and the client secrets.
+func (r *RepositoryExports) GetUserData(ctx context.Context, userID int) (domain.UserData, error) {
+	data := domain.UserData{
+		Tests:         make([]domain.Test, 0),
+		Questions:     make([]domain.Question, 0),
+		Answers:       make([]domain.Answer, 0),
+		Passages:      make([]domain.TestPassage, 0),
+		Memberships:   make([]domain.TestMember, 0),
+		Sessions:      make([]domain.ExportedSession, 0),
+		Identities:    make([]domain.LinkedIdentity, 0),
+		APIKeys:       make([]domain.APIKey, 0),
+		OAuthClients:  make([]domain.OAuthClient, 0),
+		OAuthConsents: make([]domain.ExportedConsent, 0),
+	}
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return data, err
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	var (
+		p            = &data.Profile
+		pendingEmail sql.NullString
+	)
+	err = tx.QueryRowContext(ctx, `SELECT u.id, u.name, u.email, e.new_email, u.avatar_url, u.locale, u.timezone, u.role, u.created_at, u.updated_at
+		FROM users u LEFT JOIN email_changes e ON e.user_id = u.id AND e.expires_at > now() WHERE u.id = $1`, userID).
+		Scan(&p.ID, &p.Name, &p.Email, &pendingEmail, &p.AvatarURL, &p.Locale, &p.Timezone, &p.Role, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return data, err
+	}
+	p.PendingEmail = pendingEmail.String
+
+	queries := []struct {
+		query string
+		scan  func(rows *sql.Rows) error
+	}{
+		{
+			query: "SELECT id, title, author_id, created_at, updated_at FROM tests WHERE author_id = $1 ORDER BY id",
+			scan: func(rows *sql.Rows) error {
+				var t domain.Test
+				err := rows.Scan(&t.ID, &t.Title, &t.AuthorID, &t.CreatedAt, &t.UpdatedAt)
+				data.Tests = append(data.Tests, t)
+				return err
+			},
+		},
+		{
+			query: `SELECT q.id, q.test_id, q.body, q.created_at, q.updated_at FROM questions q
+				JOIN tests t ON t.id = q.test_id WHERE t.author_id = $1 ORDER BY q.id`,
+			scan: func(rows *sql.Rows) error {
+				var q domain.Question
+				err := rows.Scan(&q.ID, &q.TestID, &q.Body, &q.CreatedAt, &q.UpdatedAt)
+				data.Questions = append(data.Questions, q)
+				return err
+			},
+		},
+		{
+			query: `SELECT a.id, a.question_id, a.title, a.correct, a.created_at, a.updated_at FROM answers a
+				JOIN questions q ON q.id = a.question_id JOIN tests t ON t.id = q.test_id WHERE t.author_id = $1 ORDER BY a.id`,
+			scan: func(rows *sql.Rows) error {
+				var a domain.Answer
+				err := rows.Scan(&a.ID, &a.QuestionID, &a.Title, &a.Correct, &a.CreatedAt, &a.UpdatedAt)
+				data.Answers = append(data.Answers, a)
+				return err
+			},
+		},
+		{
+			query: "SELECT id, user_id, test_id, passed, created_at, updated_at FROM test_passages WHERE user_id = $1 ORDER BY id",
+			scan: func(rows *sql.Rows) error {
+				var p domain.TestPassage
+				err := rows.Scan(&p.ID, &p.UserID, &p.TestID, &p.Passed, &p.CreatedAt, &p.UpdatedAt)
+				data.Passages = append(data.Passages, p)
+				return err
+			},
+		},
+		{
+			query: "SELECT test_id, user_id, role, created_at, updated_at FROM test_members WHERE user_id = $1 ORDER BY test_id",
+			scan: func(rows *sql.Rows) error {
+				var m domain.TestMember
+				err := rows.Scan(&m.TestID, &m.UserID, &m.Role, &m.CreatedAt, &m.UpdatedAt)
+				data.Memberships = append(data.Memberships, m)
+				return err
+			},
+		},
+		{
+			query: "SELECT id, client_id, scopes, expires_at, created_at FROM refresh_tokens WHERE user_id = $1 ORDER BY id",
+			scan: func(rows *sql.Rows) error {
+				var s domain.ExportedSession
+				err := rows.Scan(&s.ID, &s.ClientID, pq.Array(&s.Scopes), &s.ExpiresAt, &s.CreatedAt)
+				data.Sessions = append(data.Sessions, s)
+				return err
+			},
+		},
+		{
+			query: "SELECT id, user_id, provider, subject, email, last_login_at, created_at FROM user_identities WHERE user_id = $1 ORDER BY id",
+			scan: func(rows *sql.Rows) error {
+				var i domain.LinkedIdentity
+				err := rows.Scan(&i.ID, &i.UserID, &i.Provider, &i.Subject, &i.Email, &i.LastLoginAt, &i.CreatedAt)
+				data.Identities = append(data.Identities, i)
+				return err
+			},
+		},
+		{
+			query: "SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, revoked_at, created_at FROM api_keys WHERE user_id = $1 ORDER BY id",
+			scan: func(rows *sql.Rows) error {
+				var k domain.APIKey
+				err := rows.Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, pq.Array(&k.Scopes), &k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedAt)
+				data.APIKeys = append(data.APIKeys, k)
+				return err
+			},
+		},
+		{
+			query: "SELECT id, client_id, name, redirect_uris, scopes, owner_id, confidential, created_at FROM oauth_clients WHERE owner_id = $1 ORDER BY id",
+			scan: func(rows *sql.Rows) error {
+				var c domain.OAuthClient
+				err := rows.Scan(&c.ID, &c.ClientID, &c.Name, pq.Array(&c.RedirectURIs), pq.Array(&c.Scopes), &c.OwnerID, &c.Confidential, &c.CreatedAt)
+				data.OAuthClients = append(data.OAuthClients, c)
+				return err
+			},
+		},
+		{
+			query: "SELECT client_id, scopes, created_at, updated_at FROM oauth_consents WHERE user_id = $1 ORDER BY client_id",
+			scan: func(rows *sql.Rows) error {
+				var c domain.ExportedConsent
+				err := rows.Scan(&c.ClientID, pq.Array(&c.Scopes), &c.CreatedAt, &c.UpdatedAt)
+				data.OAuthConsents = append(data.OAuthConsents, c)
+				return err
+			},
+		},
+	}
+
+	for _, q := range queries {
+		if err = queryRows(ctx, tx, q.query, userID, q.scan); err != nil {
+			return data, err
+		}
+	}
+
+	return data, tx.Commit()
+}
+
+// queryRows runs the query with the user id and scans every row.
+func queryRows(ctx context.Context, tx *sql.Tx, query string, userID int, scan func(rows *sql.Rows) error) error {
+	rows, err := tx.QueryContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err = scan(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanExport(row scanner) (domain.DataExport, error) {
+	var e domain.DataExport
+
+	err := row.Scan(&e.ID, &e.UserID, &e.Status, &e.Error, &e.ExpiresAt, &e.CompletedAt, &e.CreatedAt, &e.LinkSecret)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return e, ErrExportNotFound
+		}
+
+		return e, err
+	}
+
+	return e, nil
+}
@@ -0,0 +1,105 @@
+// Package idempotency is a struct that contains all functions for the idempotency keys repository.
+// It implements idempotency.Store for deployments with several instances.
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/idempotency"
+)
+
+// RepositoryIdempotency provides all the functions for the idempotency keys repository.
+type RepositoryIdempotency struct {
+	db *sql.DB
+}
+
+// NewRepoIdempotency creates a new instance of RepositoryIdempotency.
+func NewRepoIdempotency(db *sql.DB) *RepositoryIdempotency {
+	return &RepositoryIdempotency{
+		db: db,
+	}
+}
+
+// CreateIdempotencyKey saves the key unless there is an unexpired key with the same scope and key,
+// the expired one is replaced. It returns the saved or the existing key and whether the key was saved.
+func (r *RepositoryIdempotency) CreateIdempotencyKey(ctx context.Context, key domain.IdempotencyKey, now time.Time) (domain.IdempotencyKey, bool, error) {
+	createKeyQuery := fmt.Sprintln(`INSERT INTO idempotency_keys (scope, key, fingerprint, expires_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (scope, key) DO UPDATE SET fingerprint = EXCLUDED.fingerprint, status = NULL, headers = NULL, body = NULL,
+		expires_at = EXCLUDED.expires_at WHERE idempotency_keys.expires_at <= $5`)
+	result, err := r.db.ExecContext(ctx, createKeyQuery, key.Scope, key.Key, key.Fingerprint, key.ExpiresAt, now)
+	if err != nil {
+		return domain.IdempotencyKey{}, false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return domain.IdempotencyKey{}, false, err
+	}
+	if rowsAffected == 1 {
+		return key, true, nil
+	}
+
+	saved, err := r.getIdempotencyKey(ctx, key.Scope, key.Key)
+	if err == sql.ErrNoRows {
+		// the existing key was released in the meantime, the request it reserved the key for has just finished
+		return domain.IdempotencyKey{}, false, idempotency.ErrKeyInProgress
+	}
+
+	return saved, false, err
+}
+
+func (r *RepositoryIdempotency) getIdempotencyKey(ctx context.Context, scope, key string) (domain.IdempotencyKey, error) {
+	k := domain.IdempotencyKey{Scope: scope, Key: key}
+
+	var (
+		status  sql.NullInt32
+		headers []byte
+		body    []byte
+	)
+	getKeyQuery := fmt.Sprintln("SELECT fingerprint, status, headers, body, expires_at FROM idempotency_keys WHERE scope = $1 AND key = $2")
+	err := r.db.QueryRowContext(ctx, getKeyQuery, scope, key).Scan(&k.Fingerprint, &status, &headers, &body, &k.ExpiresAt)
+	if err != nil {
+		return k, err
+	}
+
+	if status.Valid {
+		k.Response = &domain.IdempotentResponse{Status: int(status.Int32), Body: body}
+		if err = json.Unmarshal(headers, &k.Response.Header); err != nil {
+			return k, err
+		}
+	}
+
+	return k, nil
+}
+
+// CompleteIdempotencyKey stores the response of the key and returns error if any.
+func (r *RepositoryIdempotency) CompleteIdempotencyKey(ctx context.Context, key domain.IdempotencyKey) error {
+	headers, err := json.Marshal(key.Response.Header)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, "UPDATE idempotency_keys SET status = $3, headers = $4, body = $5 WHERE scope = $1 AND key = $2",
+		key.Scope, key.Key, key.Response.Status, headers, key.Response.Body)
+
+	return err
+}
+
+// DeleteExpiredIdempotencyKeys deletes the keys expired by now and returns error if any.
+func (r *RepositoryIdempotency) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= $1", now)
+
+	return err
+}
+
+// DeleteIdempotencyKey forgets the key and returns error if any.
+func (r *RepositoryIdempotency) DeleteIdempotencyKey(ctx context.Context, scope, key string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2", scope, key)
+
+	return err
+}
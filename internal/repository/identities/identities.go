@@ -0,0 +1,138 @@
+// Package identities is a struct that contains all functions for the linked identities repository.
+package identities
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+var (
+	ErrIdentityNotFound   = errors.New("identity not found")
+	ErrLoginStateNotFound = errors.New("login state not found")
+)
+
+const identityColumns = "id, user_id, provider, subject, email, last_login_at, created_at"
+
+// RepositoryIdentities provides all the functions for the linked identities repository.
+type RepositoryIdentities struct {
+	db *sql.DB
+}
+
+// NewRepoIdentities creates a new instance of RepositoryIdentities.
+func NewRepoIdentities(db *sql.DB) *RepositoryIdentities {
+	return &RepositoryIdentities{
+		db: db,
+	}
+}
+
+// CreateIdentity links the external identity to the user and returns error if any.
+func (r *RepositoryIdentities) CreateIdentity(ctx context.Context, identity domain.LinkedIdentity) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO user_identities (user_id, provider, subject, email, last_login_at) VALUES ($1, $2, $3, $4, now())",
+		identity.UserID, identity.Provider, identity.Subject, identity.Email)
+
+	return err
+}
+
+// GetIdentity returns the identity by the provider and the subject at the provider.
+func (r *RepositoryIdentities) GetIdentity(ctx context.Context, provider, subject string) (domain.LinkedIdentity, error) {
+	var i domain.LinkedIdentity
+
+	getIdentityQuery := fmt.Sprintf("SELECT %s FROM user_identities WHERE provider = $1 AND subject = $2", identityColumns)
+	err := r.db.QueryRowContext(ctx, getIdentityQuery, provider, subject).
+		Scan(&i.ID, &i.UserID, &i.Provider, &i.Subject, &i.Email, &i.LastLoginAt, &i.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return i, ErrIdentityNotFound
+		}
+
+		return i, err
+	}
+
+	return i, nil
+}
+
+// GetIdentitiesByUserID returns all identities linked to the user.
+func (r *RepositoryIdentities) GetIdentitiesByUserID(ctx context.Context, userID int) ([]domain.LinkedIdentity, error) {
+	identities := make([]domain.LinkedIdentity, 0)
+	allIdentitiesQuery := fmt.Sprintf("SELECT %s FROM user_identities WHERE user_id = $1 ORDER BY created_at", identityColumns)
+
+	rows, err := r.db.QueryContext(ctx, allIdentitiesQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var i domain.LinkedIdentity
+		if err = rows.Scan(&i.ID, &i.UserID, &i.Provider, &i.Subject, &i.Email, &i.LastLoginAt, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		identities = append(identities, i)
+	}
+
+	return identities, rows.Err()
+}
+
+// TouchIdentity updates the last sign-in time of the identity.
+func (r *RepositoryIdentities) TouchIdentity(ctx context.Context, identityID int) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE user_identities SET last_login_at = now() WHERE id = $1", identityID)
+
+	return err
+}
+
+// DeleteIdentity unlinks the identity of the user and returns error if any.
+func (r *RepositoryIdentities) DeleteIdentity(ctx context.Context, userID, identityID int) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM user_identities WHERE id = $1 AND user_id = $2", identityID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrIdentityNotFound
+	}
+
+	return nil
+}
+
+// CreateLoginState stores the state of the authorization request and returns error if any.
+func (r *RepositoryIdentities) CreateLoginState(ctx context.Context, state domain.OIDCLoginState) error {
+	var userID sql.NullInt64
+	if state.UserID != 0 {
+		userID = sql.NullInt64{Int64: int64(state.UserID), Valid: true}
+	}
+
+	_, err := r.db.ExecContext(ctx, "INSERT INTO oidc_login_states (state, provider, nonce, code_verifier, user_id, expires_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		state.State, state.Provider, state.Nonce, state.CodeVerifier, userID, state.ExpiresAt)
+
+	return err
+}
+
+// TakeLoginState deletes the state of the authorization request and returns it, so it can be used once.
+func (r *RepositoryIdentities) TakeLoginState(ctx context.Context, state string) (domain.OIDCLoginState, error) {
+	var (
+		s      domain.OIDCLoginState
+		userID sql.NullInt64
+	)
+
+	takeStateQuery := fmt.Sprintln("DELETE FROM oidc_login_states WHERE state = $1 RETURNING state, provider, nonce, code_verifier, user_id, expires_at")
+	err := r.db.QueryRowContext(ctx, takeStateQuery, state).Scan(&s.State, &s.Provider, &s.Nonce, &s.CodeVerifier, &userID, &s.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return s, ErrLoginStateNotFound
+		}
+
+		return s, err
+	}
+	s.UserID = int(userID.Int64)
+
+	return s, nil
+}
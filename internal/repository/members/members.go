@@ -0,0 +1,95 @@
+// Package members is a struct that contains all functions for the test members repository.
+package members
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+var (
+	ErrMemberNotFound = errors.New("test member not found")
+)
+
+// RepositoryMembers provides all the functions for the test members repository.
+type RepositoryMembers struct {
+	db *sql.DB
+}
+
+// NewRepoMembers creates a new instance of RepositoryMembers.
+func NewRepoMembers(db *sql.DB) *RepositoryMembers {
+	return &RepositoryMembers{
+		db: db,
+	}
+}
+
+// SaveTestMember adds the user to the test or changes the role if the user is already a member.
+func (r *RepositoryMembers) SaveTestMember(ctx context.Context, member domain.TestMember) error {
+	saveMemberQuery := fmt.Sprintln(`INSERT INTO test_members (test_id, user_id, role) VALUES ($1, $2, $3)
+		ON CONFLICT (test_id, user_id) DO UPDATE SET role = EXCLUDED.role, updated_at = now()`)
+	_, err := r.db.ExecContext(ctx, saveMemberQuery, member.TestID, member.UserID, member.Role)
+
+	return err
+}
+
+// GetTestMember returns the membership of the user in the test and error if any.
+func (r *RepositoryMembers) GetTestMember(ctx context.Context, testID, userID int) (domain.TestMember, error) {
+	var m domain.TestMember
+
+	getMemberQuery := fmt.Sprintln("SELECT test_id, user_id, role, created_at, updated_at FROM test_members WHERE test_id = $1 AND user_id = $2")
+	err := r.db.QueryRowContext(ctx, getMemberQuery, testID, userID).Scan(&m.TestID, &m.UserID, &m.Role, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return m, ErrMemberNotFound
+		}
+
+		return m, err
+	}
+
+	return m, nil
+}
+
+// GetTestMembers returns all members of the test and error if any.
+func (r *RepositoryMembers) GetTestMembers(ctx context.Context, testID int) ([]domain.TestMember, error) {
+	allMembers := make([]domain.TestMember, 0)
+	allMembersQuery := fmt.Sprintln("SELECT test_id, user_id, role, created_at, updated_at FROM test_members WHERE test_id = $1 ORDER BY created_at")
+
+	rows, err := r.db.QueryContext(ctx, allMembersQuery, testID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var m domain.TestMember
+	for rows.Next() {
+		if err = rows.Scan(&m.TestID, &m.UserID, &m.Role, &m.CreatedAt, &m.UpdatedAt); err != nil {
+			return nil, err
+		}
+		allMembers = append(allMembers, m)
+	}
+
+	return allMembers, rows.Err()
+}
+
+// DeleteTestMember removes the user from the test and returns error if any.
+func (r *RepositoryMembers) DeleteTestMember(ctx context.Context, testID, userID int) error {
+	deleteMemberQuery := fmt.Sprintln("DELETE FROM test_members WHERE test_id = $1 AND user_id = $2")
+	res, err := r.db.ExecContext(ctx, deleteMemberQuery, testID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrMemberNotFound
+	}
+
+	return nil
+}
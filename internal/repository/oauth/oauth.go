@@ -0,0 +1,177 @@
+// Package oauth is a struct that contains all functions for the OAuth2 authorization server repository.
+package oauth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+var (
+	ErrClientNotFound  = errors.New("oauth client not found")
+	ErrCodeNotFound    = errors.New("authorization code not found")
+	ErrConsentNotFound = errors.New("consent not found")
+)
+
+const clientColumns = "id, client_id, secret_hash, name, redirect_uris, scopes, owner_id, confidential, created_at"
+
+// RepositoryOAuth provides all the functions for the OAuth2 authorization server repository.
+type RepositoryOAuth struct {
+	db *sql.DB
+}
+
+// NewRepoOAuth creates a new instance of RepositoryOAuth.
+func NewRepoOAuth(db *sql.DB) *RepositoryOAuth {
+	return &RepositoryOAuth{
+		db: db,
+	}
+}
+
+// CreateClient stores the client and returns it with id and creation time.
+func (r *RepositoryOAuth) CreateClient(ctx context.Context, client domain.OAuthClient) (domain.OAuthClient, error) {
+	createClientQuery := fmt.Sprintln(`INSERT INTO oauth_clients (client_id, secret_hash, name, redirect_uris, scopes, owner_id, confidential)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at`)
+	err := r.db.QueryRowContext(ctx, createClientQuery, client.ClientID, client.SecretHash, client.Name,
+		pq.Array(client.RedirectURIs), pq.Array(client.Scopes), client.OwnerID, client.Confidential).
+		Scan(&client.ID, &client.CreatedAt)
+
+	return client, err
+}
+
+// GetClient returns the client by its client id.
+func (r *RepositoryOAuth) GetClient(ctx context.Context, clientID string) (domain.OAuthClient, error) {
+	getClientQuery := fmt.Sprintf("SELECT %s FROM oauth_clients WHERE client_id = $1", clientColumns)
+
+	c, err := scanClient(r.db.QueryRowContext(ctx, getClientQuery, clientID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c, ErrClientNotFound
+		}
+
+		return c, err
+	}
+
+	return c, nil
+}
+
+// GetClientsByOwner returns all clients registered by the user.
+func (r *RepositoryOAuth) GetClientsByOwner(ctx context.Context, ownerID int) ([]domain.OAuthClient, error) {
+	clients := make([]domain.OAuthClient, 0)
+	allClientsQuery := fmt.Sprintf("SELECT %s FROM oauth_clients WHERE owner_id = $1 ORDER BY created_at DESC", clientColumns)
+
+	rows, err := r.db.QueryContext(ctx, allClientsQuery, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c, err := scanClient(rows)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, c)
+	}
+
+	return clients, rows.Err()
+}
+
+// DeleteClient deletes the client of the user with its codes and consents, the issued refresh tokens stop working.
+func (r *RepositoryOAuth) DeleteClient(ctx context.Context, ownerID int, clientID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM oauth_clients WHERE client_id = $1 AND owner_id = $2", clientID, ownerID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrClientNotFound
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE client_id = $1", clientID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateAuthorizationCode stores the authorization code and returns error if any.
+func (r *RepositoryOAuth) CreateAuthorizationCode(ctx context.Context, code domain.OAuthAuthorizationCode) error {
+	createCodeQuery := fmt.Sprintln(`INSERT INTO oauth_authorization_codes (code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`)
+	_, err := r.db.ExecContext(ctx, createCodeQuery, code.CodeHash, code.ClientID, code.UserID, code.RedirectURI,
+		pq.Array(code.Scopes), code.CodeChallenge, code.ExpiresAt)
+
+	return err
+}
+
+// TakeAuthorizationCode deletes the authorization code and returns it, so it can be used once.
+func (r *RepositoryOAuth) TakeAuthorizationCode(ctx context.Context, codeHash string) (domain.OAuthAuthorizationCode, error) {
+	var c domain.OAuthAuthorizationCode
+
+	takeCodeQuery := fmt.Sprintln(`DELETE FROM oauth_authorization_codes WHERE code_hash = $1
+		RETURNING code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at`)
+	err := r.db.QueryRowContext(ctx, takeCodeQuery, codeHash).
+		Scan(&c.CodeHash, &c.ClientID, &c.UserID, &c.RedirectURI, pq.Array(&c.Scopes), &c.CodeChallenge, &c.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c, ErrCodeNotFound
+		}
+
+		return c, err
+	}
+
+	return c, nil
+}
+
+// GetConsent returns the scopes the user granted to the client.
+func (r *RepositoryOAuth) GetConsent(ctx context.Context, userID int, clientID string) (domain.OAuthConsent, error) {
+	c := domain.OAuthConsent{UserID: userID, ClientID: clientID}
+
+	err := r.db.QueryRowContext(ctx, "SELECT scopes FROM oauth_consents WHERE user_id = $1 AND client_id = $2", userID, clientID).
+		Scan(pq.Array(&c.Scopes))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c, ErrConsentNotFound
+		}
+
+		return c, err
+	}
+
+	return c, nil
+}
+
+// SaveConsent stores the scopes the user granted to the client replacing the previous ones.
+func (r *RepositoryOAuth) SaveConsent(ctx context.Context, consent domain.OAuthConsent) error {
+	saveConsentQuery := fmt.Sprintln(`INSERT INTO oauth_consents (user_id, client_id, scopes) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, client_id) DO UPDATE SET scopes = EXCLUDED.scopes, updated_at = now()`)
+	_, err := r.db.ExecContext(ctx, saveConsentQuery, consent.UserID, consent.ClientID, pq.Array(consent.Scopes))
+
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanClient(row scanner) (domain.OAuthClient, error) {
+	var c domain.OAuthClient
+	err := row.Scan(&c.ID, &c.ClientID, &c.SecretHash, &c.Name, pq.Array(&c.RedirectURIs), pq.Array(&c.Scopes),
+		&c.OwnerID, &c.Confidential, &c.CreatedAt)
+
+	return c, err
+}
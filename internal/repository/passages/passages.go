@@ -0,0 +1,68 @@
+// Package passages is a struct that contains all functions for the test passages repository.
+package passages
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+// RepositoryPassages provides all the functions for the test passages repository.
+type RepositoryPassages struct {
+	db *sql.DB
+}
+
+// NewRepoPassages creates a new instance of RepositoryPassages.
+func NewRepoPassages(db *sql.DB) *RepositoryPassages {
+	return &RepositoryPassages{
+		db: db,
+	}
+}
+
+// GetPassagesByTestID returns all results of the test and error if any.
+func (r *RepositoryPassages) GetPassagesByTestID(ctx context.Context, testID int) ([]domain.TestPassage, error) {
+	allPassages := make([]domain.TestPassage, 0)
+	allPassagesQuery := fmt.Sprintln("SELECT id, user_id, test_id, passed, created_at, updated_at FROM test_passages WHERE test_id = $1 ORDER BY created_at DESC")
+
+	rows, err := r.db.QueryContext(ctx, allPassagesQuery, testID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var p domain.TestPassage
+	for rows.Next() {
+		if err = rows.Scan(&p.ID, &p.UserID, &p.TestID, &p.Passed, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		allPassages = append(allPassages, p)
+	}
+
+	return allPassages, rows.Err()
+}
+
+// GetUserPassagesByTestIDs returns the results of the user in all the tests in one query and error if any.
+func (r *RepositoryPassages) GetUserPassagesByTestIDs(ctx context.Context, userID int, testIDs []int) ([]domain.TestPassage, error) {
+	allPassages := make([]domain.TestPassage, 0)
+	allPassagesQuery := fmt.Sprintln("SELECT id, user_id, test_id, passed, created_at, updated_at FROM test_passages WHERE user_id = $1 AND test_id = ANY($2) ORDER BY created_at DESC")
+
+	rows, err := r.db.QueryContext(ctx, allPassagesQuery, userID, pq.Array(testIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var p domain.TestPassage
+	for rows.Next() {
+		if err = rows.Scan(&p.ID, &p.UserID, &p.TestID, &p.Passed, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		allPassages = append(allPassages, p)
+	}
+
+	return allPassages, rows.Err()
+}
@@ -0,0 +1,177 @@
+package questions
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/lib/pq"
+
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+// ApplyQuestionBatch creates, updates and deletes the questions of the test in one transaction with a statement
+// per kind of operation and returns the results in the order of the batch. The operations on the questions
+// of other tests fail with ErrQuestionNotFound, the answers of the deleted questions are deleted too.
+// The atomic batch with a failed operation is rolled back, the partial one is committed.
+func (r *RepositoryQuestions) ApplyQuestionBatch(ctx context.Context, batch domain.QuestionBatch) (domain.QuestionBatchResult, error) {
+	result := domain.QuestionBatchResult{
+		Create: make([]domain.QuestionResult, len(batch.Create)),
+		Update: make([]domain.QuestionResult, len(batch.Update)),
+		Delete: make([]domain.QuestionResult, len(batch.Delete)),
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	if err = createQuestions(ctx, tx, batch.TestID, batch.Create, result.Create); err != nil {
+		return result, err
+	}
+	if err = updateQuestions(ctx, tx, batch.TestID, batch.Update, result.Update); err != nil {
+		return result, err
+	}
+	if err = deleteQuestions(ctx, tx, batch.TestID, batch.Delete, result.Delete); err != nil {
+		return result, err
+	}
+
+	if batch.Mode == domain.BatchAtomic && result.Failed() > 0 {
+		result.RollBack()
+		return result, nil
+	}
+
+	return result, tx.Commit()
+}
+
+// createQuestions inserts all the questions with one multi-row insert.
+func createQuestions(ctx context.Context, tx *sql.Tx, testID int, questions []domain.Question, results []domain.QuestionResult) error {
+	if len(questions) == 0 {
+		return nil
+	}
+
+	bodies := make([]string, len(questions))
+	for i, q := range questions {
+		bodies[i] = q.Body
+	}
+
+	createQuestionsQuery := fmt.Sprintf("INSERT INTO questions (test_id, body) "+
+		"SELECT $1, body FROM unnest($2::text[]) WITH ORDINALITY AS q(body, n) ORDER BY n RETURNING %s", questionColumns)
+	rows, err := tx.QueryContext(ctx, createQuestionsQuery, testID, pq.Array(bodies))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	created := make([]domain.Question, 0, len(questions))
+	for rows.Next() {
+		var q domain.Question
+		if err = scanQuestion(rows, &q); err != nil {
+			return err
+		}
+		created = append(created, q)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	// the ids are given in the order of the insert
+	sort.Slice(created, func(i, j int) bool { return created[i].ID < created[j].ID })
+	for i, q := range created {
+		results[i].Question = q
+	}
+
+	return nil
+}
+
+// updateQuestions updates all the questions of the test with one statement.
+func updateQuestions(ctx context.Context, tx *sql.Tx, testID int, updates []domain.QuestionUpdate, results []domain.QuestionResult) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	ids, bodies := make([]int64, len(updates)), make([]string, len(updates))
+	for i, update := range updates {
+		ids[i], bodies[i] = int64(update.ID), update.Body
+	}
+
+	updateQuestionsQuery := fmt.Sprintf("UPDATE questions SET body = u.new_body, updated_at = now() "+
+		"FROM unnest($2::int[], $3::text[]) AS u(question_id, new_body) "+
+		"WHERE id = u.question_id AND test_id = $1 RETURNING %s", questionColumns)
+	rows, err := tx.QueryContext(ctx, updateQuestionsQuery, testID, pq.Array(ids), pq.Array(bodies))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	updated := make(map[int]domain.Question, len(updates))
+	for rows.Next() {
+		var q domain.Question
+		if err = scanQuestion(rows, &q); err != nil {
+			return err
+		}
+		updated[q.ID] = q
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for i, update := range updates {
+		results[i] = changedResult(update.ID, updated)
+	}
+
+	return nil
+}
+
+// deleteQuestions deletes all the questions of the test and their answers with two statements.
+func deleteQuestions(ctx context.Context, tx *sql.Tx, testID int, deletes []domain.QuestionDelete, results []domain.QuestionResult) error {
+	if len(deletes) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(deletes))
+	for i, d := range deletes {
+		ids[i] = int64(d.ID)
+	}
+
+	rows, err := tx.QueryContext(ctx, "DELETE FROM questions WHERE id = ANY($1) AND test_id = $2 RETURNING id", pq.Array(ids), testID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	deleted := make(map[int]domain.Question, len(deletes))
+	deletedIDs := make([]int64, 0, len(deletes))
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			return err
+		}
+		deleted[id] = domain.Question{ID: id}
+		deletedIDs = append(deletedIDs, int64(id))
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM answers WHERE question_id = ANY($1)", pq.Array(deletedIDs)); err != nil {
+		return err
+	}
+
+	for i, d := range deletes {
+		results[i] = changedResult(d.ID, deleted)
+	}
+
+	return nil
+}
+
+// changedResult is the changed question or ErrQuestionNotFound if the test has no such question.
+func changedResult(questionID int, changed map[int]domain.Question) domain.QuestionResult {
+	if q, ok := changed[questionID]; ok {
+		return domain.QuestionResult{Question: q}
+	}
+
+	return domain.QuestionResult{Question: domain.Question{ID: questionID}, Err: ErrQuestionNotFound}
+}
@@ -0,0 +1,84 @@
+// Package questions is a struct that contains all functions for the questions and answers repository.
+package questions
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+var (
+	ErrQuestionNotFound = errors.New("question not found")
+)
+
+const questionColumns = "id, test_id, body, created_at, updated_at"
+
+// scanner is implemented by sql.Row and sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanQuestion(row scanner, q *domain.Question) error {
+	return row.Scan(&q.ID, &q.TestID, &q.Body, &q.CreatedAt, &q.UpdatedAt)
+}
+
+// RepositoryQuestions provides all the functions for the questions and answers repository.
+type RepositoryQuestions struct {
+	db *sql.DB
+}
+
+// NewRepoQuestions creates a new instance of RepositoryQuestions.
+func NewRepoQuestions(db *sql.DB) *RepositoryQuestions {
+	return &RepositoryQuestions{
+		db: db,
+	}
+}
+
+// GetQuestionsByTestIDs returns the questions of all the tests in one query and error if any.
+func (r *RepositoryQuestions) GetQuestionsByTestIDs(ctx context.Context, testIDs []int) ([]domain.Question, error) {
+	allQuestions := make([]domain.Question, 0)
+	allQuestionsQuery := fmt.Sprintf("SELECT %s FROM questions WHERE test_id = ANY($1) ORDER BY test_id, id", questionColumns)
+
+	rows, err := r.db.QueryContext(ctx, allQuestionsQuery, pq.Array(testIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var q domain.Question
+	for rows.Next() {
+		if err = scanQuestion(rows, &q); err != nil {
+			return nil, err
+		}
+		allQuestions = append(allQuestions, q)
+	}
+
+	return allQuestions, rows.Err()
+}
+
+// GetAnswersByQuestionIDs returns the answers of all the questions in one query and error if any.
+func (r *RepositoryQuestions) GetAnswersByQuestionIDs(ctx context.Context, questionIDs []int) ([]domain.Answer, error) {
+	allAnswers := make([]domain.Answer, 0)
+	allAnswersQuery := fmt.Sprintln("SELECT id, question_id, title, correct, created_at, updated_at FROM answers WHERE question_id = ANY($1) ORDER BY question_id, id")
+
+	rows, err := r.db.QueryContext(ctx, allAnswersQuery, pq.Array(questionIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var a domain.Answer
+	for rows.Next() {
+		if err = rows.Scan(&a.ID, &a.QuestionID, &a.Title, &a.Correct, &a.CreatedAt, &a.UpdatedAt); err != nil {
+			return nil, err
+		}
+		allAnswers = append(allAnswers, a)
+	}
+
+	return allAnswers, rows.Err()
+}
@@ -6,9 +6,19 @@ import (
 	"context"
 	"database/sql"
 	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/repository/admin"
+	"github.com/popeskul/qna-go/internal/repository/apikeys"
+	"github.com/popeskul/qna-go/internal/repository/exports"
+	"github.com/popeskul/qna-go/internal/repository/identities"
+	"github.com/popeskul/qna-go/internal/repository/members"
+	"github.com/popeskul/qna-go/internal/repository/oauth"
+	"github.com/popeskul/qna-go/internal/repository/passages"
+	"github.com/popeskul/qna-go/internal/repository/questions"
 	"github.com/popeskul/qna-go/internal/repository/sessions"
 	"github.com/popeskul/qna-go/internal/repository/tests"
+	"github.com/popeskul/qna-go/internal/repository/twofactor"
 	"github.com/popeskul/qna-go/internal/repository/user"
+	"time"
 )
 
 // Auth interface is implemented by the auth repository.
@@ -17,28 +27,150 @@ type Auth interface {
 	GetUser(ctx context.Context, email string, password []byte) (domain.User, error)
 	DeleteUserById(ctx context.Context, userID int) error
 	GetUserByEmail(ctx context.Context, email string) (domain.User, error)
+	GetUserByID(ctx context.Context, userID int) (domain.User, error)
+	UpdateUserRole(ctx context.Context, userID int, role domain.Role) error
+	UpdateUserPassword(ctx context.Context, userID int, passwordHash string) error
+}
+
+// Profiles interface is implemented by the auth repository for the account of the current user.
+type Profiles interface {
+	GetProfile(ctx context.Context, userID int) (domain.Profile, error)
+	UpdateProfile(ctx context.Context, userID int, update domain.ProfileUpdate) error
+	SaveEmailChange(ctx context.Context, change domain.EmailChange) error
+	TakeEmailChange(ctx context.Context, tokenHash string) (domain.EmailChange, error)
+	UpdateUserEmail(ctx context.Context, userID int, email string) error
+	DeleteUserWithData(ctx context.Context, userID int) error
+	AnonymizeUser(ctx context.Context, userID int) error
 }
 
 // Tests interface is implemented by the test repository.
 type Tests interface {
-	CreateTest(ctx context.Context, userID int, test domain.Test) error
+	CreateTest(ctx context.Context, userID int, test domain.Test) (domain.Test, error)
 	GetTest(ctx context.Context, testID int) (domain.Test, error)
 	GetAllTestsByUserID(ctx context.Context, userID int, args domain.GetAllTestsParams) ([]domain.Test, error)
-	UpdateTestById(ctx context.Context, testID int, test domain.Test) error
-	DeleteTestById(ctx context.Context, testID int) error
+	UpdateTestById(ctx context.Context, testID, version int, test domain.Test) (domain.Test, error)
+	PatchTestByID(ctx context.Context, testID, version int, patch domain.TestPatch) (domain.Test, error)
+	DeleteTestById(ctx context.Context, testID, version int) error
+	GetTestsByIDs(ctx context.Context, testIDs []int) ([]domain.Test, error)
+	ApplyTestBatch(ctx context.Context, authorID int, batch domain.TestBatch) (domain.TestBatchResult, error)
 }
 
 // Sessions interface is implemented by the sessions' repository.
 type Sessions interface {
-	CreateRefreshToken(ctx context.Context, token domain.RefreshSession) error
+	CreateRefreshToken(ctx context.Context, token domain.RefreshSession) (int64, error)
 	GetRefreshToken(ctx context.Context, token string) (domain.RefreshSession, error)
 }
 
+// Members interface is implemented by the test members' repository.
+type Members interface {
+	SaveTestMember(ctx context.Context, member domain.TestMember) error
+	GetTestMember(ctx context.Context, testID, userID int) (domain.TestMember, error)
+	GetTestMembers(ctx context.Context, testID int) ([]domain.TestMember, error)
+	DeleteTestMember(ctx context.Context, testID, userID int) error
+}
+
+// Passages interface is implemented by the test passages' repository.
+type Passages interface {
+	GetPassagesByTestID(ctx context.Context, testID int) ([]domain.TestPassage, error)
+	GetUserPassagesByTestIDs(ctx context.Context, userID int, testIDs []int) ([]domain.TestPassage, error)
+}
+
+// Questions interface is implemented by the questions and answers repository.
+type Questions interface {
+	GetQuestionsByTestIDs(ctx context.Context, testIDs []int) ([]domain.Question, error)
+	GetAnswersByQuestionIDs(ctx context.Context, questionIDs []int) ([]domain.Answer, error)
+	ApplyQuestionBatch(ctx context.Context, batch domain.QuestionBatch) (domain.QuestionBatchResult, error)
+}
+
+// TwoFactor interface is implemented by the two-factor authentication repository.
+type TwoFactor interface {
+	SaveTOTPSecret(ctx context.Context, userID int, secret string) error
+	GetTOTP(ctx context.Context, userID int) (domain.TOTP, error)
+	EnableTOTP(ctx context.Context, userID int, lastUsedStep int64, codeHashes []string) error
+	UpdateTOTPLastStep(ctx context.Context, userID int, step int64) error
+	DeleteTOTP(ctx context.Context, userID int) error
+	GetRecoveryCodes(ctx context.Context, userID int) ([]domain.RecoveryCode, error)
+	UseRecoveryCode(ctx context.Context, codeID int) error
+	CreateChallenge(ctx context.Context, challenge domain.TwoFactorChallenge) error
+	UseChallengeAttempt(ctx context.Context, token string, maxAttempts int) (domain.TwoFactorChallenge, error)
+	DeleteChallenge(ctx context.Context, token string) error
+}
+
+// APIKeys interface is implemented by the API keys' repository.
+type APIKeys interface {
+	CreateAPIKey(ctx context.Context, key domain.APIKey) (domain.APIKey, error)
+	GetAPIKeysByUserID(ctx context.Context, userID int) ([]domain.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID int) error
+	TouchAPIKey(ctx context.Context, keyID int) error
+}
+
+// Identities interface is implemented by the linked identities' repository.
+type Identities interface {
+	CreateIdentity(ctx context.Context, identity domain.LinkedIdentity) error
+	GetIdentity(ctx context.Context, provider, subject string) (domain.LinkedIdentity, error)
+	GetIdentitiesByUserID(ctx context.Context, userID int) ([]domain.LinkedIdentity, error)
+	TouchIdentity(ctx context.Context, identityID int) error
+	DeleteIdentity(ctx context.Context, userID, identityID int) error
+	CreateLoginState(ctx context.Context, state domain.OIDCLoginState) error
+	TakeLoginState(ctx context.Context, state string) (domain.OIDCLoginState, error)
+}
+
+// OAuth interface is implemented by the OAuth2 authorization server repository.
+type OAuth interface {
+	CreateClient(ctx context.Context, client domain.OAuthClient) (domain.OAuthClient, error)
+	GetClient(ctx context.Context, clientID string) (domain.OAuthClient, error)
+	GetClientsByOwner(ctx context.Context, ownerID int) ([]domain.OAuthClient, error)
+	DeleteClient(ctx context.Context, ownerID int, clientID string) error
+	CreateAuthorizationCode(ctx context.Context, code domain.OAuthAuthorizationCode) error
+	TakeAuthorizationCode(ctx context.Context, codeHash string) (domain.OAuthAuthorizationCode, error)
+	GetConsent(ctx context.Context, userID int, clientID string) (domain.OAuthConsent, error)
+	SaveConsent(ctx context.Context, consent domain.OAuthConsent) error
+}
+
+// Exports interface is implemented by the data exports' repository.
+type Exports interface {
+	CreateExport(ctx context.Context, userID int, linkSecret []byte) (domain.DataExport, error)
+	GetExport(ctx context.Context, exportID int) (domain.DataExport, error)
+	GetActiveExport(ctx context.Context, userID int) (domain.DataExport, error)
+	StartExport(ctx context.Context, exportID int) error
+	CompleteExport(ctx context.Context, exportID int, archive []byte, expiresAt time.Time) error
+	FailExport(ctx context.Context, exportID int, reason string) error
+	GetExportArchive(ctx context.Context, exportID int) ([]byte, error)
+	DeleteExpiredExports(ctx context.Context, staleAfter time.Duration) error
+	GetUserData(ctx context.Context, userID int) (domain.UserData, error)
+}
+
+// Admin interface is implemented by the user management and the audit log repository.
+type Admin interface {
+	SearchUsers(ctx context.Context, req domain.SearchUsersRequest) ([]domain.AdminUser, error)
+	GetAdminUser(ctx context.Context, userID int) (domain.AdminUser, error)
+	ChangeUserRole(ctx context.Context, userID int, role domain.Role, entry domain.AuditEntry) error
+	DisableUser(ctx context.Context, userID int, reason string, entry domain.AuditEntry) error
+	EnableUser(ctx context.Context, userID int, entry domain.AuditEntry) error
+	DeleteSessions(ctx context.Context, userID int, entry domain.AuditEntry) (int64, error)
+	ResetPassword(ctx context.Context, userID int, passwordHash string, entry domain.AuditEntry) error
+	DeleteUser(ctx context.Context, userID int, anonymize bool, entry domain.AuditEntry) error
+	BootstrapAdmin(ctx context.Context, email string, entry domain.AuditEntry) (int, error)
+	CreateAuditEntry(ctx context.Context, entry domain.AuditEntry) error
+	GetAuditLog(ctx context.Context, req domain.AuditLogRequest) ([]domain.AuditEntry, error)
+}
+
 // Repository is the composite of all repositories.
 type Repository struct {
 	Auth
+	Profiles
 	Tests
 	Sessions
+	Members
+	Passages
+	Questions
+	TwoFactor
+	APIKeys
+	Identities
+	OAuth
+	Exports
+	Admin
 }
 
 // NewRepository returns a new instance of the repository.
@@ -48,8 +180,18 @@ func NewRepository(db *sql.DB) *Repository {
 	}
 
 	return &Repository{
-		Auth:     user.NewRepoAuth(db),
-		Tests:    tests.NewRepoTests(db),
-		Sessions: sessions.NewRepoSessions(db),
+		Auth:       user.NewRepoAuth(db),
+		Profiles:   user.NewRepoAuth(db),
+		Tests:      tests.NewRepoTests(db),
+		Sessions:   sessions.NewRepoSessions(db),
+		Members:    members.NewRepoMembers(db),
+		Passages:   passages.NewRepoPassages(db),
+		Questions:  questions.NewRepoQuestions(db),
+		TwoFactor:  twofactor.NewRepoTwoFactor(db),
+		APIKeys:    apikeys.NewRepoAPIKeys(db),
+		Identities: identities.NewRepoIdentities(db),
+		OAuth:      oauth.NewRepoOAuth(db),
+		Exports:    exports.NewRepoExports(db),
+		Admin:      admin.NewRepoAdmin(db),
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"github.com/lib/pq"
 	"github.com/popeskul/qna-go/internal/domain"
 )
 
@@ -19,22 +20,22 @@ func NewRepoSessions(db *sql.DB) *RepositorySessions {
 	}
 }
 
-func (r *RepositorySessions) CreateRefreshToken(ctx context.Context, token domain.RefreshSession) error {
-	_, err := r.db.ExecContext(ctx, "INSERT INTO refresh_tokens (user_id, token, expires_at) values ($1, $2, $3)", token.UserID, token.Token, token.ExpiresAt)
+// CreateRefreshToken stores the refresh session and returns its id.
+func (r *RepositorySessions) CreateRefreshToken(ctx context.Context, token domain.RefreshSession) (int64, error) {
+	var id int64
+	createTokenQuery := fmt.Sprintln("INSERT INTO refresh_tokens (user_id, token, client_id, scopes, expires_at) values ($1, $2, $3, $4, $5) RETURNING id")
+	err := r.db.QueryRowContext(ctx, createTokenQuery, token.UserID, token.Token, token.ClientID, pq.Array(token.Scopes), token.ExpiresAt).
+		Scan(&id)
 
-	return err
+	return id, err
 }
 
+// GetRefreshToken returns the refresh session by token and deletes it, so the token can be used once.
 func (r *RepositorySessions) GetRefreshToken(ctx context.Context, token string) (domain.RefreshSession, error) {
-	queryGetRefreshToken := fmt.Sprintf("SELECT id, user_id, token, expires_at FROM refresh_tokens WHERE token = %s", token)
+	queryGetRefreshToken := fmt.Sprintln("DELETE FROM refresh_tokens WHERE token = $1 RETURNING id, user_id, token, client_id, scopes, expires_at")
 
 	var t domain.RefreshSession
-	err := r.db.QueryRowContext(ctx, queryGetRefreshToken).Scan(&t.ID, &t.UserID, &t.Token, &t.ExpiresAt)
-	if err != nil {
-		return t, err
-	}
-
-	_, err = r.db.Exec("DELETE FROM refresh_tokens WHERE user_id = $1", t.UserID)
+	err := r.db.QueryRowContext(ctx, queryGetRefreshToken, token).Scan(&t.ID, &t.UserID, &t.Token, &t.ClientID, pq.Array(&t.Scopes), &t.ExpiresAt)
 
 	return t, err
 }
@@ -0,0 +1,207 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/lib/pq"
+
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+// ApplyTestBatch creates, updates and deletes the tests of the batch in one transaction with a statement
+// per kind of operation and returns the results in the order of the batch. The operations on the missing tests
+// fail with ErrTestNotFound and the ones on the changed versions with ErrVersionConflict. The atomic batch
+// with a failed operation is rolled back, the partial one is committed.
+func (r *RepositoryTests) ApplyTestBatch(ctx context.Context, authorID int, batch domain.TestBatch) (domain.TestBatchResult, error) {
+	result := domain.TestBatchResult{
+		Create: make([]domain.TestResult, len(batch.Create)),
+		Update: make([]domain.TestResult, len(batch.Update)),
+		Delete: make([]domain.TestResult, len(batch.Delete)),
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	if err = createTests(ctx, tx, authorID, batch.Create, result.Create); err != nil {
+		return result, err
+	}
+	if err = updateTests(ctx, tx, batch.Update, result.Update); err != nil {
+		return result, err
+	}
+	if err = deleteTests(ctx, tx, batch.Delete, result.Delete); err != nil {
+		return result, err
+	}
+
+	if batch.Mode == domain.BatchAtomic && result.Failed() > 0 {
+		result.RollBack()
+		return result, nil
+	}
+
+	return result, tx.Commit()
+}
+
+// createTests inserts all the tests with one multi-row insert.
+func createTests(ctx context.Context, tx *sql.Tx, authorID int, tests []domain.Test, results []domain.TestResult) error {
+	if len(tests) == 0 {
+		return nil
+	}
+
+	titles := make([]string, len(tests))
+	for i, test := range tests {
+		titles[i] = test.Title
+	}
+
+	createTestsQuery := fmt.Sprintf("INSERT INTO tests (title, author_id) "+
+		"SELECT title, $2 FROM unnest($1::text[]) WITH ORDINALITY AS t(title, n) ORDER BY n RETURNING %s", testColumns)
+	rows, err := tx.QueryContext(ctx, createTestsQuery, pq.Array(titles), authorID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	created := make([]domain.Test, 0, len(tests))
+	for rows.Next() {
+		var test domain.Test
+		if err = scanTest(rows, &test); err != nil {
+			return err
+		}
+		created = append(created, test)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	// the ids are given in the order of the insert
+	sort.Slice(created, func(i, j int) bool { return created[i].ID < created[j].ID })
+	for i, test := range created {
+		results[i].Test = test
+	}
+
+	return nil
+}
+
+// updateTests updates all the tests with one statement, the tests with another version are not updated.
+func updateTests(ctx context.Context, tx *sql.Tx, updates []domain.TestUpdate, results []domain.TestResult) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	ids, versions, titles := make([]int64, len(updates)), make([]int64, len(updates)), make([]string, len(updates))
+	for i, update := range updates {
+		ids[i], versions[i], titles[i] = int64(update.ID), int64(update.Version), update.Title
+	}
+
+	updateTestsQuery := fmt.Sprintf("UPDATE tests SET title = u.new_title, updated_at = now(), version = version + 1 "+
+		"FROM unnest($1::int[], $2::int[], $3::text[]) AS u(test_id, test_version, new_title) "+
+		"WHERE id = u.test_id AND (u.test_version = 0 OR version = u.test_version) RETURNING %s", testColumns)
+	rows, err := tx.QueryContext(ctx, updateTestsQuery, pq.Array(ids), pq.Array(versions), pq.Array(titles))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	updated := make(map[int]domain.Test, len(updates))
+	for rows.Next() {
+		var test domain.Test
+		if err = scanTest(rows, &test); err != nil {
+			return err
+		}
+		updated[test.ID] = test
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	existing, err := existingTests(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+
+	for i, update := range updates {
+		results[i] = missingResult(update.ID, updated, existing)
+	}
+
+	return nil
+}
+
+// deleteTests deletes all the tests with one statement, the tests with another version are not deleted.
+func deleteTests(ctx context.Context, tx *sql.Tx, deletes []domain.TestDelete, results []domain.TestResult) error {
+	if len(deletes) == 0 {
+		return nil
+	}
+
+	ids, versions := make([]int64, len(deletes)), make([]int64, len(deletes))
+	for i, d := range deletes {
+		ids[i], versions[i] = int64(d.ID), int64(d.Version)
+	}
+
+	deleteTestsQuery := fmt.Sprintln("DELETE FROM tests USING unnest($1::int[], $2::int[]) AS d(test_id, test_version) " +
+		"WHERE id = d.test_id AND (d.test_version = 0 OR version = d.test_version) RETURNING id")
+	rows, err := tx.QueryContext(ctx, deleteTestsQuery, pq.Array(ids), pq.Array(versions))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	deleted := make(map[int]domain.Test, len(deletes))
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			return err
+		}
+		deleted[id] = domain.Test{ID: id}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	existing, err := existingTests(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+
+	for i, d := range deletes {
+		results[i] = missingResult(d.ID, deleted, existing)
+	}
+
+	return nil
+}
+
+// existingTests returns the ids of the tests that exist in the transaction.
+func existingTests(ctx context.Context, tx *sql.Tx, ids []int64) (map[int]bool, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM tests WHERE id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	existing := make(map[int]bool, len(ids))
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		existing[id] = true
+	}
+
+	return existing, rows.Err()
+}
+
+// missingResult is the changed test or explains why the test wasn't changed like missingTest does.
+func missingResult(testID int, changed map[int]domain.Test, existing map[int]bool) domain.TestResult {
+	if test, ok := changed[testID]; ok {
+		return domain.TestResult{Test: test}
+	}
+
+	if existing[testID] {
+		return domain.TestResult{Test: domain.Test{ID: testID}, Err: ErrVersionConflict}
+	}
+
+	return domain.TestResult{Test: domain.Test{ID: testID}, Err: ErrTestNotFound}
+}
@@ -6,14 +6,28 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/lib/pq"
 	"github.com/popeskul/qna-go/internal/domain"
+	"strings"
 )
 
 var (
-	ErrTest         = errors.New("error test")
-	ErrTestNotFound = errors.New("test not found")
+	ErrTest            = errors.New("error test")
+	ErrTestNotFound    = errors.New("test not found")
+	ErrVersionConflict = errors.New("the test was changed by another request")
 )
 
+const testColumns = "id, title, author_id, created_at, updated_at, version"
+
+// scanner is implemented by sql.Row and sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTest(row scanner, test *domain.Test) error {
+	return row.Scan(&test.ID, &test.Title, &test.AuthorID, &test.CreatedAt, &test.UpdatedAt, &test.Version)
+}
+
 // RepositoryTests provides all the functions for the test repository.
 type RepositoryTests struct {
 	db *sql.DB
@@ -26,37 +40,22 @@ func NewRepoTests(db *sql.DB) *RepositoryTests {
 	}
 }
 
-// CreateTest creates a new test in the database.
-func (r *RepositoryTests) CreateTest(ctx context.Context, authorID int, inputTest domain.Test) error {
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback() // nolint:errcheck
-
-	createTestQuery := fmt.Sprintln("INSERT INTO tests (title, author_id) VALUES ($1, $2)")
-	rows, err := r.db.ExecContext(ctx, createTestQuery, inputTest.Title, authorID)
-	if err != nil {
-		return err
-	}
-
-	rowsAffected, err := rows.RowsAffected()
-	if err != nil {
-		return err
-	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("no rows affected %w", ErrTest)
+// CreateTest creates a new test in the database and returns the created test and error if any.
+func (r *RepositoryTests) CreateTest(ctx context.Context, authorID int, inputTest domain.Test) (domain.Test, error) {
+	var test domain.Test
+	createTestQuery := fmt.Sprintf("INSERT INTO tests (title, author_id) VALUES ($1, $2) RETURNING %s", testColumns)
+	if err := scanTest(r.db.QueryRowContext(ctx, createTestQuery, inputTest.Title, authorID), &test); err != nil {
+		return domain.Test{}, err
 	}
 
-	return tx.Commit()
+	return test, nil
 }
 
 // GetTest returns a test by id and returns test and error if any.
 func (r *RepositoryTests) GetTest(ctx context.Context, testID int) (domain.Test, error) {
 	var test domain.Test
-	getTestQuery := fmt.Sprintln("SELECT * FROM tests WHERE id = $1")
-	if err := r.db.QueryRowContext(ctx, getTestQuery, testID).Scan(&test.ID, &test.Title, &test.AuthorID, &test.CreatedAt, &test.UpdatedAt); err != nil {
+	getTestQuery := fmt.Sprintf("SELECT %s FROM tests WHERE id = $1", testColumns)
+	if err := scanTest(r.db.QueryRowContext(ctx, getTestQuery, testID), &test); err != nil {
 		if err == sql.ErrNoRows {
 			return test, ErrTestNotFound
 		}
@@ -67,10 +66,32 @@ func (r *RepositoryTests) GetTest(ctx context.Context, testID int) (domain.Test,
 	return test, nil
 }
 
+// GetTestsByIDs returns the existing tests of the ids in one query and error if any.
+func (r *RepositoryTests) GetTestsByIDs(ctx context.Context, testIDs []int) ([]domain.Test, error) {
+	allTests := make([]domain.Test, 0, len(testIDs))
+	testsByIDsQuery := fmt.Sprintf("SELECT %s FROM tests WHERE id = ANY($1) ORDER BY id", testColumns)
+
+	rows, err := r.db.QueryContext(ctx, testsByIDsQuery, pq.Array(testIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var t domain.Test
+	for rows.Next() {
+		if err = scanTest(rows, &t); err != nil {
+			return nil, err
+		}
+		allTests = append(allTests, t)
+	}
+
+	return allTests, rows.Err()
+}
+
 // GetAllTestsByUserID get all test from db by user id and returns tests and error if any.
 func (r *RepositoryTests) GetAllTestsByUserID(ctx context.Context, userID int, args domain.GetAllTestsParams) ([]domain.Test, error) {
 	allTests := make([]domain.Test, 0)
-	allTestsQuery := fmt.Sprintln("SELECT * FROM tests WHERE author_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3")
+	allTestsQuery := fmt.Sprintf("SELECT %s FROM tests WHERE author_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3", testColumns)
 
 	rows, err := r.db.QueryContext(ctx, allTestsQuery, userID, args.Limit, args.Offset)
 	if err != nil {
@@ -79,7 +100,7 @@ func (r *RepositoryTests) GetAllTestsByUserID(ctx context.Context, userID int, a
 
 	var t domain.Test
 	for rows.Next() {
-		if err = rows.Scan(&t.ID, &t.Title, &t.AuthorID, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		if err = scanTest(rows, &t); err != nil {
 			return nil, err
 		}
 		allTests = append(allTests, t)
@@ -89,32 +110,68 @@ func (r *RepositoryTests) GetAllTestsByUserID(ctx context.Context, userID int, a
 	return allTests, err
 }
 
-// UpdateTestById updates a test by id and returns error if any.
-func (r *RepositoryTests) UpdateTestById(ctx context.Context, testID int, inputTest domain.Test) error {
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return err
+// UpdateTestById updates a test by id if its version is still the given one and returns the updated test.
+// Returns ErrTestNotFound if the test doesn't exist and ErrVersionConflict if its version changed,
+// domain.AnyVersion updates any version.
+func (r *RepositoryTests) UpdateTestById(ctx context.Context, testID, version int, inputTest domain.Test) (domain.Test, error) {
+	updateTestQuery := fmt.Sprintf("UPDATE tests SET title = $1, updated_at = now(), version = version + 1 "+
+		"WHERE id = $2 AND ($3 = 0 OR version = $3) RETURNING %s", testColumns)
+
+	var test domain.Test
+	if err := scanTest(r.db.QueryRowContext(ctx, updateTestQuery, inputTest.Title, testID, version), &test); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.Test{}, r.missingTest(ctx, testID, ErrTestNotFound)
+		}
+
+		return domain.Test{}, err
 	}
-	defer tx.Rollback() // nolint:errcheck
 
-	updateTestQuery := fmt.Sprintln("UPDATE tests SET title = $1 WHERE id = $2")
-	if _, err = r.db.ExecContext(ctx, updateTestQuery, inputTest.Title, testID); err != nil {
-		return err
+	return test, nil
+}
+
+// PatchTestByID updates only the fields set in the patch if the version of the test is still the given one
+// and returns the updated test. The empty patch changes nothing, even the version.
+// Returns ErrTestNotFound if the test doesn't exist and ErrVersionConflict if its version changed.
+func (r *RepositoryTests) PatchTestByID(ctx context.Context, testID, version int, patch domain.TestPatch) (domain.Test, error) {
+	var patchTestQuery string
+	args := []interface{}{testID, version}
+
+	if patch.Empty() {
+		patchTestQuery = fmt.Sprintf("SELECT %s FROM tests WHERE id = $1 AND ($2 = 0 OR version = $2)", testColumns)
+	} else {
+		sets := []string{"updated_at = now()", "version = version + 1"}
+		if patch.Title != nil {
+			args = append(args, *patch.Title)
+			sets = append(sets, fmt.Sprintf("title = $%d", len(args)))
+		}
+
+		patchTestQuery = fmt.Sprintf("UPDATE tests SET %s WHERE id = $1 AND ($2 = 0 OR version = $2) RETURNING %s",
+			strings.Join(sets, ", "), testColumns)
 	}
 
-	return tx.Commit()
+	var test domain.Test
+	if err := scanTest(r.db.QueryRowContext(ctx, patchTestQuery, args...), &test); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.Test{}, r.missingTest(ctx, testID, ErrTestNotFound)
+		}
+
+		return domain.Test{}, err
+	}
+
+	return test, nil
 }
 
-// DeleteTestById deletes a test by id and returns error if any.
-func (r *RepositoryTests) DeleteTestById(ctx context.Context, testID int) error {
+// DeleteTestById deletes a test by id if its version is still the given one and returns error if any.
+// Returns ErrVersionConflict if the version of the test changed, domain.AnyVersion deletes any version.
+func (r *RepositoryTests) DeleteTestById(ctx context.Context, testID, version int) error {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback() // nolint:errcheck
 
-	deleteTestQuery := fmt.Sprintln("DELETE FROM tests WHERE id = $1 RETURNING id")
-	res, err := r.db.ExecContext(ctx, deleteTestQuery, testID)
+	deleteTestQuery := fmt.Sprintln("DELETE FROM tests WHERE id = $1 AND ($2 = 0 OR version = $2) RETURNING id")
+	res, err := r.db.ExecContext(ctx, deleteTestQuery, testID, version)
 	if err != nil {
 		return err
 	}
@@ -125,8 +182,23 @@ func (r *RepositoryTests) DeleteTestById(ctx context.Context, testID int) error
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("no rows affected %w", ErrTest)
+		return r.missingTest(ctx, testID, fmt.Errorf("no rows affected %w", ErrTest))
 	}
 
 	return tx.Commit()
 }
+
+// missingTest explains why the conditional change found no test: notFound if the test doesn't exist,
+// ErrVersionConflict if it exists with another version.
+func (r *RepositoryTests) missingTest(ctx context.Context, testID int, notFound error) error {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM tests WHERE id = $1)", testID).Scan(&exists); err != nil {
+		return err
+	}
+
+	if exists {
+		return ErrVersionConflict
+	}
+
+	return notFound
+}
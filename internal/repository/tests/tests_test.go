@@ -77,12 +77,16 @@ func TestRepositoryTests_CreateTest(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.args.repo.CreateTest(ctx, tt.args.userID, tt.args.input)
+			created, err := tt.args.repo.CreateTest(ctx, tt.args.userID, tt.args.input)
 
 			if err != tt.want.err {
 				t.Errorf("RepositoryTests.CreateTest() error = %v, wantErr %v", err, tt.want.err)
 			}
 
+			if created.ID <= testID || created.Title != tt.args.input.Title || created.AuthorID != tt.args.userID || created.Version != 1 {
+				t.Errorf("RepositoryTests.CreateTest() = %+v, want the created test", created)
+			}
+
 			t.Cleanup(func() {
 				helperDeleteTestByTitle(t, tt.args.input.Title)
 			})
@@ -101,9 +105,10 @@ func TestRepositoryTests_UpdateTestById(t *testing.T) {
 	updatedTitle := util.RandomString(10)
 
 	type args struct {
-		repo   *RepositoryTests
-		testID int
-		input  domain.Test
+		repo    *RepositoryTests
+		testID  int
+		version int
+		input   domain.Test
 	}
 	type want struct {
 		rest domain.Test
@@ -130,6 +135,23 @@ func TestRepositoryTests_UpdateTestById(t *testing.T) {
 				err: nil,
 			},
 		},
+		{
+			name: "Fail: update stale version",
+			args: args{
+				repo:    mockRepo,
+				testID:  createdID,
+				version: 1,
+				input: domain.Test{
+					Title: util.RandomString(10),
+				},
+			},
+			want: want{
+				rest: domain.Test{
+					Title: updatedTitle,
+				},
+				err: ErrVersionConflict,
+			},
+		},
 		{
 			name: "Fail: update test with empty title",
 			args: args{
@@ -145,7 +167,7 @@ func TestRepositoryTests_UpdateTestById(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.args.repo.UpdateTestById(ctx, tt.args.testID, tt.args.input)
+			_, err := tt.args.repo.UpdateTestById(ctx, tt.args.testID, tt.args.version, tt.args.input)
 			if err != tt.want.err {
 				t.Errorf("RepositoryTests.UpdateTestById() error = %v, wantErr %v", err, tt.want.err)
 			}
@@ -162,6 +184,78 @@ func TestRepositoryTests_UpdateTestById(t *testing.T) {
 	})
 }
 
+func TestRepositoryTests_PatchTestByID(t *testing.T) {
+	ctx := context.Background()
+	mockTestAuthorID := 1
+	input := randomTest()
+	createdID := helperCreateTest(t, mockTestAuthorID, input)
+	patchedTitle := util.RandomString(10)
+
+	tests := []struct {
+		name    string
+		testID  int
+		version int
+		patch   domain.TestPatch
+		title   string
+		err     error
+	}{
+		{
+			name:    "Success: empty patch keeps the title and the version",
+			testID:  createdID,
+			version: 1,
+			patch:   domain.TestPatch{},
+			title:   input.Title,
+		},
+		{
+			name:    "Success: patch the title",
+			testID:  createdID,
+			version: 1,
+			patch:   domain.TestPatch{Title: &patchedTitle},
+			title:   patchedTitle,
+		},
+		{
+			name:    "Fail: patch stale version",
+			testID:  createdID,
+			version: 1,
+			patch:   domain.TestPatch{Title: &patchedTitle},
+			err:     ErrVersionConflict,
+		},
+		{
+			name:    "Fail: empty patch of stale version",
+			testID:  createdID,
+			version: 1,
+			patch:   domain.TestPatch{},
+			err:     ErrVersionConflict,
+		},
+		{
+			name:   "Fail: test not found",
+			testID: 0,
+			patch:  domain.TestPatch{Title: &patchedTitle},
+			err:    ErrTestNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test, err := mockRepo.PatchTestByID(ctx, tt.testID, tt.version, tt.patch)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("RepositoryTests.PatchTestByID() error = %v, wantErr %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+
+			if test.ID != createdID || test.Title != tt.title || test.AuthorID != mockTestAuthorID {
+				t.Errorf("RepositoryTests.PatchTestByID() = %+v, want title %s", test, tt.title)
+			}
+		})
+	}
+
+	t.Cleanup(func() {
+		helperDeleteTest(t, createdID)
+	})
+}
+
 func TestRepositoryTests_DeleteTestById(t *testing.T) {
 	ctx := context.Background()
 	userIDZero := helperCreateTest(t, 1, randomTest())
@@ -203,7 +297,7 @@ func TestRepositoryTests_DeleteTestById(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.args.repo.DeleteTestById(ctx, tt.args.testID)
+			err := tt.args.repo.DeleteTestById(ctx, tt.args.testID, domain.AnyVersion)
 			if errors.Unwrap(err) != tt.want.err {
 				t.Errorf("RepositoryTests.DeleteTestById() error = %v, wantErr %v", err, tt.want.err)
 			}
@@ -302,6 +396,113 @@ func TestRepositoryTests_GetAllTestsByUserID(t *testing.T) {
 	}
 }
 
+func TestRepositoryTests_ApplyTestBatch(t *testing.T) {
+	ctx := context.Background()
+	mockUserID := 1
+
+	type want struct {
+		failed      int
+		createErr   error
+		updateErr   error
+		deleteErr   error
+		created     bool
+		updateTitle bool
+	}
+
+	tests := []struct {
+		name          string
+		mode          domain.BatchMode
+		updateVersion int
+		deleteMissing bool
+		want          want
+	}{
+		{
+			name: "Success: atomic batch",
+			mode: domain.BatchAtomic,
+			want: want{created: true, updateTitle: true},
+		},
+		{
+			name:          "Fail: atomic batch with version conflict is rolled back",
+			mode:          domain.BatchAtomic,
+			updateVersion: 2,
+			want: want{
+				failed:    4,
+				createErr: domain.ErrBatchRolledBack,
+				updateErr: ErrVersionConflict,
+				deleteErr: domain.ErrBatchRolledBack,
+			},
+		},
+		{
+			name:          "Success: partial batch with missing test",
+			mode:          domain.BatchPartial,
+			deleteMissing: true,
+			want: want{
+				failed:      1,
+				deleteErr:   ErrTestNotFound,
+				created:     true,
+				updateTitle: true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updateID := helperCreateTest(t, mockUserID, randomTest())
+			deleteID := helperCreateTest(t, mockUserID, randomTest())
+			if tt.deleteMissing {
+				helperDeleteTest(t, deleteID)
+			}
+
+			batch := domain.TestBatch{
+				Mode:   tt.mode,
+				Create: []domain.Test{randomTest(), randomTest()},
+				Update: []domain.TestUpdate{{ID: updateID, Version: tt.updateVersion, Title: util.RandomString(10)}},
+				Delete: []domain.TestDelete{{ID: deleteID, Version: 1}},
+			}
+
+			result, err := mockRepo.ApplyTestBatch(ctx, mockUserID, batch)
+			if err != nil {
+				t.Fatalf("RepositoryTests.ApplyTestBatch() error = %v", err)
+			}
+
+			if result.Failed() != tt.want.failed {
+				t.Errorf("RepositoryTests.ApplyTestBatch() failed = %d, want %d", result.Failed(), tt.want.failed)
+			}
+
+			for i, created := range result.Create {
+				if created.Err != tt.want.createErr {
+					t.Errorf("RepositoryTests.ApplyTestBatch() create error = %v, want %v", created.Err, tt.want.createErr)
+				}
+				if tt.want.created && created.Test.Title != batch.Create[i].Title {
+					t.Errorf("RepositoryTests.ApplyTestBatch() created = %+v, want the title %s", created.Test, batch.Create[i].Title)
+				}
+			}
+			if result.Update[0].Err != tt.want.updateErr {
+				t.Errorf("RepositoryTests.ApplyTestBatch() update error = %v, want %v", result.Update[0].Err, tt.want.updateErr)
+			}
+			if result.Delete[0].Err != tt.want.deleteErr {
+				t.Errorf("RepositoryTests.ApplyTestBatch() delete error = %v, want %v", result.Delete[0].Err, tt.want.deleteErr)
+			}
+
+			updated, err := mockRepo.GetTest(ctx, updateID)
+			if err != nil {
+				t.Fatalf("RepositoryTests.GetTest() error = %v", err)
+			}
+			if (updated.Title == batch.Update[0].Title) != tt.want.updateTitle {
+				t.Errorf("RepositoryTests.ApplyTestBatch() updated = %+v, want updated %v", updated, tt.want.updateTitle)
+			}
+
+			t.Cleanup(func() {
+				for _, test := range batch.Create {
+					helperDeleteTestByTitle(t, test.Title)
+				}
+				helperDeleteTest(t, updateID)
+				helperDeleteTest(t, deleteID)
+			})
+		})
+	}
+}
+
 func randomTest() domain.Test {
 	return domain.Test{
 		Title: util.RandomString(10),
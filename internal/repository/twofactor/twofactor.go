@@ -0,0 +1,205 @@
+// Package twofactor is a struct that contains all functions for the two-factor authentication repository.
+package twofactor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+var (
+	ErrTOTPNotFound      = errors.New("two-factor authentication is not enrolled")
+	ErrChallengeNotFound = errors.New("two-factor challenge not found")
+	ErrCodeUsed          = errors.New("two-factor code is already used")
+)
+
+// RepositoryTwoFactor provides all the functions for the two-factor authentication repository.
+type RepositoryTwoFactor struct {
+	db *sql.DB
+}
+
+// NewRepoTwoFactor creates a new instance of RepositoryTwoFactor.
+func NewRepoTwoFactor(db *sql.DB) *RepositoryTwoFactor {
+	return &RepositoryTwoFactor{
+		db: db,
+	}
+}
+
+// SaveTOTPSecret stores a new not yet enabled secret of the user and returns error if any.
+func (r *RepositoryTwoFactor) SaveTOTPSecret(ctx context.Context, userID int, secret string) error {
+	saveSecretQuery := fmt.Sprintln(`INSERT INTO user_totp (user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, enabled = false, last_used_step = 0, updated_at = now()`)
+	_, err := r.db.ExecContext(ctx, saveSecretQuery, userID, secret)
+
+	return err
+}
+
+// GetTOTP returns the TOTP settings of the user and error if any.
+func (r *RepositoryTwoFactor) GetTOTP(ctx context.Context, userID int) (domain.TOTP, error) {
+	var t domain.TOTP
+
+	getTOTPQuery := fmt.Sprintln("SELECT user_id, secret, enabled, last_used_step FROM user_totp WHERE user_id = $1")
+	err := r.db.QueryRowContext(ctx, getTOTPQuery, userID).Scan(&t.UserID, &t.Secret, &t.Enabled, &t.LastUsedStep)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return t, ErrTOTPNotFound
+		}
+
+		return t, err
+	}
+
+	return t, nil
+}
+
+// EnableTOTP enables the TOTP of the user and replaces the recovery codes in one transaction.
+func (r *RepositoryTwoFactor) EnableTOTP(ctx context.Context, userID int, lastUsedStep int64, codeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	enableQuery := fmt.Sprintln("UPDATE user_totp SET enabled = true, last_used_step = $1, updated_at = now() WHERE user_id = $2")
+	res, err := tx.ExecContext(ctx, enableQuery, lastUsedStep, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrTOTPNotFound
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+
+	for _, codeHash := range codeHashes {
+		if _, err = tx.ExecContext(ctx, "INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)", userID, codeHash); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UpdateTOTPLastStep remembers the time step of the last accepted code so it can't be reused.
+// Returns ErrCodeUsed if the step or a later one is already used, so only one of the concurrent uses succeeds.
+func (r *RepositoryTwoFactor) UpdateTOTPLastStep(ctx context.Context, userID int, step int64) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE user_totp SET last_used_step = $1, updated_at = now() WHERE user_id = $2 AND last_used_step < $1",
+		step, userID)
+	if err != nil {
+		return err
+	}
+
+	return codeUsed(res)
+}
+
+// DeleteTOTP disables the two-factor authentication of the user and deletes the recovery codes.
+func (r *RepositoryTwoFactor) DeleteTOTP(ctx context.Context, userID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM user_totp WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetRecoveryCodes returns the unused recovery codes of the user and error if any.
+func (r *RepositoryTwoFactor) GetRecoveryCodes(ctx context.Context, userID int) ([]domain.RecoveryCode, error) {
+	codes := make([]domain.RecoveryCode, 0)
+
+	rows, err := r.db.QueryContext(ctx, "SELECT id, user_id, code_hash FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var c domain.RecoveryCode
+	for rows.Next() {
+		if err = rows.Scan(&c.ID, &c.UserID, &c.CodeHash); err != nil {
+			return nil, err
+		}
+		codes = append(codes, c)
+	}
+
+	return codes, rows.Err()
+}
+
+// UseRecoveryCode marks the recovery code as used and returns error if any.
+// Returns ErrCodeUsed if the code is already used, so only one of the concurrent uses succeeds.
+func (r *RepositoryTwoFactor) UseRecoveryCode(ctx context.Context, codeID int) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE recovery_codes SET used_at = now() WHERE id = $1 AND used_at IS NULL", codeID)
+	if err != nil {
+		return err
+	}
+
+	return codeUsed(res)
+}
+
+// codeUsed returns ErrCodeUsed if the update of the code changed nothing.
+func codeUsed(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrCodeUsed
+	}
+
+	return nil
+}
+
+// CreateChallenge stores a new sign-in challenge and returns error if any.
+func (r *RepositoryTwoFactor) CreateChallenge(ctx context.Context, challenge domain.TwoFactorChallenge) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO two_factor_challenges (token, user_id, expires_at) VALUES ($1, $2, $3)",
+		challenge.Token, challenge.UserID, challenge.ExpiresAt)
+
+	return err
+}
+
+// UseChallengeAttempt counts an attempt to confirm the sign-in challenge and returns the challenge.
+// The attempt is counted only if the challenge has not expired and has attempts left, otherwise
+// ErrChallengeNotFound is returned. The check and the count are one statement, so the concurrent
+// attempts can't get past the limit.
+func (r *RepositoryTwoFactor) UseChallengeAttempt(ctx context.Context, token string, maxAttempts int) (domain.TwoFactorChallenge, error) {
+	var c domain.TwoFactorChallenge
+
+	useAttemptQuery := fmt.Sprintln(`UPDATE two_factor_challenges SET attempts = attempts + 1
+		WHERE token = $1 AND attempts < $2 AND expires_at > now()
+		RETURNING token, user_id, attempts, expires_at`)
+	err := r.db.QueryRowContext(ctx, useAttemptQuery, token, maxAttempts).Scan(&c.Token, &c.UserID, &c.Attempts, &c.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c, ErrChallengeNotFound
+		}
+
+		return c, err
+	}
+
+	return c, nil
+}
+
+// DeleteChallenge deletes the sign-in challenge and returns error if any.
+func (r *RepositoryTwoFactor) DeleteChallenge(ctx context.Context, token string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM two_factor_challenges WHERE token = $1", token)
+
+	return err
+}
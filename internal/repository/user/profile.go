@@ -0,0 +1,241 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+var (
+	ErrUserNotFound        = errors.New("user not found")
+	ErrEmailTaken          = errors.New("email is already taken")
+	ErrEmailChangeNotFound = errors.New("email change not found")
+)
+
+// uniqueViolation is the postgres error code of the unique constraint violation.
+const uniqueViolation = "23505"
+
+// ownedRefreshTokensQuery deletes the refresh tokens of the user and the ones issued to the user's OAuth2 clients.
+const ownedRefreshTokensQuery = "DELETE FROM refresh_tokens WHERE user_id = $1 OR client_id IN (SELECT client_id FROM oauth_clients WHERE owner_id = $1)"
+
+// GetProfile returns the profile of the user with the pending email change if any.
+// The anonymized accounts are not found.
+func (r *RepositoryAuth) GetProfile(ctx context.Context, userID int) (domain.Profile, error) {
+	var (
+		p            domain.Profile
+		pendingEmail sql.NullString
+	)
+
+	getProfileQuery := fmt.Sprintln(`SELECT u.id, u.name, u.email, e.new_email, u.avatar_url, u.locale, u.timezone, u.role, u.created_at, u.updated_at
+		FROM users u LEFT JOIN email_changes e ON e.user_id = u.id AND e.expires_at > now()
+		WHERE u.id = $1 AND u.deleted_at IS NULL`)
+	err := r.db.QueryRowContext(ctx, getProfileQuery, userID).
+		Scan(&p.ID, &p.Name, &p.Email, &pendingEmail, &p.AvatarURL, &p.Locale, &p.Timezone, &p.Role, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return p, ErrUserNotFound
+		}
+
+		return p, err
+	}
+	p.PendingEmail = pendingEmail.String
+
+	return p, nil
+}
+
+// UpdateProfile sets the fields of the profile which are not nil and returns an error if any.
+func (r *RepositoryAuth) UpdateProfile(ctx context.Context, userID int, update domain.ProfileUpdate) error {
+	updateProfileQuery := fmt.Sprintln(`UPDATE users SET name = COALESCE($1, name), avatar_url = COALESCE($2, avatar_url),
+		locale = COALESCE($3, locale), timezone = COALESCE($4, timezone), updated_at = now()
+		WHERE id = $5 AND deleted_at IS NULL`)
+	result, err := r.db.ExecContext(ctx, updateProfileQuery, update.Name, update.AvatarURL, update.Locale, update.Timezone, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// SaveEmailChange stores the email change of the user replacing the previous one and returns an error if any.
+func (r *RepositoryAuth) SaveEmailChange(ctx context.Context, change domain.EmailChange) error {
+	saveChangeQuery := fmt.Sprintln(`INSERT INTO email_changes (user_id, new_email, token_hash, expires_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET new_email = EXCLUDED.new_email, token_hash = EXCLUDED.token_hash,
+		expires_at = EXCLUDED.expires_at, created_at = now()`)
+	_, err := r.db.ExecContext(ctx, saveChangeQuery, change.UserID, change.NewEmail, change.TokenHash, change.ExpiresAt)
+
+	return err
+}
+
+// TakeEmailChange deletes the email change by the hash of its token and returns it, so it can be used once.
+func (r *RepositoryAuth) TakeEmailChange(ctx context.Context, tokenHash string) (domain.EmailChange, error) {
+	var c domain.EmailChange
+
+	takeChangeQuery := fmt.Sprintln("DELETE FROM email_changes WHERE token_hash = $1 RETURNING user_id, new_email, token_hash, expires_at")
+	err := r.db.QueryRowContext(ctx, takeChangeQuery, tokenHash).Scan(&c.UserID, &c.NewEmail, &c.TokenHash, &c.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c, ErrEmailChangeNotFound
+		}
+
+		return c, err
+	}
+
+	return c, nil
+}
+
+// UpdateUserEmail sets the confirmed email of the user and returns ErrEmailTaken if another user has it.
+func (r *RepositoryAuth) UpdateUserEmail(ctx context.Context, userID int, email string) error {
+	updateEmailQuery := fmt.Sprintln("UPDATE users SET email = $1, confirmed = TRUE, updated_at = now() WHERE id = $2 AND deleted_at IS NULL")
+	result, err := r.db.ExecContext(ctx, updateEmailQuery, email, userID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return ErrEmailTaken
+		}
+
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// DeleteUserWithData deletes the user with the tests of the user with their questions, answers and passages,
+// the passages of the user and the refresh tokens in one transaction.
+func (r *RepositoryAuth) DeleteUserWithData(ctx context.Context, userID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	if err = DeleteUserTx(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteUserTx deletes the user as DeleteUserWithData does in the transaction,
+// so the caller can write its own rows in the same transaction.
+func DeleteUserTx(ctx context.Context, tx *sql.Tx, userID int) error {
+	if err := deleteUserData(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM users WHERE id = $1", userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrDeleteUser
+	}
+
+	return nil
+}
+
+func deleteUserData(ctx context.Context, tx *sql.Tx, userID int) error {
+	deleteQueries := []string{
+		"DELETE FROM answers WHERE question_id IN (SELECT q.id FROM questions q JOIN tests t ON t.id = q.test_id WHERE t.author_id = $1)",
+		"DELETE FROM questions WHERE test_id IN (SELECT id FROM tests WHERE author_id = $1)",
+		"DELETE FROM test_passages WHERE user_id = $1 OR test_id IN (SELECT id FROM tests WHERE author_id = $1)",
+		"DELETE FROM tests WHERE author_id = $1",
+		ownedRefreshTokensQuery,
+	}
+	for _, query := range deleteQueries {
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AnonymizeUser removes the personal data of the user and keeps the account row, so the tests
+// and the passages of the user stay as they are. The credentials, the refresh tokens, the test
+// memberships, the OAuth2 clients and the data exports of the user are deleted, so the account can't be used anymore.
+func (r *RepositoryAuth) AnonymizeUser(ctx context.Context, userID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	if err = AnonymizeUserTx(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AnonymizeUserTx anonymizes the user as AnonymizeUser does in the transaction,
+// so the caller can write its own rows in the same transaction.
+func AnonymizeUserTx(ctx context.Context, tx *sql.Tx, userID int) error {
+	anonymizeUserQuery := fmt.Sprintln(`UPDATE users SET name = 'Deleted user', email = 'deleted-' || id || '@invalid', password = '',
+		avatar_url = '', locale = '', timezone = '', confirmed = FALSE, deleted_at = now(), updated_at = now()
+		WHERE id = $1 AND deleted_at IS NULL`)
+	result, err := tx.ExecContext(ctx, anonymizeUserQuery, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrDeleteUser
+	}
+
+	deleteQueries := []string{
+		ownedRefreshTokensQuery,
+		"DELETE FROM oauth_clients WHERE owner_id = $1",
+		"DELETE FROM oauth_consents WHERE user_id = $1",
+		"DELETE FROM oauth_authorization_codes WHERE user_id = $1",
+		"DELETE FROM test_members WHERE user_id = $1",
+		"DELETE FROM user_totp WHERE user_id = $1",
+		"DELETE FROM recovery_codes WHERE user_id = $1",
+		"DELETE FROM two_factor_challenges WHERE user_id = $1",
+		"DELETE FROM api_keys WHERE user_id = $1",
+		"DELETE FROM user_identities WHERE user_id = $1",
+		"DELETE FROM oidc_login_states WHERE user_id = $1",
+		"DELETE FROM email_changes WHERE user_id = $1",
+		// the row of the user stays, so the cascade of the exports never fires
+		"DELETE FROM data_exports WHERE user_id = $1",
+	}
+	for _, query := range deleteQueries {
+		if _, err = tx.ExecContext(ctx, query, userID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
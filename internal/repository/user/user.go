@@ -12,6 +12,7 @@ import (
 var (
 	ErrCreateUser = errors.New("error creating user")
 	ErrDeleteUser = errors.New("error deleting user")
+	ErrUpdateUser = errors.New("error updating user")
 )
 
 // RepositoryAuth provides all the functions to execute the queries and transactions.
@@ -56,8 +57,8 @@ func (r *RepositoryAuth) CreateUser(ctx context.Context, u domain.User) error {
 func (r *RepositoryAuth) GetUser(ctx context.Context, email string, password []byte) (domain.User, error) {
 	var user domain.User
 
-	getUserQuery := fmt.Sprintln("SELECT id, name, email, password, created_at, updated_at FROM users WHERE email = $1 AND password = $2")
-	err := r.db.QueryRowContext(ctx, getUserQuery, email, password).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt)
+	getUserQuery := fmt.Sprintln("SELECT id, name, email, password, role, disabled_at, created_at, updated_at FROM users WHERE email = $1 AND password = $2")
+	err := r.db.QueryRowContext(ctx, getUserQuery, email, password).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Role, &user.DisabledAt, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		return user, err
 	}
@@ -69,8 +70,8 @@ func (r *RepositoryAuth) GetUser(ctx context.Context, email string, password []b
 func (r *RepositoryAuth) GetUserByEmail(ctx context.Context, email string) (domain.User, error) {
 	var user domain.User
 
-	getUserQuery := fmt.Sprintln("SELECT id, name, email, password, created_at, updated_at FROM users WHERE email = $1")
-	err := r.db.QueryRowContext(ctx, getUserQuery, email).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt)
+	getUserQuery := fmt.Sprintln("SELECT id, name, email, password, role, disabled_at, created_at, updated_at FROM users WHERE email = $1")
+	err := r.db.QueryRowContext(ctx, getUserQuery, email).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Role, &user.DisabledAt, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		return user, err
 	}
@@ -78,6 +79,63 @@ func (r *RepositoryAuth) GetUserByEmail(ctx context.Context, email string) (doma
 	return user, nil
 }
 
+// GetUserByID returns a user from the database and ErrUserNotFound if there is no user with the id.
+func (r *RepositoryAuth) GetUserByID(ctx context.Context, userID int) (domain.User, error) {
+	var user domain.User
+
+	getUserQuery := fmt.Sprintln("SELECT id, name, email, password, role, disabled_at, created_at, updated_at FROM users WHERE id = $1")
+	err := r.db.QueryRowContext(ctx, getUserQuery, userID).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Role, &user.DisabledAt, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return user, ErrUserNotFound
+		}
+
+		return user, err
+	}
+
+	return user, nil
+}
+
+// UpdateUserRole sets the role of a user and returns an error if any.
+func (r *RepositoryAuth) UpdateUserRole(ctx context.Context, userID int, role domain.Role) error {
+	updateRoleQuery := fmt.Sprintln("UPDATE users SET role = $1, updated_at = now() WHERE id = $2")
+	result, err := r.db.ExecContext(ctx, updateRoleQuery, role, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrUpdateUser
+	}
+
+	return nil
+}
+
+// UpdateUserPassword sets the password hash of a user and returns an error if any.
+func (r *RepositoryAuth) UpdateUserPassword(ctx context.Context, userID int, passwordHash string) error {
+	updatePasswordQuery := fmt.Sprintln("UPDATE users SET password = $1, updated_at = now() WHERE id = $2")
+	result, err := r.db.ExecContext(ctx, updatePasswordQuery, passwordHash, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrUpdateUser
+	}
+
+	return nil
+}
+
 // DeleteUserById deletes a user from the database and returns an error if any.
 func (r *RepositoryAuth) DeleteUserById(ctx context.Context, userID int) error {
 	tx, err := r.db.BeginTx(ctx, nil)
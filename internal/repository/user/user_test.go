@@ -3,6 +3,7 @@ package user
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"github.com/joho/godotenv"
 	"github.com/popeskul/qna-go/internal/config"
 	"github.com/popeskul/qna-go/internal/db"
@@ -173,6 +174,32 @@ func TestRepositoryAuth_GetUser(t *testing.T) {
 	})
 }
 
+func TestRepositoryAuth_GetUserByID(t *testing.T) {
+	ctx := context.Background()
+	u := randomUser()
+	if err := mockRepo.CreateUser(ctx, u); err != nil {
+		t.Fatalf("error creating user: %v", err)
+	}
+
+	userID, err := findUserIDByEmail(u.Email)
+	if err != nil {
+		t.Fatalf("error finding user: %v", err)
+	}
+
+	got, err := mockRepo.GetUserByID(ctx, userID)
+	if err != nil || got.Email != u.Email {
+		t.Errorf("RepositoryAuth.GetUserByID() = %v, %v, want the user %s", got.Email, err, u.Email)
+	}
+
+	if err = mockRepo.DeleteUserById(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = mockRepo.GetUserByID(ctx, userID); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("RepositoryAuth.GetUserByID() error = %v, want %v", err, ErrUserNotFound)
+	}
+}
+
 func TestRepositoryAuth_DeleteUserById(t *testing.T) {
 	ctx := context.Background()
 	user := randomUser()
@@ -226,6 +253,77 @@ func TestRepositoryAuth_DeleteUserById(t *testing.T) {
 	}
 }
 
+func TestRepositoryAuth_AnonymizeUser(t *testing.T) {
+	ctx := context.Background()
+	u := randomUser()
+	if err := mockRepo.CreateUser(ctx, u); err != nil {
+		t.Fatalf("error creating user: %v", err)
+	}
+
+	userID, err := findUserIDByEmail(u.Email)
+	if err != nil {
+		t.Fatalf("error finding user: %v", err)
+	}
+
+	t.Cleanup(func() {
+		helperDeleteUserByID(t, userID)
+	})
+
+	if _, err = mockDB.ExecContext(ctx, "INSERT INTO data_exports (user_id, status, archive, link_secret) VALUES ($1, 'ready', $2, $3)",
+		userID, []byte("archive"), []byte("secret")); err != nil {
+		t.Fatalf("error creating export: %v", err)
+	}
+
+	if err = mockRepo.AnonymizeUser(ctx, userID); err != nil {
+		t.Fatalf("RepositoryAuth.AnonymizeUser() error = %v", err)
+	}
+
+	var exports int
+	if err = mockDB.QueryRowContext(ctx, "SELECT count(*) FROM data_exports WHERE user_id = $1", userID).Scan(&exports); err != nil {
+		t.Fatal(err)
+	}
+	if exports != 0 {
+		t.Errorf("got %d exports of the anonymized user, want 0", exports)
+	}
+}
+
+func TestRepositoryAuth_DeleteUserWithData(t *testing.T) {
+	ctx := context.Background()
+	u := randomUser()
+	if err := mockRepo.CreateUser(ctx, u); err != nil {
+		t.Fatalf("error creating user: %v", err)
+	}
+
+	userID, err := findUserIDByEmail(u.Email)
+	if err != nil {
+		t.Fatalf("error finding user: %v", err)
+	}
+
+	if _, err = mockDB.ExecContext(ctx, "INSERT INTO tests (title, author_id) VALUES ($1, $2)", util.RandomString(10), userID); err != nil {
+		t.Fatalf("error creating test: %v", err)
+	}
+
+	if err = mockRepo.DeleteUserWithData(ctx, userID); err != nil {
+		t.Fatalf("RepositoryAuth.DeleteUserWithData() error = %v", err)
+	}
+
+	var tests int
+	if err = mockDB.QueryRowContext(ctx, "SELECT count(*) FROM tests WHERE author_id = $1", userID).Scan(&tests); err != nil {
+		t.Fatal(err)
+	}
+	if tests != 0 {
+		t.Errorf("got %d tests of the deleted user, want 0", tests)
+	}
+
+	if _, err = mockRepo.GetUserByID(ctx, userID); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("RepositoryAuth.GetUserByID() error = %v, want %v", err, ErrUserNotFound)
+	}
+
+	if err = mockRepo.DeleteUserWithData(ctx, userID); err != ErrDeleteUser {
+		t.Errorf("RepositoryAuth.DeleteUserWithData() error = %v, want %v", err, ErrDeleteUser)
+	}
+}
+
 func randomUser() domain.User {
 	return domain.User{
 		Name:     util.RandomString(10),
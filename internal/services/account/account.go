@@ -0,0 +1,305 @@
+// Package account is a service with all business logic for the profile and the account of the current user.
+package account
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	netmail "net/mail"
+	"net/url"
+	"strings"
+	"time"
+	_ "time/tzdata" // the timezones are validated without the system database
+
+	"golang.org/x/text/language"
+
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/mail"
+	"github.com/popeskul/qna-go/internal/repository"
+	"github.com/popeskul/qna-go/internal/repository/user"
+)
+
+// DeletionPolicy defines what happens with the data of the deleted account.
+type DeletionPolicy string
+
+const (
+	// DeleteData deletes the user with the tests, the passages and the refresh tokens.
+	DeleteData DeletionPolicy = "delete"
+	// AnonymizeData removes the personal data and keeps the tests and the passages of the user.
+	AnonymizeData DeletionPolicy = "anonymize"
+)
+
+// DefaultEmailChangeTTL is how long the email change waits for the confirmation by default.
+const DefaultEmailChangeTTL = 24 * time.Hour
+
+const (
+	minNameLength   = 3
+	maxNameLength   = 255
+	maxEmailLength  = 255
+	maxAvatarLength = 2048
+	tokenBytes      = 32
+)
+
+var (
+	ErrInvalidName        = errors.New("name must be from 3 to 255 characters")
+	ErrInvalidEmail       = errors.New("invalid email")
+	ErrInvalidAvatarURL   = errors.New("avatar url must be an absolute http or https url")
+	ErrInvalidLocale      = errors.New("invalid locale")
+	ErrInvalidTimezone    = errors.New("invalid timezone")
+	ErrEmailChangeExpired = errors.New("email change is expired")
+)
+
+// Config defines the account management.
+type Config struct {
+	DeletionPolicy DeletionPolicy
+	// ConfirmEmailURL is the page confirming the email change, the token is added as the token query parameter.
+	// If it is empty the message contains only the token.
+	ConfirmEmailURL string
+	EmailChangeTTL  time.Duration
+}
+
+// ServiceAccount compose all functions for the account of the current user.
+type ServiceAccount struct {
+	repo     repository.Auth
+	profiles repository.Profiles
+	mailer   mail.Sender
+	cfg      Config
+	now      func() time.Time
+}
+
+// NewServiceAccount create service with all fields.
+func NewServiceAccount(repo repository.Auth, profiles repository.Profiles, mailer mail.Sender, cfg Config) *ServiceAccount {
+	if cfg.DeletionPolicy == "" {
+		cfg.DeletionPolicy = DeleteData
+	}
+
+	if cfg.EmailChangeTTL == 0 {
+		cfg.EmailChangeTTL = DefaultEmailChangeTTL
+	}
+
+	return &ServiceAccount{
+		repo:     repo,
+		profiles: profiles,
+		mailer:   mailer,
+		cfg:      cfg,
+		now:      time.Now,
+	}
+}
+
+// GetProfile returns the profile of the user.
+func (s *ServiceAccount) GetProfile(ctx context.Context, userID int) (domain.Profile, error) {
+	return s.profiles.GetProfile(ctx, userID)
+}
+
+// UpdateProfile validates and saves the fields present in the request and returns the updated profile.
+// The new email is not saved, the confirmation link is sent to it and the email is changed by ConfirmEmailChange.
+func (s *ServiceAccount) UpdateProfile(ctx context.Context, userID int, req domain.UpdateProfileRequest) (domain.Profile, error) {
+	profile, err := s.profiles.GetProfile(ctx, userID)
+	if err != nil {
+		return profile, err
+	}
+
+	update, err := validateProfile(req)
+	if err != nil {
+		return profile, err
+	}
+
+	var newEmail string
+	if req.Email != nil {
+		newEmail, err = validateEmail(*req.Email)
+		if err != nil {
+			return profile, err
+		}
+
+		if strings.EqualFold(newEmail, profile.Email) {
+			newEmail = ""
+		} else if _, err = s.repo.GetUserByEmail(ctx, newEmail); err == nil {
+			return profile, user.ErrEmailTaken
+		}
+	}
+
+	if update != (domain.ProfileUpdate{}) {
+		if err = s.profiles.UpdateProfile(ctx, userID, update); err != nil {
+			return profile, err
+		}
+	}
+
+	if newEmail != "" {
+		if err = s.requestEmailChange(ctx, userID, newEmail); err != nil {
+			return profile, err
+		}
+	}
+
+	return s.profiles.GetProfile(ctx, userID)
+}
+
+// requestEmailChange saves the new email and sends the confirmation link to it.
+func (s *ServiceAccount) requestEmailChange(ctx context.Context, userID int, newEmail string) error {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	token := hex.EncodeToString(b)
+
+	err := s.profiles.SaveEmailChange(ctx, domain.EmailChange{
+		UserID:    userID,
+		NewEmail:  newEmail,
+		TokenHash: hashToken(token),
+		ExpiresAt: s.now().Add(s.cfg.EmailChangeTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      newEmail,
+		Subject: "Confirm your new email",
+		Body:    fmt.Sprintf("Confirm the new email of your account: %s\nThe link expires in %s.", s.confirmLink(token), s.cfg.EmailChangeTTL),
+	})
+}
+
+// confirmLink returns the link confirming the email change or the token itself if there is no confirmation page.
+func (s *ServiceAccount) confirmLink(token string) string {
+	if s.cfg.ConfirmEmailURL == "" {
+		return token
+	}
+
+	u, err := url.Parse(s.cfg.ConfirmEmailURL)
+	if err != nil {
+		return token
+	}
+
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+// ConfirmEmailChange sets the new email of the user by the token sent to it.
+// The token is used once, the previous email gets the notice about the change.
+func (s *ServiceAccount) ConfirmEmailChange(ctx context.Context, token string) error {
+	change, err := s.profiles.TakeEmailChange(ctx, hashToken(token))
+	if err != nil {
+		return err
+	}
+
+	if !change.ExpiresAt.After(s.now()) {
+		return ErrEmailChangeExpired
+	}
+
+	previous, err := s.repo.GetUserByID(ctx, change.UserID)
+	if err != nil {
+		return err
+	}
+
+	if err = s.profiles.UpdateUserEmail(ctx, change.UserID, change.NewEmail); err != nil {
+		return err
+	}
+
+	// the email is already changed, the notice is not worth failing the confirmation
+	_ = s.mailer.Send(ctx, mail.Message{
+		To:      previous.Email,
+		Subject: "Your email was changed",
+		Body:    fmt.Sprintf("The email of your account was changed to %s.", change.NewEmail),
+	})
+
+	return nil
+}
+
+// DeleteAccount deletes the account of the user with the configured policy in one transaction.
+func (s *ServiceAccount) DeleteAccount(ctx context.Context, userID int) error {
+	if s.cfg.DeletionPolicy == AnonymizeData {
+		return s.profiles.AnonymizeUser(ctx, userID)
+	}
+
+	return s.profiles.DeleteUserWithData(ctx, userID)
+}
+
+// validateProfile validates the fields of the request stored as they are.
+func validateProfile(req domain.UpdateProfileRequest) (domain.ProfileUpdate, error) {
+	var update domain.ProfileUpdate
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if n := len([]rune(name)); n < minNameLength || n > maxNameLength {
+			return update, ErrInvalidName
+		}
+		update.Name = &name
+	}
+
+	if req.AvatarURL != nil {
+		avatarURL := strings.TrimSpace(*req.AvatarURL)
+		if avatarURL != "" && !validAvatarURL(avatarURL) {
+			return update, ErrInvalidAvatarURL
+		}
+		update.AvatarURL = &avatarURL
+	}
+
+	if req.Locale != nil {
+		locale := strings.TrimSpace(*req.Locale)
+		if locale != "" {
+			tag, err := language.Parse(locale)
+			if err != nil {
+				return update, ErrInvalidLocale
+			}
+			locale = tag.String()
+		}
+		update.Locale = &locale
+	}
+
+	if req.Timezone != nil {
+		timezone := strings.TrimSpace(*req.Timezone)
+		if timezone != "" {
+			if timezone == "Local" {
+				return update, ErrInvalidTimezone
+			}
+
+			if _, err := time.LoadLocation(timezone); err != nil {
+				return update, ErrInvalidTimezone
+			}
+		}
+		update.Timezone = &timezone
+	}
+
+	return update, nil
+}
+
+// validateEmail returns the email if it is a plain address.
+func validateEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	if len(email) > maxEmailLength {
+		return "", ErrInvalidEmail
+	}
+
+	addr, err := netmail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", ErrInvalidEmail
+	}
+
+	return email, nil
+}
+
+// validAvatarURL checks the avatar url is an absolute http or https url.
+func validAvatarURL(avatarURL string) bool {
+	if len(avatarURL) > maxAvatarLength {
+		return false
+	}
+
+	u, err := url.Parse(avatarURL)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
+
+// hashToken returns the hash of the email change token kept in the db.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,131 @@
+package account
+
+import (
+	"testing"
+
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestValidateProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   domain.UpdateProfileRequest
+		want    domain.ProfileUpdate
+		wantErr error
+	}{
+		{
+			name:  "Success: nothing to change",
+			input: domain.UpdateProfileRequest{},
+			want:  domain.ProfileUpdate{},
+		},
+		{
+			name: "Success: normalize the fields",
+			input: domain.UpdateProfileRequest{
+				Name:      strPtr("  Jane Doe "),
+				AvatarURL: strPtr("https://example.com/a.png"),
+				Locale:    strPtr("pt-br"),
+				Timezone:  strPtr("America/Sao_Paulo"),
+			},
+			want: domain.ProfileUpdate{
+				Name:      strPtr("Jane Doe"),
+				AvatarURL: strPtr("https://example.com/a.png"),
+				Locale:    strPtr("pt-BR"),
+				Timezone:  strPtr("America/Sao_Paulo"),
+			},
+		},
+		{
+			name:  "Success: clear the optional fields",
+			input: domain.UpdateProfileRequest{AvatarURL: strPtr(""), Locale: strPtr(""), Timezone: strPtr("")},
+			want:  domain.ProfileUpdate{AvatarURL: strPtr(""), Locale: strPtr(""), Timezone: strPtr("")},
+		},
+		{
+			name:    "Error: short name",
+			input:   domain.UpdateProfileRequest{Name: strPtr(" ab ")},
+			wantErr: ErrInvalidName,
+		},
+		{
+			name:    "Error: relative avatar url",
+			input:   domain.UpdateProfileRequest{AvatarURL: strPtr("/avatar.png")},
+			wantErr: ErrInvalidAvatarURL,
+		},
+		{
+			name:    "Error: avatar url with another scheme",
+			input:   domain.UpdateProfileRequest{AvatarURL: strPtr("ftp://example.com/a.png")},
+			wantErr: ErrInvalidAvatarURL,
+		},
+		{
+			name:    "Error: invalid locale",
+			input:   domain.UpdateProfileRequest{Locale: strPtr("not a locale")},
+			wantErr: ErrInvalidLocale,
+		},
+		{
+			name:    "Error: unknown timezone",
+			input:   domain.UpdateProfileRequest{Timezone: strPtr("Mars/Olympus")},
+			wantErr: ErrInvalidTimezone,
+		},
+		{
+			name:    "Error: local timezone of the server",
+			input:   domain.UpdateProfileRequest{Timezone: strPtr("Local")},
+			wantErr: ErrInvalidTimezone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validateProfile(tt.input)
+			if err != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			for field, pair := range map[string][2]*string{
+				"name":       {tt.want.Name, got.Name},
+				"avatar_url": {tt.want.AvatarURL, got.AvatarURL},
+				"locale":     {tt.want.Locale, got.Locale},
+				"timezone":   {tt.want.Timezone, got.Timezone},
+			} {
+				want, have := pair[0], pair[1]
+				if (want == nil) != (have == nil) || (want != nil && *want != *have) {
+					t.Errorf("%s: expected %v, got %v", field, deref(want), deref(have))
+				}
+			}
+		})
+	}
+}
+
+func TestValidateEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{name: "Success: plain address", input: "jane@example.com"},
+		{name: "Success: trim spaces", input: " jane@example.com "},
+		{name: "Error: no domain", input: "jane", wantErr: true},
+		{name: "Error: address with a name", input: "Jane <jane@example.com>", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := validateEmail(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return "<nil>"
+	}
+
+	return *s
+}
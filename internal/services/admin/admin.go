@@ -0,0 +1,293 @@
+// Package admin is a service with all business logic for the user management by the operators.
+package admin
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/hash"
+	"github.com/popeskul/qna-go/internal/lockout"
+	"github.com/popeskul/qna-go/internal/repository"
+	"github.com/popeskul/qna-go/internal/services/account"
+	"github.com/popeskul/qna-go/internal/token"
+)
+
+// ImpersonationTTL is how long the impersonation token is valid.
+const ImpersonationTTL = 15 * time.Minute
+
+const (
+	defaultLimit           = 20
+	temporaryPasswordLen   = 20
+	temporaryPasswordChars = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+var (
+	ErrInvalidRole         = errors.New("invalid role")
+	ErrSelfAction          = errors.New("the action is not allowed on your own account")
+	ErrImpersonateAdmin    = errors.New("an admin can't be impersonated")
+	ErrImpersonateDisabled = errors.New("a disabled user can't be impersonated")
+)
+
+// ServiceAdmin compose all functions for the user management.
+// Every action is written with its audit entry in one transaction.
+type ServiceAdmin struct {
+	repo           repository.Admin
+	tokenManager   token.Manager
+	hashManager    *hash.Manager
+	deletionPolicy account.DeletionPolicy
+}
+
+// NewServiceAdmin create service with all fields. The users are deleted with the deletion policy of the accounts.
+func NewServiceAdmin(
+	repo repository.Admin,
+	tokenManager token.Manager,
+	hashManager *hash.Manager,
+	deletionPolicy account.DeletionPolicy) *ServiceAdmin {
+	return &ServiceAdmin{
+		repo:           repo,
+		tokenManager:   tokenManager,
+		hashManager:    hashManager,
+		deletionPolicy: deletionPolicy,
+	}
+}
+
+// SearchUsers returns the users matching the request, 20 by default.
+func (s *ServiceAdmin) SearchUsers(ctx context.Context, req domain.SearchUsersRequest) ([]domain.AdminUser, error) {
+	if req.Limit == 0 {
+		req.Limit = defaultLimit
+	}
+
+	return s.repo.SearchUsers(ctx, req)
+}
+
+// GetUser returns the user and records that the operator has viewed it.
+func (s *ServiceAdmin) GetUser(ctx context.Context, actorID, userID int) (domain.AdminUser, error) {
+	u, err := s.repo.GetAdminUser(ctx, userID)
+	if err != nil {
+		return u, err
+	}
+
+	return u, s.audit(ctx, actorID, domain.AuditViewUser, userID, nil)
+}
+
+// UpdateUserRole assign a new role to the user. The new role is applied to the tokens issued after the change.
+func (s *ServiceAdmin) UpdateUserRole(ctx context.Context, actorID, userID int, role domain.Role) error {
+	if !role.Valid() {
+		return ErrInvalidRole
+	}
+
+	u, err := s.repo.GetAdminUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	entry, err := newAuditEntry(ctx, actorID, domain.AuditUpdateRole, userID, map[string]interface{}{"from": u.Role, "to": role})
+	if err != nil {
+		return err
+	}
+
+	return s.repo.ChangeUserRole(ctx, userID, role, entry)
+}
+
+// DisableUser disables the user and wipes the sessions. The disabled user can't sign in or refresh the tokens,
+// the access tokens issued before stay valid until they expire.
+func (s *ServiceAdmin) DisableUser(ctx context.Context, actorID, userID int, reason string) error {
+	if actorID == userID {
+		return ErrSelfAction
+	}
+
+	entry, err := newAuditEntry(ctx, actorID, domain.AuditDisableUser, userID, map[string]interface{}{"reason": reason})
+	if err != nil {
+		return err
+	}
+
+	return s.repo.DisableUser(ctx, userID, reason, entry)
+}
+
+// EnableUser enables the disabled user.
+func (s *ServiceAdmin) EnableUser(ctx context.Context, actorID, userID int) error {
+	entry, err := newAuditEntry(ctx, actorID, domain.AuditEnableUser, userID, nil)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.EnableUser(ctx, userID, entry)
+}
+
+// ForceLogout deletes the refresh tokens of the user and returns how many were deleted.
+// The number is recorded in the sessions detail of the audit entry.
+// The access tokens issued before stay valid until they expire.
+func (s *ServiceAdmin) ForceLogout(ctx context.Context, actorID, userID int) (int64, error) {
+	if _, err := s.repo.GetAdminUser(ctx, userID); err != nil {
+		return 0, err
+	}
+
+	entry, err := newAuditEntry(ctx, actorID, domain.AuditForceLogout, userID, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.repo.DeleteSessions(ctx, userID, entry)
+}
+
+// ResetPassword sets a random temporary password, wipes the sessions of the user and returns the password.
+// The password isn't stored in plain text and is returned only once.
+func (s *ServiceAdmin) ResetPassword(ctx context.Context, actorID, userID int, reason string) (string, error) {
+	if _, err := s.repo.GetAdminUser(ctx, userID); err != nil {
+		return "", err
+	}
+
+	temporaryPassword, err := newTemporaryPassword()
+	if err != nil {
+		return "", err
+	}
+
+	hashedPassword, err := s.hashManager.HashPassword(temporaryPassword)
+	if err != nil {
+		return "", err
+	}
+
+	entry, err := newAuditEntry(ctx, actorID, domain.AuditResetPassword, userID, map[string]interface{}{"reason": reason})
+	if err != nil {
+		return "", err
+	}
+
+	if err = s.repo.ResetPassword(ctx, userID, hashedPassword, entry); err != nil {
+		return "", err
+	}
+
+	return temporaryPassword, nil
+}
+
+// Impersonate issues a short-lived access token to act as the user. The token has no refresh token
+// and can't be used to manage the account of the user. Admins and disabled users can't be impersonated.
+func (s *ServiceAdmin) Impersonate(ctx context.Context, actorID, userID int, reason string) (domain.ImpersonationResponse, error) {
+	var response domain.ImpersonationResponse
+
+	if actorID == userID {
+		return response, ErrSelfAction
+	}
+
+	u, err := s.repo.GetAdminUser(ctx, userID)
+	if err != nil {
+		return response, err
+	}
+
+	if u.Role == domain.RoleAdmin {
+		return response, ErrImpersonateAdmin
+	}
+
+	if u.DisabledAt != nil || u.DeletedAt != nil {
+		return response, ErrImpersonateDisabled
+	}
+
+	accessToken, err := s.tokenManager.CreateToken(token.Claims{
+		UserID:         u.ID,
+		Role:           string(u.Role),
+		ImpersonatorID: actorID,
+	}, ImpersonationTTL)
+	if err != nil {
+		return response, err
+	}
+
+	payload, err := s.tokenManager.VerifyToken(accessToken)
+	if err != nil {
+		return response, err
+	}
+
+	response = domain.ImpersonationResponse{AccessToken: accessToken, ExpiresAt: payload.ExpiredAt}
+
+	return response, s.audit(ctx, actorID, domain.AuditImpersonate, userID, map[string]interface{}{
+		"reason":   reason,
+		"token_id": payload.ID,
+	})
+}
+
+// DeleteUser deletes the account of the user with the configured deletion policy.
+func (s *ServiceAdmin) DeleteUser(ctx context.Context, actorID, userID int, reason string) error {
+	if actorID == userID {
+		return ErrSelfAction
+	}
+
+	u, err := s.repo.GetAdminUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	entry, err := newAuditEntry(ctx, actorID, domain.AuditDeleteUser, userID, map[string]interface{}{"reason": reason, "email": u.Email})
+	if err != nil {
+		return err
+	}
+
+	return s.repo.DeleteUser(ctx, userID, s.deletionPolicy == account.AnonymizeData, entry)
+}
+
+// BootstrapAdmin assigns the admin role to the user with the email if there is no admin yet,
+// so the first admin can be created from the config. It returns admin.ErrAdminExists if there is an admin
+// and admin.ErrUserNotFound if the email has no enabled user. The audit entry has no actor.
+func (s *ServiceAdmin) BootstrapAdmin(ctx context.Context, email string) error {
+	_, err := s.repo.BootstrapAdmin(ctx, strings.TrimSpace(email), domain.AuditEntry{Action: domain.AuditBootstrapAdmin})
+
+	return err
+}
+
+// GetAuditLog returns the audit log entries matching the request, the latest first, 20 by default.
+func (s *ServiceAdmin) GetAuditLog(ctx context.Context, req domain.AuditLogRequest) ([]domain.AuditEntry, error) {
+	if req.Limit == 0 {
+		req.Limit = defaultLimit
+	}
+
+	return s.repo.GetAuditLog(ctx, req)
+}
+
+// audit records the action which changes nothing, as viewing the user.
+func (s *ServiceAdmin) audit(ctx context.Context, actorID int, action domain.AuditAction, targetUserID int, details map[string]interface{}) error {
+	entry, err := newAuditEntry(ctx, actorID, action, targetUserID, details)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.CreateAuditEntry(ctx, entry)
+}
+
+// newAuditEntry returns the entry of the action with the client IP taken from lockout.WithClientIP.
+func newAuditEntry(ctx context.Context, actorID int, action domain.AuditAction, targetUserID int, details map[string]interface{}) (domain.AuditEntry, error) {
+	entry := domain.AuditEntry{
+		ActorID:      &actorID,
+		Action:       action,
+		TargetUserID: &targetUserID,
+		IP:           lockout.ClientIP(ctx),
+	}
+
+	if details != nil {
+		b, err := json.Marshal(details)
+		if err != nil {
+			return entry, err
+		}
+		entry.Details = b
+	}
+
+	return entry, nil
+}
+
+// newTemporaryPassword returns a random password without the look-alike characters.
+func newTemporaryPassword() (string, error) {
+	b := make([]byte, temporaryPasswordLen)
+	max := big.NewInt(int64(len(temporaryPasswordChars)))
+
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = temporaryPasswordChars[n.Int64()]
+	}
+
+	return string(b), nil
+}
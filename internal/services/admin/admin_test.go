@@ -0,0 +1,197 @@
+package admin
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/lockout"
+	"github.com/popeskul/qna-go/internal/repository/admin"
+	"github.com/popeskul/qna-go/internal/services/account"
+	"github.com/popeskul/qna-go/internal/token"
+	"github.com/popeskul/qna-go/internal/util"
+)
+
+// memoryRepo keeps the users and the audit log in memory.
+type memoryRepo struct {
+	users      map[int]domain.AdminUser
+	entries    []domain.AuditEntry
+	anonymized []int
+}
+
+func (r *memoryRepo) SearchUsers(_ context.Context, _ domain.SearchUsersRequest) ([]domain.AdminUser, error) {
+	return nil, nil
+}
+
+func (r *memoryRepo) GetAdminUser(_ context.Context, userID int) (domain.AdminUser, error) {
+	u, ok := r.users[userID]
+	if !ok {
+		return u, admin.ErrUserNotFound
+	}
+
+	return u, nil
+}
+
+func (r *memoryRepo) ChangeUserRole(_ context.Context, _ int, _ domain.Role, entry domain.AuditEntry) error {
+	return r.CreateAuditEntry(context.Background(), entry)
+}
+
+func (r *memoryRepo) DisableUser(_ context.Context, _ int, _ string, entry domain.AuditEntry) error {
+	return r.CreateAuditEntry(context.Background(), entry)
+}
+
+func (r *memoryRepo) EnableUser(_ context.Context, _ int, entry domain.AuditEntry) error {
+	return r.CreateAuditEntry(context.Background(), entry)
+}
+
+func (r *memoryRepo) DeleteSessions(_ context.Context, _ int, entry domain.AuditEntry) (int64, error) {
+	return 0, r.CreateAuditEntry(context.Background(), entry)
+}
+
+func (r *memoryRepo) ResetPassword(_ context.Context, _ int, _ string, entry domain.AuditEntry) error {
+	return r.CreateAuditEntry(context.Background(), entry)
+}
+
+func (r *memoryRepo) DeleteUser(_ context.Context, userID int, anonymize bool, entry domain.AuditEntry) error {
+	if _, ok := r.users[userID]; !ok {
+		return admin.ErrUserNotFound
+	}
+
+	if anonymize {
+		r.anonymized = append(r.anonymized, userID)
+	}
+	delete(r.users, userID)
+
+	return r.CreateAuditEntry(context.Background(), entry)
+}
+
+func (r *memoryRepo) BootstrapAdmin(_ context.Context, _ string, _ domain.AuditEntry) (int, error) {
+	return 0, admin.ErrUserNotFound
+}
+
+func (r *memoryRepo) CreateAuditEntry(_ context.Context, entry domain.AuditEntry) error {
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func (r *memoryRepo) GetAuditLog(_ context.Context, _ domain.AuditLogRequest) ([]domain.AuditEntry, error) {
+	return r.entries, nil
+}
+
+func TestServiceAdmin_Impersonate(t *testing.T) {
+	tokenManager, err := token.NewPasetoManager(util.RandomString(32))
+	if err != nil {
+		t.Fatalf("error creating token manager: %v", err)
+	}
+
+	disabledAt := time.Now()
+	repo := &memoryRepo{users: map[int]domain.AdminUser{
+		1: {ID: 1, Role: domain.RoleAdmin},
+		2: {ID: 2, Role: domain.RoleAuthor},
+		3: {ID: 3, Role: domain.RoleAdmin},
+		4: {ID: 4, Role: domain.RoleAuthor, DisabledAt: &disabledAt},
+	}}
+	s := NewServiceAdmin(repo, tokenManager, nil, account.DeleteData)
+	ctx := lockout.WithClientIP(context.Background(), "10.0.0.1")
+
+	tests := []struct {
+		name   string
+		userID int
+		err    error
+	}{
+		{name: "Error: yourself", userID: 1, err: ErrSelfAction},
+		{name: "Error: another admin", userID: 3, err: ErrImpersonateAdmin},
+		{name: "Error: disabled user", userID: 4, err: ErrImpersonateDisabled},
+		{name: "Error: unknown user", userID: 5, err: admin.ErrUserNotFound},
+		{name: "Success", userID: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := s.Impersonate(ctx, 1, tt.userID, "support")
+			if err != tt.err {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+
+			if tt.err != nil {
+				return
+			}
+
+			payload, err := tokenManager.VerifyToken(response.AccessToken)
+			if err != nil {
+				t.Fatalf("error verifying token: %v", err)
+			}
+
+			if payload.UserID != 2 || payload.ImpersonatorID != 1 || payload.SessionID != 0 {
+				t.Errorf("unexpected payload: %+v", payload)
+			}
+
+			if ttl := time.Until(response.ExpiresAt); ttl > ImpersonationTTL {
+				t.Errorf("the token is valid for %v", ttl)
+			}
+		})
+	}
+
+	if len(repo.entries) != 1 {
+		t.Fatalf("expected 1 audit entry, got %d", len(repo.entries))
+	}
+
+	entry := repo.entries[0]
+	if entry.Action != domain.AuditImpersonate || *entry.ActorID != 1 || *entry.TargetUserID != 2 || entry.IP != "10.0.0.1" ||
+		!strings.Contains(string(entry.Details), `"reason":"support"`) {
+		t.Errorf("unexpected audit entry: %+v", entry)
+	}
+}
+
+func TestServiceAdmin_DeleteUser(t *testing.T) {
+	repo := &memoryRepo{users: map[int]domain.AdminUser{
+		1: {ID: 1, Role: domain.RoleAdmin},
+		2: {ID: 2, Email: "user@example.com", Role: domain.RoleAuthor},
+	}}
+	s := NewServiceAdmin(repo, nil, nil, account.AnonymizeData)
+
+	if err := s.DeleteUser(context.Background(), 1, 1, "cleanup"); err != ErrSelfAction {
+		t.Fatalf("expected %v, got %v", ErrSelfAction, err)
+	}
+
+	if err := s.DeleteUser(context.Background(), 1, 2, "cleanup"); err != nil {
+		t.Fatalf("error deleting user: %v", err)
+	}
+
+	if len(repo.anonymized) != 1 || repo.anonymized[0] != 2 {
+		t.Errorf("expected the user to be anonymized with the deletion policy, got %v", repo.anonymized)
+	}
+
+	if len(repo.entries) != 1 {
+		t.Fatalf("expected 1 audit entry, got %d", len(repo.entries))
+	}
+
+	entry := repo.entries[0]
+	if entry.Action != domain.AuditDeleteUser || *entry.TargetUserID != 2 || !strings.Contains(string(entry.Details), `"email":"user@example.com"`) {
+		t.Errorf("unexpected audit entry: %+v", entry)
+	}
+}
+
+func TestNewTemporaryPassword(t *testing.T) {
+	first, err := newTemporaryPassword()
+	if err != nil {
+		t.Fatalf("error generating password: %v", err)
+	}
+
+	second, err := newTemporaryPassword()
+	if err != nil {
+		t.Fatalf("error generating password: %v", err)
+	}
+
+	if len(first) != temporaryPasswordLen || first == second {
+		t.Errorf("unexpected passwords %q and %q", first, second)
+	}
+
+	for _, r := range first {
+		if !strings.ContainsRune(temporaryPasswordChars, r) {
+			t.Errorf("unexpected character %q", r)
+		}
+	}
+}
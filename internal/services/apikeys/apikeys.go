@@ -0,0 +1,150 @@
+// Package apikeys is a service with all business logic for personal API keys.
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/policy"
+	"github.com/popeskul/qna-go/internal/repository"
+	"github.com/popeskul/qna-go/internal/token"
+)
+
+// KeyPrefix starts every API key, so it can be told apart from access tokens.
+const KeyPrefix = "qna_"
+
+const (
+	keyBytes     = 32
+	displayChars = 8
+)
+
+var (
+	ErrInvalidScope    = errors.New("invalid api key scope")
+	ErrExpiresInPast   = errors.New("api key expiration time is in the past")
+	ErrInvalidAPIKey   = errors.New("invalid api key")
+	ErrAPIKeyNotActive = errors.New("api key is revoked or expired")
+)
+
+// ServiceAPIKeys compose all functions for API keys.
+type ServiceAPIKeys struct {
+	repo  repository.APIKeys
+	users repository.Auth
+	now   func() time.Time
+}
+
+// NewServiceAPIKeys create service with all fields.
+func NewServiceAPIKeys(repo repository.APIKeys, users repository.Auth) *ServiceAPIKeys {
+	return &ServiceAPIKeys{
+		repo:  repo,
+		users: users,
+		now:   time.Now,
+	}
+}
+
+// IsAPIKey check if the credential looks like an API key.
+func IsAPIKey(key string) bool {
+	return strings.HasPrefix(key, KeyPrefix)
+}
+
+// CreateAPIKey generate a new API key for the user with the scopes.
+// The key is returned only here, the db keeps its hash.
+func (s *ServiceAPIKeys) CreateAPIKey(ctx context.Context, userID int, req domain.CreateAPIKeyRequest) (domain.CreatedAPIKey, error) {
+	for _, scope := range req.Scopes {
+		if !policy.Grantable(policy.Permission(scope)) {
+			return domain.CreatedAPIKey{}, ErrInvalidScope
+		}
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(s.now()) {
+		return domain.CreatedAPIKey{}, ErrExpiresInPast
+	}
+
+	b := make([]byte, keyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return domain.CreatedAPIKey{}, err
+	}
+	key := KeyPrefix + hex.EncodeToString(b)
+
+	apiKey, err := s.repo.CreateAPIKey(ctx, domain.APIKey{
+		UserID:    userID,
+		Name:      req.Name,
+		Prefix:    key[:len(KeyPrefix)+displayChars],
+		KeyHash:   hashKey(key),
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		return domain.CreatedAPIKey{}, err
+	}
+
+	return domain.CreatedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+// GetAPIKeys return all API keys of the user.
+func (s *ServiceAPIKeys) GetAPIKeys(ctx context.Context, userID int) ([]domain.APIKey, error) {
+	return s.repo.GetAPIKeysByUserID(ctx, userID)
+}
+
+// RevokeAPIKey revoke the API key of the user, the key stops working immediately.
+func (s *ServiceAPIKeys) RevokeAPIKey(ctx context.Context, userID, keyID int) error {
+	return s.repo.RevokeAPIKey(ctx, userID, keyID)
+}
+
+// AuthenticateAPIKey check the API key and return the payload of its owner restricted to the key scopes.
+// The role is loaded from the db, so a role change applies to existing keys.
+func (s *ServiceAPIKeys) AuthenticateAPIKey(ctx context.Context, key string) (*token.Payload, error) {
+	if !IsAPIKey(key) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	apiKey, err := s.repo.GetAPIKeyByHash(ctx, hashKey(key))
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := s.now()
+	if apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(now)) {
+		return nil, ErrAPIKeyNotActive
+	}
+
+	user, err := s.users.GetUserByID(ctx, apiKey.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Disabled() {
+		return nil, domain.ErrUserDisabled
+	}
+
+	if err = s.repo.TouchAPIKey(ctx, apiKey.ID); err != nil {
+		return nil, err
+	}
+
+	payload := &token.Payload{
+		Subject:   strconv.Itoa(user.ID),
+		UserID:    user.ID,
+		Role:      string(user.Role),
+		Scopes:    apiKey.Scopes,
+		IssuedAt:  apiKey.CreatedAt,
+		NotBefore: apiKey.CreatedAt,
+	}
+	if apiKey.ExpiresAt != nil {
+		payload.ExpiredAt = *apiKey.ExpiresAt
+	}
+
+	return payload, nil
+}
+
+// hashKey returns the hex encoded SHA-256 of the key. The key has enough entropy,
+// so a fast hash is enough and lets the key be found by its hash.
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -3,44 +3,81 @@ package auth
 
 import (
 	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/popeskul/qna-go/internal/domain"
 	"github.com/popeskul/qna-go/internal/hash"
+	"github.com/popeskul/qna-go/internal/lockout"
+	"github.com/popeskul/qna-go/internal/oidc"
+	"github.com/popeskul/qna-go/internal/password"
 	"github.com/popeskul/qna-go/internal/repository"
 	"github.com/popeskul/qna-go/internal/repository/sessions"
 	"github.com/popeskul/qna-go/internal/token"
-	"math/rand"
 	"os"
+	"sync"
 	"time"
 )
 
 var (
-	ErrSignIn = errors.New("wrong user or password")
+	ErrSignIn              = errors.New("wrong user or password")
+	ErrInvalidRole         = errors.New("invalid role")
+	ErrPassword            = errors.New("wrong password")
+	ErrUserExists          = errors.New("user with this email already exists")
+	ErrInvalidRefreshToken = errors.New("refresh token is invalid or expired")
 )
 
 // ServiceAuth compose all functions.
 type ServiceAuth struct {
 	repo           repository.Auth
+	twoFactor      repository.TwoFactor
+	identities     repository.Identities
 	tokenManger    token.Manager
 	hashManager    *hash.Manager
+	passwords      *password.Validator
 	sessionManager *sessions.RepositorySessions
+	loginGuard     *lockout.Guard
+	providers      map[string]*oidc.Provider
+
+	dummyHashOnce sync.Once
+	dummyHash     string
 }
 
 // NewServiceAuth create service with all fields.
-func NewServiceAuth(repo repository.Auth, tokenManger token.Manager, hashManager *hash.Manager, sessionManager *sessions.RepositorySessions) *ServiceAuth {
+func NewServiceAuth(
+	repo repository.Auth,
+	twoFactor repository.TwoFactor,
+	identities repository.Identities,
+	tokenManger token.Manager,
+	hashManager *hash.Manager,
+	passwords *password.Validator,
+	sessionManager *sessions.RepositorySessions,
+	loginGuard *lockout.Guard,
+	providers map[string]*oidc.Provider) *ServiceAuth {
 	return &ServiceAuth{
 		repo:           repo,
+		twoFactor:      twoFactor,
+		identities:     identities,
 		tokenManger:    tokenManger,
 		hashManager:    hashManager,
+		passwords:      passwords,
 		sessionManager: sessionManager,
+		loginGuard:     loginGuard,
+		providers:      providers,
 	}
 }
 
 // CreateUser create new user in db and return error if any.
+// The password must follow the password policy, otherwise *password.ValidationError is returned.
 func (s *ServiceAuth) CreateUser(ctx context.Context, user domain.User) error {
 	if _, err := s.GetUserByEmail(ctx, user.Email); err == nil {
-		return errors.New("user with this email already exists")
+		return ErrUserExists
+	}
+
+	if err := s.passwords.Validate(user.Password, user); err != nil {
+		return err
 	}
 
 	hashedPassword, err := s.hashManager.HashPassword(user.Password)
@@ -52,17 +89,102 @@ func (s *ServiceAuth) CreateUser(ctx context.Context, user domain.User) error {
 	return s.repo.CreateUser(ctx, user)
 }
 
+// ChangePassword sets the new password of the user after checking the current one.
+// A user signed up with an identity provider has no password and sets the first one without it.
+// The new password must follow the password policy, otherwise *password.ValidationError is returned.
+func (s *ServiceAuth) ChangePassword(ctx context.Context, userID int, currentPassword, newPassword string) error {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.Password != "" && !s.hashManager.CheckPasswordHash(currentPassword, user.Password) {
+		return ErrPassword
+	}
+
+	if err = s.passwords.Validate(newPassword, user); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.hashManager.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.UpdateUserPassword(ctx, userID, hashedPassword)
+}
+
+// SignIn check the credentials and return access and refresh tokens.
+// If the user has enabled two-factor authentication it returns *ChallengeError instead,
+// and the tokens are issued by VerifyTwoFactor.
+// Failed attempts are counted per email and per client IP taken from lockout.WithClientIP,
+// too many of them make SignIn return *lockout.RetryError for existing and unknown emails alike.
+// The wrong second factors are counted with them by VerifyTwoFactor.
 func (s *ServiceAuth) SignIn(ctx context.Context, user domain.User) (string, string, error) {
+	ip := lockout.ClientIP(ctx)
+	if err := s.loginGuard.Check(ctx, user.Email, ip); err != nil {
+		return "", "", err
+	}
+
 	userByEmail, err := s.GetUserByEmail(ctx, user.Email)
 	if err != nil {
-		return "", "", ErrSignIn
+		// spend the same time as for a wrong password so the response doesn't reveal the email exists
+		s.hashManager.CheckPasswordHash(user.Password, s.getDummyHash())
+		return "", "", s.failSignIn(ctx, user.Email, ip)
+	}
+
+	ok, needsRehash := s.hashManager.VerifyPassword(user.Password, userByEmail.Password)
+	if !ok {
+		return "", "", s.failSignIn(ctx, user.Email, ip)
+	}
+
+	if needsRehash {
+		s.rehashPassword(ctx, userByEmail.ID, user.Password)
 	}
 
-	if ok := s.hashManager.CheckPasswordHash(user.Password, userByEmail.Password); !ok {
-		return "", "", ErrSignIn
+	if userByEmail.Disabled() {
+		return "", "", domain.ErrUserDisabled
 	}
 
-	return s.generateToken(ctx, userByEmail.ID)
+	// the failures are kept until the second factor passes, VerifyTwoFactor forgets them
+	if err = s.requireTwoFactor(ctx, userByEmail.ID); err != nil {
+		return "", "", err
+	}
+
+	if err = s.loginGuard.Succeed(ctx, user.Email); err != nil {
+		return "", "", err
+	}
+
+	return s.generateToken(ctx, userByEmail)
+}
+
+// rehashPassword replaces the outdated hash of the password with the one of the current hasher.
+// The sign in doesn't fail if it can't, the hash is replaced on the next sign in.
+func (s *ServiceAuth) rehashPassword(ctx context.Context, userID int, password string) {
+	hashedPassword, err := s.hashManager.HashPassword(password)
+	if err != nil {
+		return
+	}
+
+	_ = s.repo.UpdateUserPassword(ctx, userID, hashedPassword)
+}
+
+// failSignIn records the failed attempt and returns ErrSignIn.
+func (s *ServiceAuth) failSignIn(ctx context.Context, email, ip string) error {
+	if err := s.loginGuard.Fail(ctx, email, ip); err != nil {
+		return err
+	}
+
+	return ErrSignIn
+}
+
+// getDummyHash returns a hash to compare the password with when the user is not found.
+func (s *ServiceAuth) getDummyHash() string {
+	s.dummyHashOnce.Do(func() {
+		s.dummyHash, _ = s.hashManager.HashPassword(fmt.Sprintf("%x", time.Now().UnixNano()))
+	})
+
+	return s.dummyHash
 }
 
 // GetUser get user from db and return user and error if any.
@@ -75,6 +197,21 @@ func (s *ServiceAuth) GetUserByEmail(ctx context.Context, email string) (domain.
 	return s.repo.GetUserByEmail(ctx, email)
 }
 
+// GetUserByID get user from db by id and return user and error if any.
+func (s *ServiceAuth) GetUserByID(ctx context.Context, userID int) (domain.User, error) {
+	return s.repo.GetUserByID(ctx, userID)
+}
+
+// UpdateUserRole assign a new role to the user and return error if any.
+// The new role is applied to the tokens issued after the change.
+func (s *ServiceAuth) UpdateUserRole(ctx context.Context, userID int, role domain.Role) error {
+	if !role.Valid() {
+		return ErrInvalidRole
+	}
+
+	return s.repo.UpdateUserRole(ctx, userID, role)
+}
+
 // DeleteUserById delete user from db by id and return error if any.
 func (s *ServiceAuth) DeleteUserById(ctx context.Context, userID int) error {
 	return s.repo.DeleteUserById(ctx, userID)
@@ -86,24 +223,37 @@ func (s *ServiceAuth) VerifyToken(ctx context.Context, token string) (*token.Pay
 
 func (s *ServiceAuth) GenerateAccessRefreshTokens(ctx context.Context, refreshToken string) (string, string, error) {
 	session, err := s.sessionManager.GetRefreshToken(ctx, refreshToken)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", ErrInvalidRefreshToken
+	}
 	if err != nil {
 		return "", "", err
 	}
 
 	if session.ExpiresAt.Before(time.Now()) {
-		return "", "", errors.New("refresh token expired")
+		return "", "", ErrInvalidRefreshToken
 	}
 
-	return s.generateToken(ctx, int(session.UserID))
-}
+	// the refresh tokens of the oauth clients are exchanged at the token endpoint
+	if session.ClientID != "" {
+		return "", "", ErrInvalidRefreshToken
+	}
 
-func (s *ServiceAuth) generateToken(ctx context.Context, userID int) (string, string, error) {
-	duration, err := time.ParseDuration(os.Getenv("ACCESS_TOKEN_DURATION"))
+	user, err := s.repo.GetUserByID(ctx, int(session.UserID))
 	if err != nil {
 		return "", "", err
 	}
 
-	accessToken, err := s.tokenManger.CreateToken(userID, duration)
+	return s.generateToken(ctx, user)
+}
+
+// generateToken issues the access and refresh tokens of the user, the disabled user gets domain.ErrUserDisabled.
+func (s *ServiceAuth) generateToken(ctx context.Context, user domain.User) (string, string, error) {
+	if user.Disabled() {
+		return "", "", domain.ErrUserDisabled
+	}
+
+	duration, err := time.ParseDuration(os.Getenv("ACCESS_TOKEN_DURATION"))
 	if err != nil {
 		return "", "", err
 	}
@@ -113,26 +263,33 @@ func (s *ServiceAuth) generateToken(ctx context.Context, userID int) (string, st
 		return "", "", err
 	}
 
-	if err = s.sessionManager.CreateRefreshToken(ctx, domain.RefreshSession{
+	sessionID, err := s.sessionManager.CreateRefreshToken(ctx, domain.RefreshSession{
 		Token:     refreshToken,
-		UserID:    int64(userID),
+		UserID:    int64(user.ID),
 		ExpiresAt: time.Now().Add(time.Hour * 24 * 30),
-	}); err != nil {
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	accessToken, err := s.tokenManger.CreateToken(token.Claims{
+		UserID:    user.ID,
+		Role:      string(user.Role),
+		SessionID: sessionID,
+	}, duration)
+	if err != nil {
 		return "", "", err
 	}
 
 	return accessToken, refreshToken, nil
 }
 
+// newRefreshToken returns a random refresh token, it is also the id of the session.
 func newRefreshToken() (string, error) {
 	b := make([]byte, 32)
-
-	s := rand.NewSource(time.Now().Unix())
-	r := rand.New(s)
-
-	if _, err := r.Read(b); err != nil {
+	if _, err := rand.Read(b); err != nil {
 		return "", err
 	}
 
-	return fmt.Sprintf("%x", b), nil
+	return hex.EncodeToString(b), nil
 }
@@ -11,14 +11,18 @@ import (
 	"github.com/popeskul/qna-go/internal/db/postgres"
 	"github.com/popeskul/qna-go/internal/domain"
 	"github.com/popeskul/qna-go/internal/hash"
+	"github.com/popeskul/qna-go/internal/lockout"
+	"github.com/popeskul/qna-go/internal/password"
 	"github.com/popeskul/qna-go/internal/repository"
 	"github.com/popeskul/qna-go/internal/repository/sessions"
 	"github.com/popeskul/qna-go/internal/token"
 	"github.com/popeskul/qna-go/internal/util"
+	"golang.org/x/crypto/bcrypt"
 	"log"
 	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 var (
@@ -56,7 +60,13 @@ func TestMain(m *testing.M) {
 
 	mockRepo = repository.NewRepository(mockDB)
 	sessionManager := sessions.NewRepoSessions(db)
-	mockService = NewServiceAuth(mockRepo, pasetoMaker, hashManager, sessionManager)
+	loginGuard := lockout.NewGuard(lockout.NewMemoryStore(), lockout.Config{
+		MaxAttempts:   5,
+		MaxIPAttempts: 100,
+		Window:        time.Minute,
+		Duration:      time.Minute,
+	})
+	mockService = NewServiceAuth(mockRepo, mockRepo, mockRepo, pasetoMaker, hashManager, password.NewValidator(password.DefaultPolicy, nil), sessionManager, loginGuard, nil)
 
 	os.Exit(m.Run())
 }
@@ -191,6 +201,47 @@ func TestServiceAuth_GetUser(t *testing.T) {
 	})
 }
 
+func TestServiceAuth_SignInRehash(t *testing.T) {
+	ctx := context.Background()
+	u := randomUser()
+
+	// the hash of the password created before the pepper and Argon2id
+	legacy, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = mockRepo.CreateUser(ctx, domain.User{Name: u.Name, Email: u.Email, Password: string(legacy)}); err != nil {
+		t.Fatalf("Some error occured. Err: %s", err)
+	}
+
+	userID, err := findUserIDByEmail(u.Email)
+	if err != nil {
+		t.Fatalf("Some error occured. Err: %s", err)
+	}
+
+	if _, _, err = mockService.SignIn(ctx, u); err != nil {
+		t.Fatalf("ServiceAuth.SignIn() error = %v", err)
+	}
+
+	user, err := mockRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		t.Fatalf("Some error occured. Err: %s", err)
+	}
+
+	if user.Password == string(legacy) || !strings.HasPrefix(user.Password, "$argon2id$") {
+		t.Errorf("password is not rehashed: %s", user.Password)
+	}
+
+	if _, _, err = mockService.SignIn(ctx, u); err != nil {
+		t.Errorf("ServiceAuth.SignIn() with the new hash error = %v", err)
+	}
+
+	t.Cleanup(func() {
+		helperDeleteUserByID(t, userID)
+	})
+}
+
 func helperDeleteUserByID(t *testing.T, userID int) {
 	t.Helper()
 
@@ -244,3 +295,20 @@ func loadConfig() (*config.Config, error) {
 
 	return cfg, nil
 }
+
+func TestNewRefreshToken(t *testing.T) {
+	first, err := newRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the tokens of the sign ins in the same second must differ
+	second, err := newRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(first) != 64 || first == second {
+		t.Errorf("newRefreshToken() = %s and %s, want two different 32-byte hex tokens", first, second)
+	}
+}
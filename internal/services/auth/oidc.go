@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/oidc"
+	"github.com/popeskul/qna-go/internal/repository/identities"
+)
+
+// LoginStateTTL is how long the login with the identity provider may take.
+const LoginStateTTL = 10 * time.Minute
+
+var (
+	ErrUnknownProvider   = errors.New("unknown identity provider")
+	ErrInvalidLoginState = errors.New("login state is invalid or expired")
+	ErrIdentityLinked    = errors.New("identity is linked to another user")
+	ErrEmailNotVerified  = errors.New("email is not verified by the identity provider")
+	ErrEmailRequired     = errors.New("identity provider didn't share the email")
+	ErrLastSignInMethod  = errors.New("the only sign in method of the user can't be unlinked")
+	ErrAccountExists     = errors.New("an account with the email already exists, sign in to it and link the identity")
+)
+
+// StartOIDCLogin returns the URL of the identity provider to redirect the user to and the state of the login.
+// The state must be bound to the client, the callback is accepted only from the client that started the login.
+// If userID is set, the identity is linked to the user instead of signing in.
+func (s *ServiceAuth) StartOIDCLogin(ctx context.Context, providerName string, userID int) (string, string, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", "", ErrUnknownProvider
+	}
+
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		return "", "", err
+	}
+	codeVerifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err = s.identities.CreateLoginState(ctx, domain.OIDCLoginState{
+		State:        state,
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		UserID:       userID,
+		ExpiresAt:    time.Now().Add(LoginStateTTL),
+	}); err != nil {
+		return "", "", err
+	}
+
+	return authURL, state, nil
+}
+
+// FinishOIDCLogin exchanges the code returned by the identity provider and returns access and refresh tokens.
+// The identity is found by the subject at the provider. The first sign in creates a new user, or links it
+// to the user with the same verified email if the provider is trusted with the emails. Like SignIn it returns *ChallengeError
+// if the user has enabled two-factor authentication. userID is the signed-in user finishing the login, 0 if none,
+// the identity is linked only if it is the user that started the linking.
+func (s *ServiceAuth) FinishOIDCLogin(ctx context.Context, providerName, state, code string, userID int) (string, string, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", "", ErrUnknownProvider
+	}
+
+	loginState, err := s.identities.TakeLoginState(ctx, state)
+	if err != nil {
+		if errors.Is(err, identities.ErrLoginStateNotFound) {
+			return "", "", ErrInvalidLoginState
+		}
+
+		return "", "", err
+	}
+
+	if loginState.Provider != providerName || loginState.ExpiresAt.Before(time.Now()) {
+		return "", "", ErrInvalidLoginState
+	}
+
+	if loginState.UserID != 0 && loginState.UserID != userID {
+		return "", "", ErrInvalidLoginState
+	}
+
+	identity, err := provider.Exchange(ctx, code, loginState.CodeVerifier)
+	if err != nil {
+		return "", "", err
+	}
+
+	if identity.Nonce != loginState.Nonce {
+		return "", "", oidc.ErrInvalidIDToken
+	}
+
+	resolvedID, err := s.resolveIdentity(ctx, provider, providerName, identity, loginState.UserID)
+	if err != nil {
+		return "", "", err
+	}
+
+	user, err := s.repo.GetUserByID(ctx, resolvedID)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err = s.requireTwoFactor(ctx, user.ID); err != nil {
+		return "", "", err
+	}
+
+	return s.generateToken(ctx, user)
+}
+
+// resolveIdentity returns the user of the external identity linking or provisioning it when needed.
+func (s *ServiceAuth) resolveIdentity(ctx context.Context, provider *oidc.Provider, providerName string, identity oidc.Identity, linkToUserID int) (int, error) {
+	linked, err := s.identities.GetIdentity(ctx, providerName, identity.Subject)
+	if err == nil {
+		if linkToUserID != 0 && linked.UserID != linkToUserID {
+			return 0, ErrIdentityLinked
+		}
+
+		return linked.UserID, s.identities.TouchIdentity(ctx, linked.ID)
+	}
+	if !errors.Is(err, identities.ErrIdentityNotFound) {
+		return 0, err
+	}
+
+	userID := linkToUserID
+	if userID == 0 {
+		if userID, err = s.provisionUser(ctx, provider, identity); err != nil {
+			return 0, err
+		}
+	}
+
+	if err = s.identities.CreateIdentity(ctx, domain.LinkedIdentity{
+		UserID:   userID,
+		Provider: providerName,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}); err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+// provisionUser creates a new user with the verified email of the identity. The existing user with the email
+// is returned only if the provider is trusted with the emails, otherwise ErrAccountExists is returned and the
+// user links the identity after signing in, so whoever controls the email at the provider can't take over the account.
+// The created user has no password and signs in only through the identity provider.
+func (s *ServiceAuth) provisionUser(ctx context.Context, provider *oidc.Provider, identity oidc.Identity) (int, error) {
+	if identity.Email == "" {
+		return 0, ErrEmailRequired
+	}
+	if !identity.EmailVerified {
+		return 0, ErrEmailNotVerified
+	}
+
+	if user, err := s.repo.GetUserByEmail(ctx, identity.Email); err == nil {
+		if !provider.TrustsEmail() {
+			return 0, ErrAccountExists
+		}
+
+		return user.ID, nil
+	}
+
+	name := identity.Name
+	if name == "" {
+		name = strings.Split(identity.Email, "@")[0]
+	}
+
+	if err := s.repo.CreateUser(ctx, domain.User{Name: name, Email: identity.Email}); err != nil {
+		return 0, err
+	}
+
+	user, err := s.repo.GetUserByEmail(ctx, identity.Email)
+	if err != nil {
+		return 0, err
+	}
+
+	return user.ID, nil
+}
+
+// GetIdentities returns the external identities linked to the user.
+func (s *ServiceAuth) GetIdentities(ctx context.Context, userID int) ([]domain.LinkedIdentity, error) {
+	return s.identities.GetIdentitiesByUserID(ctx, userID)
+}
+
+// UnlinkIdentity unlinks the external identity from the user.
+// The last identity of a user without a password can't be unlinked.
+func (s *ServiceAuth) UnlinkIdentity(ctx context.Context, userID, identityID int) error {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.Password == "" {
+		linked, err := s.identities.GetIdentitiesByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if len(linked) <= 1 {
+			return ErrLastSignInMethod
+		}
+	}
+
+	return s.identities.DeleteIdentity(ctx, userID, identityID)
+}
@@ -0,0 +1,246 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/popeskul/qna-go/internal/oidc"
+	"github.com/popeskul/qna-go/internal/oidc/oidctest"
+	"github.com/popeskul/qna-go/internal/util"
+)
+
+const testProvider = "company"
+
+// newOIDCTestService creates the service with the test identity provider, trustEmail trusts it with the emails.
+func newOIDCTestService(t *testing.T, trustEmail bool) (*ServiceAuth, *oidctest.Server) {
+	idp, err := oidctest.NewServer("qna", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(idp.Close)
+
+	providers := map[string]*oidc.Provider{
+		testProvider: oidc.NewProvider(oidc.Config{
+			IssuerURL:    idp.URL,
+			ClientID:     "qna",
+			ClientSecret: "secret",
+			RedirectURL:  "http://localhost:8080/api/v1/auth/oidc/company/callback",
+			Scopes:       []string{"email", "profile"},
+			TrustEmail:   trustEmail,
+		}, nil),
+	}
+
+	s := NewServiceAuth(mockRepo, mockRepo, mockRepo, mockService.tokenManger, mockService.hashManager,
+		mockService.passwords, mockService.sessionManager, mockService.loginGuard, providers)
+
+	return s, idp
+}
+
+// oidcLogin runs the whole flow for the user of the identity provider.
+func oidcLogin(t *testing.T, s *ServiceAuth, userID int) error {
+	ctx := context.Background()
+
+	authURL, _, err := s.StartOIDCLogin(ctx, testProvider, userID)
+	if err != nil {
+		t.Fatalf("StartOIDCLogin() error = %v", err)
+	}
+
+	code, state, err := oidctest.Authorize(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = s.FinishOIDCLogin(ctx, testProvider, state, code, userID)
+	return err
+}
+
+func TestServiceAuth_OIDCLogin(t *testing.T) {
+	ctx := context.Background()
+	s, idp := newOIDCTestService(t, false)
+
+	email := util.RandomString(10) + "@example.com"
+	idp.SetUser(oidctest.User{Subject: util.RandomString(10), Email: email, EmailVerified: true, Name: "SSO user"})
+
+	// the first sign in creates the user
+	if err := oidcLogin(t, s, 0); err != nil {
+		t.Fatalf("first sign in error = %v", err)
+	}
+
+	userID, err := findUserIDByEmail(email)
+	if err != nil {
+		t.Fatalf("user is not provisioned: %v", err)
+	}
+	t.Cleanup(func() {
+		helperDeleteUserByID(t, userID)
+	})
+
+	// the next sign in finds the linked identity
+	if err = oidcLogin(t, s, 0); err != nil {
+		t.Fatalf("second sign in error = %v", err)
+	}
+
+	linked, err := s.GetIdentities(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(linked) != 1 || linked[0].Provider != testProvider {
+		t.Fatalf("unexpected identities: %v", linked)
+	}
+
+	if err = s.UnlinkIdentity(ctx, userID, linked[0].ID); !errors.Is(err, ErrLastSignInMethod) {
+		t.Errorf("UnlinkIdentity() error = %v, want %v", err, ErrLastSignInMethod)
+	}
+}
+
+func TestServiceAuth_OIDCLinking(t *testing.T) {
+	ctx := context.Background()
+	s, idp := newOIDCTestService(t, true)
+
+	u := randomUser()
+	if err := mockService.CreateUser(ctx, u); err != nil {
+		t.Fatalf("error creating user: %v", err)
+	}
+	userID, err := findUserIDByEmail(u.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := randomUser()
+	if err = mockService.CreateUser(ctx, other); err != nil {
+		t.Fatalf("error creating user: %v", err)
+	}
+	otherID, err := findUserIDByEmail(other.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		helperDeleteUserByID(t, userID)
+		helperDeleteUserByID(t, otherID)
+	})
+
+	// the email is not verified, so the existing account is not taken over
+	idp.SetUser(oidctest.User{Subject: util.RandomString(10), Email: u.Email})
+	if err = oidcLogin(t, s, 0); !errors.Is(err, ErrEmailNotVerified) {
+		t.Fatalf("sign in with unverified email error = %v, want %v", err, ErrEmailNotVerified)
+	}
+
+	// the verified email links the identity to the existing user, the provider is trusted with the emails
+	subject := util.RandomString(10)
+	idp.SetUser(oidctest.User{Subject: subject, Email: u.Email, EmailVerified: true})
+	if err = oidcLogin(t, s, 0); err != nil {
+		t.Fatalf("sign in with verified email error = %v", err)
+	}
+
+	linked, err := s.GetIdentities(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(linked) != 1 || linked[0].Subject != subject {
+		t.Fatalf("unexpected identities: %v", linked)
+	}
+
+	// the identity can't be linked to the other user
+	if err = oidcLogin(t, s, otherID); !errors.Is(err, ErrIdentityLinked) {
+		t.Fatalf("link to other user error = %v, want %v", err, ErrIdentityLinked)
+	}
+
+	// the user with the password can unlink the identity
+	if err = s.UnlinkIdentity(ctx, userID, linked[0].ID); err != nil {
+		t.Fatalf("UnlinkIdentity() error = %v", err)
+	}
+
+	// and link it explicitly to the other user
+	if err = oidcLogin(t, s, otherID); err != nil {
+		t.Fatalf("link to other user error = %v", err)
+	}
+}
+
+func TestServiceAuth_OIDCExistingEmail(t *testing.T) {
+	ctx := context.Background()
+	s, idp := newOIDCTestService(t, false)
+
+	u := randomUser()
+	if err := mockService.CreateUser(ctx, u); err != nil {
+		t.Fatalf("error creating user: %v", err)
+	}
+	userID, err := findUserIDByEmail(u.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		helperDeleteUserByID(t, userID)
+	})
+
+	// whoever controls the email at the provider doesn't get the existing account
+	idp.SetUser(oidctest.User{Subject: util.RandomString(10), Email: u.Email, EmailVerified: true})
+	if err = oidcLogin(t, s, 0); !errors.Is(err, ErrAccountExists) {
+		t.Fatalf("sign in with the email of the existing user error = %v, want %v", err, ErrAccountExists)
+	}
+
+	linked, err := s.GetIdentities(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(linked) != 0 {
+		t.Fatalf("the identity is linked without the user: %v", linked)
+	}
+
+	// the signed-in user links the identity themself
+	if err = oidcLogin(t, s, userID); err != nil {
+		t.Fatalf("link by the user error = %v", err)
+	}
+	if err = oidcLogin(t, s, 0); err != nil {
+		t.Fatalf("sign in with the linked identity error = %v", err)
+	}
+}
+
+func TestServiceAuth_OIDCLoginState(t *testing.T) {
+	ctx := context.Background()
+	s, idp := newOIDCTestService(t, false)
+
+	idp.SetUser(oidctest.User{Subject: util.RandomString(10)})
+
+	if _, _, err := s.StartOIDCLogin(ctx, "unknown", 0); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("StartOIDCLogin() error = %v, want %v", err, ErrUnknownProvider)
+	}
+
+	// the linking started by one user can't be finished by another one
+	authURL, _, err := s.StartOIDCLogin(ctx, testProvider, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, state, err := oidctest.Authorize(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = s.FinishOIDCLogin(ctx, testProvider, state, code, 2); !errors.Is(err, ErrInvalidLoginState) {
+		t.Errorf("FinishOIDCLogin() by another user error = %v, want %v", err, ErrInvalidLoginState)
+	}
+
+	authURL, startedState, err := s.StartOIDCLogin(ctx, testProvider, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, state, err = oidctest.Authorize(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != startedState {
+		t.Errorf("StartOIDCLogin() state = %s, want the state of the URL %s", startedState, state)
+	}
+
+	if _, _, err = s.FinishOIDCLogin(ctx, testProvider, "forged", code, 0); !errors.Is(err, ErrInvalidLoginState) {
+		t.Errorf("FinishOIDCLogin() with forged state error = %v, want %v", err, ErrInvalidLoginState)
+	}
+
+	// the identity provider didn't share the email
+	if _, _, err = s.FinishOIDCLogin(ctx, testProvider, state, code, 0); !errors.Is(err, ErrEmailRequired) {
+		t.Errorf("FinishOIDCLogin() error = %v, want %v", err, ErrEmailRequired)
+	}
+
+	if _, _, err = s.FinishOIDCLogin(ctx, testProvider, state, code, 0); !errors.Is(err, ErrInvalidLoginState) {
+		t.Errorf("FinishOIDCLogin() with used state error = %v, want %v", err, ErrInvalidLoginState)
+	}
+}
@@ -0,0 +1,287 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/lockout"
+	"github.com/popeskul/qna-go/internal/repository/twofactor"
+	"github.com/popeskul/qna-go/internal/totp"
+)
+
+const (
+	totpIssuer           = "Qna"
+	recoveryCodesCount   = 10
+	challengeTTL         = 5 * time.Minute
+	maxChallengeAttempts = 5
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled    = errors.New("two-factor authentication is not enrolled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrInvalidChallenge        = errors.New("two-factor challenge is invalid or expired")
+)
+
+// ChallengeError is returned by SignIn when the sign-in has to be confirmed with a second factor.
+// Token must be passed to VerifyTwoFactor together with a TOTP or recovery code.
+type ChallengeError struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+func (e *ChallengeError) Error() string {
+	return "two-factor authentication required"
+}
+
+// EnrollTwoFactor generate a new TOTP secret for the user and return it with the otpauth URI.
+// The second factor is not required until it is activated with ActivateTwoFactor.
+func (s *ServiceAuth) EnrollTwoFactor(ctx context.Context, userID int) (domain.TwoFactorEnrollment, error) {
+	current, err := s.twoFactor.GetTOTP(ctx, userID)
+	if err != nil && !errors.Is(err, twofactor.ErrTOTPNotFound) {
+		return domain.TwoFactorEnrollment{}, err
+	}
+	if current.Enabled {
+		return domain.TwoFactorEnrollment{}, ErrTwoFactorAlreadyEnabled
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return domain.TwoFactorEnrollment{}, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return domain.TwoFactorEnrollment{}, err
+	}
+
+	if err = s.twoFactor.SaveTOTPSecret(ctx, userID, secret); err != nil {
+		return domain.TwoFactorEnrollment{}, err
+	}
+
+	return domain.TwoFactorEnrollment{
+		Secret: secret,
+		URI:    totp.URI(totpIssuer, user.Email, secret),
+	}, nil
+}
+
+// ActivateTwoFactor enable the enrolled TOTP after the user proves it with a code
+// and return the recovery codes. The recovery codes are stored hashed and can't be shown again.
+func (s *ServiceAuth) ActivateTwoFactor(ctx context.Context, userID int, code string) ([]string, error) {
+	t, err := s.twoFactor.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, twofactor.ErrTOTPNotFound) {
+			return nil, ErrTwoFactorNotEnrolled
+		}
+
+		return nil, err
+	}
+	if t.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	step, ok := totp.Validate(t.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := s.newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.twoFactor.EnableTOTP(ctx, userID, step, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DisableTwoFactor turn off the second factor after the user proves it with a TOTP or recovery code.
+func (s *ServiceAuth) DisableTwoFactor(ctx context.Context, userID int, code string) error {
+	t, err := s.twoFactor.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, twofactor.ErrTOTPNotFound) {
+			return ErrTwoFactorNotEnabled
+		}
+
+		return err
+	}
+	if !t.Enabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	if err = s.checkSecondFactor(ctx, t, code); err != nil {
+		return err
+	}
+
+	return s.twoFactor.DeleteTOTP(ctx, userID)
+}
+
+// VerifyTwoFactor complete the sign-in started by SignIn and return access and refresh tokens.
+// Every attempt is counted by the challenge, and a wrong code is also counted by the login guard
+// like a wrong password, so the codes can't be guessed with fresh challenges.
+func (s *ServiceAuth) VerifyTwoFactor(ctx context.Context, challengeToken, code string) (string, string, error) {
+	challenge, err := s.twoFactor.UseChallengeAttempt(ctx, challengeToken, maxChallengeAttempts)
+	if err != nil {
+		if errors.Is(err, twofactor.ErrChallengeNotFound) {
+			// the challenge is expired or out of attempts, it is useless from now on
+			if err = s.twoFactor.DeleteChallenge(ctx, challengeToken); err != nil {
+				return "", "", err
+			}
+
+			return "", "", ErrInvalidChallenge
+		}
+
+		return "", "", err
+	}
+
+	user, err := s.repo.GetUserByID(ctx, challenge.UserID)
+	if err != nil {
+		return "", "", err
+	}
+
+	ip := lockout.ClientIP(ctx)
+	if err = s.loginGuard.Check(ctx, user.Email, ip); err != nil {
+		return "", "", err
+	}
+
+	t, err := s.twoFactor.GetTOTP(ctx, challenge.UserID)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err = s.checkSecondFactor(ctx, t, code); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			if failErr := s.loginGuard.Fail(ctx, user.Email, ip); failErr != nil {
+				return "", "", failErr
+			}
+		}
+
+		return "", "", err
+	}
+
+	if err = s.twoFactor.DeleteChallenge(ctx, challengeToken); err != nil {
+		return "", "", err
+	}
+
+	if err = s.loginGuard.Succeed(ctx, user.Email); err != nil {
+		return "", "", err
+	}
+
+	return s.generateToken(ctx, user)
+}
+
+// requireTwoFactor returns *ChallengeError if the user has enabled two-factor authentication.
+func (s *ServiceAuth) requireTwoFactor(ctx context.Context, userID int) error {
+	t, err := s.twoFactor.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, twofactor.ErrTOTPNotFound) {
+			return nil
+		}
+
+		return err
+	}
+	if !t.Enabled {
+		return nil
+	}
+
+	token, err := newChallengeToken()
+	if err != nil {
+		return err
+	}
+
+	challenge := domain.TwoFactorChallenge{
+		Token:     token,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(challengeTTL),
+	}
+	if err = s.twoFactor.CreateChallenge(ctx, challenge); err != nil {
+		return err
+	}
+
+	return &ChallengeError{
+		Token:     challenge.Token,
+		ExpiresAt: challenge.ExpiresAt,
+	}
+}
+
+// checkSecondFactor accepts a TOTP code that was not used before or an unused recovery code.
+func (s *ServiceAuth) checkSecondFactor(ctx context.Context, t domain.TOTP, code string) error {
+	if step, ok := totp.Validate(t.Secret, code, time.Now()); ok {
+		if step <= t.LastUsedStep {
+			return ErrInvalidTwoFactorCode
+		}
+
+		return usedCodeError(s.twoFactor.UpdateTOTPLastStep(ctx, t.UserID, step))
+	}
+
+	codes, err := s.twoFactor.GetRecoveryCodes(ctx, t.UserID)
+	if err != nil {
+		return err
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	for _, c := range codes {
+		if s.hashManager.CheckPasswordHash(normalized, c.CodeHash) {
+			return usedCodeError(s.twoFactor.UseRecoveryCode(ctx, c.ID))
+		}
+	}
+
+	return ErrInvalidTwoFactorCode
+}
+
+// usedCodeError rejects the code used by a concurrent request like any other used code.
+func usedCodeError(err error) error {
+	if errors.Is(err, twofactor.ErrCodeUsed) {
+		return ErrInvalidTwoFactorCode
+	}
+
+	return err
+}
+
+// newRecoveryCodes returns recovery codes to show to the user and their hashes to store.
+func (s *ServiceAuth) newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	for i := 0; i < recoveryCodesCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(encoding.EncodeToString(b))[:10]
+		hashed, err := s.hashManager.HashPassword(code)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		codes = append(codes, code[:5]+"-"+code[5:])
+		hashes = append(hashes, hashed)
+	}
+
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
+
+func newChallengeToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
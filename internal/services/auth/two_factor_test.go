@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/lockout"
+	"github.com/popeskul/qna-go/internal/repository/twofactor"
+	"github.com/popeskul/qna-go/internal/totp"
+)
+
+func TestServiceAuth_TwoFactor(t *testing.T) {
+	ctx := context.Background()
+	u := randomUser()
+
+	if err := mockService.CreateUser(ctx, u); err != nil {
+		t.Fatalf("error creating user: %v", err)
+	}
+
+	userID, err := findUserIDByEmail(u.Email)
+	if err != nil {
+		t.Fatalf("error finding user: %v", err)
+	}
+
+	t.Cleanup(func() {
+		helperDeleteUserByID(t, userID)
+	})
+
+	enrollment, err := mockService.EnrollTwoFactor(ctx, userID)
+	if err != nil {
+		t.Fatalf("EnrollTwoFactor() error = %v", err)
+	}
+
+	// not activated yet, sign in doesn't require the second factor
+	if _, _, err = mockService.SignIn(ctx, u); err != nil {
+		t.Fatalf("SignIn() before activation error = %v", err)
+	}
+
+	if _, err = mockService.ActivateTwoFactor(ctx, userID, "000000"); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("ActivateTwoFactor() with wrong code error = %v, want %v", err, ErrInvalidTwoFactorCode)
+	}
+
+	// use the previous period to leave the current one for the sign in
+	activationCode, err := totp.GenerateCode(enrollment.Secret, time.Now().Add(-totp.Period*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recoveryCodes, err := mockService.ActivateTwoFactor(ctx, userID, activationCode)
+	if err != nil {
+		t.Fatalf("ActivateTwoFactor() error = %v", err)
+	}
+	if len(recoveryCodes) != recoveryCodesCount {
+		t.Fatalf("got %d recovery codes, want %d", len(recoveryCodes), recoveryCodesCount)
+	}
+
+	_, _, err = mockService.SignIn(ctx, u)
+	var challenge *ChallengeError
+	if !errors.As(err, &challenge) {
+		t.Fatalf("SignIn() error = %v, want challenge", err)
+	}
+
+	if _, _, err = mockService.VerifyTwoFactor(ctx, challenge.Token, activationCode); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("VerifyTwoFactor() with reused code error = %v, want %v", err, ErrInvalidTwoFactorCode)
+	}
+
+	code, err := totp.GenerateCode(enrollment.Secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accessToken, _, err := mockService.VerifyTwoFactor(ctx, challenge.Token, code)
+	if err != nil {
+		t.Fatalf("VerifyTwoFactor() error = %v", err)
+	}
+	if accessToken == "" {
+		t.Fatal("access token is empty")
+	}
+
+	if _, _, err = mockService.VerifyTwoFactor(ctx, challenge.Token, code); !errors.Is(err, ErrInvalidChallenge) {
+		t.Fatalf("VerifyTwoFactor() with used challenge error = %v, want %v", err, ErrInvalidChallenge)
+	}
+
+	// a concurrent verification read the last used step before the code was used
+	stale := domain.TOTP{UserID: userID, Secret: enrollment.Secret}
+	if err = mockService.checkSecondFactor(ctx, stale, code); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("checkSecondFactor() with concurrently used code error = %v, want %v", err, ErrInvalidTwoFactorCode)
+	}
+
+	_, _, err = mockService.SignIn(ctx, u)
+	if !errors.As(err, &challenge) {
+		t.Fatalf("SignIn() error = %v, want challenge", err)
+	}
+
+	unused, err := mockRepo.GetRecoveryCodes(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err = mockService.VerifyTwoFactor(ctx, challenge.Token, recoveryCodes[0]); err != nil {
+		t.Fatalf("VerifyTwoFactor() with recovery code error = %v", err)
+	}
+
+	// the code used by the verification can't be used again by a concurrent one
+	left, err := mockRepo.GetRecoveryCodes(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range unused {
+		if !containsRecoveryCode(left, c.ID) {
+			if err = mockRepo.UseRecoveryCode(ctx, c.ID); !errors.Is(err, twofactor.ErrCodeUsed) {
+				t.Fatalf("UseRecoveryCode() with used code error = %v, want %v", err, twofactor.ErrCodeUsed)
+			}
+		}
+	}
+
+	if err = mockService.DisableTwoFactor(ctx, userID, recoveryCodes[0]); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("DisableTwoFactor() with used recovery code error = %v, want %v", err, ErrInvalidTwoFactorCode)
+	}
+
+	if err = mockService.DisableTwoFactor(ctx, userID, recoveryCodes[1]); err != nil {
+		t.Fatalf("DisableTwoFactor() error = %v", err)
+	}
+
+	if _, _, err = mockService.SignIn(ctx, u); err != nil {
+		t.Fatalf("SignIn() after disabling error = %v", err)
+	}
+}
+
+func TestServiceAuth_TwoFactorAttemptLimit(t *testing.T) {
+	ctx := context.Background()
+	u := randomUser()
+
+	if err := mockService.CreateUser(ctx, u); err != nil {
+		t.Fatalf("error creating user: %v", err)
+	}
+
+	userID, err := findUserIDByEmail(u.Email)
+	if err != nil {
+		t.Fatalf("error finding user: %v", err)
+	}
+
+	t.Cleanup(func() {
+		helperDeleteUserByID(t, userID)
+	})
+
+	enrollment, err := mockService.EnrollTwoFactor(ctx, userID)
+	if err != nil {
+		t.Fatalf("EnrollTwoFactor() error = %v", err)
+	}
+
+	activationCode, err := totp.GenerateCode(enrollment.Secret, time.Now().Add(-totp.Period*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = mockService.ActivateTwoFactor(ctx, userID, activationCode); err != nil {
+		t.Fatalf("ActivateTwoFactor() error = %v", err)
+	}
+
+	// every sign in with the right password starts a new challenge, the wrong codes still add up
+	var challenge *ChallengeError
+	for i := 0; i < 5; i++ {
+		_, _, err = mockService.SignIn(ctx, u)
+		if !errors.As(err, &challenge) {
+			t.Fatalf("SignIn() attempt %d error = %v, want challenge", i+1, err)
+		}
+
+		if _, _, err = mockService.VerifyTwoFactor(ctx, challenge.Token, "000000"); !errors.Is(err, ErrInvalidTwoFactorCode) {
+			t.Fatalf("VerifyTwoFactor() attempt %d error = %v, want %v", i+1, err, ErrInvalidTwoFactorCode)
+		}
+	}
+
+	var retry *lockout.RetryError
+	if _, _, err = mockService.SignIn(ctx, u); !errors.As(err, &retry) {
+		t.Fatalf("SignIn() after the limit error = %v, want *lockout.RetryError", err)
+	}
+
+	code, err := totp.GenerateCode(enrollment.Secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the last challenge has attempts left but the guard rejects it
+	if _, _, err = mockService.VerifyTwoFactor(ctx, challenge.Token, code); !errors.As(err, &retry) {
+		t.Fatalf("VerifyTwoFactor() after the limit error = %v, want *lockout.RetryError", err)
+	}
+}
+
+func containsRecoveryCode(codes []domain.RecoveryCode, codeID int) bool {
+	for _, c := range codes {
+		if c.ID == codeID {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/policy"
+	"github.com/popeskul/qna-go/internal/services/apikeys"
+	"github.com/popeskul/qna-go/internal/token"
+)
+
+const bearerScheme = "Bearer"
+
+var (
+	ErrUserIdNotFound    = errors.New("user id not found")
+	ErrTokenNotFound     = errors.New("accessToken not found")
+	ErrAuthEmptyToken    = errors.New("empty auth header")
+	ErrInvalidAuthHeader = errors.New("authorization header is invalid")
+)
+
+// Authenticate verifies the credential of the request found by the transport in this order:
+//  1. authorization: Bearer <access token or API key>
+//  2. the API key
+//
+// A credential that is set is never mixed with the next one, if it is invalid the request is rejected.
+func (s *Service) Authenticate(ctx context.Context, authorization, apiKey string) (*token.Payload, error) {
+	if authorization != "" {
+		credential, err := ParseBearer(authorization)
+		if err != nil {
+			return nil, err
+		}
+
+		return s.VerifyCredential(ctx, credential)
+	}
+
+	if apiKey != "" {
+		return s.APIKeys.AuthenticateAPIKey(ctx, apiKey)
+	}
+
+	return nil, ErrTokenNotFound
+}
+
+// VerifyCredential verifies the access token or the API key.
+func (s *Service) VerifyCredential(ctx context.Context, credential string) (*token.Payload, error) {
+	if apikeys.IsAPIKey(credential) {
+		return s.APIKeys.AuthenticateAPIKey(ctx, credential)
+	}
+
+	payload, err := s.Auth.VerifyToken(ctx, credential)
+	if err != nil {
+		if errors.Is(err, token.ErrExpiredToken) {
+			return nil, token.ErrExpiredToken
+		}
+		return nil, token.ErrInvalidToken
+	}
+
+	return payload, nil
+}
+
+// ParseBearer returns the credential of the bearer authorization header.
+// The scheme is case-insensitive.
+func ParseBearer(header string) (string, error) {
+	fields := strings.Fields(header)
+	if len(fields) == 0 || !strings.EqualFold(fields[0], bearerScheme) {
+		return "", ErrInvalidAuthHeader
+	}
+
+	if len(fields) == 1 {
+		return "", ErrAuthEmptyToken
+	}
+
+	if len(fields) > 2 {
+		return "", ErrInvalidAuthHeader
+	}
+
+	return fields[1], nil
+}
+
+// SubjectOf returns the subject the policy checks for the payload of the authenticated credential.
+func SubjectOf(payload *token.Payload) policy.Subject {
+	scopes := make([]policy.Permission, 0, len(payload.Scopes))
+	for _, scope := range payload.Scopes {
+		scopes = append(scopes, policy.Permission(scope))
+	}
+
+	return policy.Subject{
+		UserID: payload.UserID,
+		Role:   domain.Role(payload.Role),
+		Scopes: scopes,
+	}
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseBearer(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		credential string
+		err        error
+	}{
+		{name: "bearer", header: "Bearer token", credential: "token"},
+		{name: "case-insensitive scheme", header: "bearer token", credential: "token"},
+		{name: "another scheme", header: "Basic token", err: ErrInvalidAuthHeader},
+		{name: "empty credential", header: "Bearer ", err: ErrAuthEmptyToken},
+		{name: "extra fields", header: "Bearer token extra", err: ErrInvalidAuthHeader},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credential, err := ParseBearer(tt.header)
+			if !errors.Is(err, tt.err) || credential != tt.credential {
+				t.Errorf("ParseBearer(%q) = %q, %v, want %q, %v", tt.header, credential, err, tt.credential, tt.err)
+			}
+		})
+	}
+}
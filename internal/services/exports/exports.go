@@ -0,0 +1,253 @@
+// Package exports is a service with all business logic for the data exports of the users.
+// The export runs in the background: it collects everything stored about the user into a ZIP
+// of JSON files, keeps it for the retention time and serves it by the signed download links.
+package exports
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/repository"
+	"github.com/popeskul/qna-go/internal/repository/exports"
+)
+
+const (
+	// DefaultDownloadURL is the download endpoint of the archive, it is formatted with the id of the export.
+	DefaultDownloadURL = "/api/v1/me/exports/%d/download"
+	DefaultLinkTTL     = time.Hour
+	DefaultRetention   = 7 * 24 * time.Hour
+	DefaultWorkers     = 2
+	// DefaultTimeout is how long the export may run, after it the export counts as interrupted.
+	DefaultTimeout = 30 * time.Minute
+
+	linkSecretBytes = 32
+)
+
+var ErrInvalidLink = errors.New("download link is invalid or expired")
+
+// Config defines the data exports.
+type Config struct {
+	DownloadURL string
+	// LinkTTL is how long the download link works, the new link can be taken from the status of the export.
+	LinkTTL time.Duration
+	// Retention is how long the archive is kept.
+	Retention time.Duration
+	// Workers is how many exports run at once.
+	Workers int
+	Timeout time.Duration
+}
+
+// ServiceExports compose all functions for the data exports.
+type ServiceExports struct {
+	repo    repository.Exports
+	cfg     Config
+	workers chan struct{}
+	now     func() time.Time
+}
+
+// NewServiceExports create service with all fields.
+func NewServiceExports(repo repository.Exports, cfg Config) *ServiceExports {
+	if cfg.DownloadURL == "" {
+		cfg.DownloadURL = DefaultDownloadURL
+	}
+	if cfg.LinkTTL == 0 {
+		cfg.LinkTTL = DefaultLinkTTL
+	}
+	if cfg.Retention == 0 {
+		cfg.Retention = DefaultRetention
+	}
+	if cfg.Workers == 0 {
+		cfg.Workers = DefaultWorkers
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+
+	return &ServiceExports{
+		repo:    repo,
+		cfg:     cfg,
+		workers: make(chan struct{}, cfg.Workers),
+		now:     time.Now,
+	}
+}
+
+// CreateExport starts the export of the user data in the background and returns it.
+// If the previous export of the user is not finished yet, it is returned instead of a new one.
+func (s *ServiceExports) CreateExport(ctx context.Context, userID int) (domain.DataExport, error) {
+	if err := s.repo.DeleteExpiredExports(ctx, s.cfg.Timeout); err != nil {
+		return domain.DataExport{}, err
+	}
+
+	active, err := s.repo.GetActiveExport(ctx, userID)
+	if err == nil {
+		return active, nil
+	}
+	if err != exports.ErrExportNotFound {
+		return active, err
+	}
+
+	secret := make([]byte, linkSecretBytes)
+	if _, err = rand.Read(secret); err != nil {
+		return domain.DataExport{}, err
+	}
+
+	export, err := s.repo.CreateExport(ctx, userID, secret)
+	if err != nil {
+		return export, err
+	}
+
+	go s.run(export.ID, userID)
+
+	return export, nil
+}
+
+// run builds the archive of the export. It is not bound to the request, so it survives the end of it.
+func (s *ServiceExports) run(exportID, userID int) {
+	s.workers <- struct{}{}
+	defer func() { <-s.workers }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	defer cancel()
+
+	if err := s.repo.StartExport(ctx, exportID); err != nil {
+		return
+	}
+
+	archive, err := s.buildArchive(ctx, userID)
+	if err != nil {
+		_ = s.repo.FailExport(context.Background(), exportID, err.Error())
+		return
+	}
+
+	if err = s.repo.CompleteExport(ctx, exportID, archive, s.now().Add(s.cfg.Retention)); err != nil {
+		_ = s.repo.FailExport(context.Background(), exportID, err.Error())
+	}
+}
+
+// buildArchive collects the user data into a ZIP with a JSON file for every kind of data.
+func (s *ServiceExports) buildArchive(ctx context.Context, userID int) ([]byte, error) {
+	data, err := s.repo.GetUserData(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"profile.json", data.Profile},
+		{"tests.json", data.Tests},
+		{"questions.json", data.Questions},
+		{"answers.json", data.Answers},
+		{"passages.json", data.Passages},
+		{"memberships.json", data.Memberships},
+		{"sessions.json", data.Sessions},
+		{"identities.json", data.Identities},
+		{"api_keys.json", data.APIKeys},
+		{"oauth_clients.json", data.OAuthClients},
+		{"oauth_consents.json", data.OAuthConsents},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: s.now()})
+		if err != nil {
+			return nil, err
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err = enc.Encode(f.content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// GetExport returns the export of the user, the ready export has a new download link.
+func (s *ServiceExports) GetExport(ctx context.Context, userID, exportID int) (domain.DataExport, error) {
+	export, err := s.repo.GetExport(ctx, exportID)
+	if err != nil {
+		return export, err
+	}
+
+	if export.UserID != userID {
+		return domain.DataExport{}, exports.ErrExportNotFound
+	}
+
+	if export.Status != domain.ExportReady || export.ExpiresAt == nil || !export.ExpiresAt.After(s.now()) {
+		return export, nil
+	}
+
+	linkExpiresAt := s.now().Add(s.cfg.LinkTTL).Truncate(time.Second)
+	if linkExpiresAt.After(*export.ExpiresAt) {
+		linkExpiresAt = export.ExpiresAt.Truncate(time.Second)
+	}
+
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(linkExpiresAt.Unix(), 10))
+	q.Set("signature", sign(export.LinkSecret, export.ID, linkExpiresAt.Unix()))
+
+	export.DownloadURL = fmt.Sprintf(s.cfg.DownloadURL, export.ID) + "?" + q.Encode()
+	export.LinkExpiresAt = &linkExpiresAt
+
+	return export, nil
+}
+
+// DownloadExport returns the archive of the export if the download link is valid.
+// The link is signed with the secret of the export, so it works without the session of the user.
+func (s *ServiceExports) DownloadExport(ctx context.Context, exportID int, expires int64, signature string) ([]byte, error) {
+	if time.Unix(expires, 0).Before(s.now()) {
+		return nil, ErrInvalidLink
+	}
+
+	export, err := s.repo.GetExport(ctx, exportID)
+	if err != nil {
+		if err == exports.ErrExportNotFound {
+			return nil, ErrInvalidLink
+		}
+
+		return nil, err
+	}
+
+	if !hmac.Equal([]byte(sign(export.LinkSecret, export.ID, expires)), []byte(signature)) {
+		return nil, ErrInvalidLink
+	}
+
+	archive, err := s.repo.GetExportArchive(ctx, exportID)
+	if err != nil {
+		if err == exports.ErrArchiveNotFound {
+			return nil, ErrInvalidLink
+		}
+
+		return nil, err
+	}
+
+	return archive, nil
+}
+
+// sign returns the signature of the download link of the export.
+func sign(secret []byte, exportID int, expires int64) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(fmt.Sprintf("%d:%d", exportID, expires)))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,250 @@
+package exports
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/repository/exports"
+)
+
+// memoryRepo keeps the exports in memory.
+type memoryRepo struct {
+	mu       sync.Mutex
+	exports  map[int]*domain.DataExport
+	archives map[int][]byte
+	data     domain.UserData
+}
+
+func newMemoryRepo(data domain.UserData) *memoryRepo {
+	return &memoryRepo{
+		exports:  make(map[int]*domain.DataExport),
+		archives: make(map[int][]byte),
+		data:     data,
+	}
+}
+
+func (r *memoryRepo) CreateExport(_ context.Context, userID int, linkSecret []byte) (domain.DataExport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e := &domain.DataExport{ID: len(r.exports) + 1, UserID: userID, Status: domain.ExportPending, LinkSecret: linkSecret, CreatedAt: time.Now()}
+	r.exports[e.ID] = e
+
+	return *e, nil
+}
+
+func (r *memoryRepo) GetExport(_ context.Context, exportID int) (domain.DataExport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.exports[exportID]
+	if !ok {
+		return domain.DataExport{}, exports.ErrExportNotFound
+	}
+
+	return *e, nil
+}
+
+func (r *memoryRepo) GetActiveExport(_ context.Context, userID int) (domain.DataExport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range r.exports {
+		if e.UserID == userID && (e.Status == domain.ExportPending || e.Status == domain.ExportRunning) {
+			return *e, nil
+		}
+	}
+
+	return domain.DataExport{}, exports.ErrExportNotFound
+}
+
+func (r *memoryRepo) setStatus(exportID int, status domain.ExportStatus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.exports[exportID].Status = status
+}
+
+func (r *memoryRepo) StartExport(_ context.Context, exportID int) error {
+	r.setStatus(exportID, domain.ExportRunning)
+	return nil
+}
+
+func (r *memoryRepo) CompleteExport(_ context.Context, exportID int, archive []byte, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.exports[exportID].Status = domain.ExportReady
+	r.exports[exportID].ExpiresAt = &expiresAt
+	r.archives[exportID] = archive
+
+	return nil
+}
+
+func (r *memoryRepo) FailExport(_ context.Context, exportID int, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.exports[exportID].Status = domain.ExportFailed
+	r.exports[exportID].Error = reason
+
+	return nil
+}
+
+func (r *memoryRepo) GetExportArchive(_ context.Context, exportID int) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	archive, ok := r.archives[exportID]
+	if !ok {
+		return nil, exports.ErrArchiveNotFound
+	}
+
+	return archive, nil
+}
+
+func (r *memoryRepo) DeleteExpiredExports(_ context.Context, _ time.Duration) error {
+	return nil
+}
+
+func (r *memoryRepo) GetUserData(_ context.Context, _ int) (domain.UserData, error) {
+	return r.data, nil
+}
+
+func waitReady(t *testing.T, s *ServiceExports, userID, exportID int) domain.DataExport {
+	t.Helper()
+
+	for i := 0; i < 100; i++ {
+		export, err := s.GetExport(context.Background(), userID, exportID)
+		if err != nil {
+			t.Fatalf("error getting export: %v", err)
+		}
+
+		if export.Status == domain.ExportReady || export.Status == domain.ExportFailed {
+			return export
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("export is not finished")
+
+	return domain.DataExport{}
+}
+
+func TestServiceExports_Export(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepo(domain.UserData{
+		Profile: domain.Profile{ID: 1, Name: "Jane", Email: "jane@example.com"},
+		Tests:   []domain.Test{{ID: 10, Title: "Go basics", AuthorID: 1}},
+	})
+	s := NewServiceExports(repo, Config{LinkTTL: time.Minute})
+
+	export, err := s.CreateExport(ctx, 1)
+	if err != nil {
+		t.Fatalf("error creating export: %v", err)
+	}
+
+	ready := waitReady(t, s, 1, export.ID)
+	if ready.Status != domain.ExportReady {
+		t.Fatalf("expected ready export, got %+v", ready)
+	}
+
+	if _, err = s.GetExport(ctx, 2, export.ID); err != exports.ErrExportNotFound {
+		t.Errorf("expected the export of another user to be not found, got %v", err)
+	}
+
+	link, err := url.Parse(ready.DownloadURL)
+	if err != nil {
+		t.Fatalf("error parsing download url: %v", err)
+	}
+
+	if !strings.HasPrefix(link.Path, "/api/v1/me/exports/") {
+		t.Errorf("unexpected download url: %s", ready.DownloadURL)
+	}
+
+	expires, _ := strconv.ParseInt(link.Query().Get("expires"), 10, 64)
+	signature := link.Query().Get("signature")
+
+	t.Run("Error: tampered link", func(t *testing.T) {
+		if _, err := s.DownloadExport(ctx, export.ID, expires+60, signature); err != ErrInvalidLink {
+			t.Errorf("expected ErrInvalidLink, got %v", err)
+		}
+	})
+
+	t.Run("Error: expired link", func(t *testing.T) {
+		s.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+		defer func() { s.now = time.Now }()
+
+		if _, err := s.DownloadExport(ctx, export.ID, expires, signature); err != ErrInvalidLink {
+			t.Errorf("expected ErrInvalidLink, got %v", err)
+		}
+	})
+
+	t.Run("Success: download archive", func(t *testing.T) {
+		archive, err := s.DownloadExport(ctx, export.ID, expires, signature)
+		if err != nil {
+			t.Fatalf("error downloading export: %v", err)
+		}
+
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			t.Fatalf("error reading archive: %v", err)
+		}
+
+		files := make(map[string]*zip.File)
+		for _, f := range zr.File {
+			files[f.Name] = f
+		}
+
+		for _, name := range []string{"profile.json", "tests.json", "questions.json", "answers.json", "passages.json", "sessions.json"} {
+			if _, ok := files[name]; !ok {
+				t.Errorf("archive has no %s", name)
+			}
+		}
+
+		rc, err := files["tests.json"].Open()
+		if err != nil {
+			t.Fatalf("error opening tests.json: %v", err)
+		}
+		defer rc.Close()
+
+		var tests []domain.Test
+		if err = json.NewDecoder(rc).Decode(&tests); err != nil || len(tests) != 1 || tests[0].Title != "Go basics" {
+			t.Errorf("unexpected tests.json: %+v, %v", tests, err)
+		}
+	})
+}
+
+func TestServiceExports_CreateExportReturnsActive(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepo(domain.UserData{})
+	s := NewServiceExports(repo, Config{})
+
+	// take the only worker, so the export stays pending
+	s.workers = make(chan struct{}, 1)
+	s.workers <- struct{}{}
+	defer func() { <-s.workers }()
+
+	first, err := s.CreateExport(ctx, 1)
+	if err != nil {
+		t.Fatalf("error creating export: %v", err)
+	}
+
+	second, err := s.CreateExport(ctx, 1)
+	if err != nil {
+		t.Fatalf("error creating export: %v", err)
+	}
+
+	if first.ID != second.ID {
+		t.Errorf("expected the pending export %d, got %d", first.ID, second.ID)
+	}
+}
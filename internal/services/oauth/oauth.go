@@ -0,0 +1,540 @@
+// Package oauth is a service with all business logic of the OAuth2 authorization server:
+// the authorization code grant with PKCE (RFC 6749, RFC 7636), the refresh token and
+// client credentials grants and the token introspection (RFC 7662).
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/oidc"
+	"github.com/popeskul/qna-go/internal/policy"
+	"github.com/popeskul/qna-go/internal/repository"
+	"github.com/popeskul/qna-go/internal/repository/oauth"
+	"github.com/popeskul/qna-go/internal/token"
+)
+
+const (
+	authorizationCodeTTL = 5 * time.Minute
+	refreshTokenTTL      = 30 * 24 * time.Hour
+
+	clientIDBytes     = 16
+	clientSecretBytes = 32
+	codeBytes         = 32
+	refreshTokenBytes = 32
+
+	// codeChallengeLength is the length of the base64url encoded SHA-256 of the code verifier.
+	codeChallengeLength = 43
+
+	tokenTypeBearer = "Bearer"
+)
+
+// Grant types supported by the token endpoint.
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantRefreshToken      = "refresh_token"
+	GrantClientCredentials = "client_credentials"
+)
+
+// Error is the error of the authorization server from RFC 6749 section 5.2.
+type Error struct {
+	Code        string
+	Description string
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+func newError(code, description string) *Error {
+	return &Error{Code: code, Description: description}
+}
+
+// Error codes of RFC 6749.
+const (
+	CodeInvalidRequest          = "invalid_request"
+	CodeInvalidClient           = "invalid_client"
+	CodeInvalidGrant            = "invalid_grant"
+	CodeUnauthorizedClient      = "unauthorized_client"
+	CodeUnsupportedGrantType    = "unsupported_grant_type"
+	CodeUnsupportedResponseType = "unsupported_response_type"
+	CodeInvalidScope            = "invalid_scope"
+	CodeAccessDenied            = "access_denied"
+)
+
+var (
+	ErrInvalidRedirectURI = errors.New("redirect uri must be an absolute http(s) url without fragment")
+	ErrInvalidScope       = errors.New("invalid oauth client scope")
+)
+
+// ServiceOAuth compose all functions of the authorization server.
+type ServiceOAuth struct {
+	repo         repository.OAuth
+	users        repository.Auth
+	sessions     repository.Sessions
+	tokenManager token.Manager
+	now          func() time.Time
+}
+
+// NewServiceOAuth create service with all fields.
+func NewServiceOAuth(repo repository.OAuth, users repository.Auth, sessions repository.Sessions, tokenManager token.Manager) *ServiceOAuth {
+	return &ServiceOAuth{
+		repo:         repo,
+		users:        users,
+		sessions:     sessions,
+		tokenManager: tokenManager,
+		now:          time.Now,
+	}
+}
+
+// RegisterClient registers a client of the user. The secret of a confidential client is returned only here.
+func (s *ServiceOAuth) RegisterClient(ctx context.Context, ownerID int, req domain.CreateOAuthClientRequest) (domain.RegisteredOAuthClient, error) {
+	for _, scope := range req.Scopes {
+		if !policy.Grantable(policy.Permission(scope)) {
+			return domain.RegisteredOAuthClient{}, ErrInvalidScope
+		}
+	}
+
+	for _, uri := range req.RedirectURIs {
+		if !validRedirectURI(uri) {
+			return domain.RegisteredOAuthClient{}, ErrInvalidRedirectURI
+		}
+	}
+
+	clientID, err := randomHex(clientIDBytes)
+	if err != nil {
+		return domain.RegisteredOAuthClient{}, err
+	}
+
+	var secret, secretHash string
+	if req.Confidential {
+		if secret, err = randomHex(clientSecretBytes); err != nil {
+			return domain.RegisteredOAuthClient{}, err
+		}
+		secretHash = hashSecret(secret)
+	}
+
+	client, err := s.repo.CreateClient(ctx, domain.OAuthClient{
+		ClientID:     clientID,
+		SecretHash:   secretHash,
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		Scopes:       req.Scopes,
+		OwnerID:      ownerID,
+		Confidential: req.Confidential,
+	})
+	if err != nil {
+		return domain.RegisteredOAuthClient{}, err
+	}
+
+	return domain.RegisteredOAuthClient{OAuthClient: client, ClientSecret: secret}, nil
+}
+
+// GetClients returns all clients registered by the user.
+func (s *ServiceOAuth) GetClients(ctx context.Context, ownerID int) ([]domain.OAuthClient, error) {
+	return s.repo.GetClientsByOwner(ctx, ownerID)
+}
+
+// DeleteClient deletes the client of the user, the refresh tokens issued to it stop working.
+func (s *ServiceOAuth) DeleteClient(ctx context.Context, ownerID int, clientID string) error {
+	return s.repo.DeleteClient(ctx, ownerID, clientID)
+}
+
+// Authorize validates the authorization request of the client and returns what to show on the consent screen.
+// The errors about the client and the redirect URI must be shown to the user, the client can't be trusted with them.
+func (s *ServiceOAuth) Authorize(ctx context.Context, userID int, req domain.AuthorizationRequest) (domain.ConsentRequest, error) {
+	client, err := s.repo.GetClient(ctx, req.ClientID)
+	if err != nil {
+		if errors.Is(err, oauth.ErrClientNotFound) {
+			return domain.ConsentRequest{}, newError(CodeInvalidRequest, "unknown client")
+		}
+
+		return domain.ConsentRequest{}, err
+	}
+
+	if !contains(client.RedirectURIs, req.RedirectURI) {
+		return domain.ConsentRequest{}, newError(CodeInvalidRequest, "redirect uri is not registered for the client")
+	}
+
+	if req.ResponseType != "code" {
+		return domain.ConsentRequest{}, newError(CodeUnsupportedResponseType, "only the code response type is supported")
+	}
+
+	if req.CodeChallengeMethod != "S256" || len(req.CodeChallenge) != codeChallengeLength {
+		return domain.ConsentRequest{}, newError(CodeInvalidRequest, "code challenge with the S256 method is required")
+	}
+
+	scopes, err := requestedScopes(req.Scope, client.Scopes)
+	if err != nil {
+		return domain.ConsentRequest{}, err
+	}
+
+	consent := domain.ConsentRequest{
+		Client:  client,
+		Scopes:  scopes,
+		Request: req,
+	}
+
+	granted, err := s.repo.GetConsent(ctx, userID, client.ClientID)
+	if err == nil {
+		consent.Granted = subset(scopes, granted.Scopes)
+	} else if !errors.Is(err, oauth.ErrConsentNotFound) {
+		return domain.ConsentRequest{}, err
+	}
+
+	return consent, nil
+}
+
+// Approve applies the decision of the user on the consent screen and returns the redirect URL of the client
+// with the authorization code or with the access_denied error.
+func (s *ServiceOAuth) Approve(ctx context.Context, userID int, decision domain.ConsentDecision) (string, error) {
+	consent, err := s.Authorize(ctx, userID, decision.AuthorizationRequest)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	if decision.State != "" {
+		params.Set("state", decision.State)
+	}
+
+	if !decision.Approve {
+		params.Set("error", CodeAccessDenied)
+		return redirectURL(decision.RedirectURI, params)
+	}
+
+	if err = s.saveConsent(ctx, userID, consent); err != nil {
+		return "", err
+	}
+
+	code, err := randomHex(codeBytes)
+	if err != nil {
+		return "", err
+	}
+
+	if err = s.repo.CreateAuthorizationCode(ctx, domain.OAuthAuthorizationCode{
+		CodeHash:      hashSecret(code),
+		ClientID:      consent.Client.ClientID,
+		UserID:        userID,
+		RedirectURI:   decision.RedirectURI,
+		Scopes:        consent.Scopes,
+		CodeChallenge: decision.CodeChallenge,
+		ExpiresAt:     s.now().Add(authorizationCodeTTL),
+	}); err != nil {
+		return "", err
+	}
+
+	params.Set("code", code)
+
+	return redirectURL(decision.RedirectURI, params)
+}
+
+// saveConsent adds the approved scopes to the ones the user already granted to the client.
+func (s *ServiceOAuth) saveConsent(ctx context.Context, userID int, consent domain.ConsentRequest) error {
+	if consent.Granted {
+		return nil
+	}
+
+	scopes := append([]string(nil), consent.Scopes...)
+	granted, err := s.repo.GetConsent(ctx, userID, consent.Client.ClientID)
+	if err == nil {
+		for _, scope := range granted.Scopes {
+			if !contains(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	} else if !errors.Is(err, oauth.ErrConsentNotFound) {
+		return err
+	}
+
+	return s.repo.SaveConsent(ctx, domain.OAuthConsent{
+		UserID:   userID,
+		ClientID: consent.Client.ClientID,
+		Scopes:   scopes,
+	})
+}
+
+// Exchange authenticates the client and issues the tokens for the grant of the token request.
+func (s *ServiceOAuth) Exchange(ctx context.Context, clientID, clientSecret string, req domain.TokenRequest) (domain.TokenResponse, error) {
+	client, err := s.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return domain.TokenResponse{}, err
+	}
+
+	switch req.GrantType {
+	case GrantAuthorizationCode:
+		return s.exchangeCode(ctx, client, req)
+	case GrantRefreshToken:
+		return s.exchangeRefreshToken(ctx, client, req)
+	case GrantClientCredentials:
+		return s.exchangeClientCredentials(ctx, client, req)
+	default:
+		return domain.TokenResponse{}, newError(CodeUnsupportedGrantType, "grant type is not supported")
+	}
+}
+
+func (s *ServiceOAuth) exchangeCode(ctx context.Context, client domain.OAuthClient, req domain.TokenRequest) (domain.TokenResponse, error) {
+	if req.Code == "" || req.CodeVerifier == "" {
+		return domain.TokenResponse{}, newError(CodeInvalidRequest, "code and code verifier are required")
+	}
+
+	code, err := s.repo.TakeAuthorizationCode(ctx, hashSecret(req.Code))
+	if err != nil {
+		if errors.Is(err, oauth.ErrCodeNotFound) {
+			return domain.TokenResponse{}, newError(CodeInvalidGrant, "authorization code is invalid")
+		}
+
+		return domain.TokenResponse{}, err
+	}
+
+	if code.ClientID != client.ClientID || code.RedirectURI != req.RedirectURI || code.ExpiresAt.Before(s.now()) {
+		return domain.TokenResponse{}, newError(CodeInvalidGrant, "authorization code is invalid")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(oidc.CodeChallenge(req.CodeVerifier)), []byte(code.CodeChallenge)) != 1 {
+		return domain.TokenResponse{}, newError(CodeInvalidGrant, "code verifier doesn't match the code challenge")
+	}
+
+	user, err := s.users.GetUserByID(ctx, code.UserID)
+	if err != nil {
+		return domain.TokenResponse{}, newError(CodeInvalidGrant, "user not found")
+	}
+
+	return s.issueTokens(ctx, client, user, code.Scopes, true)
+}
+
+func (s *ServiceOAuth) exchangeRefreshToken(ctx context.Context, client domain.OAuthClient, req domain.TokenRequest) (domain.TokenResponse, error) {
+	if req.RefreshToken == "" {
+		return domain.TokenResponse{}, newError(CodeInvalidRequest, "refresh token is required")
+	}
+
+	session, err := s.sessions.GetRefreshToken(ctx, req.RefreshToken)
+	if err != nil || session.ClientID != client.ClientID || session.ExpiresAt.Before(s.now()) {
+		return domain.TokenResponse{}, newError(CodeInvalidGrant, "refresh token is invalid")
+	}
+
+	scopes := session.Scopes
+	if req.Scope != "" {
+		if scopes, err = requestedScopes(req.Scope, session.Scopes); err != nil {
+			return domain.TokenResponse{}, err
+		}
+	}
+
+	user, err := s.users.GetUserByID(ctx, int(session.UserID))
+	if err != nil {
+		return domain.TokenResponse{}, newError(CodeInvalidGrant, "user not found")
+	}
+
+	return s.issueTokens(ctx, client, user, scopes, true)
+}
+
+// exchangeClientCredentials issues the token acting as the owner of the client restricted to the client scopes.
+// Only a confidential client can use the grant and no refresh token is issued.
+func (s *ServiceOAuth) exchangeClientCredentials(ctx context.Context, client domain.OAuthClient, req domain.TokenRequest) (domain.TokenResponse, error) {
+	if !client.Confidential {
+		return domain.TokenResponse{}, newError(CodeUnauthorizedClient, "public clients can't use the client credentials grant")
+	}
+
+	scopes, err := requestedScopes(req.Scope, client.Scopes)
+	if err != nil {
+		return domain.TokenResponse{}, err
+	}
+
+	owner, err := s.users.GetUserByID(ctx, client.OwnerID)
+	if err != nil {
+		return domain.TokenResponse{}, err
+	}
+
+	return s.issueTokens(ctx, client, owner, scopes, false)
+}
+
+func (s *ServiceOAuth) issueTokens(ctx context.Context, client domain.OAuthClient, user domain.User, scopes []string, withRefresh bool) (domain.TokenResponse, error) {
+	if user.Disabled() {
+		return domain.TokenResponse{}, newError(CodeInvalidGrant, "user is disabled")
+	}
+
+	duration, err := time.ParseDuration(os.Getenv("ACCESS_TOKEN_DURATION"))
+	if err != nil {
+		return domain.TokenResponse{}, err
+	}
+
+	resp := domain.TokenResponse{
+		TokenType: tokenTypeBearer,
+		ExpiresIn: int64(duration.Seconds()),
+		Scope:     strings.Join(scopes, " "),
+	}
+
+	claims := token.Claims{
+		UserID:   user.ID,
+		Role:     string(user.Role),
+		Scopes:   scopes,
+		ClientID: client.ClientID,
+	}
+
+	if withRefresh {
+		if resp.RefreshToken, err = randomHex(refreshTokenBytes); err != nil {
+			return domain.TokenResponse{}, err
+		}
+
+		if claims.SessionID, err = s.sessions.CreateRefreshToken(ctx, domain.RefreshSession{
+			UserID:    int64(user.ID),
+			Token:     resp.RefreshToken,
+			ClientID:  client.ClientID,
+			Scopes:    scopes,
+			ExpiresAt: s.now().Add(refreshTokenTTL),
+		}); err != nil {
+			return domain.TokenResponse{}, err
+		}
+	}
+
+	if resp.AccessToken, err = s.tokenManager.CreateToken(claims, duration); err != nil {
+		return domain.TokenResponse{}, err
+	}
+
+	return resp, nil
+}
+
+// Introspect returns the state of the access token. Only a confidential client can introspect,
+// and only the tokens issued to it, the other tokens are reported as not active.
+func (s *ServiceOAuth) Introspect(ctx context.Context, clientID, clientSecret, accessToken string) (domain.Introspection, error) {
+	client, err := s.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return domain.Introspection{}, err
+	}
+
+	if !client.Confidential {
+		return domain.Introspection{}, newError(CodeUnauthorizedClient, "public clients can't introspect tokens")
+	}
+
+	payload, err := s.tokenManager.VerifyToken(accessToken)
+	if err != nil || payload.ClientID != client.ClientID {
+		return domain.Introspection{Active: false}, nil
+	}
+
+	return domain.Introspection{
+		Active:    true,
+		Scope:     strings.Join(payload.Scopes, " "),
+		ClientID:  payload.ClientID,
+		Subject:   payload.Subject,
+		UserID:    payload.UserID,
+		TokenType: tokenTypeBearer,
+		ExpiresAt: payload.ExpiredAt.Unix(),
+		IssuedAt:  payload.IssuedAt.Unix(),
+		Issuer:    payload.Issuer,
+		Audience:  payload.Audience,
+	}, nil
+}
+
+// authenticateClient returns the client if the secret is correct. A public client has no secret.
+func (s *ServiceOAuth) authenticateClient(ctx context.Context, clientID, clientSecret string) (domain.OAuthClient, error) {
+	if clientID == "" {
+		return domain.OAuthClient{}, newError(CodeInvalidClient, "client authentication failed")
+	}
+
+	client, err := s.repo.GetClient(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, oauth.ErrClientNotFound) {
+			return domain.OAuthClient{}, newError(CodeInvalidClient, "client authentication failed")
+		}
+
+		return domain.OAuthClient{}, err
+	}
+
+	if client.Confidential {
+		if subtle.ConstantTimeCompare([]byte(hashSecret(clientSecret)), []byte(client.SecretHash)) != 1 {
+			return domain.OAuthClient{}, newError(CodeInvalidClient, "client authentication failed")
+		}
+	} else if clientSecret != "" {
+		return domain.OAuthClient{}, newError(CodeInvalidClient, "client authentication failed")
+	}
+
+	return client, nil
+}
+
+// requestedScopes parses the space-delimited scope parameter, empty means all allowed scopes.
+// Every scope must be allowed, so the token is never issued without scopes.
+func requestedScopes(scope string, allowed []string) ([]string, error) {
+	scopes := strings.Fields(scope)
+	if len(scopes) == 0 {
+		scopes = allowed
+	}
+
+	if len(scopes) == 0 {
+		return nil, newError(CodeInvalidScope, "no scope is requested")
+	}
+
+	for _, s := range scopes {
+		if !contains(allowed, s) || !policy.Grantable(policy.Permission(s)) {
+			return nil, newError(CodeInvalidScope, "scope "+s+" is not allowed for the client")
+		}
+	}
+
+	return scopes, nil
+}
+
+func validRedirectURI(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "https" || u.Scheme == "http") && u.Host != "" && u.Fragment == ""
+}
+
+func redirectURL(uri string, params url.Values) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func subset(scopes, of []string) bool {
+	for _, s := range scopes {
+		if !contains(of, s) {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// hashSecret returns the hex encoded SHA-256 of the random secret. The secrets have enough entropy,
+// so a fast hash is enough and lets the code be found by its hash.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -7,9 +7,19 @@ import (
 	"github.com/popeskul/cache"
 	"github.com/popeskul/qna-go/internal/domain"
 	"github.com/popeskul/qna-go/internal/hash"
+	"github.com/popeskul/qna-go/internal/lockout"
+	"github.com/popeskul/qna-go/internal/mail"
+	"github.com/popeskul/qna-go/internal/oidc"
+	"github.com/popeskul/qna-go/internal/password"
+	"github.com/popeskul/qna-go/internal/policy"
 	"github.com/popeskul/qna-go/internal/repository"
 	"github.com/popeskul/qna-go/internal/repository/sessions"
+	"github.com/popeskul/qna-go/internal/services/account"
+	"github.com/popeskul/qna-go/internal/services/admin"
+	"github.com/popeskul/qna-go/internal/services/apikeys"
 	"github.com/popeskul/qna-go/internal/services/auth"
+	"github.com/popeskul/qna-go/internal/services/exports"
+	"github.com/popeskul/qna-go/internal/services/oauth"
 	"github.com/popeskul/qna-go/internal/services/tests"
 	"github.com/popeskul/qna-go/internal/token"
 )
@@ -17,33 +27,109 @@ import (
 // Auth interface is implemented by auth service.
 type Auth interface {
 	CreateUser(ctx context.Context, userInput domain.User) error
+	ChangePassword(ctx context.Context, userID int, currentPassword, newPassword string) error
 	SignIn(ctx context.Context, userInput domain.User) (string, string, error)
 	GetUser(ctx context.Context, email string, password []byte) (domain.User, error)
 	GetUserByEmail(ctx context.Context, email string) (domain.User, error)
+	GetUserByID(ctx context.Context, userID int) (domain.User, error)
+	UpdateUserRole(ctx context.Context, userID int, role domain.Role) error
 	VerifyToken(ctx context.Context, token string) (*token.Payload, error)
 	GenerateAccessRefreshTokens(ctx context.Context, token string) (string, string, error)
+	EnrollTwoFactor(ctx context.Context, userID int) (domain.TwoFactorEnrollment, error)
+	ActivateTwoFactor(ctx context.Context, userID int, code string) ([]string, error)
+	DisableTwoFactor(ctx context.Context, userID int, code string) error
+	VerifyTwoFactor(ctx context.Context, challengeToken, code string) (string, string, error)
+	StartOIDCLogin(ctx context.Context, provider string, userID int) (string, string, error)
+	FinishOIDCLogin(ctx context.Context, provider, state, code string, userID int) (string, string, error)
+	GetIdentities(ctx context.Context, userID int) ([]domain.LinkedIdentity, error)
+	UnlinkIdentity(ctx context.Context, userID, identityID int) error
+}
+
+// Account interface is implemented by account service.
+type Account interface {
+	GetProfile(ctx context.Context, userID int) (domain.Profile, error)
+	UpdateProfile(ctx context.Context, userID int, req domain.UpdateProfileRequest) (domain.Profile, error)
+	ConfirmEmailChange(ctx context.Context, token string) error
+	DeleteAccount(ctx context.Context, userID int) error
+}
+
+// Exports interface is implemented by data exports service.
+type Exports interface {
+	CreateExport(ctx context.Context, userID int) (domain.DataExport, error)
+	GetExport(ctx context.Context, userID, exportID int) (domain.DataExport, error)
+	DownloadExport(ctx context.Context, exportID int, expires int64, signature string) ([]byte, error)
+}
+
+// Admin interface is implemented by the user management service.
+type Admin interface {
+	SearchUsers(ctx context.Context, req domain.SearchUsersRequest) ([]domain.AdminUser, error)
+	GetUser(ctx context.Context, actorID, userID int) (domain.AdminUser, error)
+	UpdateUserRole(ctx context.Context, actorID, userID int, role domain.Role) error
+	DisableUser(ctx context.Context, actorID, userID int, reason string) error
+	EnableUser(ctx context.Context, actorID, userID int) error
+	ForceLogout(ctx context.Context, actorID, userID int) (int64, error)
+	ResetPassword(ctx context.Context, actorID, userID int, reason string) (string, error)
+	Impersonate(ctx context.Context, actorID, userID int, reason string) (domain.ImpersonationResponse, error)
+	DeleteUser(ctx context.Context, actorID, userID int, reason string) error
+	GetAuditLog(ctx context.Context, req domain.AuditLogRequest) ([]domain.AuditEntry, error)
+	BootstrapAdmin(ctx context.Context, email string) error
 }
 
 // Sessions interface is implemented by sessions' repository.
 type Sessions interface {
-	CreateRefreshToken(ctx context.Context, token domain.RefreshSession) error
+	CreateRefreshToken(ctx context.Context, token domain.RefreshSession) (int64, error)
 	GetRefreshToken(ctx context.Context, token string) (domain.RefreshSession, error)
 }
 
 // Tests interface is implemented by tests service.
 type Tests interface {
-	CreateTest(ctx context.Context, userID int, test domain.Test) error
+	CreateTest(ctx context.Context, userID int, test domain.Test) (domain.Test, error)
 	GetTest(ctx context.Context, testID int) (domain.Test, error)
 	GetAllTestsByUserID(ctx context.Context, userID int, args domain.GetAllTestsParams) ([]domain.Test, error)
-	UpdateTestByID(ctx context.Context, testID int, test domain.Test) error
-	DeleteTestByID(ctx context.Context, testID int) error
+	AuthorizeTest(ctx context.Context, subject policy.Subject, testID int, perm policy.Permission) (domain.Test, error)
+	UpdateTestByID(ctx context.Context, subject policy.Subject, testID, version int, test domain.Test) (domain.Test, error)
+	PatchTestByID(ctx context.Context, subject policy.Subject, testID, version int, patch domain.TestPatch) (domain.Test, error)
+	DeleteTestByID(ctx context.Context, subject policy.Subject, testID, version int) error
+	ApplyTestBatch(ctx context.Context, subject policy.Subject, batch domain.TestBatch) (domain.TestBatchResult, error)
+	ApplyQuestionBatch(ctx context.Context, subject policy.Subject, batch domain.QuestionBatch) (domain.QuestionBatchResult, error)
+	GetTestResults(ctx context.Context, subject policy.Subject, testID int) ([]domain.TestPassage, error)
+	GetTestMembers(ctx context.Context, subject policy.Subject, testID int) ([]domain.TestMember, error)
+	SaveTestMember(ctx context.Context, subject policy.Subject, member domain.TestMember) error
+	DeleteTestMember(ctx context.Context, subject policy.Subject, testID, userID int) error
+	GetQuestions(ctx context.Context, testIDs []int) ([]domain.Question, error)
+	GetAnswers(ctx context.Context, questionIDs []int) ([]domain.Answer, error)
+	GetUserPassages(ctx context.Context, userID int, testIDs []int) ([]domain.TestPassage, error)
+}
+
+// APIKeys interface is implemented by API keys service.
+type APIKeys interface {
+	CreateAPIKey(ctx context.Context, userID int, req domain.CreateAPIKeyRequest) (domain.CreatedAPIKey, error)
+	GetAPIKeys(ctx context.Context, userID int) ([]domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID int) error
+	AuthenticateAPIKey(ctx context.Context, key string) (*token.Payload, error)
+}
+
+// OAuth interface is implemented by the OAuth2 authorization server service.
+type OAuth interface {
+	RegisterClient(ctx context.Context, ownerID int, req domain.CreateOAuthClientRequest) (domain.RegisteredOAuthClient, error)
+	GetClients(ctx context.Context, ownerID int) ([]domain.OAuthClient, error)
+	DeleteClient(ctx context.Context, ownerID int, clientID string) error
+	Authorize(ctx context.Context, userID int, req domain.AuthorizationRequest) (domain.ConsentRequest, error)
+	Approve(ctx context.Context, userID int, decision domain.ConsentDecision) (string, error)
+	Exchange(ctx context.Context, clientID, clientSecret string, req domain.TokenRequest) (domain.TokenResponse, error)
+	Introspect(ctx context.Context, clientID, clientSecret, accessToken string) (domain.Introspection, error)
 }
 
 // Service struct is composed of all services.
 type Service struct {
 	Auth
+	Account
+	Exports
+	Admin
 	Tests
 	Sessions
+	APIKeys
+	OAuth
 	TokenMaker token.Manager
 	Cache      *cache.Cache
 }
@@ -53,10 +139,25 @@ func NewService(
 	repo *repository.Repository,
 	tokenManager token.Manager,
 	hashManager *hash.Manager,
+	passwords *password.Validator,
 	cache *cache.Cache,
-	sessionManager *sessions.RepositorySessions) *Service {
+	sessionManager *sessions.RepositorySessions,
+	loginGuard *lockout.Guard,
+	providers map[string]*oidc.Provider,
+	mailer mail.Sender,
+	accountConfig account.Config,
+	exportConfig exports.Config) *Service {
+	accountService := account.NewServiceAccount(repo, repo, mailer, accountConfig)
+
 	return &Service{
-		Auth:  auth.NewServiceAuth(repo, tokenManager, hashManager, sessionManager),
-		Tests: tests.NewServiceTests(repo, cache),
+		Auth:    auth.NewServiceAuth(repo, repo, repo, tokenManager, hashManager, passwords, sessionManager, loginGuard, providers),
+		Account: accountService,
+		Admin:   admin.NewServiceAdmin(repo, tokenManager, hashManager, accountConfig.DeletionPolicy),
+		Exports: exports.NewServiceExports(repo, exportConfig),
+		Tests:   tests.NewServiceTests(repo, repo, repo, repo, repo, cache),
+		APIKeys: apikeys.NewServiceAPIKeys(repo, repo),
+		OAuth:   oauth.NewServiceOAuth(repo, repo, repo, tokenManager),
+
+		TokenMaker: tokenManager,
 	}
 }
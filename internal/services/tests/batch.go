@@ -0,0 +1,226 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/policy"
+)
+
+var (
+	ErrEmptyBatch         = errors.New("the batch has no operations")
+	ErrBatchTooLarge      = fmt.Errorf("the batch has more than %d operations", domain.MaxBatchSize)
+	ErrDuplicateBatchItem = errors.New("the batch already has an operation of the same kind on the item")
+)
+
+// checkBatchSize returns error if the batch is empty or too large.
+func checkBatchSize(size int) error {
+	if size == 0 {
+		return ErrEmptyBatch
+	}
+
+	if size > domain.MaxBatchSize {
+		return ErrBatchTooLarge
+	}
+
+	return nil
+}
+
+// ApplyTestBatch creates, updates and deletes the tests the subject is allowed to and returns the result of every
+// operation in the order of the batch. The tests are created by the subject. The operations the subject is not
+// allowed to and the repeated operations on the same test fail without touching the database, so an atomic batch
+// with such an operation fails as a whole.
+func (s *ServiceTests) ApplyTestBatch(ctx context.Context, subject policy.Subject, batch domain.TestBatch) (domain.TestBatchResult, error) {
+	if err := checkBatchSize(batch.Size()); err != nil {
+		return domain.TestBatchResult{}, err
+	}
+
+	result := domain.TestBatchResult{
+		Create: make([]domain.TestResult, len(batch.Create)),
+		Update: make([]domain.TestResult, len(batch.Update)),
+		Delete: make([]domain.TestResult, len(batch.Delete)),
+	}
+
+	// the operations passing the checks are applied, their indexes in the batch map the results back
+	apply := domain.TestBatch{Mode: batch.Mode}
+	var createIndexes, updateIndexes, deleteIndexes []int
+
+	if len(batch.Create) > 0 && !policy.Can(subject, policy.CreateTest) {
+		for i := range result.Create {
+			result.Create[i].Err = policy.ErrForbidden
+		}
+	} else {
+		apply.Create = batch.Create
+		for i := range batch.Create {
+			createIndexes = append(createIndexes, i)
+		}
+	}
+
+	updateIDs := make([]int, len(batch.Update))
+	for i, update := range batch.Update {
+		updateIDs[i] = update.ID
+	}
+	updateErrs, err := s.authorizeTests(ctx, subject, updateIDs, policy.UpdateTest)
+	if err != nil {
+		return domain.TestBatchResult{}, err
+	}
+	for i, update := range batch.Update {
+		result.Update[i] = domain.TestResult{Test: domain.Test{ID: update.ID}, Err: updateErrs[i]}
+		if updateErrs[i] == nil {
+			apply.Update = append(apply.Update, update)
+			updateIndexes = append(updateIndexes, i)
+		}
+	}
+
+	deleteIDs := make([]int, len(batch.Delete))
+	for i, d := range batch.Delete {
+		deleteIDs[i] = d.ID
+	}
+	deleteErrs, err := s.authorizeTests(ctx, subject, deleteIDs, policy.DeleteTest)
+	if err != nil {
+		return domain.TestBatchResult{}, err
+	}
+	for i, d := range batch.Delete {
+		result.Delete[i] = domain.TestResult{Test: domain.Test{ID: d.ID}, Err: deleteErrs[i]}
+		if deleteErrs[i] == nil {
+			apply.Delete = append(apply.Delete, d)
+			deleteIndexes = append(deleteIndexes, i)
+		}
+	}
+
+	if batch.Mode == domain.BatchAtomic && result.Failed() > 0 {
+		result.RollBack()
+		return result, nil
+	}
+
+	if apply.Size() == 0 {
+		return result, nil
+	}
+
+	applied, err := s.repo.ApplyTestBatch(ctx, subject.UserID, apply)
+	if err != nil {
+		return domain.TestBatchResult{}, err
+	}
+
+	for j, i := range createIndexes {
+		result.Create[i] = applied.Create[j]
+	}
+	for j, i := range updateIndexes {
+		result.Update[i] = applied.Update[j]
+		s.cache.Delete(result.Update[i].Test.ID)
+	}
+	for j, i := range deleteIndexes {
+		result.Delete[i] = applied.Delete[j]
+		s.cache.Delete(result.Delete[i].Test.ID)
+	}
+
+	return result, nil
+}
+
+// authorizeTests check the permission of the subject on the tests of the ids loaded in one query and returns
+// the error of every id: policy.ErrForbidden, ErrDuplicateBatchItem for the repeated ids or nil.
+// The missing tests are not reported, the batch reports them.
+func (s *ServiceTests) authorizeTests(ctx context.Context, subject policy.Subject, testIDs []int, perm policy.Permission) ([]error, error) {
+	errs := make([]error, len(testIDs))
+	if len(testIDs) == 0 {
+		return errs, nil
+	}
+
+	found, err := s.repo.GetTestsByIDs(ctx, testIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	testErrs := make(map[int]error, len(found))
+	for _, test := range found {
+		err = s.authorize(ctx, subject, test, perm)
+		if err != nil && !errors.Is(err, policy.ErrForbidden) {
+			return nil, err
+		}
+		testErrs[test.ID] = err
+	}
+
+	seen := make(map[int]bool, len(testIDs))
+	for i, id := range testIDs {
+		if seen[id] {
+			errs[i] = ErrDuplicateBatchItem
+			continue
+		}
+		seen[id] = true
+		errs[i] = testErrs[id]
+	}
+
+	return errs, nil
+}
+
+// ApplyQuestionBatch creates, updates and deletes the questions of the test if the subject is allowed to update it
+// and returns the result of every operation in the order of the batch. The repeated operations on the same question
+// fail without touching the database, so an atomic batch with such an operation fails as a whole.
+func (s *ServiceTests) ApplyQuestionBatch(ctx context.Context, subject policy.Subject, batch domain.QuestionBatch) (domain.QuestionBatchResult, error) {
+	if err := checkBatchSize(batch.Size()); err != nil {
+		return domain.QuestionBatchResult{}, err
+	}
+
+	if _, err := s.AuthorizeTest(ctx, subject, batch.TestID, policy.UpdateTest); err != nil {
+		return domain.QuestionBatchResult{}, err
+	}
+
+	result := domain.QuestionBatchResult{
+		Create: make([]domain.QuestionResult, len(batch.Create)),
+		Update: make([]domain.QuestionResult, len(batch.Update)),
+		Delete: make([]domain.QuestionResult, len(batch.Delete)),
+	}
+
+	apply := domain.QuestionBatch{TestID: batch.TestID, Mode: batch.Mode, Create: batch.Create}
+	var updateIndexes, deleteIndexes []int
+
+	seen := make(map[int]bool, len(batch.Update))
+	for i, update := range batch.Update {
+		result.Update[i].Question.ID = update.ID
+		if seen[update.ID] {
+			result.Update[i].Err = ErrDuplicateBatchItem
+			continue
+		}
+		seen[update.ID] = true
+		apply.Update = append(apply.Update, update)
+		updateIndexes = append(updateIndexes, i)
+	}
+
+	seen = make(map[int]bool, len(batch.Delete))
+	for i, d := range batch.Delete {
+		result.Delete[i].Question.ID = d.ID
+		if seen[d.ID] {
+			result.Delete[i].Err = ErrDuplicateBatchItem
+			continue
+		}
+		seen[d.ID] = true
+		apply.Delete = append(apply.Delete, d)
+		deleteIndexes = append(deleteIndexes, i)
+	}
+
+	if batch.Mode == domain.BatchAtomic && result.Failed() > 0 {
+		result.RollBack()
+		return result, nil
+	}
+
+	if apply.Size() == 0 {
+		return result, nil
+	}
+
+	applied, err := s.questions.ApplyQuestionBatch(ctx, apply)
+	if err != nil {
+		return domain.QuestionBatchResult{}, err
+	}
+
+	copy(result.Create, applied.Create)
+	for j, i := range updateIndexes {
+		result.Update[i] = applied.Update[j]
+	}
+	for j, i := range deleteIndexes {
+		result.Delete[i] = applied.Delete[j]
+	}
+
+	return result, nil
+}
@@ -3,28 +3,45 @@ package tests
 
 import (
 	"context"
+	"errors"
+
 	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/policy"
 	"github.com/popeskul/qna-go/internal/repository"
+	"github.com/popeskul/qna-go/internal/repository/members"
 
 	"github.com/popeskul/cache"
 )
 
+var (
+	ErrInvalidMemberRole = errors.New("invalid member role")
+	ErrOwnerAsMember     = errors.New("the owner of the test can't be added as a member")
+)
+
 // ServiceTests compose all functions for tests.
 type ServiceTests struct {
-	repo  repository.Tests
-	cache *cache.Cache
+	repo      repository.Tests
+	members   repository.Members
+	users     repository.Auth
+	passages  repository.Passages
+	questions repository.Questions
+	cache     *cache.Cache
 }
 
 // NewServiceTests create service with all fields.
-func NewServiceTests(repo repository.Tests, cache *cache.Cache) *ServiceTests {
+func NewServiceTests(repo repository.Tests, members repository.Members, users repository.Auth, passages repository.Passages, questions repository.Questions, cache *cache.Cache) *ServiceTests {
 	return &ServiceTests{
-		repo:  repo,
-		cache: cache,
+		repo:      repo,
+		members:   members,
+		users:     users,
+		passages:  passages,
+		questions: questions,
+		cache:     cache,
 	}
 }
 
-// CreateTest create new test in db and return testID and error if test not found.
-func (s *ServiceTests) CreateTest(ctx context.Context, userID int, test domain.Test) error {
+// CreateTest create new test in db and return the created test and error if any.
+func (s *ServiceTests) CreateTest(ctx context.Context, userID int, test domain.Test) (domain.Test, error) {
 	return s.repo.CreateTest(ctx, userID, test)
 }
 
@@ -50,33 +67,89 @@ func (s *ServiceTests) GetAllTestsByUserID(ctx context.Context, userID int, args
 	return s.repo.GetAllTestsByUserID(ctx, userID, args)
 }
 
-// UpdateTestByID update test in db and return test and error if test not found.
-func (s *ServiceTests) UpdateTestByID(ctx context.Context, testID int, test domain.Test) error {
-	if err := s.repo.UpdateTestById(ctx, testID, test); err != nil {
-		return err
+// AuthorizeTest get test by testID and check that the subject has the permission on it
+// either by its role or by the membership in the test.
+// Returns policy.ErrForbidden if the permission is not granted.
+func (s *ServiceTests) AuthorizeTest(ctx context.Context, subject policy.Subject, testID int, perm policy.Permission) (domain.Test, error) {
+	test, err := s.GetTest(ctx, testID)
+	if err != nil {
+		return domain.Test{}, err
 	}
 
-	if v, ok := s.cache.Get(testID); ok {
-		cachedTest, ok := v.(domain.Test)
-		if !ok {
-			return nil
-		}
+	if err = s.authorize(ctx, subject, test, perm); err != nil {
+		return domain.Test{}, err
+	}
 
-		if cachedTest.Title != test.Title && test.Title != "" {
-			cachedTest.Title = test.Title
-		}
-		if cachedTest.AuthorID != test.AuthorID && test.AuthorID != 0 {
-			cachedTest.AuthorID = test.AuthorID
+	return test, nil
+}
+
+// authorize check that the subject has the permission on the test either by its role or by the membership.
+func (s *ServiceTests) authorize(ctx context.Context, subject policy.Subject, test domain.Test, perm policy.Permission) error {
+	if policy.CanOn(subject, perm, test.AuthorID) {
+		return nil
+	}
+
+	if !subject.InScope(perm) {
+		return policy.ErrForbidden
+	}
+
+	member, err := s.members.GetTestMember(ctx, test.ID, subject.UserID)
+	if err != nil {
+		if errors.Is(err, members.ErrMemberNotFound) {
+			return policy.ErrForbidden
 		}
-		s.cache.Set(testID, cachedTest)
+
+		return err
 	}
 
-	return nil
+	return policy.AuthorizeMember(member, perm)
+}
+
+// UpdateTestByID update test in db if the subject is allowed to and the test still has the version,
+// return the updated test. Returns tests.ErrVersionConflict if the test was changed since the version.
+func (s *ServiceTests) UpdateTestByID(ctx context.Context, subject policy.Subject, testID, version int, test domain.Test) (domain.Test, error) {
+	if _, err := s.AuthorizeTest(ctx, subject, testID, policy.UpdateTest); err != nil {
+		return domain.Test{}, err
+	}
+
+	updated, err := s.repo.UpdateTestById(ctx, testID, version, test)
+	if err != nil {
+		return domain.Test{}, err
+	}
+
+	s.cache.Delete(testID)
+
+	return updated, nil
+}
+
+// PatchTestByID apply the merge patch to the test if the subject is allowed to and the test still has the version,
+// return the patched test. The empty patch changes nothing and returns the test as is.
+func (s *ServiceTests) PatchTestByID(ctx context.Context, subject policy.Subject, testID, version int, patch domain.TestPatch) (domain.Test, error) {
+	if _, err := s.AuthorizeTest(ctx, subject, testID, policy.UpdateTest); err != nil {
+		return domain.Test{}, err
+	}
+
+	test, err := s.repo.PatchTestByID(ctx, testID, version, patch)
+	if err != nil {
+		return domain.Test{}, err
+	}
+
+	// the cached test is dropped rather than merged, the next read gets the test as the database has it
+	if !patch.Empty() {
+		s.cache.Delete(testID)
+	}
+
+	return test, nil
 }
 
-// DeleteTestByID delete test in db and return error if test not found.
-func (s *ServiceTests) DeleteTestByID(ctx context.Context, testID int) error {
-	if err := s.repo.DeleteTestById(ctx, testID); err != nil {
+// DeleteTestByID delete test in db if the subject is allowed to and the test still has the version,
+// return error if test not found.
+func (s *ServiceTests) DeleteTestByID(ctx context.Context, subject policy.Subject, testID, version int) error {
+	if _, err := s.AuthorizeTest(ctx, subject, testID, policy.DeleteTest); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteTestById(ctx, testID, version); err != nil {
 		return err
 	}
 
@@ -84,3 +157,71 @@ func (s *ServiceTests) DeleteTestByID(ctx context.Context, testID int) error {
 
 	return nil
 }
+
+// GetTestResults get all passages of the test if the subject is allowed to see them.
+func (s *ServiceTests) GetTestResults(ctx context.Context, subject policy.Subject, testID int) ([]domain.TestPassage, error) {
+	if _, err := s.AuthorizeTest(ctx, subject, testID, policy.ViewResults); err != nil {
+		return nil, err
+	}
+
+	return s.passages.GetPassagesByTestID(ctx, testID)
+}
+
+// GetTestMembers get all collaborators of the test if the subject is allowed to read the test.
+func (s *ServiceTests) GetTestMembers(ctx context.Context, subject policy.Subject, testID int) ([]domain.TestMember, error) {
+	if _, err := s.AuthorizeTest(ctx, subject, testID, policy.ReadTest); err != nil {
+		return nil, err
+	}
+
+	return s.members.GetTestMembers(ctx, testID)
+}
+
+// SaveTestMember invite the user to the test or change the role of an existing member.
+// user.ErrUserNotFound is returned if there is no such user.
+func (s *ServiceTests) SaveTestMember(ctx context.Context, subject policy.Subject, member domain.TestMember) error {
+	if !member.Role.Valid() {
+		return ErrInvalidMemberRole
+	}
+
+	test, err := s.AuthorizeTest(ctx, subject, member.TestID, policy.ManageMembers)
+	if err != nil {
+		return err
+	}
+
+	if test.AuthorID == member.UserID {
+		return ErrOwnerAsMember
+	}
+
+	// the unknown user is reported before the foreign key of the membership rejects it
+	if _, err = s.users.GetUserByID(ctx, member.UserID); err != nil {
+		return err
+	}
+
+	return s.members.SaveTestMember(ctx, member)
+}
+
+// DeleteTestMember remove the user from the collaborators of the test.
+func (s *ServiceTests) DeleteTestMember(ctx context.Context, subject policy.Subject, testID, userID int) error {
+	if _, err := s.AuthorizeTest(ctx, subject, testID, policy.ManageMembers); err != nil {
+		return err
+	}
+
+	return s.members.DeleteTestMember(ctx, testID, userID)
+}
+
+// GetQuestions get the questions of the tests in one query.
+// The tests must be authorized by the caller.
+func (s *ServiceTests) GetQuestions(ctx context.Context, testIDs []int) ([]domain.Question, error) {
+	return s.questions.GetQuestionsByTestIDs(ctx, testIDs)
+}
+
+// GetAnswers get the answers of the questions in one query.
+// The tests of the questions must be authorized by the caller.
+func (s *ServiceTests) GetAnswers(ctx context.Context, questionIDs []int) ([]domain.Answer, error) {
+	return s.questions.GetAnswersByQuestionIDs(ctx, questionIDs)
+}
+
+// GetUserPassages get the results of the user in the tests in one query.
+func (s *ServiceTests) GetUserPassages(ctx context.Context, userID int, testIDs []int) ([]domain.TestPassage, error) {
+	return s.passages.GetUserPassagesByTestIDs(ctx, userID, testIDs)
+}
@@ -9,14 +9,17 @@ import (
 	"github.com/popeskul/qna-go/internal/db"
 	"github.com/popeskul/qna-go/internal/db/postgres"
 	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/policy"
 	"github.com/popeskul/qna-go/internal/repository"
 	"github.com/popeskul/qna-go/internal/repository/tests"
 	"github.com/popeskul/qna-go/internal/util"
 	"log"
 	"os"
 	"testing"
+	"time"
 
 	_ "github.com/lib/pq"
+	"github.com/popeskul/cache"
 )
 
 var (
@@ -81,12 +84,16 @@ func TestServiceTests_CreateTest(t *testing.T) {
 
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
-			err := mockRepo.CreateTest(ctx, tt.args.userID, tt.args.input)
+			created, err := mockRepo.CreateTest(ctx, tt.args.userID, tt.args.input)
 
 			if err != tt.want.err {
 				t.Fatalf("ServiceTests.CreateTest() error = %v, wantErr %v", err, tt.want.err)
 			}
 
+			if created.Title != tt.want.title {
+				t.Errorf("ServiceTests.CreateTest() title = %s, want %s", created.Title, tt.want.title)
+			}
+
 			t.Cleanup(func() {
 				helperDeleteTestByTitle(t, tt.args.input.Title)
 			})
@@ -98,9 +105,10 @@ func TestServiceTests_UpdateTestById(t *testing.T) {
 	ctx := context.Background()
 	mockUserID := 1
 	test := randomTest()
-	if err := mockRepo.CreateTest(ctx, mockUserID, test); err != nil {
+	if _, err := mockRepo.CreateTest(ctx, mockUserID, test); err != nil {
 		t.Fatalf("Some error occured. Err: %s", err)
 	}
+	testID := helperFindTestByTitle(t, test.Title).ID
 
 	type args struct {
 		repo   *repository.Repository
@@ -144,7 +152,7 @@ func TestServiceTests_UpdateTestById(t *testing.T) {
 
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
-			err := mockRepo.UpdateTestById(ctx, tt.args.userID, tt.args.input)
+			_, err := mockRepo.UpdateTestById(ctx, testID, domain.AnyVersion, tt.args.input)
 
 			if err != tt.want.err {
 				t.Fatalf("ServiceTests.UpdateTestById() error = %v, wantErr %v", err, tt.want.err)
@@ -153,7 +161,134 @@ func TestServiceTests_UpdateTestById(t *testing.T) {
 	}
 
 	t.Cleanup(func() {
-		helperDeleteTestByTitle(t, test.Title)
+		helperDeleteTest(t, testID)
+	})
+}
+
+func TestServiceTests_PatchTestByID(t *testing.T) {
+	ctx := context.Background()
+	mockUserID := 1
+	test := randomTest()
+	testID := helperCreateTest(t, mockUserID, test)
+
+	service := NewServiceTests(mockRepo.Tests, mockRepo.Members, mockRepo.Auth, mockRepo.Passages, mockRepo.Questions, cache.New(time.Minute))
+	owner := policy.Subject{UserID: mockUserID, Role: domain.RoleAuthor}
+	stranger := policy.Subject{UserID: mockUserID + 1, Role: domain.RoleAuthor}
+	patchedTitle := util.RandomString(10)
+
+	// the read caches the test, the patch must not leave the stale title there
+	if _, err := service.GetTest(ctx, testID); err != nil {
+		t.Fatalf("Some error occured. Err: %s", err)
+	}
+
+	testCases := []struct {
+		name    string
+		subject policy.Subject
+		testID  int
+		version int
+		patch   domain.TestPatch
+		title   string
+		err     error
+	}{
+		{name: "Success: empty patch", subject: owner, testID: testID, patch: domain.TestPatch{}, title: test.Title},
+		{name: "Fail: not allowed", subject: stranger, testID: testID, patch: domain.TestPatch{Title: &patchedTitle}, err: policy.ErrForbidden},
+		{name: "Fail: test not found", subject: owner, testID: 0, patch: domain.TestPatch{Title: &patchedTitle}, err: tests.ErrTestNotFound},
+		{name: "Fail: stale version", subject: owner, testID: testID, version: 2, patch: domain.TestPatch{Title: &patchedTitle}, err: tests.ErrVersionConflict},
+		{name: "Success: patch title", subject: owner, testID: testID, version: 1, patch: domain.TestPatch{Title: &patchedTitle}, title: patchedTitle},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			patched, err := service.PatchTestByID(ctx, tt.subject, tt.testID, tt.version, tt.patch)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ServiceTests.PatchTestByID() error = %v, wantErr %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+
+			if patched.Title != tt.title {
+				t.Errorf("ServiceTests.PatchTestByID() title = %s, want %s", patched.Title, tt.title)
+			}
+
+			read, err := service.GetTest(ctx, testID)
+			if err != nil {
+				t.Fatalf("Some error occured. Err: %s", err)
+			}
+			if read.Title != tt.title {
+				t.Errorf("ServiceTests.GetTest() title = %s, want %s", read.Title, tt.title)
+			}
+		})
+	}
+
+	t.Cleanup(func() {
+		helperDeleteTest(t, testID)
+	})
+}
+
+func TestServiceTests_ApplyTestBatch(t *testing.T) {
+	ctx := context.Background()
+	mockUserID := 1
+	testID := helperCreateTest(t, mockUserID, randomTest())
+
+	service := NewServiceTests(mockRepo.Tests, mockRepo.Members, mockRepo.Auth, mockRepo.Passages, mockRepo.Questions, cache.New(time.Minute))
+	owner := policy.Subject{UserID: mockUserID, Role: domain.RoleAuthor}
+	stranger := policy.Subject{UserID: mockUserID + 1, Role: domain.RoleAuthor}
+	update := domain.TestUpdate{ID: testID, Title: util.RandomString(10)}
+
+	testCases := []struct {
+		name      string
+		subject   policy.Subject
+		batch     domain.TestBatch
+		updateErr []error
+		err       error
+	}{
+		{name: "Fail: empty batch", subject: owner, batch: domain.TestBatch{Mode: domain.BatchAtomic}, err: ErrEmptyBatch},
+		{
+			name:      "Fail: not allowed",
+			subject:   stranger,
+			batch:     domain.TestBatch{Mode: domain.BatchPartial, Update: []domain.TestUpdate{update}},
+			updateErr: []error{policy.ErrForbidden},
+		},
+		{
+			name:      "Fail: atomic batch with duplicate is rolled back",
+			subject:   owner,
+			batch:     domain.TestBatch{Mode: domain.BatchAtomic, Update: []domain.TestUpdate{update, update}},
+			updateErr: []error{domain.ErrBatchRolledBack, ErrDuplicateBatchItem},
+		},
+		{
+			name:      "Success: partial batch with duplicate",
+			subject:   owner,
+			batch:     domain.TestBatch{Mode: domain.BatchPartial, Update: []domain.TestUpdate{update, update}},
+			updateErr: []error{nil, ErrDuplicateBatchItem},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := service.ApplyTestBatch(ctx, tt.subject, tt.batch)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ServiceTests.ApplyTestBatch() error = %v, wantErr %v", err, tt.err)
+			}
+
+			for i, want := range tt.updateErr {
+				if !errors.Is(result.Update[i].Err, want) {
+					t.Errorf("ServiceTests.ApplyTestBatch() update %d error = %v, want %v", i, result.Update[i].Err, want)
+				}
+			}
+		})
+	}
+
+	read, err := service.GetTest(ctx, testID)
+	if err != nil {
+		t.Fatalf("Some error occured. Err: %s", err)
+	}
+	if read.Title != update.Title {
+		t.Errorf("ServiceTests.GetTest() title = %s, want %s", read.Title, update.Title)
+	}
+
+	t.Cleanup(func() {
+		helperDeleteTest(t, testID)
 	})
 }
 
@@ -162,7 +297,7 @@ func TestServiceTests_DeleteTestById(t *testing.T) {
 	mockUserID := 1
 
 	testMock := randomTest()
-	if err := mockRepo.CreateTest(ctx, mockUserID, testMock); err != nil {
+	if _, err := mockRepo.CreateTest(ctx, mockUserID, testMock); err != nil {
 		t.Fatalf("Some error occured. Err: %s", err)
 	}
 
@@ -204,7 +339,7 @@ func TestServiceTests_DeleteTestById(t *testing.T) {
 
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
-			err := mockRepo.DeleteTestById(ctx, tt.args.testID)
+			err := mockRepo.DeleteTestById(ctx, tt.args.testID, domain.AnyVersion)
 			if errors.Unwrap(err) != tt.want.err {
 				t.Fatalf("ServiceTests.DeleteTestById() error = %v, wantErr %v", err, tt.want.err)
 			}
@@ -0,0 +1,112 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	algEdDSA    = "EdDSA"
+	keyIDHeader = "kid"
+)
+
+// SigningMethodEdDSA signs JWT with Ed25519 keys, jwt-go v3 doesn't have it.
+var SigningMethodEdDSA = &signingMethodEd25519{}
+
+func init() {
+	jwt.RegisterSigningMethod(algEdDSA, func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+type signingMethodEd25519 struct{}
+
+func (m *signingMethodEd25519) Alg() string {
+	return algEdDSA
+}
+
+func (m *signingMethodEd25519) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok || len(privateKey) != ed25519.PrivateKeySize {
+		return "", jwt.ErrInvalidKeyType
+	}
+
+	return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
+
+func (m *signingMethodEd25519) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok || len(publicKey) != ed25519.PublicKeySize {
+		return jwt.ErrInvalidKeyType
+	}
+
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		return jwt.ErrSignatureInvalid
+	}
+
+	return nil
+}
+
+// JWTEd25519Maker is a JWT maker signing tokens with Ed25519 keys of the key ring.
+// The ID of the signing key is in the kid header.
+type JWTEd25519Maker struct {
+	keys *KeyRing
+	options
+}
+
+// NewJWTEd25519Maker returns a new JWTEd25519Maker with the key ring.
+func NewJWTEd25519Maker(keys *KeyRing, opts ...Option) (Manager, error) {
+	if keys == nil {
+		return nil, ErrSigningKeyMissing
+	}
+
+	return &JWTEd25519Maker{
+		keys:    keys,
+		options: newOptions(opts),
+	}, nil
+}
+
+// CreateToken returns a new token with the claims for specific duration.
+func (maker *JWTEd25519Maker) CreateToken(claims Claims, duration time.Duration) (string, error) {
+	payload, err := maker.newPayload(claims, duration)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := maker.keys.SigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	jwtToken := jwt.NewWithClaims(SigningMethodEdDSA, newJWTClaims(payload))
+	jwtToken.Header[keyIDHeader] = key.ID
+
+	return jwtToken.SignedString(key.PrivateKey)
+}
+
+// VerifyToken returns the payload of the token if the token is valid.
+func (maker *JWTEd25519Maker) VerifyToken(token string) (*Payload, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		if token.Method != SigningMethodEdDSA {
+			return nil, ErrInvalidToken
+		}
+
+		keyID, _ := token.Header[keyIDHeader].(string)
+
+		return maker.keys.PublicKey(keyID)
+	}
+
+	return parseJWT(token, keyFunc, maker.options)
+}
+
+// JWKS returns the public keys accepted by the maker.
+func (maker *JWTEd25519Maker) JWKS() JWKS {
+	return maker.keys.JWKS()
+}
@@ -1,36 +1,38 @@
 package token
 
 import (
-	"errors"
-	"github.com/dgrijalva/jwt-go"
 	"time"
+
+	"github.com/dgrijalva/jwt-go"
 )
 
 const minSecretLength = 32
 
 type JWTMaker struct {
 	secretKey string
+	options
 }
 
 // NewJWTMaker returns a new JWTMaker.
-func NewJWTMaker(secretKey string) (Manager, error) {
+func NewJWTMaker(secretKey string, opts ...Option) (Manager, error) {
 	if len(secretKey) < minSecretLength {
 		return nil, ErrSecretIsTooShort
 	}
 
 	return &JWTMaker{
 		secretKey: secretKey,
+		options:   newOptions(opts),
 	}, nil
 }
 
-// CreateToken returns a new token for specific userID and duration.
-func (maker *JWTMaker) CreateToken(userID int, duration time.Duration) (string, error) {
-	payload, err := NewPayload(userID, duration)
+// CreateToken returns a new token with the claims for specific duration.
+func (maker *JWTMaker) CreateToken(claims Claims, duration time.Duration) (string, error) {
+	payload, err := maker.newPayload(claims, duration)
 	if err != nil {
 		return "", err
 	}
 
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, newJWTClaims(payload))
 	return jwtToken.SignedString([]byte(maker.secretKey))
 }
 
@@ -45,20 +47,52 @@ func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
 		return []byte(maker.secretKey), nil
 	}
 
-	claims, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
-	if err != nil {
-		verr, ok := err.(*jwt.ValidationError)
-		if ok && errors.Is(verr.Inner, ErrExpiredToken) {
-			return nil, ErrExpiredToken
-		}
+	return parseJWT(token, keyFunc, maker.options)
+}
 
-		return nil, ErrInvalidToken
+// jwtClaims is the Payload with the time claims as NumericDate as RFC 7519 requires.
+// The fields of jwtClaims hide the fields of Payload with the same json names.
+type jwtClaims struct {
+	Payload
+	IssuedAt  int64 `json:"iat"`
+	NotBefore int64 `json:"nbf"`
+	ExpiredAt int64 `json:"exp"`
+}
+
+func newJWTClaims(p *Payload) *jwtClaims {
+	return &jwtClaims{
+		Payload:   *p,
+		IssuedAt:  p.IssuedAt.Unix(),
+		NotBefore: p.NotBefore.Unix(),
+		ExpiredAt: p.ExpiredAt.Unix(),
 	}
+}
+
+// Valid is called by jwt-go, the claims are validated by the maker after parsing.
+func (c *jwtClaims) Valid() error {
+	return nil
+}
+
+func (c *jwtClaims) payload() *Payload {
+	p := c.Payload
+	p.IssuedAt = time.Unix(c.IssuedAt, 0)
+	p.NotBefore = time.Unix(c.NotBefore, 0)
+	p.ExpiredAt = time.Unix(c.ExpiredAt, 0)
 
-	payload, ok := claims.Claims.(*Payload)
-	if !ok {
+	return &p
+}
+
+// parseJWT verifies the signature of the token and validates its claims.
+func parseJWT(token string, keyFunc jwt.Keyfunc, opts options) (*Payload, error) {
+	claims := &jwtClaims{}
+	if _, err := jwt.ParseWithClaims(token, claims, keyFunc); err != nil {
 		return nil, ErrInvalidToken
 	}
 
+	payload := claims.payload()
+	if err := opts.validate(payload); err != nil {
+		return nil, err
+	}
+
 	return payload, nil
 }
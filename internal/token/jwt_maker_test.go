@@ -8,6 +8,7 @@ import (
 
 func TestJWTMaker(t *testing.T) {
 	userID := 1
+	role := "author"
 	jwtMaker, err := NewJWTMaker(util.RandomString(32))
 	if err != nil {
 		t.Fatal(err)
@@ -18,7 +19,7 @@ func TestJWTMaker(t *testing.T) {
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(duration)
 
-	token, err := jwtMaker.CreateToken(userID, duration)
+	token, err := jwtMaker.CreateToken(Claims{UserID: userID, Role: role}, duration)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -31,10 +32,13 @@ func TestJWTMaker(t *testing.T) {
 	if payload.UserID != userID {
 		t.Fatal("user_id is not correct")
 	}
-	if payload.IssuedAt < time.Duration(issuedAt.Unix()) {
+	if payload.Role != role {
+		t.Fatal("role is not correct")
+	}
+	if payload.IssuedAt.Before(issuedAt.Add(-time.Second)) {
 		t.Fatal("issued_at is not correct")
 	}
-	if payload.ExpiredAt > time.Duration(expiredAt.Unix()) {
+	if payload.ExpiredAt.After(expiredAt.Add(time.Second)) {
 		t.Fatal("expired_at is not correct")
 	}
 }
@@ -45,7 +49,7 @@ func TestExpiredJWTToken(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	token, err := jwtMaker.CreateToken(1, -time.Minute)
+	token, err := jwtMaker.CreateToken(Claims{UserID: 1, Role: "author"}, -time.Minute)
 	if err != nil {
 		t.Fatal(err)
 	}
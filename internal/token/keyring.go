@@ -0,0 +1,239 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrKeyNotFound       = errors.New("key not found")
+	ErrInvalidKey        = errors.New("invalid key")
+	ErrEmptyKeyID        = errors.New("key id is empty")
+	ErrRemoveSigningKey  = errors.New("signing key can't be removed")
+	ErrSigningKeyMissing = errors.New("signing key is missing")
+)
+
+// Key is an Ed25519 key identified by ID. A key without the private part only verifies tokens.
+type Key struct {
+	ID         string
+	PrivateKey ed25519.PrivateKey
+	PublicKey  ed25519.PublicKey
+}
+
+// GenerateKey returns a new random Key with the ID.
+func GenerateKey(id string) (Key, error) {
+	if id == "" {
+		return Key{}, ErrEmptyKeyID
+	}
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return Key{}, err
+	}
+
+	return Key{ID: id, PrivateKey: private, PublicKey: public}, nil
+}
+
+// ParsePrivateKey returns the Key from the base64 encoded 32 bytes seed or 64 bytes private key.
+func ParsePrivateKey(id, encoded string) (Key, error) {
+	if id == "" {
+		return Key{}, ErrEmptyKeyID
+	}
+
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return Key{}, ErrInvalidKey
+	}
+
+	var private ed25519.PrivateKey
+	switch len(b) {
+	case ed25519.SeedSize:
+		private = ed25519.NewKeyFromSeed(b)
+	case ed25519.PrivateKeySize:
+		private = ed25519.PrivateKey(b)
+	default:
+		return Key{}, ErrInvalidKey
+	}
+
+	return Key{ID: id, PrivateKey: private, PublicKey: private.Public().(ed25519.PublicKey)}, nil
+}
+
+// ParsePublicKey returns the verification only Key from the base64 encoded public key.
+func ParsePublicKey(id, encoded string) (Key, error) {
+	if id == "" {
+		return Key{}, ErrEmptyKeyID
+	}
+
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(b) != ed25519.PublicKeySize {
+		return Key{}, ErrInvalidKey
+	}
+
+	return Key{ID: id, PublicKey: ed25519.PublicKey(b)}, nil
+}
+
+// KeyRing keeps the key which signs new tokens and all the keys which verify them.
+// Rotation adds a new signing key and keeps the previous one for verification
+// until the tokens signed by it are expired, so nobody is logged out.
+type KeyRing struct {
+	mu        sync.RWMutex
+	signingID string
+	keys      map[string]Key
+}
+
+// NewKeyRing creates a new KeyRing with the signing key and additional verification keys.
+func NewKeyRing(signing Key, verification ...Key) (*KeyRing, error) {
+	r := &KeyRing{keys: make(map[string]Key)}
+
+	for _, k := range verification {
+		if err := r.Add(k); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.Rotate(signing); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// NewVerificationKeyRing creates a new KeyRing without the signing key.
+// It is used by the services that only verify tokens.
+func NewVerificationKeyRing(keys ...Key) (*KeyRing, error) {
+	r := &KeyRing{keys: make(map[string]Key)}
+
+	for _, k := range keys {
+		if err := r.Add(k); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// Add adds the verification key, the key with the same ID is replaced.
+func (r *KeyRing) Add(key Key) error {
+	if key.ID == "" {
+		return ErrEmptyKeyID
+	}
+	if len(key.PublicKey) != ed25519.PublicKeySize {
+		return ErrInvalidKey
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys[key.ID] = key
+
+	return nil
+}
+
+// Rotate makes the key the signing key. The previous signing key is still used for verification.
+func (r *KeyRing) Rotate(key Key) error {
+	if len(key.PrivateKey) != ed25519.PrivateKeySize {
+		return ErrSigningKeyMissing
+	}
+
+	if err := r.Add(key); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.signingID = key.ID
+
+	return nil
+}
+
+// Remove stops accepting tokens signed by the key.
+func (r *KeyRing) Remove(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id != "" && id == r.signingID {
+		return ErrRemoveSigningKey
+	}
+
+	if _, ok := r.keys[id]; !ok {
+		return ErrKeyNotFound
+	}
+
+	delete(r.keys, id)
+
+	return nil
+}
+
+// SigningKey returns the key which signs new tokens or ErrSigningKeyMissing if the ring only verifies tokens.
+func (r *KeyRing) SigningKey() (Key, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.signingID == "" {
+		return Key{}, ErrSigningKeyMissing
+	}
+
+	return r.keys[r.signingID], nil
+}
+
+// PublicKey returns the public key by ID.
+func (r *KeyRing) PublicKey(id string) (ed25519.PublicKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	k, ok := r.keys[id]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+
+	return k.PublicKey, nil
+}
+
+// JWKS returns all verification keys as a JSON Web Key Set.
+func (r *KeyRing) JWKS() JWKS {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	set := JWKS{Keys: make([]JSONWebKey, 0, len(r.keys))}
+	for _, k := range r.keys {
+		set.Keys = append(set.Keys, JSONWebKey{
+			KeyType: "OKP",
+			Curve:   "Ed25519",
+			Alg:     algEdDSA,
+			Use:     "sig",
+			KeyID:   k.ID,
+			X:       base64.RawURLEncoding.EncodeToString(k.PublicKey),
+		})
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].KeyID < set.Keys[j].KeyID
+	})
+
+	return set
+}
+
+// JSONWebKey is an Ed25519 public key in the RFC 8037 format.
+type JSONWebKey struct {
+	KeyType string `json:"kty"`
+	Curve   string `json:"crv"`
+	Alg     string `json:"alg"`
+	Use     string `json:"use"`
+	KeyID   string `json:"kid"`
+	X       string `json:"x"`
+}
+
+// JWKS is the set of public keys to verify the tokens.
+type JWKS struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// KeySet is implemented by the managers signing tokens with asymmetric keys.
+type KeySet interface {
+	JWKS() JWKS
+}
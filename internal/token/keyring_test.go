@@ -0,0 +1,154 @@
+package token
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func newTestKey(t *testing.T, id string) Key {
+	key, err := GenerateKey(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestAsymmetricManagers_Rotation(t *testing.T) {
+	makers := map[string]func(*KeyRing, ...Option) (Manager, error){
+		"paseto-public": NewPasetoPublicManager,
+		"jwt-ed25519":   NewJWTEd25519Maker,
+	}
+
+	for name, newMaker := range makers {
+		t.Run(name, func(t *testing.T) {
+			ring, err := NewKeyRing(newTestKey(t, "key-1"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			maker, err := newMaker(ring)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			oldToken, err := maker.CreateToken(Claims{UserID: 1, Role: "author"}, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err = ring.Rotate(newTestKey(t, "key-2")); err != nil {
+				t.Fatal(err)
+			}
+
+			newToken, err := maker.CreateToken(Claims{UserID: 2, Role: "author"}, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if payload, err := maker.VerifyToken(oldToken); err != nil || payload.UserID != 1 {
+				t.Fatalf("token signed by the previous key: payload = %v, error = %v", payload, err)
+			}
+			if payload, err := maker.VerifyToken(newToken); err != nil || payload.UserID != 2 {
+				t.Fatalf("token signed by the new key: payload = %v, error = %v", payload, err)
+			}
+
+			// a service that holds only the public keys verifies tokens but can't create them
+			var publicKeys []Key
+			for _, id := range []string{"key-1", "key-2"} {
+				publicKey, err := ring.PublicKey(id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				publicKeys = append(publicKeys, Key{ID: id, PublicKey: publicKey})
+			}
+			verifierRing, err := NewVerificationKeyRing(publicKeys...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			verifier, err := newMaker(verifierRing)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = verifier.VerifyToken(newToken); err != nil {
+				t.Fatalf("verifier error = %v", err)
+			}
+			if _, err = verifier.CreateToken(Claims{UserID: 1, Role: "author"}, time.Minute); err != ErrSigningKeyMissing {
+				t.Fatalf("verifier CreateToken() error = %v, want %v", err, ErrSigningKeyMissing)
+			}
+
+			if err = ring.Remove("key-1"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err = maker.VerifyToken(oldToken); err != ErrInvalidToken {
+				t.Fatalf("token signed by the removed key: error = %v, want %v", err, ErrInvalidToken)
+			}
+
+			expired, err := maker.CreateToken(Claims{UserID: 1, Role: "author"}, -time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = maker.VerifyToken(expired); err != ErrExpiredToken {
+				t.Fatalf("expired token: error = %v, want %v", err, ErrExpiredToken)
+			}
+
+			other, err := NewKeyRing(newTestKey(t, "key-2"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			otherMaker, err := newMaker(other)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = otherMaker.VerifyToken(newToken); err != ErrInvalidToken {
+				t.Fatalf("token signed by unknown key with the same id: error = %v, want %v", err, ErrInvalidToken)
+			}
+		})
+	}
+}
+
+func TestKeyRing_JWKS(t *testing.T) {
+	signing := newTestKey(t, "key-2")
+	ring, err := NewKeyRing(signing, newTestKey(t, "key-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ring.Remove("key-2"); err != ErrRemoveSigningKey {
+		t.Fatalf("Remove() error = %v, want %v", err, ErrRemoveSigningKey)
+	}
+
+	set := ring.JWKS()
+	if len(set.Keys) != 2 || set.Keys[0].KeyID != "key-1" || set.Keys[1].KeyID != "key-2" {
+		t.Fatalf("unexpected keys: %v", set.Keys)
+	}
+
+	jwk := set.Keys[1]
+	if jwk.KeyType != "OKP" || jwk.Curve != "Ed25519" || jwk.X != base64.RawURLEncoding.EncodeToString(signing.PublicKey) {
+		t.Fatalf("unexpected key: %v", jwk)
+	}
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	key := newTestKey(t, "key-1")
+
+	parsed, err := ParsePrivateKey("key-1", base64.StdEncoding.EncodeToString(key.PrivateKey.Seed()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.PublicKey.Equal(key.PublicKey) {
+		t.Fatal("public key of the parsed seed is not correct")
+	}
+
+	public, err := ParsePublicKey("key-1", base64.StdEncoding.EncodeToString(key.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if public.PrivateKey != nil || !public.PublicKey.Equal(key.PublicKey) {
+		t.Fatal("parsed public key is not correct")
+	}
+
+	if _, err = ParsePrivateKey("key-1", "c2hvcnQ="); err != ErrInvalidKey {
+		t.Fatalf("ParsePrivateKey() error = %v, want %v", err, ErrInvalidKey)
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 func TestPasetoMaker(t *testing.T) {
 	userID := 1
+	role := "author"
 	pasetoMaker, err := NewPasetoManager(util.RandomString(32))
 	if err != nil {
 		t.Fatal(err)
@@ -18,7 +19,7 @@ func TestPasetoMaker(t *testing.T) {
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(duration)
 
-	token, err := pasetoMaker.CreateToken(userID, duration)
+	token, err := pasetoMaker.CreateToken(Claims{UserID: userID, Role: role}, duration)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -31,10 +32,13 @@ func TestPasetoMaker(t *testing.T) {
 	if payload.UserID != userID {
 		t.Fatal("user_id is not correct")
 	}
-	if payload.IssuedAt < time.Duration(issuedAt.Unix()) {
+	if payload.Role != role {
+		t.Fatal("role is not correct")
+	}
+	if payload.IssuedAt.Before(issuedAt.Add(-time.Second)) {
 		t.Fatal("issued_at is not correct")
 	}
-	if payload.ExpiredAt > time.Duration(expiredAt.Unix()) {
+	if payload.ExpiredAt.After(expiredAt.Add(time.Second)) {
 		t.Fatal("expired_at is not correct")
 	}
 }
@@ -46,7 +50,7 @@ func TestExpiredPasetoToken(t *testing.T) {
 	}
 
 	wrongDuration := -time.Minute
-	token, err := pasetoMaker.CreateToken(1, wrongDuration)
+	token, err := pasetoMaker.CreateToken(Claims{UserID: 1, Role: "author"}, wrongDuration)
 	if err != nil {
 		t.Fatal(err)
 	}
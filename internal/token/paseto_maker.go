@@ -10,10 +10,11 @@ import (
 type PasetoManager struct {
 	paseto        *paseto.V2
 	symmetrickKey []byte
+	options
 }
 
 // NewPasetoManager create new paseto maker with symmetric key and return paseto maker and error if any.
-func NewPasetoManager(symmetrickKey string) (Manager, error) {
+func NewPasetoManager(symmetrickKey string, opts ...Option) (Manager, error) {
 	if len(symmetrickKey) < chacha20poly1305.KeySize {
 		return nil, ErrSecretIsTooShort
 	}
@@ -21,12 +22,13 @@ func NewPasetoManager(symmetrickKey string) (Manager, error) {
 	return &PasetoManager{
 		paseto:        paseto.NewV2(),
 		symmetrickKey: []byte(symmetrickKey),
+		options:       newOptions(opts),
 	}, nil
 }
 
 // CreateToken create new token and return token and error if any.
-func (maker *PasetoManager) CreateToken(userID int, duration time.Duration) (string, error) {
-	payload, err := NewPayload(userID, duration)
+func (maker *PasetoManager) CreateToken(claims Claims, duration time.Duration) (string, error) {
+	payload, err := maker.newPayload(claims, duration)
 	if err != nil {
 		return "", err
 	}
@@ -42,7 +44,7 @@ func (maker *PasetoManager) VerifyToken(token string) (*Payload, error) {
 		return nil, err
 	}
 
-	err = payload.Valid()
+	err = maker.validate(payload)
 	if err != nil {
 		return nil, err
 	}
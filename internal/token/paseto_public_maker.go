@@ -0,0 +1,77 @@
+package token
+
+import (
+	"time"
+
+	"github.com/o1egl/paseto"
+)
+
+// keyFooter is the footer of the public paseto token with the ID of the signing key.
+type keyFooter struct {
+	KeyID string `json:"kid"`
+}
+
+// PasetoPublicManager is a paseto v2.public maker. Tokens are signed by the signing key of the key ring
+// and verified by the key with the ID from the token footer.
+type PasetoPublicManager struct {
+	paseto *paseto.V2
+	keys   *KeyRing
+	options
+}
+
+// NewPasetoPublicManager create new paseto public maker with the key ring.
+func NewPasetoPublicManager(keys *KeyRing, opts ...Option) (Manager, error) {
+	if keys == nil {
+		return nil, ErrSigningKeyMissing
+	}
+
+	return &PasetoPublicManager{
+		paseto:  paseto.NewV2(),
+		keys:    keys,
+		options: newOptions(opts),
+	}, nil
+}
+
+// CreateToken create new token signed by the signing key and return token and error if any.
+func (maker *PasetoPublicManager) CreateToken(claims Claims, duration time.Duration) (string, error) {
+	payload, err := maker.newPayload(claims, duration)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := maker.keys.SigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	return maker.paseto.Sign(key.PrivateKey, payload, keyFooter{KeyID: key.ID})
+}
+
+// VerifyToken verify token and return payload and error if any.
+func (maker *PasetoPublicManager) VerifyToken(token string) (*Payload, error) {
+	var footer keyFooter
+	if err := paseto.ParseFooter(token, &footer); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	publicKey, err := maker.keys.PublicKey(footer.KeyID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	payload := &Payload{}
+	if err = maker.paseto.Verify(token, publicKey, payload, nil); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if err = maker.validate(payload); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// JWKS returns the public keys accepted by the maker.
+func (maker *PasetoPublicManager) JWKS() JWKS {
+	return maker.keys.JWKS()
+}
@@ -2,6 +2,7 @@ package token
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,34 +12,150 @@ var (
 	ErrExpiredToken     = fmt.Errorf("token is expired")
 	ErrInvalidToken     = fmt.Errorf("invalid token")
 	ErrSecretIsTooShort = fmt.Errorf("secret key is too short")
+	ErrTokenNotValidYet = fmt.Errorf("token is not valid yet")
+	ErrInvalidIssuer    = fmt.Errorf("token issuer is invalid")
+	ErrInvalidAudience  = fmt.Errorf("token audience is invalid")
 )
 
+// Claims are the application claims of the token.
+type Claims struct {
+	UserID int
+	Role   string
+	Scopes []string
+	// SessionID is the ID of the refresh session the token is issued for.
+	SessionID int64
+	// ClientID is the OAuth2 client the token is issued to, empty for the tokens of the application.
+	ClientID string
+	// ImpersonatorID is the operator acting as the user, zero for the tokens of the user themself.
+	ImpersonatorID int
+}
+
 // Payload contains the payload data of the token.
+// The registered claims use the names from RFC 7519.
 type Payload struct {
-	ID        uuid.UUID     `json:"id"`
-	UserID    int           `json:"user_id"`
-	IssuedAt  time.Duration `json:"issued_at"`
-	ExpiredAt time.Duration `json:"expired_at"`
+	ID        uuid.UUID `json:"jti"`
+	Issuer    string    `json:"iss,omitempty"`
+	Audience  string    `json:"aud,omitempty"`
+	Subject   string    `json:"sub"`
+	UserID    int       `json:"user_id"`
+	Role      string    `json:"role"`
+	Scopes    []string  `json:"scopes,omitempty"`
+	SessionID int64     `json:"sid,omitempty"`
+	ClientID  string    `json:"client_id,omitempty"`
+	IssuedAt  time.Time `json:"iat"`
+	NotBefore time.Time `json:"nbf"`
+	ExpiredAt time.Time `json:"exp"`
+
+	ImpersonatorID int `json:"impersonator_id,omitempty"`
 }
 
-func NewPayload(userID int, duration time.Duration) (*Payload, error) {
+// NewPayload returns a new payload with the claims valid from now for the duration.
+func NewPayload(claims Claims, duration time.Duration) (*Payload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
 	}
 
+	now := time.Now()
+
 	return &Payload{
 		ID:        tokenID,
-		UserID:    userID,
-		IssuedAt:  time.Duration(time.Now().Unix()),
-		ExpiredAt: time.Duration(time.Now().Add(duration).Unix()),
+		Subject:   strconv.Itoa(claims.UserID),
+		UserID:    claims.UserID,
+		Role:      claims.Role,
+		Scopes:    claims.Scopes,
+		SessionID: claims.SessionID,
+		ClientID:  claims.ClientID,
+		IssuedAt:  now,
+		NotBefore: now,
+		ExpiredAt: now.Add(duration),
+
+		ImpersonatorID: claims.ImpersonatorID,
 	}, nil
 }
 
-// Valid check if the token payload is valid or not.
+// Valid check if the token payload is valid or not at the current time.
 func (p *Payload) Valid() error {
-	if time.Duration(time.Now().Unix()) > p.ExpiredAt {
+	return p.validAt(time.Now(), 0)
+}
+
+func (p *Payload) validAt(now time.Time, leeway time.Duration) error {
+	if now.After(p.ExpiredAt.Add(leeway)) {
 		return ErrExpiredToken
 	}
+
+	if now.Add(leeway).Before(p.NotBefore) {
+		return ErrTokenNotValidYet
+	}
+
+	return nil
+}
+
+// Option configures the registered claims of the tokens created and accepted by a Manager.
+type Option func(*options)
+
+// WithIssuer sets the iss claim of the created tokens and requires it in the verified ones.
+func WithIssuer(issuer string) Option {
+	return func(o *options) {
+		o.issuer = issuer
+	}
+}
+
+// WithAudience sets the aud claim of the created tokens and requires it in the verified ones.
+func WithAudience(audience string) Option {
+	return func(o *options) {
+		o.audience = audience
+	}
+}
+
+// WithLeeway allows the clock skew between the services when checking exp and nbf.
+func WithLeeway(leeway time.Duration) Option {
+	return func(o *options) {
+		o.leeway = leeway
+	}
+}
+
+type options struct {
+	issuer   string
+	audience string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+func newOptions(opts []Option) options {
+	o := options{now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// newPayload returns the payload with the issuer and audience of the options.
+func (o options) newPayload(claims Claims, duration time.Duration) (*Payload, error) {
+	payload, err := NewPayload(claims, duration)
+	if err != nil {
+		return nil, err
+	}
+
+	payload.Issuer = o.issuer
+	payload.Audience = o.audience
+
+	return payload, nil
+}
+
+// validate check the time, the issuer and the audience of the payload.
+func (o options) validate(p *Payload) error {
+	if err := p.validAt(o.now(), o.leeway); err != nil {
+		return err
+	}
+
+	if o.issuer != "" && p.Issuer != o.issuer {
+		return ErrInvalidIssuer
+	}
+
+	if o.audience != "" && p.Audience != o.audience {
+		return ErrInvalidAudience
+	}
+
 	return nil
 }
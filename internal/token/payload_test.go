@@ -0,0 +1,179 @@
+package token
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/popeskul/qna-go/internal/util"
+)
+
+func newTestManagers(t *testing.T, opts ...Option) map[string]Manager {
+	secret := util.RandomString(32)
+	key, err := GenerateKey("key-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ring, err := NewKeyRing(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	managers := make(map[string]Manager)
+	for name, newManager := range map[string]func() (Manager, error){
+		"paseto-local":  func() (Manager, error) { return NewPasetoManager(secret, opts...) },
+		"paseto-public": func() (Manager, error) { return NewPasetoPublicManager(ring, opts...) },
+		"jwt-hs256":     func() (Manager, error) { return NewJWTMaker(secret, opts...) },
+		"jwt-ed25519":   func() (Manager, error) { return NewJWTEd25519Maker(ring, opts...) },
+	} {
+		m, err := newManager()
+		if err != nil {
+			t.Fatal(err)
+		}
+		managers[name] = m
+	}
+
+	return managers
+}
+
+func TestManagers_Claims(t *testing.T) {
+	issuer := newTestManagers(t, WithIssuer("qna"), WithAudience("qna-api"))
+	claims := Claims{UserID: 7, Role: "reviewer", Scopes: []string{"tests:read"}, SessionID: 42, ImpersonatorID: 3}
+
+	for name, m := range issuer {
+		t.Run(name, func(t *testing.T) {
+			token, err := m.CreateToken(claims, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			payload, err := m.VerifyToken(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if payload.Issuer != "qna" || payload.Audience != "qna-api" || payload.Subject != "7" {
+				t.Errorf("registered claims are not correct: %+v", payload)
+			}
+			if payload.UserID != 7 || payload.Role != "reviewer" || payload.SessionID != 42 || payload.ImpersonatorID != 3 ||
+				len(payload.Scopes) != 1 || payload.Scopes[0] != "tests:read" {
+				t.Errorf("claims are not correct: %+v", payload)
+			}
+			if payload.NotBefore.IsZero() || !payload.ExpiredAt.After(payload.IssuedAt) {
+				t.Errorf("time claims are not correct: %+v", payload)
+			}
+		})
+	}
+}
+
+func TestManagers_ValidateIssuerAudience(t *testing.T) {
+	secret := util.RandomString(32)
+	newManagers := map[string]func(opts ...Option) (Manager, error){
+		"paseto-local": func(opts ...Option) (Manager, error) { return NewPasetoManager(secret, opts...) },
+		"jwt-hs256":    func(opts ...Option) (Manager, error) { return NewJWTMaker(secret, opts...) },
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		err  error
+	}{
+		{name: "same issuer and audience", opts: []Option{WithIssuer("qna"), WithAudience("qna-api")}},
+		{name: "no requirements", opts: nil},
+		{name: "other issuer", opts: []Option{WithIssuer("other"), WithAudience("qna-api")}, err: ErrInvalidIssuer},
+		{name: "other audience", opts: []Option{WithIssuer("qna"), WithAudience("other")}, err: ErrInvalidAudience},
+	}
+
+	for name, newManager := range newManagers {
+		issuer, err := newManager(WithIssuer("qna"), WithAudience("qna-api"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		token, err := issuer.CreateToken(Claims{UserID: 1}, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, tt := range tests {
+			t.Run(name+": "+tt.name, func(t *testing.T) {
+				verifier, err := newManager(tt.opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if _, err = verifier.VerifyToken(token); err != tt.err {
+					t.Errorf("VerifyToken() error = %v, want %v", err, tt.err)
+				}
+			})
+		}
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	o := newOptions([]Option{WithIssuer("qna"), WithAudience("qna-api"), WithLeeway(time.Minute)})
+	o.now = func() time.Time { return now }
+
+	valid := Payload{
+		Issuer:    "qna",
+		Audience:  "qna-api",
+		IssuedAt:  now,
+		NotBefore: now,
+		ExpiredAt: now.Add(time.Minute),
+	}
+
+	tests := []struct {
+		name   string
+		modify func(p *Payload)
+		err    error
+	}{
+		{name: "valid", modify: func(p *Payload) {}},
+		{name: "expired within leeway", modify: func(p *Payload) { p.ExpiredAt = now.Add(-30 * time.Second) }},
+		{name: "expired", modify: func(p *Payload) { p.ExpiredAt = now.Add(-2 * time.Minute) }, err: ErrExpiredToken},
+		{name: "not valid yet", modify: func(p *Payload) { p.NotBefore = now.Add(2 * time.Minute) }, err: ErrTokenNotValidYet},
+		{name: "wrong issuer", modify: func(p *Payload) { p.Issuer = "other" }, err: ErrInvalidIssuer},
+		{name: "wrong audience", modify: func(p *Payload) { p.Audience = "" }, err: ErrInvalidAudience},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid
+			tt.modify(&p)
+
+			if err := o.validate(&p); err != tt.err {
+				t.Errorf("validate() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestJWT_NumericDate(t *testing.T) {
+	m, err := NewJWTMaker(util.RandomString(32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := m.CreateToken(Claims{UserID: 1}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	segment, err := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var claims map[string]interface{}
+	if err = json.Unmarshal(segment, &claims); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"iat", "nbf", "exp"} {
+		if _, ok := claims[name].(float64); !ok {
+			t.Errorf("%s = %v, want NumericDate", name, claims[name])
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 secret from the RFC 6238 test vectors.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestGenerateCode(t *testing.T) {
+	tests := []struct {
+		name string
+		unix int64
+		want string
+	}{
+		{name: "59", unix: 59, want: "287082"},
+		{name: "1111111109", unix: 1111111109, want: "081804"},
+		{name: "1111111111", unix: 1111111111, want: "050471"},
+		{name: "1234567890", unix: 1234567890, want: "005924"},
+		{name: "2000000000", unix: 2000000000, want: "279037"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateCode(rfcSecret, time.Unix(tt.unix, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("GenerateCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	code, err := GenerateCode(secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := Validate(secret, code, now); !ok {
+		t.Error("current code is not valid")
+	}
+	if _, ok := Validate(secret, code, now.Add(Period*time.Second)); !ok {
+		t.Error("code from the previous period is not valid")
+	}
+	if _, ok := Validate(secret, code, now.Add(3*Period*time.Second)); ok {
+		t.Error("code from three periods ago is valid")
+	}
+	if _, ok := Validate(secret, "12345", now); ok {
+		t.Error("short code is valid")
+	}
+	if _, ok := Validate("not base32!", code, now); ok {
+		t.Error("code is valid for invalid secret")
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("Qna", "user@gmail.com", "SECRET")
+
+	if !strings.HasPrefix(uri, "otpauth://totp/Qna:user@gmail.com?") {
+		t.Errorf("unexpected uri prefix: %s", uri)
+	}
+	if !strings.Contains(uri, "secret=SECRET") || !strings.Contains(uri, "issuer=Qna") {
+		t.Errorf("uri doesn't contain secret or issuer: %s", uri)
+	}
+}
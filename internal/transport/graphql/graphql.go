@@ -0,0 +1,90 @@
+// Package graphql serves the tests with their questions, answers and the attempts of the user
+// in one round trip. The resolvers call services.Service, the nested lists are fetched in one
+// query per level of the request and the queries are limited by the depth and the complexity.
+package graphql
+
+import (
+	"context"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+
+	"github.com/popeskul/qna-go/internal/policy"
+	"github.com/popeskul/qna-go/internal/services"
+)
+
+// DefaultConfig is used for the limits missing in config.
+var DefaultConfig = Config{
+	MaxDepth:      15,
+	MaxComplexity: 2000,
+}
+
+// Config represents the limits of the queries.
+type Config struct {
+	// MaxDepth is the maximum nesting of the fields.
+	MaxDepth int
+	// MaxComplexity is the maximum estimated number of the objects, see complexity.
+	MaxComplexity int
+}
+
+// Request is the GraphQL request of the client.
+type Request struct {
+	Query         string                 `json:"query" binding:"required"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// Executor executes the requests against the schema.
+type Executor struct {
+	schema  gql.Schema
+	service *services.Service
+	cfg     Config
+}
+
+// NewExecutor creates the schema over the service and returns error if the schema is invalid.
+func NewExecutor(service *services.Service, cfg Config) (*Executor, error) {
+	if cfg.MaxDepth == 0 {
+		cfg.MaxDepth = DefaultConfig.MaxDepth
+	}
+	if cfg.MaxComplexity == 0 {
+		cfg.MaxComplexity = DefaultConfig.MaxComplexity
+	}
+
+	schema, err := newSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Executor{
+		schema:  schema,
+		service: service,
+		cfg:     cfg,
+	}, nil
+}
+
+// Execute parses, validates and checks the limits of the request and executes it on behalf of the subject.
+// The result without data means that the request was rejected before the execution.
+func (e *Executor) Execute(ctx context.Context, subject policy.Subject, req Request) *gql.Result {
+	doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		return &gql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	validation := gql.ValidateDocument(&e.schema, doc, nil)
+	if !validation.IsValid {
+		return &gql.Result{Errors: validation.Errors}
+	}
+
+	if err = e.checkLimits(doc, req.OperationName, req.Variables); err != nil {
+		return &gql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	return gql.Execute(gql.ExecuteParams{
+		Schema:        e.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withRequest(ctx, newRequest(e.service, subject)),
+	})
+}
@@ -0,0 +1,379 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql/testutil"
+
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/policy"
+	"github.com/popeskul/qna-go/internal/repository/tests"
+	"github.com/popeskul/qna-go/internal/services"
+)
+
+const (
+	authorID  = 1
+	learnerID = 2
+	strangeID = 3
+)
+
+// fakeTests keeps the tests of the author in memory, the learner is a member of the tests and can only read them.
+// The calls of the batch methods are recorded.
+type fakeTests struct {
+	services.Tests
+	tests     map[int]domain.Test
+	questions []domain.Question
+	answers   []domain.Answer
+	passages  []domain.TestPassage
+	calls     map[string][][]int
+}
+
+func newFakeTests() *fakeTests {
+	f := &fakeTests{tests: make(map[int]domain.Test), calls: make(map[string][][]int)}
+
+	for testID := 1; testID <= 3; testID++ {
+		f.tests[testID] = domain.Test{ID: testID, Title: "Test", AuthorID: authorID}
+		f.passages = append(f.passages, domain.TestPassage{ID: testID, UserID: learnerID, TestID: testID, Passed: testID%2 == 0})
+
+		for i := 0; i < 2; i++ {
+			questionID := len(f.questions) + 1
+			f.questions = append(f.questions, domain.Question{ID: questionID, TestID: testID, Body: "Question"})
+			f.answers = append(f.answers,
+				domain.Answer{ID: 2*questionID - 1, QuestionID: questionID, Title: "Yes", Correct: true},
+				domain.Answer{ID: 2 * questionID, QuestionID: questionID, Title: "No"})
+		}
+	}
+
+	return f
+}
+
+func (f *fakeTests) AuthorizeTest(_ context.Context, subject policy.Subject, testID int, perm policy.Permission) (domain.Test, error) {
+	test, ok := f.tests[testID]
+	if !ok {
+		return test, tests.ErrTestNotFound
+	}
+
+	if test.AuthorID == subject.UserID || subject.UserID == learnerID && perm == policy.ReadTest {
+		return test, nil
+	}
+
+	return domain.Test{}, policy.ErrForbidden
+}
+
+func (f *fakeTests) GetAllTestsByUserID(_ context.Context, userID int, args domain.GetAllTestsParams) ([]domain.Test, error) {
+	list := make([]domain.Test, 0)
+	for id := 1; id <= len(f.tests); id++ {
+		if f.tests[id].AuthorID == userID && len(list) < args.Limit {
+			list = append(list, f.tests[id])
+		}
+	}
+
+	return list, nil
+}
+
+func (f *fakeTests) GetQuestions(_ context.Context, testIDs []int) ([]domain.Question, error) {
+	f.record("questions", testIDs)
+
+	list := make([]domain.Question, 0)
+	for _, q := range f.questions {
+		if contains(testIDs, q.TestID) {
+			list = append(list, q)
+		}
+	}
+
+	return list, nil
+}
+
+func (f *fakeTests) GetAnswers(_ context.Context, questionIDs []int) ([]domain.Answer, error) {
+	f.record("answers", questionIDs)
+
+	list := make([]domain.Answer, 0)
+	for _, a := range f.answers {
+		if contains(questionIDs, a.QuestionID) {
+			list = append(list, a)
+		}
+	}
+
+	return list, nil
+}
+
+func (f *fakeTests) GetUserPassages(_ context.Context, userID int, testIDs []int) ([]domain.TestPassage, error) {
+	f.record("attempts", testIDs)
+
+	list := make([]domain.TestPassage, 0)
+	for _, p := range f.passages {
+		if p.UserID == userID && contains(testIDs, p.TestID) {
+			list = append(list, p)
+		}
+	}
+
+	return list, nil
+}
+
+func (f *fakeTests) record(method string, keys []int) {
+	sorted := append([]int(nil), keys...)
+	sort.Ints(sorted)
+	f.calls[method] = append(f.calls[method], sorted)
+}
+
+func contains(list []int, v int) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newTestExecutor(t *testing.T, cfg Config) (*Executor, *fakeTests) {
+	t.Helper()
+
+	fake := newFakeTests()
+	executor, err := NewExecutor(&services.Service{Tests: fake}, cfg)
+	if err != nil {
+		t.Fatalf("error creating executor: %v", err)
+	}
+
+	return executor, fake
+}
+
+func TestExecutor_Batching(t *testing.T) {
+	executor, fake := newTestExecutor(t, Config{})
+	author := policy.Subject{UserID: authorID, Role: domain.RoleAuthor}
+
+	result := executor.Execute(context.Background(), author, Request{Query: `{
+		tests { id questions { id answers { id correct } } attempts { passed } }
+	}`})
+	if result.HasErrors() {
+		t.Fatalf("unexpected errors: %v", result.Errors)
+	}
+
+	expectedCalls := map[string][][]int{
+		"questions": {{1, 2, 3}},
+		"answers":   {{1, 2, 3, 4, 5, 6}},
+		"attempts":  {{1, 2, 3}},
+	}
+	for method, expected := range expectedCalls {
+		if got := fake.calls[method]; len(got) != 1 || !equal(got[0], expected[0]) {
+			t.Errorf("expected %s to be fetched once with %v, got %v", method, expected, got)
+		}
+	}
+
+	list := result.Data.(map[string]interface{})["tests"].([]interface{})
+	if len(list) != 3 {
+		t.Fatalf("expected 3 tests, got %v", list)
+	}
+
+	questions := list[0].(map[string]interface{})["questions"].([]interface{})
+	answers := questions[0].(map[string]interface{})["answers"].([]interface{})
+	if len(questions) != 2 || len(answers) != 2 || answers[0].(map[string]interface{})["correct"] != true {
+		t.Errorf("unexpected questions of the test: %v", questions)
+	}
+}
+
+func TestExecutor_Test(t *testing.T) {
+	executor, _ := newTestExecutor(t, Config{})
+	query := `query Test($id: Int!) { test(id: $id) { title questions { answers { title correct } } attempts { passed } } }`
+
+	tests := []struct {
+		name    string
+		subject policy.Subject
+		id      int
+		check   func(t *testing.T, test map[string]interface{})
+		err     string
+	}{
+		{
+			name:    "Success: the author sees the correct answers",
+			subject: policy.Subject{UserID: authorID, Role: domain.RoleAuthor},
+			id:      1,
+			check: func(t *testing.T, test map[string]interface{}) {
+				answer := firstAnswer(test)
+				if answer["correct"] != true {
+					t.Errorf("expected the correct answer, got %v", answer)
+				}
+			},
+		},
+		{
+			name:    "Success: the learner doesn't see the correct answers",
+			subject: policy.Subject{UserID: learnerID, Role: domain.RoleLearner},
+			id:      2,
+			check: func(t *testing.T, test map[string]interface{}) {
+				if answer := firstAnswer(test); answer["correct"] != nil {
+					t.Errorf("expected the hidden correct answer, got %v", answer)
+				}
+
+				attempts := test["attempts"].([]interface{})
+				if len(attempts) != 1 || attempts[0].(map[string]interface{})["passed"] != true {
+					t.Errorf("unexpected attempts: %v", attempts)
+				}
+			},
+		},
+		{
+			name:    "Error: test not found",
+			subject: policy.Subject{UserID: authorID, Role: domain.RoleAuthor},
+			id:      42,
+			err:     tests.ErrTestNotFound.Error(),
+		},
+		{
+			name:    "Error: the user is not a member of the test",
+			subject: policy.Subject{UserID: strangeID, Role: domain.RoleLearner},
+			id:      1,
+			err:     policy.ErrForbidden.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := executor.Execute(context.Background(), tt.subject, Request{
+				Query:         query,
+				OperationName: "Test",
+				Variables:     map[string]interface{}{"id": tt.id},
+			})
+
+			test, _ := result.Data.(map[string]interface{})["test"].(map[string]interface{})
+			if tt.err != "" {
+				if len(result.Errors) != 1 || result.Errors[0].Message != tt.err || test != nil {
+					t.Errorf("expected error %q, got %v", tt.err, result.Errors)
+				}
+				return
+			}
+
+			if result.HasErrors() {
+				t.Fatalf("unexpected errors: %v", result.Errors)
+			}
+			tt.check(t, test)
+		})
+	}
+}
+
+func TestExecutor_Limits(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   Config
+		query string
+		err   error
+	}{
+		{
+			name:  "Success: introspection with the default limits",
+			query: testutil.IntrospectionQuery,
+		},
+		{
+			name:  "Success: full page of the tests with the default limits",
+			query: `{ tests(pageSize: 10) { questions { answers { title } } attempts { passed } } }`,
+		},
+		{
+			name:  "Error: too deep",
+			cfg:   Config{MaxDepth: 3},
+			query: `{ tests { questions { answers { title } } } }`,
+			err:   ErrMaxDepth,
+		},
+		{
+			name:  "Error: too deep with the fragments",
+			cfg:   Config{MaxDepth: 3},
+			query: `{ tests { ...TestQuestions } } fragment TestQuestions on Test { questions { ... on Question { answers { title } } } }`,
+			err:   ErrMaxDepth,
+		},
+		{
+			name:  "Error: too complex",
+			cfg:   Config{MaxComplexity: 100},
+			query: `query Tests($size: Int) { tests(pageSize: $size) { questions { answers { title } } } }`,
+			err:   ErrMaxComplexity,
+		},
+		{
+			name:  "Success: the fragments spread many times are walked once",
+			query: nestedFragments(40, "title"),
+		},
+		{
+			name:  "Error: too complex with the fragments spread many times",
+			query: nestedFragments(40, "questions { id }"),
+			err:   ErrMaxComplexity,
+		},
+		{
+			name:  "Error: too complex with the aliases",
+			cfg:   Config{MaxComplexity: 100},
+			query: `{ a: tests(pageSize: 5) { questions { id } } b: tests(pageSize: 5) { questions { id } } }`,
+			err:   ErrMaxComplexity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor, fake := newTestExecutor(t, tt.cfg)
+
+			result := executor.Execute(context.Background(), policy.Subject{UserID: authorID, Role: domain.RoleAuthor}, Request{
+				Query:     tt.query,
+				Variables: map[string]interface{}{"size": float64(10)},
+			})
+
+			if tt.err == nil {
+				if result.HasErrors() {
+					t.Errorf("unexpected errors: %v", result.Errors)
+				}
+				return
+			}
+
+			if len(result.Errors) != 1 || !errors.Is(result.Errors[0].OriginalError(), tt.err) {
+				t.Fatalf("expected error %v, got %v", tt.err, result.Errors)
+			}
+
+			if result.Data != nil || len(fake.calls) != 0 {
+				t.Errorf("the rejected query is executed: %v %v", result.Data, fake.calls)
+			}
+		})
+	}
+}
+
+// nestedFragments returns the query with n fragments, every fragment spreads the next one twice.
+func nestedFragments(n int, fields string) string {
+	var query strings.Builder
+	query.WriteString("{ tests { ...F0 } }")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&query, " fragment F%d on Test { %s ...F%d ...F%d }", i, fields, i+1, i+1)
+	}
+	fmt.Fprintf(&query, " fragment F%d on Test { %s }", n, fields)
+
+	return query.String()
+}
+
+func TestExecutor_InvalidQuery(t *testing.T) {
+	executor, _ := newTestExecutor(t, Config{})
+
+	for _, query := range []string{`{ tests {`, `{ tests { unknown } }`, `{ tests(pageSize: "ten") { id } }`} {
+		result := executor.Execute(context.Background(), policy.Subject{UserID: authorID, Role: domain.RoleAuthor}, Request{Query: query})
+		if result.Data != nil || !result.HasErrors() {
+			t.Errorf("expected the query %q to be rejected, got %v", query, result)
+		}
+	}
+
+	result := executor.Execute(context.Background(), policy.Subject{UserID: authorID, Role: domain.RoleAuthor}, Request{Query: `{ tests(pageSize: 100) { id } }`})
+	if len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Message, "pageSize") {
+		t.Errorf("expected the page size error, got %v", result.Errors)
+	}
+}
+
+func firstAnswer(test map[string]interface{}) map[string]interface{} {
+	questions := test["questions"].([]interface{})
+	answers := questions[0].(map[string]interface{})["answers"].([]interface{})
+
+	return answers[0].(map[string]interface{})
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,250 @@
+package graphql
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// defaultListSize is the estimated length of the lists without the pageSize argument.
+const defaultListSize = 10
+
+// maxEstimate caps the complexity, so the fragments spread many times don't overflow it.
+const maxEstimate = 1 << 30
+
+var (
+	ErrMaxDepth      = errors.New("query is too deep")
+	ErrMaxComplexity = errors.New("query is too complex")
+)
+
+// limits walks the selections of the operation with the fragments resolved.
+// The document must be validated, so the fragments are known and have no cycles.
+// The depth and the complexity of every fragment are computed once, so spreading the fragments
+// many times doesn't make the walk exponential.
+type limits struct {
+	schema    *gql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	depths    map[string]int
+	costs     map[string]int
+}
+
+// checkLimits returns error if the operation is nested deeper than MaxDepth or costs more than MaxComplexity.
+func (e *Executor) checkLimits(doc *ast.Document, operationName string, variables map[string]interface{}) error {
+	l := limits{
+		schema:    &e.schema,
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: variables,
+		depths:    make(map[string]int),
+		costs:     make(map[string]int),
+	}
+
+	var operation *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		switch d := definition.(type) {
+		case *ast.OperationDefinition:
+			if operation == nil && (operationName == "" || d.Name != nil && d.Name.Value == operationName) {
+				operation = d
+			}
+		case *ast.FragmentDefinition:
+			l.fragments[d.Name.Value] = d
+		}
+	}
+
+	// the executor reports the unknown operation
+	if operation == nil || operation.Operation != ast.OperationTypeQuery {
+		return nil
+	}
+
+	if depth := l.depth(operation.SelectionSet); depth > e.cfg.MaxDepth {
+		return fmt.Errorf("%w: the depth %d exceeds %d", ErrMaxDepth, depth, e.cfg.MaxDepth)
+	}
+
+	if complexity := l.complexity(e.schema.QueryType(), operation.SelectionSet); complexity > e.cfg.MaxComplexity {
+		return fmt.Errorf("%w: the complexity %d exceeds %d", ErrMaxComplexity, complexity, e.cfg.MaxComplexity)
+	}
+
+	return nil
+}
+
+// depth returns the deepest nesting of the fields in the selections.
+func (l *limits) depth(set *ast.SelectionSet) int {
+	if set == nil {
+		return 0
+	}
+
+	max := 0
+	for _, selection := range set.Selections {
+		d := 0
+		switch s := selection.(type) {
+		case *ast.Field:
+			d = 1 + l.depth(s.SelectionSet)
+		case *ast.InlineFragment:
+			d = l.depth(s.SelectionSet)
+		case *ast.FragmentSpread:
+			d = l.fragmentDepth(s.Name.Value)
+		}
+
+		if d > max {
+			max = d
+		}
+	}
+
+	return max
+}
+
+// complexity estimates the number of the objects returned for the selections: every object costs 1,
+// the objects of a list are multiplied by its pageSize argument or by defaultListSize. The scalars are free.
+// The introspection is served from the schema without the repositories, it is limited only by the depth.
+func (l *limits) complexity(parent gql.Type, set *ast.SelectionSet) int {
+	if set == nil {
+		return 0
+	}
+
+	total := 0
+	for _, selection := range set.Selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name.Value, "__") {
+				continue
+			}
+
+			field := fieldDefinition(parent, s.Name.Value)
+			if field == nil || s.SelectionSet == nil {
+				continue
+			}
+
+			fieldType, isList := unwrapType(field.Type)
+			cost := capEstimate(1 + l.complexity(fieldType, s.SelectionSet))
+			if isList {
+				if size := l.listSize(field, s); size > 0 && cost > maxEstimate/size {
+					cost = maxEstimate
+				} else {
+					cost *= size
+				}
+			}
+			total = capEstimate(total + cost)
+		case *ast.InlineFragment:
+			fragmentType := parent
+			if s.TypeCondition != nil {
+				fragmentType = l.schema.Type(s.TypeCondition.Name.Value)
+			}
+			total = capEstimate(total + l.complexity(fragmentType, s.SelectionSet))
+		case *ast.FragmentSpread:
+			total = capEstimate(total + l.fragmentComplexity(s.Name.Value))
+		}
+	}
+
+	return total
+}
+
+// fragmentDepth returns the depth of the fragment computed on its first spread.
+func (l *limits) fragmentDepth(name string) int {
+	if d, ok := l.depths[name]; ok {
+		return d
+	}
+
+	d := 0
+	if fragment, ok := l.fragments[name]; ok {
+		d = l.depth(fragment.SelectionSet)
+	}
+	l.depths[name] = d
+
+	return d
+}
+
+// fragmentComplexity returns the complexity of the fragment computed on its first spread.
+// It depends only on the fragment because its type condition is fixed.
+func (l *limits) fragmentComplexity(name string) int {
+	if cost, ok := l.costs[name]; ok {
+		return cost
+	}
+
+	cost := 0
+	if fragment, ok := l.fragments[name]; ok {
+		cost = l.complexity(l.schema.Type(fragment.TypeCondition.Name.Value), fragment.SelectionSet)
+	}
+	l.costs[name] = cost
+
+	return cost
+}
+
+// capEstimate caps the estimate by maxEstimate.
+func capEstimate(estimate int) int {
+	if estimate > maxEstimate {
+		return maxEstimate
+	}
+
+	return estimate
+}
+
+// listSize returns the pageSize argument of the field, its default value or defaultListSize.
+// The size is capped by maxPageSize, the larger pages are rejected by the resolvers anyway.
+func (l *limits) listSize(field *gql.FieldDefinition, node *ast.Field) int {
+	size := defaultListSize
+	for _, arg := range field.Args {
+		if arg.Name() == "pageSize" {
+			if v, ok := arg.DefaultValue.(int); ok {
+				size = v
+			}
+		}
+	}
+
+	for _, arg := range node.Arguments {
+		if arg.Name.Value != "pageSize" {
+			continue
+		}
+
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(v.Value); err == nil {
+				size = n
+			}
+		case *ast.Variable:
+			switch n := l.variables[v.Name.Value].(type) {
+			case float64:
+				size = int(n)
+			case int:
+				size = n
+			}
+		}
+	}
+
+	if size < 1 {
+		return 1
+	}
+	if size > maxPageSize {
+		return maxPageSize
+	}
+
+	return size
+}
+
+// fieldDefinition returns the field of the type or nil if there is none.
+func fieldDefinition(parent gql.Type, name string) *gql.FieldDefinition {
+	if fielder, ok := parent.(interface{ Fields() gql.FieldDefinitionMap }); ok {
+		return fielder.Fields()[name]
+	}
+
+	return nil
+}
+
+// unwrapType returns the named type of the field and whether it is a list.
+func unwrapType(t gql.Type) (gql.Type, bool) {
+	isList := false
+	for {
+		switch wrapped := t.(type) {
+		case *gql.NonNull:
+			t = wrapped.OfType
+		case *gql.List:
+			isList = true
+			t = wrapped.OfType
+		default:
+			return t, isList
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/policy"
+	"github.com/popeskul/qna-go/internal/services"
+)
+
+// requestKey is the context key of the request.
+type requestKey struct{}
+
+// request keeps the subject and the loaders of one GraphQL request.
+type request struct {
+	service   *services.Service
+	subject   policy.Subject
+	questions *loader[domain.Question]
+	answers   *loader[domain.Answer]
+	attempts  *loader[domain.TestPassage]
+}
+
+func newRequest(service *services.Service, subject policy.Subject) *request {
+	return &request{
+		service: service,
+		subject: subject,
+		questions: newLoader(func(ctx context.Context, testIDs []int) (map[int][]domain.Question, error) {
+			list, err := service.Tests.GetQuestions(ctx, testIDs)
+			return groupBy(list, func(q domain.Question) int { return q.TestID }), err
+		}),
+		answers: newLoader(func(ctx context.Context, questionIDs []int) (map[int][]domain.Answer, error) {
+			list, err := service.Tests.GetAnswers(ctx, questionIDs)
+			return groupBy(list, func(a domain.Answer) int { return a.QuestionID }), err
+		}),
+		attempts: newLoader(func(ctx context.Context, testIDs []int) (map[int][]domain.TestPassage, error) {
+			list, err := service.Tests.GetUserPassages(ctx, subject.UserID, testIDs)
+			return groupBy(list, func(p domain.TestPassage) int { return p.TestID }), err
+		}),
+	}
+}
+
+func withRequest(ctx context.Context, r *request) context.Context {
+	return context.WithValue(ctx, requestKey{}, r)
+}
+
+func requestFrom(ctx context.Context) *request {
+	r, _ := ctx.Value(requestKey{}).(*request)
+	return r
+}
+
+// loader collects the keys requested on one level of the query and fetches them in one call.
+// The executor resolves the thunks level by level, so the keys of all the objects of the level
+// are collected before the first thunk of the level calls fetch.
+type loader[V any] struct {
+	mu      sync.Mutex
+	fetch   func(ctx context.Context, keys []int) (map[int][]V, error)
+	pending []int
+	loaded  map[int][]V
+	errs    map[int]error
+}
+
+func newLoader[V any](fetch func(ctx context.Context, keys []int) (map[int][]V, error)) *loader[V] {
+	return &loader[V]{
+		fetch:  fetch,
+		loaded: make(map[int][]V),
+		errs:   make(map[int]error),
+	}
+}
+
+// load schedules the key to be fetched with the other keys of the level.
+func (l *loader[V]) load(key int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.loaded[key]; ok {
+		return
+	}
+
+	for _, k := range l.pending {
+		if k == key {
+			return
+		}
+	}
+
+	l.pending = append(l.pending, key)
+}
+
+// get fetches the pending keys if any and returns the values of the key.
+func (l *loader[V]) get(ctx context.Context, key int) ([]V, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.pending) > 0 {
+		keys := l.pending
+		l.pending = nil
+
+		values, err := l.fetch(ctx, keys)
+		for _, k := range keys {
+			l.loaded[k] = values[k]
+			l.errs[k] = err
+		}
+	}
+
+	return l.loaded[key], l.errs[key]
+}
+
+// groupBy groups the values by the key.
+func groupBy[V any](values []V, key func(V) int) map[int][]V {
+	groups := make(map[int][]V)
+	for _, v := range values {
+		groups[key(v)] = append(groups[key(v)], v)
+	}
+
+	return groups
+}
@@ -0,0 +1,272 @@
+package graphql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	gql "github.com/graphql-go/graphql"
+
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/policy"
+)
+
+const maxPageSize = 10
+
+var ErrInvalidPage = fmt.Errorf("page must be at least 1 and pageSize from 1 to %d", maxPageSize)
+
+// testNode is the test and whether the user can edit it, the correct answers are shown only to the editors.
+type testNode struct {
+	ID        int
+	Title     string
+	AuthorID  int
+	CreatedAt string
+	UpdatedAt string
+	canEdit   bool
+}
+
+// questionNode is the question of the test.
+type questionNode struct {
+	ID        int
+	Body      string
+	CreatedAt string
+	UpdatedAt string
+	canEdit   bool
+}
+
+// answerNode is the answer option of the question.
+type answerNode struct {
+	ID      int
+	Title   string
+	correct bool
+	canEdit bool
+}
+
+func newTestNode(test domain.Test, canEdit bool) testNode {
+	return testNode{
+		ID:        test.ID,
+		Title:     test.Title,
+		AuthorID:  test.AuthorID,
+		CreatedAt: test.CreatedAt,
+		UpdatedAt: test.UpdatedAt,
+		canEdit:   canEdit,
+	}
+}
+
+func newSchema() (gql.Schema, error) {
+	attemptType := gql.NewObject(gql.ObjectConfig{
+		Name:        "Attempt",
+		Description: "The attempt of the current user to pass the test.",
+		Fields: gql.Fields{
+			"id":        &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"testId":    &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"passed":    &gql.Field{Type: gql.NewNonNull(gql.Boolean)},
+			"createdAt": &gql.Field{Type: gql.NewNonNull(gql.String)},
+		},
+	})
+
+	answerType := gql.NewObject(gql.ObjectConfig{
+		Name:        "Answer",
+		Description: "The answer option of the question.",
+		Fields: gql.Fields{
+			"id":    &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"title": &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"correct": &gql.Field{
+				Type:        gql.Boolean,
+				Description: "Whether the answer is correct, null unless the user can edit the test.",
+				Resolve:     resolveCorrect,
+			},
+		},
+	})
+
+	questionType := gql.NewObject(gql.ObjectConfig{
+		Name:        "Question",
+		Description: "The question of the test.",
+		Fields: gql.Fields{
+			"id":        &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"body":      &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"createdAt": &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"updatedAt": &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"answers": &gql.Field{
+				Type:    gql.NewNonNull(gql.NewList(gql.NewNonNull(answerType))),
+				Resolve: resolveAnswers,
+			},
+		},
+	})
+
+	testType := gql.NewObject(gql.ObjectConfig{
+		Name:        "Test",
+		Description: "The test with its questions and the attempts of the current user.",
+		Fields: gql.Fields{
+			"id":        &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"title":     &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"authorId":  &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"createdAt": &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"updatedAt": &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"questions": &gql.Field{
+				Type:    gql.NewNonNull(gql.NewList(gql.NewNonNull(questionType))),
+				Resolve: resolveQuestions,
+			},
+			"attempts": &gql.Field{
+				Type:    gql.NewNonNull(gql.NewList(gql.NewNonNull(attemptType))),
+				Resolve: resolveAttempts,
+			},
+		},
+	})
+
+	queryType := gql.NewObject(gql.ObjectConfig{
+		Name: "Query",
+		Fields: gql.Fields{
+			"test": &gql.Field{
+				Type:        testType,
+				Description: "The test if the user can read it.",
+				Args: gql.FieldConfigArgument{
+					"id": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.Int)},
+				},
+				Resolve: resolveTest,
+			},
+			"tests": &gql.Field{
+				Type:        gql.NewNonNull(gql.NewList(gql.NewNonNull(testType))),
+				Description: "The tests of the user page by page.",
+				Args: gql.FieldConfigArgument{
+					"page":     &gql.ArgumentConfig{Type: gql.Int, DefaultValue: 1},
+					"pageSize": &gql.ArgumentConfig{Type: gql.Int, DefaultValue: maxPageSize},
+				},
+				Resolve: resolveTests,
+			},
+		},
+	})
+
+	return gql.NewSchema(gql.SchemaConfig{Query: queryType})
+}
+
+// resolveTest returns the test if the user can read it either by the role or by the membership.
+func resolveTest(p gql.ResolveParams) (interface{}, error) {
+	r := requestFrom(p.Context)
+	id, _ := p.Args["id"].(int)
+
+	test, err := r.service.Tests.AuthorizeTest(p.Context, r.subject, id, policy.ReadTest)
+	if err != nil {
+		return nil, err
+	}
+
+	canEdit := policy.CanOn(r.subject, policy.UpdateTest, test.AuthorID)
+	if !canEdit {
+		_, err = r.service.Tests.AuthorizeTest(p.Context, r.subject, id, policy.UpdateTest)
+		canEdit = err == nil
+	}
+
+	return newTestNode(test, canEdit), nil
+}
+
+// resolveTests returns the tests of the user like GET /tests.
+func resolveTests(p gql.ResolveParams) (interface{}, error) {
+	r := requestFrom(p.Context)
+	if !policy.Can(r.subject, policy.ReadTest) {
+		return nil, policy.ErrForbidden
+	}
+
+	page, _ := p.Args["page"].(int)
+	pageSize, _ := p.Args["pageSize"].(int)
+	if page < 1 || pageSize < 1 || pageSize > maxPageSize {
+		return nil, ErrInvalidPage
+	}
+
+	list, err := r.service.Tests.GetAllTestsByUserID(p.Context, r.subject.UserID, domain.GetAllTestsParams{
+		Limit:  pageSize,
+		Offset: (page - 1) * pageSize,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	nodes := make([]testNode, 0, len(list))
+	for _, test := range list {
+		nodes = append(nodes, newTestNode(test, policy.CanOn(r.subject, policy.UpdateTest, test.AuthorID)))
+	}
+
+	return nodes, nil
+}
+
+// resolveQuestions returns the thunk of the questions, the questions of all the tests are fetched at once.
+func resolveQuestions(p gql.ResolveParams) (interface{}, error) {
+	r := requestFrom(p.Context)
+	test, _ := p.Source.(testNode)
+	r.questions.load(test.ID)
+
+	return func() (interface{}, error) {
+		list, err := r.questions.get(p.Context, test.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		nodes := make([]questionNode, 0, len(list))
+		for _, q := range list {
+			nodes = append(nodes, questionNode{
+				ID:        q.ID,
+				Body:      q.Body,
+				CreatedAt: q.CreatedAt,
+				UpdatedAt: q.UpdatedAt,
+				canEdit:   test.canEdit,
+			})
+		}
+
+		return nodes, nil
+	}, nil
+}
+
+// resolveAnswers returns the thunk of the answers, the answers of all the questions are fetched at once.
+func resolveAnswers(p gql.ResolveParams) (interface{}, error) {
+	r := requestFrom(p.Context)
+	question, _ := p.Source.(questionNode)
+	r.answers.load(question.ID)
+
+	return func() (interface{}, error) {
+		list, err := r.answers.get(p.Context, question.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		nodes := make([]answerNode, 0, len(list))
+		for _, a := range list {
+			nodes = append(nodes, answerNode{
+				ID:      a.ID,
+				Title:   a.Title,
+				correct: a.Correct,
+				canEdit: question.canEdit,
+			})
+		}
+
+		return nodes, nil
+	}, nil
+}
+
+// resolveAttempts returns the thunk of the attempts of the user, the attempts in all the tests are fetched at once.
+func resolveAttempts(p gql.ResolveParams) (interface{}, error) {
+	r := requestFrom(p.Context)
+	test, _ := p.Source.(testNode)
+	r.attempts.load(test.ID)
+
+	return func() (interface{}, error) {
+		list, err := r.attempts.get(p.Context, test.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if list == nil {
+			list = make([]domain.TestPassage, 0)
+		}
+
+		return list, nil
+	}, nil
+}
+
+// resolveCorrect hides the correct answers from the users who pass the test.
+func resolveCorrect(p gql.ResolveParams) (interface{}, error) {
+	answer, _ := p.Source.(answerNode)
+	if !answer.canEdit {
+		return nil, nil
+	}
+
+	return answer.correct, nil
+}
@@ -0,0 +1,150 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/lockout"
+	"github.com/popeskul/qna-go/internal/password"
+	"github.com/popeskul/qna-go/internal/services"
+	"github.com/popeskul/qna-go/internal/services/auth"
+	"github.com/popeskul/qna-go/internal/transport/grpc/pb"
+)
+
+// authServer implements pb.AuthServiceServer over the auth service.
+type authServer struct {
+	pb.UnimplementedAuthServiceServer
+	service *services.Service
+}
+
+// SignUp creates the user. The broken rules of the password policy are returned in the errdetails.BadRequest.
+func (s *authServer) SignUp(ctx context.Context, req *pb.SignUpRequest) (*emptypb.Empty, error) {
+	if req.GetEmail() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email and password are required")
+	}
+
+	err := s.service.Auth.CreateUser(ctx, domain.User{
+		Name:     req.GetName(),
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+	})
+	if err != nil {
+		if st, ok := passwordPolicyStatus(err); ok {
+			return nil, st.Err()
+		}
+
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// SignIn returns the tokens or the two-factor challenge.
+// Too many failed attempts are rejected with ResourceExhausted and the errdetails.RetryInfo.
+func (s *authServer) SignIn(ctx context.Context, req *pb.SignInRequest) (*pb.SignInResponse, error) {
+	if req.GetEmail() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email and password are required")
+	}
+
+	ctx = lockout.WithClientIP(ctx, clientIP(ctx))
+	accessToken, refreshToken, err := s.service.Auth.SignIn(ctx, domain.User{Email: req.GetEmail(), Password: req.GetPassword()})
+	if err != nil {
+		var retry *lockout.RetryError
+		if errors.As(err, &retry) {
+			st, detailsErr := status.New(codes.ResourceExhausted, err.Error()).
+				WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retry.RetryAfter)})
+			if detailsErr != nil {
+				return nil, status.Error(codes.ResourceExhausted, err.Error())
+			}
+			return nil, st.Err()
+		}
+
+		var challenge *auth.ChallengeError
+		if errors.As(err, &challenge) {
+			return &pb.SignInResponse{Result: &pb.SignInResponse_Challenge{Challenge: &pb.TwoFactorChallenge{
+				ChallengeToken: challenge.Token,
+				ExpiresAt:      timestamppb.New(challenge.ExpiresAt),
+			}}}, nil
+		}
+
+		return nil, authStatus(err)
+	}
+
+	return &pb.SignInResponse{Result: &pb.SignInResponse_Tokens{Tokens: &pb.Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}}}, nil
+}
+
+// VerifyTwoFactor completes the sign in with the two-factor code.
+func (s *authServer) VerifyTwoFactor(ctx context.Context, req *pb.VerifyTwoFactorRequest) (*pb.Tokens, error) {
+	if req.GetChallengeToken() == "" || req.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "challenge token and code are required")
+	}
+
+	accessToken, refreshToken, err := s.service.Auth.VerifyTwoFactor(ctx, req.GetChallengeToken(), req.GetCode())
+	if err != nil {
+		return nil, authStatus(err)
+	}
+
+	return &pb.Tokens{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// Refresh issues new tokens for the refresh token.
+func (s *authServer) Refresh(ctx context.Context, req *pb.RefreshRequest) (*pb.Tokens, error) {
+	if req.GetRefreshToken() == "" {
+		return nil, status.Error(codes.Unauthenticated, services.ErrAuthEmptyToken.Error())
+	}
+
+	accessToken, refreshToken, err := s.service.Auth.GenerateAccessRefreshTokens(ctx, req.GetRefreshToken())
+	if err != nil {
+		if errors.Is(err, domain.ErrUserDisabled) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return &pb.Tokens{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// authStatus maps the errors of the signing in to the status codes.
+func authStatus(err error) error {
+	switch {
+	case errors.Is(err, auth.ErrSignIn), errors.Is(err, auth.ErrInvalidTwoFactorCode), errors.Is(err, auth.ErrInvalidChallenge):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, domain.ErrUserDisabled):
+		return status.Error(codes.PermissionDenied, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// passwordPolicyStatus returns the status with the violations of the password policy and reports if the error is one.
+func passwordPolicyStatus(err error) (*status.Status, bool) {
+	var validationErr *password.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, false
+	}
+
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(validationErr.Violations))
+	for _, v := range validationErr.Violations {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: "password", Description: v.Message})
+	}
+
+	st := status.New(codes.InvalidArgument, validationErr.Error())
+	withDetails, detailsErr := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if detailsErr != nil {
+		return st, true
+	}
+
+	return withDetails, true
+}
@@ -0,0 +1,128 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/popeskul/qna-go/internal/policy"
+	"github.com/popeskul/qna-go/internal/services"
+	"github.com/popeskul/qna-go/internal/token"
+	"github.com/popeskul/qna-go/internal/transport/grpc/pb"
+)
+
+const (
+	authorizationMetadata = "authorization"
+	apiKeyMetadata        = "x-api-key"
+)
+
+// publicServices are called without the credential.
+var publicServices = map[string]bool{
+	pb.AuthService_ServiceDesc.ServiceName: true,
+}
+
+// payloadKey is the context key of the payload of the authenticated credential.
+type payloadKey struct{}
+
+// loggingInterceptor is an interceptor that logs the call like loggingMiddleware logs the request.
+func (s *Server) loggingInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	s.logger.Infof("%s: [gRPC] - %s ", time.Now().Format(time.RFC3339), info.FullMethod)
+
+	resp, err := handler(ctx, req)
+	if err != nil {
+		s.logger.Infof("%s: [gRPC] - %s %s", time.Now().Format(time.RFC3339), info.FullMethod, status.Code(err))
+	}
+
+	return resp, err
+}
+
+// authInterceptor is an interceptor that authenticates the user like authMiddleware does.
+// The credential is taken from the metadata in this order:
+//  1. authorization: Bearer <access token or API key>
+//  2. x-api-key: <API key>
+//
+// The methods of the public services are called without the credential.
+func (s *Server) authInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if publicServices[serviceName(info.FullMethod)] {
+		return handler(ctx, req)
+	}
+
+	payload, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return handler(context.WithValue(ctx, payloadKey{}, payload), req)
+}
+
+// authenticate finds the credential in the metadata and verifies it with the service like authMiddleware does.
+func (s *Server) authenticate(ctx context.Context) (*token.Payload, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	return s.service.Authenticate(ctx, firstValue(md, authorizationMetadata), firstValue(md, apiKeyMetadata))
+}
+
+// firstValue returns the first value of the metadata key or an empty string.
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+// serviceName returns the service of the full method name /package.Service/Method.
+func serviceName(fullMethod string) string {
+	name := strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[:i]
+	}
+
+	return name
+}
+
+// getSubject get the user id and role from the context and returns them and an error if they are not found.
+func getSubject(ctx context.Context) (policy.Subject, error) {
+	payload, ok := ctx.Value(payloadKey{}).(*token.Payload)
+	if !ok || payload == nil {
+		return policy.Subject{}, status.Error(codes.Unauthenticated, services.ErrUserIdNotFound.Error())
+	}
+
+	return services.SubjectOf(payload), nil
+}
+
+// requirePermission returns the subject if the user's role has the permission like permissionMiddleware.
+func requirePermission(ctx context.Context, perm policy.Permission) (policy.Subject, error) {
+	subject, err := getSubject(ctx)
+	if err != nil {
+		return subject, err
+	}
+
+	if !policy.Can(subject, perm) {
+		return subject, status.Error(codes.PermissionDenied, policy.ErrForbidden.Error())
+	}
+
+	return subject, nil
+}
+
+// clientIP returns the IP of the peer, the signing in is throttled per IP.
+func clientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
+}
@@ -0,0 +1,252 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: tests.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// TestsServiceClient is the client API for TestsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TestsServiceClient interface {
+	CreateTest(ctx context.Context, in *CreateTestRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	GetTest(ctx context.Context, in *GetTestRequest, opts ...grpc.CallOption) (*Test, error)
+	// ListTests returns the tests of the user page by page.
+	ListTests(ctx context.Context, in *ListTestsRequest, opts ...grpc.CallOption) (*ListTestsResponse, error)
+	UpdateTest(ctx context.Context, in *UpdateTestRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	DeleteTest(ctx context.Context, in *DeleteTestRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type testsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTestsServiceClient(cc grpc.ClientConnInterface) TestsServiceClient {
+	return &testsServiceClient{cc}
+}
+
+func (c *testsServiceClient) CreateTest(ctx context.Context, in *CreateTestRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/qna.v1.TestsService/CreateTest", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *testsServiceClient) GetTest(ctx context.Context, in *GetTestRequest, opts ...grpc.CallOption) (*Test, error) {
+	out := new(Test)
+	err := c.cc.Invoke(ctx, "/qna.v1.TestsService/GetTest", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *testsServiceClient) ListTests(ctx context.Context, in *ListTestsRequest, opts ...grpc.CallOption) (*ListTestsResponse, error) {
+	out := new(ListTestsResponse)
+	err := c.cc.Invoke(ctx, "/qna.v1.TestsService/ListTests", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *testsServiceClient) UpdateTest(ctx context.Context, in *UpdateTestRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/qna.v1.TestsService/UpdateTest", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *testsServiceClient) DeleteTest(ctx context.Context, in *DeleteTestRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/qna.v1.TestsService/DeleteTest", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TestsServiceServer is the server API for TestsService service.
+// All implementations must embed UnimplementedTestsServiceServer
+// for forward compatibility
+type TestsServiceServer interface {
+	CreateTest(context.Context, *CreateTestRequest) (*emptypb.Empty, error)
+	GetTest(context.Context, *GetTestRequest) (*Test, error)
+	// ListTests returns the tests of the user page by page.
+	ListTests(context.Context, *ListTestsRequest) (*ListTestsResponse, error)
+	UpdateTest(context.Context, *UpdateTestRequest) (*emptypb.Empty, error)
+	DeleteTest(context.Context, *DeleteTestRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedTestsServiceServer()
+}
+
+// UnimplementedTestsServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedTestsServiceServer struct {
+}
+
+func (UnimplementedTestsServiceServer) CreateTest(context.Context, *CreateTestRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateTest not implemented")
+}
+func (UnimplementedTestsServiceServer) GetTest(context.Context, *GetTestRequest) (*Test, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTest not implemented")
+}
+func (UnimplementedTestsServiceServer) ListTests(context.Context, *ListTestsRequest) (*ListTestsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTests not implemented")
+}
+func (UnimplementedTestsServiceServer) UpdateTest(context.Context, *UpdateTestRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateTest not implemented")
+}
+func (UnimplementedTestsServiceServer) DeleteTest(context.Context, *DeleteTestRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteTest not implemented")
+}
+func (UnimplementedTestsServiceServer) mustEmbedUnimplementedTestsServiceServer() {}
+
+// UnsafeTestsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TestsServiceServer will
+// result in compilation errors.
+type UnsafeTestsServiceServer interface {
+	mustEmbedUnimplementedTestsServiceServer()
+}
+
+func RegisterTestsServiceServer(s grpc.ServiceRegistrar, srv TestsServiceServer) {
+	s.RegisterService(&TestsService_ServiceDesc, srv)
+}
+
+func _TestsService_CreateTest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateTestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TestsServiceServer).CreateTest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/qna.v1.TestsService/CreateTest",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TestsServiceServer).CreateTest(ctx, req.(*CreateTestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TestsService_GetTest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TestsServiceServer).GetTest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/qna.v1.TestsService/GetTest",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TestsServiceServer).GetTest(ctx, req.(*GetTestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TestsService_ListTests_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTestsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TestsServiceServer).ListTests(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/qna.v1.TestsService/ListTests",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TestsServiceServer).ListTests(ctx, req.(*ListTestsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TestsService_UpdateTest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateTestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TestsServiceServer).UpdateTest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/qna.v1.TestsService/UpdateTest",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TestsServiceServer).UpdateTest(ctx, req.(*UpdateTestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TestsService_DeleteTest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteTestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TestsServiceServer).DeleteTest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/qna.v1.TestsService/DeleteTest",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TestsServiceServer).DeleteTest(ctx, req.(*DeleteTestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TestsService_ServiceDesc is the grpc.ServiceDesc for TestsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TestsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "qna.v1.TestsService",
+	HandlerType: (*TestsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateTest",
+			Handler:    _TestsService_CreateTest_Handler,
+		},
+		{
+			MethodName: "GetTest",
+			Handler:    _TestsService_GetTest_Handler,
+		},
+		{
+			MethodName: "ListTests",
+			Handler:    _TestsService_ListTests_Handler,
+		},
+		{
+			MethodName: "UpdateTest",
+			Handler:    _TestsService_UpdateTest_Handler,
+		},
+		{
+			MethodName: "DeleteTest",
+			Handler:    _TestsService_DeleteTest_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "tests.proto",
+}
@@ -0,0 +1,45 @@
+// Package grpc defines the gRPC transport. It serves the auth and the tests services
+// over the same services.Service as the rest transport.
+package grpc
+
+import (
+	"net"
+
+	"google.golang.org/grpc"
+
+	"github.com/popeskul/qna-go/internal/logger"
+	"github.com/popeskul/qna-go/internal/services"
+	"github.com/popeskul/qna-go/internal/transport/grpc/pb"
+)
+
+// Server is the gRPC server with all the necessary dependencies.
+type Server struct {
+	service *services.Service
+	logger  *logger.Logger
+	server  *grpc.Server
+}
+
+// NewServer creates a new gRPC server with the auth and the tests services registered.
+// Note that the server is not started, you must call Serve to start it.
+func NewServer(service *services.Service, logger *logger.Logger) *Server {
+	s := &Server{
+		service: service,
+		logger:  logger,
+	}
+
+	s.server = grpc.NewServer(grpc.ChainUnaryInterceptor(s.loggingInterceptor, s.authInterceptor))
+	pb.RegisterAuthServiceServer(s.server, &authServer{service: service})
+	pb.RegisterTestsServiceServer(s.server, &testsServer{service: service})
+
+	return s
+}
+
+// Serve accepts the connections on the listener until Stop or GracefulStop is called.
+func (s *Server) Serve(lis net.Listener) error {
+	return s.server.Serve(lis)
+}
+
+// GracefulStop stops accepting the connections and waits for the pending calls to finish.
+func (s *Server) GracefulStop() {
+	s.server.GracefulStop()
+}
@@ -0,0 +1,356 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/lockout"
+	"github.com/popeskul/qna-go/internal/logger"
+	"github.com/popeskul/qna-go/internal/password"
+	"github.com/popeskul/qna-go/internal/policy"
+	"github.com/popeskul/qna-go/internal/repository/tests"
+	"github.com/popeskul/qna-go/internal/services"
+	"github.com/popeskul/qna-go/internal/services/auth"
+	"github.com/popeskul/qna-go/internal/token"
+	"github.com/popeskul/qna-go/internal/transport/grpc/pb"
+	"github.com/popeskul/qna-go/internal/util"
+)
+
+const (
+	authorEmail   = "author@example.com"
+	disabledEmail = "disabled@example.com"
+	twoFactorMail = "2fa@example.com"
+	apiKey        = "qna_test_key"
+)
+
+// fakeAuth signs in the known users with the password "secret123".
+type fakeAuth struct {
+	services.Auth
+	tokenManager token.Manager
+	ips          []string
+}
+
+func (a *fakeAuth) CreateUser(_ context.Context, user domain.User) error {
+	if len(user.Password) < 8 {
+		return &password.ValidationError{Violations: []password.Violation{{Code: "min_length", Message: "password is too short"}}}
+	}
+
+	return nil
+}
+
+func (a *fakeAuth) SignIn(ctx context.Context, user domain.User) (string, string, error) {
+	a.ips = append(a.ips, lockout.ClientIP(ctx))
+
+	if user.Password != "secret123" {
+		return "", "", auth.ErrSignIn
+	}
+
+	switch user.Email {
+	case disabledEmail:
+		return "", "", domain.ErrUserDisabled
+	case twoFactorMail:
+		return "", "", &auth.ChallengeError{Token: "challenge", ExpiresAt: time.Now().Add(time.Minute)}
+	case authorEmail:
+		accessToken, err := a.tokenManager.CreateToken(token.Claims{UserID: 1, Role: string(domain.RoleAuthor)}, time.Minute)
+		return accessToken, "refresh", err
+	}
+
+	return "", "", auth.ErrSignIn
+}
+
+func (a *fakeAuth) VerifyToken(_ context.Context, accessToken string) (*token.Payload, error) {
+	return a.tokenManager.VerifyToken(accessToken)
+}
+
+// fakeAPIKeys authenticates the only key as a learner.
+type fakeAPIKeys struct {
+	services.APIKeys
+}
+
+func (k *fakeAPIKeys) AuthenticateAPIKey(_ context.Context, key string) (*token.Payload, error) {
+	if key != apiKey {
+		return nil, token.ErrInvalidToken
+	}
+
+	return &token.Payload{UserID: 2, Role: string(domain.RoleLearner)}, nil
+}
+
+// fakeTests keeps the tests in memory, only the author of a test can read it.
+type fakeTests struct {
+	services.Tests
+	tests map[int]domain.Test
+}
+
+func (t *fakeTests) CreateTest(_ context.Context, userID int, test domain.Test) (domain.Test, error) {
+	test.ID = len(t.tests) + 1
+	test.AuthorID = userID
+	t.tests[test.ID] = test
+
+	return test, nil
+}
+
+func (t *fakeTests) AuthorizeTest(_ context.Context, subject policy.Subject, testID int, _ policy.Permission) (domain.Test, error) {
+	test, ok := t.tests[testID]
+	if !ok {
+		return test, tests.ErrTestNotFound
+	}
+
+	if test.AuthorID != subject.UserID {
+		return test, policy.ErrForbidden
+	}
+
+	return test, nil
+}
+
+func (t *fakeTests) GetAllTestsByUserID(_ context.Context, userID int, _ domain.GetAllTestsParams) ([]domain.Test, error) {
+	list := make([]domain.Test, 0)
+	for _, test := range t.tests {
+		if test.AuthorID == userID {
+			list = append(list, test)
+		}
+	}
+
+	return list, nil
+}
+
+// newTestClient starts the server on the bufconn listener and returns the connection to it.
+func newTestClient(t *testing.T, service *services.Service) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer(service, logger.GetLogger())
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("error dialing bufconn: %v", err)
+	}
+
+	t.Cleanup(func() {
+		conn.Close()
+		srv.GracefulStop()
+	})
+
+	return conn
+}
+
+func newTestService(t *testing.T) (*services.Service, *fakeAuth) {
+	t.Helper()
+
+	tokenManager, err := token.NewPasetoManager(util.RandomString(32))
+	if err != nil {
+		t.Fatalf("error creating token manager: %v", err)
+	}
+
+	fakeAuth := &fakeAuth{tokenManager: tokenManager}
+
+	return &services.Service{
+		Auth:    fakeAuth,
+		APIKeys: &fakeAPIKeys{},
+		Tests:   &fakeTests{tests: make(map[int]domain.Test)},
+	}, fakeAuth
+}
+
+func TestAuthService(t *testing.T) {
+	service, fakeAuth := newTestService(t)
+	client := pb.NewAuthServiceClient(newTestClient(t, service))
+	ctx := context.Background()
+
+	t.Run("Success: sign in", func(t *testing.T) {
+		resp, err := client.SignIn(ctx, &pb.SignInRequest{Email: authorEmail, Password: "secret123"})
+		if err != nil {
+			t.Fatalf("error signing in: %v", err)
+		}
+
+		if resp.GetTokens().GetAccessToken() == "" || resp.GetTokens().GetRefreshToken() != "refresh" {
+			t.Errorf("unexpected tokens: %v", resp)
+		}
+
+		if ip := fakeAuth.ips[len(fakeAuth.ips)-1]; ip == "" {
+			t.Error("the client IP is not passed to the auth service")
+		}
+	})
+
+	t.Run("Success: two-factor challenge", func(t *testing.T) {
+		resp, err := client.SignIn(ctx, &pb.SignInRequest{Email: twoFactorMail, Password: "secret123"})
+		if err != nil {
+			t.Fatalf("error signing in: %v", err)
+		}
+
+		if resp.GetChallenge().GetChallengeToken() != "challenge" || resp.GetTokens() != nil {
+			t.Errorf("expected the challenge, got %v", resp)
+		}
+	})
+
+	errorTests := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{
+			name: "Error: wrong password",
+			call: func() error {
+				_, err := client.SignIn(ctx, &pb.SignInRequest{Email: authorEmail, Password: "wrong"})
+				return err
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "Error: disabled user",
+			call: func() error {
+				_, err := client.SignIn(ctx, &pb.SignInRequest{Email: disabledEmail, Password: "secret123"})
+				return err
+			},
+			code: codes.PermissionDenied,
+		},
+		{
+			name: "Error: sign up without password",
+			call: func() error {
+				_, err := client.SignUp(ctx, &pb.SignUpRequest{Email: authorEmail})
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := status.Code(tt.call()); code != tt.code {
+				t.Errorf("expected %v, got %v", tt.code, code)
+			}
+		})
+	}
+
+	t.Run("Error: password policy violations in the details", func(t *testing.T) {
+		_, err := client.SignUp(ctx, &pb.SignUpRequest{Name: "Jane", Email: "jane@example.com", Password: "short"})
+
+		st := status.Convert(err)
+		if st.Code() != codes.InvalidArgument || len(st.Details()) != 1 {
+			t.Fatalf("unexpected status: %v", st)
+		}
+
+		badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+		if !ok || len(badRequest.GetFieldViolations()) != 1 || badRequest.GetFieldViolations()[0].GetField() != "password" {
+			t.Errorf("unexpected details: %v", st.Details())
+		}
+	})
+}
+
+func TestTestsService(t *testing.T) {
+	service, _ := newTestService(t)
+	conn := newTestClient(t, service)
+	client := pb.NewTestsServiceClient(conn)
+	ctx := context.Background()
+
+	signIn, err := pb.NewAuthServiceClient(conn).SignIn(ctx, &pb.SignInRequest{Email: authorEmail, Password: "secret123"})
+	if err != nil {
+		t.Fatalf("error signing in: %v", err)
+	}
+
+	authorCtx := metadata.AppendToOutgoingContext(ctx, authorizationMetadata, "Bearer "+signIn.GetTokens().GetAccessToken())
+	learnerCtx := metadata.AppendToOutgoingContext(ctx, apiKeyMetadata, apiKey)
+
+	if _, err = client.CreateTest(authorCtx, &pb.CreateTestRequest{Title: "Go basics"}); err != nil {
+		t.Fatalf("error creating test: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{
+			name: "Success: author reads the test",
+			call: func() error {
+				test, err := client.GetTest(authorCtx, &pb.GetTestRequest{Id: 1})
+				if err == nil && (test.GetTitle() != "Go basics" || test.GetAuthorId() != 1) {
+					t.Errorf("unexpected test: %v", test)
+				}
+				return err
+			},
+			code: codes.OK,
+		},
+		{
+			name: "Success: author lists the tests",
+			call: func() error {
+				resp, err := client.ListTests(authorCtx, &pb.ListTestsRequest{PageId: 1, PageSize: 5})
+				if err == nil && len(resp.GetTests()) != 1 {
+					t.Errorf("expected 1 test, got %v", resp.GetTests())
+				}
+				return err
+			},
+			code: codes.OK,
+		},
+		{
+			name: "Error: without credential",
+			call: func() error {
+				_, err := client.GetTest(ctx, &pb.GetTestRequest{Id: 1})
+				return err
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "Error: invalid token",
+			call: func() error {
+				_, err := client.GetTest(metadata.AppendToOutgoingContext(ctx, authorizationMetadata, "Bearer invalid"), &pb.GetTestRequest{Id: 1})
+				return err
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "Error: test not found",
+			call: func() error {
+				_, err := client.GetTest(authorCtx, &pb.GetTestRequest{Id: 42})
+				return err
+			},
+			code: codes.NotFound,
+		},
+		{
+			name: "Error: learner with api key reads the test of another user",
+			call: func() error {
+				_, err := client.GetTest(learnerCtx, &pb.GetTestRequest{Id: 1})
+				return err
+			},
+			code: codes.PermissionDenied,
+		},
+		{
+			name: "Error: learner can't create tests",
+			call: func() error {
+				_, err := client.CreateTest(learnerCtx, &pb.CreateTestRequest{Title: "Not allowed"})
+				return err
+			},
+			code: codes.PermissionDenied,
+		},
+		{
+			name: "Error: page size out of range",
+			call: func() error {
+				_, err := client.ListTests(authorCtx, &pb.ListTestsRequest{PageId: 1, PageSize: 100})
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := status.Code(tt.call()); code != tt.code {
+				t.Errorf("expected %v, got %v", tt.code, code)
+			}
+		})
+	}
+}
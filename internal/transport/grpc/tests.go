@@ -0,0 +1,149 @@
+package grpc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/policy"
+	"github.com/popeskul/qna-go/internal/repository/tests"
+	"github.com/popeskul/qna-go/internal/services"
+	"github.com/popeskul/qna-go/internal/transport/grpc/pb"
+)
+
+const (
+	minPageSize = 5
+	maxPageSize = 10
+)
+
+// testsServer implements pb.TestsServiceServer over the tests service.
+type testsServer struct {
+	pb.UnimplementedTestsServiceServer
+	service *services.Service
+}
+
+// CreateTest creates the test of the user.
+func (s *testsServer) CreateTest(ctx context.Context, req *pb.CreateTestRequest) (*emptypb.Empty, error) {
+	subject, err := requirePermission(ctx, policy.CreateTest)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetTitle() == "" {
+		return nil, status.Error(codes.InvalidArgument, "title is required")
+	}
+
+	if _, err = s.service.Tests.CreateTest(ctx, subject.UserID, domain.Test{Title: req.GetTitle()}); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// GetTest returns the test if the user can read it.
+func (s *testsServer) GetTest(ctx context.Context, req *pb.GetTestRequest) (*pb.Test, error) {
+	subject, err := getSubject(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	test, err := s.service.Tests.AuthorizeTest(ctx, subject, int(req.GetId()), policy.ReadTest)
+	if err != nil {
+		return nil, testStatus(err)
+	}
+
+	return toPBTest(test), nil
+}
+
+// ListTests returns the tests of the user page by page.
+func (s *testsServer) ListTests(ctx context.Context, req *pb.ListTestsRequest) (*pb.ListTestsResponse, error) {
+	subject, err := requirePermission(ctx, policy.ReadTest)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetPageId() < 1 || req.GetPageSize() < minPageSize || req.GetPageSize() > maxPageSize {
+		return nil, status.Errorf(codes.InvalidArgument, "page_id must be at least 1 and page_size from %d to %d", minPageSize, maxPageSize)
+	}
+
+	list, err := s.service.Tests.GetAllTestsByUserID(ctx, subject.UserID, domain.GetAllTestsParams{
+		Limit:  int(req.GetPageSize()),
+		Offset: int((req.GetPageId() - 1) * req.GetPageSize()),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "tests not found")
+		}
+
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	response := &pb.ListTestsResponse{Tests: make([]*pb.Test, 0, len(list))}
+	for _, test := range list {
+		response.Tests = append(response.Tests, toPBTest(test))
+	}
+
+	return response, nil
+}
+
+// UpdateTest updates the test if the user can edit it.
+func (s *testsServer) UpdateTest(ctx context.Context, req *pb.UpdateTestRequest) (*emptypb.Empty, error) {
+	subject, err := getSubject(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the requests carry no version of the test, the last write wins
+	if _, err = s.service.Tests.UpdateTestByID(ctx, subject, int(req.GetId()), domain.AnyVersion, domain.Test{Title: req.GetTitle()}); err != nil {
+		return nil, testStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// DeleteTest deletes the test if the user can delete it.
+func (s *testsServer) DeleteTest(ctx context.Context, req *pb.DeleteTestRequest) (*emptypb.Empty, error) {
+	if req.GetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "test id is required")
+	}
+
+	subject, err := getSubject(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.service.Tests.DeleteTestByID(ctx, subject, int(req.GetId()), domain.AnyVersion); err != nil {
+		return nil, testStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// testStatus maps the errors returned by the tests service to the status codes.
+func testStatus(err error) error {
+	switch {
+	case errors.Is(err, tests.ErrTestNotFound):
+		return status.Error(codes.NotFound, tests.ErrTestNotFound.Error())
+	case errors.Is(err, tests.ErrTest):
+		return status.Error(codes.NotFound, tests.ErrTest.Error())
+	case errors.Is(err, policy.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func toPBTest(test domain.Test) *pb.Test {
+	return &pb.Test{
+		Id:        int64(test.ID),
+		Title:     test.Title,
+		AuthorId:  int64(test.AuthorID),
+		CreatedAt: test.CreatedAt,
+		UpdatedAt: test.UpdatedAt,
+	}
+}
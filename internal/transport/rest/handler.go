@@ -3,13 +3,23 @@
 package rest
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-contrib/sessions/cookie"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/popeskul/qna-go/docs"
+	"github.com/popeskul/qna-go/internal/idempotency"
+	"github.com/popeskul/qna-go/internal/live"
 	"github.com/popeskul/qna-go/internal/logger"
+	"github.com/popeskul/qna-go/internal/notify"
 	"github.com/popeskul/qna-go/internal/services"
+	"github.com/popeskul/qna-go/internal/transport/graphql"
 	v1 "github.com/popeskul/qna-go/internal/transport/rest/v1"
+	"github.com/popeskul/qna-go/internal/transport/ws"
+	"github.com/popeskul/qna-go/internal/validation"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -19,20 +29,37 @@ type Handlers struct {
 	service *services.Service
 	store   cookie.Store
 	logger  *logger.Logger
+	graphQL *graphql.Executor
+	rooms   *live.Manager
+	events  notify.Broker
+	keys    *idempotency.Keys
 }
 
 // NewHandler creates a new Handlers with the necessary dependencies.
-func NewHandler(service *services.Service, store cookie.Store, logger *logger.Logger) *Handlers {
+func NewHandler(service *services.Service, store cookie.Store, logger *logger.Logger, graphQL *graphql.Executor, rooms *live.Manager, events notify.Broker,
+	keys *idempotency.Keys) *Handlers {
 	return &Handlers{
 		service: service,
 		store:   store,
 		logger:  logger,
+		graphQL: graphQL,
+		rooms:   rooms,
+		events:  events,
+		keys:    keys,
 	}
 }
 
 // Init initializes the rest transport handlers and returns a gin engine.
-func (h *Handlers) Init() *gin.Engine {
-	router := gin.Default()
+// The client IP is taken from X-Forwarded-For only if the request comes from one of trustedProxies.
+func (h *Handlers) Init(trustedProxies []string) (*gin.Engine, error) {
+	// the validator of gin is global, the bound requests of all the handlers are checked by it
+	binding.Validator = validation.New()
+
+	router := gin.New()
+	router.Use(gin.LoggerWithFormatter(logFormatter), gin.Recovery())
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, err
+	}
 	router.Use(sessions.Sessions("session", h.store))
 
 	docs.SwaggerInfo.BasePath = "/api/v1"
@@ -43,11 +70,38 @@ func (h *Handlers) Init() *gin.Engine {
 
 	apiV1 := router.Group("/api/v1")
 	{
-		handlersV1 := v1.NewHandler(h.service, h.store, h.logger)
+		handlersV1 := v1.NewHandler(h.service, h.store, h.logger, h.graphQL, h.events, h.keys)
 		handlersV1.Init(apiV1)
+
+		ws.NewHandler(h.service, h.rooms, h.logger).Init(apiV1.Group("/live"))
 	}
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 
-	return router
+	return router, nil
+}
+
+// logFormatter is the format of gin.Logger with the credentials in the query redacted,
+// like the access token of WebSocket that can't be sent in a header.
+func logFormatter(param gin.LogFormatterParams) string {
+	var statusColor, methodColor, resetColor string
+	if param.IsOutputColor() {
+		statusColor = param.StatusCodeColor()
+		methodColor = param.MethodColor()
+		resetColor = param.ResetColor()
+	}
+
+	if param.Latency > time.Minute {
+		param.Latency = param.Latency.Truncate(time.Second)
+	}
+
+	return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		statusColor, param.StatusCode, resetColor,
+		param.Latency,
+		param.ClientIP,
+		methodColor, param.Method, resetColor,
+		logger.RedactPath(param.Path),
+		param.ErrorMessage,
+	)
 }
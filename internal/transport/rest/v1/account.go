@@ -0,0 +1,147 @@
+// Package v1 defines the handlers for the 1 version.
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/apperror"
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+// GetProfile godoc
+// @Summary Get profile
+// @Security ApiKeyAuth
+// @Tags account
+// @Description Get the profile of the current user.
+// @ID get-profile
+// @Produce  json
+// @Success 200 {object} domain.Profile
+// @Failure 401,404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /me [get]
+func (h *Handlers) GetProfile(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	profile, err := h.service.Account.GetProfile(c, userID)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// UpdateProfile godoc
+// @Summary Update profile
+// @Security ApiKeyAuth
+// @Tags account
+// @Description Update the fields of the profile present in the body.
+// @Description The new email is changed after it is confirmed with the link sent to it, until then it is the pending_email.
+// @ID update-profile
+// @Accept  json
+// @Produce  json
+// @Param profile body domain.UpdateProfileRequest true "profile fields"
+// @Success 200 {object} domain.Profile
+// @Failure 400,401,403,404,409 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /me [patch]
+func (h *Handlers) UpdateProfile(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	var request domain.UpdateProfileRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	profile, err := h.service.Account.UpdateProfile(c, userID, request)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// ConfirmEmailChange godoc
+// @Summary Confirm email change
+// @Tags account
+// @Description Change the email of the user by the token sent to the new email.
+// @ID confirm-email-change
+// @Produce  json
+// @Param token query string true "token from the confirmation link"
+// @Success 200
+// @Failure 400,404,409 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /me/email/confirm [get]
+func (h *Handlers) ConfirmEmailChange(c *gin.Context) {
+	var request domain.ConfirmEmailRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	if err := h.service.Account.ConfirmEmailChange(c, request.Token); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// DeleteAccount godoc
+// @Summary Delete account
+// @Security ApiKeyAuth
+// @Tags account
+// @Description Delete the account of the current user. The tests, the passages and the refresh tokens
+// @Description are deleted or anonymized according to the account deletion policy.
+// @ID delete-account
+// @Produce  json
+// @Success 200
+// @Failure 401,403,404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /me [delete]
+func (h *Handlers) DeleteAccount(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	if err = h.service.Account.DeleteAccount(c, userID); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	if err = clearSession(c); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// clearSession removes the access token from the session and expires the refresh token cookie.
+func clearSession(c *gin.Context) error {
+	c.Header("Set-Cookie", "refresh-accessToken=; Path=/; Max-Age=0; HttpOnly")
+
+	session := sessions.Default(c)
+	session.Clear()
+	session.Options(sessions.Options{
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+
+	return session.Save()
+}
@@ -0,0 +1,209 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/util"
+)
+
+var emailChangeTokenPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+func TestHandlers_UpdateProfile(t *testing.T) {
+	ctx := context.Background()
+	u := randomUser()
+
+	helperCreatUser(t, ctx, u)
+	userID, err := findUserIDByEmail(u.Email)
+	if err != nil {
+		t.Fatalf("error finding user id: %v", err)
+	}
+
+	token, refreshToken, err := mockServices.Auth.SignIn(ctx, u)
+	if err != nil {
+		t.Fatalf("error generating accessToken: %v", err)
+	}
+
+	other := randomUser()
+	helperCreatUser(t, ctx, other)
+
+	newEmail := util.RandomString(10) + "@gmail.com"
+
+	tests := []struct {
+		name   string
+		input  map[string]interface{}
+		status int
+		check  func(t *testing.T, profile domain.Profile)
+	}{
+		{
+			name:   "Error: short name",
+			input:  map[string]interface{}{"name": "ab"},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Error: avatar is not an url",
+			input:  map[string]interface{}{"avatar_url": "javascript:alert(1)"},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Error: unknown timezone",
+			input:  map[string]interface{}{"timezone": "Mars/Olympus"},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Error: email of another user",
+			input:  map[string]interface{}{"email": other.Email},
+			status: http.StatusConflict,
+		},
+		{
+			name: "Success: update profile",
+			input: map[string]interface{}{
+				"name":       "New Name",
+				"avatar_url": "https://example.com/avatar.png",
+				"locale":     "en-us",
+				"timezone":   "Europe/Berlin",
+			},
+			status: http.StatusOK,
+			check: func(t *testing.T, profile domain.Profile) {
+				if profile.Name != "New Name" || profile.AvatarURL != "https://example.com/avatar.png" ||
+					profile.Locale != "en-US" || profile.Timezone != "Europe/Berlin" {
+					t.Errorf("profile is not updated: %+v", profile)
+				}
+			},
+		},
+		{
+			name:   "Success: change email waits for the confirmation",
+			input:  map[string]interface{}{"email": newEmail},
+			status: http.StatusOK,
+			check: func(t *testing.T, profile domain.Profile) {
+				if profile.Email != u.Email || profile.PendingEmail != newEmail {
+					t.Errorf("expected %s pending for %s, got %+v", newEmail, u.Email, profile)
+				}
+
+				if profile.Name != "New Name" {
+					t.Errorf("the fields missing in the body must not change: %+v", profile)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, _ := json.Marshal(tt.input)
+			req := httptest.NewRequest(http.MethodPatch, "/api/v1/me", bytes.NewReader(input))
+			req.Header.Set("Content-Type", "application/json")
+
+			r := gin.Default()
+			r.Use(sessions.Sessions("session", mockHandlers.store))
+			r.PATCH("/api/v1/me", setSessionMiddleware(t, token), mockHandlers.authMiddleware, mockHandlers.noDelegationMiddleware, mockHandlers.UpdateProfile)
+
+			testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+				if w.Code != tt.status {
+					t.Errorf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+					return false
+				}
+
+				if tt.check != nil {
+					var profile domain.Profile
+					if err := json.Unmarshal(w.Body.Bytes(), &profile); err != nil {
+						t.Errorf("error parsing profile: %v", err)
+						return false
+					}
+					tt.check(t, profile)
+				}
+
+				return true
+			})
+		})
+	}
+
+	t.Run("Success: confirm email change", func(t *testing.T) {
+		msg, ok := mockMailer.lastMessage(newEmail)
+		if !ok {
+			t.Fatalf("no confirmation sent to %s", newEmail)
+		}
+
+		changeToken := emailChangeTokenPattern.FindString(msg.Body)
+		r := gin.Default()
+		r.GET("/api/v1/me/email/confirm", mockHandlers.ConfirmEmailChange)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/me/email/confirm?token="+changeToken, nil)
+		testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+			return w.Code == http.StatusOK
+		})
+
+		req = httptest.NewRequest(http.MethodGet, "/api/v1/me/email/confirm?token="+changeToken, nil)
+		testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+			return w.Code == http.StatusNotFound
+		})
+
+		profile, err := mockServices.Account.GetProfile(ctx, userID)
+		if err != nil {
+			t.Fatalf("error getting profile: %v", err)
+		}
+
+		if profile.Email != newEmail || profile.PendingEmail != "" {
+			t.Errorf("expected email %s, got %+v", newEmail, profile)
+		}
+	})
+
+	t.Cleanup(func() {
+		helperDeleteUserByID(t, userID)
+		helperDeleteUserByEmail(t, other.Email)
+		helperDeleteRefreshTokenByToken(t, refreshToken)
+	})
+}
+
+func TestHandlers_DeleteAccount(t *testing.T) {
+	ctx := context.Background()
+	u := randomUser()
+
+	helperCreatUser(t, ctx, u)
+	userID, err := findUserIDByEmail(u.Email)
+	if err != nil {
+		t.Fatalf("error finding user id: %v", err)
+	}
+
+	token, refreshToken, err := mockServices.Auth.SignIn(ctx, u)
+	if err != nil {
+		t.Fatalf("error generating accessToken: %v", err)
+	}
+
+	testID := helperCreateTest(t, userID, domain.Test{Title: util.RandomString(10)})
+
+	r := gin.Default()
+	r.Use(sessions.Sessions("session", mockHandlers.store))
+	r.DELETE("/api/v1/me", setSessionMiddleware(t, token), mockHandlers.authMiddleware, mockHandlers.noDelegationMiddleware, mockHandlers.DeleteAccount)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/me", nil)
+	testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+		return w.Code == http.StatusOK
+	})
+
+	if _, err = findUserIDByEmail(u.Email); err == nil {
+		t.Error("the user is not deleted")
+	}
+
+	var count int
+	if err = mockDB.QueryRow("SELECT count(*) FROM tests WHERE id = $1", testID).Scan(&count); err != nil || count != 0 {
+		t.Errorf("the test of the user is not deleted: %d, %v", count, err)
+	}
+
+	if err = mockDB.QueryRow("SELECT count(*) FROM refresh_tokens WHERE token = $1", refreshToken).Scan(&count); err != nil || count != 0 {
+		t.Errorf("the refresh token of the user is not deleted: %d, %v", count, err)
+	}
+
+	t.Cleanup(func() {
+		helperDeleteTestByID(t, testID)
+		helperDeleteUserByID(t, userID)
+		helperDeleteRefreshTokenByToken(t, refreshToken)
+	})
+}
@@ -0,0 +1,357 @@
+// Package v1 defines the handlers for the 1 version.
+package v1
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/apperror"
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/lockout"
+)
+
+// SearchUsers godoc
+// @Summary Search users
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description Search the users by the name or the email, the role and the disabled state.
+// @ID search-users
+// @Produce  json
+// @Param q query string false "part of the name or the email"
+// @Param role query string false "role"
+// @Param disabled query bool false "disabled users only or enabled users only"
+// @Param limit query int false "limit, 20 by default"
+// @Param offset query int false "offset"
+// @Success 200 {array} domain.AdminUser
+// @Failure 400,401,403 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /admin/users [get]
+func (h *Handlers) SearchUsers(c *gin.Context) {
+	var request domain.SearchUsersRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	users, err := h.service.Admin.SearchUsers(c, request)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+// GetUser godoc
+// @Summary Get user
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description Get the user with the number of the active sessions. The view is recorded in the audit log.
+// @ID get-user
+// @Produce  json
+// @Param id path int true "user id"
+// @Success 200 {object} domain.AdminUser
+// @Failure 400,401,403,404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /admin/users/{id} [get]
+func (h *Handlers) GetUser(c *gin.Context) {
+	actorID, userID, ok := adminTarget(c)
+	if !ok {
+		return
+	}
+
+	u, err := h.service.Admin.GetUser(adminContext(c), actorID, userID)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, u)
+}
+
+// UpdateUserRole godoc
+// @Summary Assign role to user
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description Assign role to user. The role is applied to the tokens issued after the change.
+// @ID update-user-role
+// @Accept  json
+// @Produce  json
+// @Param id path int true "user id"
+// @Param role body domain.UpdateRoleRequest true "role"
+// @Success 200
+// @Failure 400,401,403,404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /admin/users/{id}/role [put]
+func (h *Handlers) UpdateUserRole(c *gin.Context) {
+	actorID, userID, ok := adminTarget(c)
+	if !ok {
+		return
+	}
+
+	var request domain.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	if err := h.service.Admin.UpdateUserRole(adminContext(c), actorID, userID, request.Role); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// DisableUser godoc
+// @Summary Disable user
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description Disable the user and delete the refresh tokens. The disabled user can't sign in,
+// @Description the access tokens issued before stay valid until they expire.
+// @ID disable-user
+// @Accept  json
+// @Produce  json
+// @Param id path int true "user id"
+// @Param input body domain.AdminActionRequest true "reason"
+// @Success 200
+// @Failure 400,401,403,404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /admin/users/{id}/disable [post]
+func (h *Handlers) DisableUser(c *gin.Context) {
+	actorID, userID, ok := adminTarget(c)
+	if !ok {
+		return
+	}
+
+	var request domain.AdminActionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	if err := h.service.Admin.DisableUser(adminContext(c), actorID, userID, request.Reason); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// EnableUser godoc
+// @Summary Enable user
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description Enable the disabled user.
+// @ID enable-user
+// @Produce  json
+// @Param id path int true "user id"
+// @Success 200
+// @Failure 400,401,403,404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /admin/users/{id}/enable [post]
+func (h *Handlers) EnableUser(c *gin.Context) {
+	actorID, userID, ok := adminTarget(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Admin.EnableUser(adminContext(c), actorID, userID); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// ForceLogout godoc
+// @Summary Force logout
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description Delete the refresh tokens of the user, the access tokens issued before stay valid until they expire.
+// @ID force-logout
+// @Produce  json
+// @Param id path int true "user id"
+// @Success 200 {object} domain.ForceLogoutResponse
+// @Failure 400,401,403,404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /admin/users/{id}/logout [post]
+func (h *Handlers) ForceLogout(c *gin.Context) {
+	actorID, userID, ok := adminTarget(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.service.Admin.ForceLogout(adminContext(c), actorID, userID)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.ForceLogoutResponse{Sessions: deleted})
+}
+
+// ResetUserPassword godoc
+// @Summary Reset password
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description Set a temporary password and delete the refresh tokens of the user.
+// @Description The password is returned only once.
+// @ID reset-user-password
+// @Accept  json
+// @Produce  json
+// @Param id path int true "user id"
+// @Param input body domain.AdminActionRequest true "reason"
+// @Success 200 {object} domain.ResetPasswordResponse
+// @Failure 400,401,403,404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /admin/users/{id}/password-reset [post]
+func (h *Handlers) ResetUserPassword(c *gin.Context) {
+	actorID, userID, ok := adminTarget(c)
+	if !ok {
+		return
+	}
+
+	var request domain.AdminActionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	temporaryPassword, err := h.service.Admin.ResetPassword(adminContext(c), actorID, userID, request.Reason)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, domain.ResetPasswordResponse{TemporaryPassword: temporaryPassword})
+}
+
+// ImpersonateUser godoc
+// @Summary Impersonate user
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description Get a short-lived access token to act as the user. There is no refresh token and the token
+// @Description can't manage the account of the user. Admins and disabled users can't be impersonated.
+// @ID impersonate-user
+// @Accept  json
+// @Produce  json
+// @Param id path int true "user id"
+// @Param input body domain.AdminActionRequest true "reason"
+// @Success 200 {object} domain.ImpersonationResponse
+// @Failure 400,401,403,404,409 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /admin/users/{id}/impersonate [post]
+func (h *Handlers) ImpersonateUser(c *gin.Context) {
+	actorID, userID, ok := adminTarget(c)
+	if !ok {
+		return
+	}
+
+	var request domain.AdminActionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	response, err := h.service.Admin.Impersonate(adminContext(c), actorID, userID, request.Reason)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteUser godoc
+// @Summary Delete user
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description Delete the account of the user with the configured deletion policy.
+// @ID delete-user
+// @Accept  json
+// @Produce  json
+// @Param id path int true "user id"
+// @Param input body domain.AdminActionRequest true "reason"
+// @Success 200
+// @Failure 400,401,403,404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /admin/users/{id} [delete]
+func (h *Handlers) DeleteUser(c *gin.Context) {
+	actorID, userID, ok := adminTarget(c)
+	if !ok {
+		return
+	}
+
+	var request domain.AdminActionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	if err := h.service.Admin.DeleteUser(adminContext(c), actorID, userID, request.Reason); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// GetAuditLog godoc
+// @Summary Get audit log
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description Get the actions of the operators, the latest first.
+// @ID get-audit-log
+// @Produce  json
+// @Param actor_id query int false "operator id"
+// @Param target_user_id query int false "user id"
+// @Param action query string false "action"
+// @Param limit query int false "limit, 20 by default"
+// @Param offset query int false "offset"
+// @Success 200 {array} domain.AuditEntry
+// @Failure 400,401,403 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /admin/audit-log [get]
+func (h *Handlers) GetAuditLog(c *gin.Context) {
+	var request domain.AuditLogRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	entries, err := h.service.Admin.GetAuditLog(c, request)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// adminTarget returns the id of the operator and the id of the user from the path.
+// If any of them is missing the error response is written and ok is false.
+func adminTarget(c *gin.Context) (actorID, userID int, ok bool) {
+	actorID, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return 0, 0, false
+	}
+
+	userID, err = strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return 0, 0, false
+	}
+
+	return actorID, userID, true
+}
+
+// adminContext returns the context of the request with the client IP recorded in the audit log.
+func adminContext(c *gin.Context) context.Context {
+	return lockout.WithClientIP(c.Request.Context(), c.ClientIP())
+}
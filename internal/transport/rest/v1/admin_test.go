@@ -0,0 +1,296 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/policy"
+)
+
+func TestHandlers_UpdateUserRole(t *testing.T) {
+	ctx := context.Background()
+	admin := randomUser()
+	author := randomUser()
+
+	helperCreatUser(t, ctx, admin)
+	helperCreatUser(t, ctx, author)
+
+	adminID, err := findUserIDByEmail(admin.Email)
+	if err != nil {
+		t.Fatalf("error finding user id: %v", err)
+	}
+	authorID, err := findUserIDByEmail(author.Email)
+	if err != nil {
+		t.Fatalf("error finding user id: %v", err)
+	}
+
+	if err = mockRepo.UpdateUserRole(ctx, adminID, domain.RoleAdmin); err != nil {
+		t.Fatalf("error updating role: %v", err)
+	}
+
+	adminToken, adminRefreshToken, err := mockServices.Auth.SignIn(ctx, admin)
+	if err != nil {
+		t.Fatalf("error generating accessToken: %v", err)
+	}
+	authorToken, authorRefreshToken, err := mockServices.Auth.SignIn(ctx, author)
+	if err != nil {
+		t.Fatalf("error generating accessToken: %v", err)
+	}
+
+	type args struct {
+		token string
+		id    int
+		input []byte
+	}
+	tests := []struct {
+		name   string
+		args   args
+		status int
+	}{
+		{
+			name: "Success: Admin assigns reviewer role",
+			args: args{
+				token: adminToken,
+				id:    authorID,
+				input: []byte(`{"role": "reviewer"}`),
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "Error: with unknown role",
+			args: args{
+				token: adminToken,
+				id:    authorID,
+				input: []byte(`{"role": "root"}`),
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "Error: user not found",
+			args: args{
+				token: adminToken,
+				id:    2134234234,
+				input: []byte(`{"role": "reviewer"}`),
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name: "Error: not an admin",
+			args: args{
+				token: authorToken,
+				id:    adminID,
+				input: []byte(`{"role": "learner"}`),
+			},
+			status: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/users/"+strconv.Itoa(tt.args.id)+"/role", bytes.NewReader(tt.args.input))
+			req.Header.Set("Content-Type", "application/json")
+
+			r := gin.Default()
+			r.Use(sessions.Sessions("session", mockHandlers.store))
+			r.PUT("/api/v1/admin/users/:id/role", setSessionMiddleware(t, tt.args.token), mockHandlers.authMiddleware, mockHandlers.permissionMiddleware(policy.ManageUsers), mockHandlers.UpdateUserRole)
+
+			testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+				return w.Code == tt.status
+			})
+		})
+	}
+
+	updated, err := mockServices.Auth.GetUserByID(ctx, authorID)
+	if err != nil {
+		t.Fatalf("error getting user: %v", err)
+	}
+	if updated.Role != domain.RoleReviewer {
+		t.Errorf("got role %v, want %v", updated.Role, domain.RoleReviewer)
+	}
+
+	t.Cleanup(func() {
+		helperDeleteUserByID(t, adminID)
+		helperDeleteUserByID(t, authorID)
+		helperDeleteRefreshTokenByToken(t, adminRefreshToken)
+		helperDeleteRefreshTokenByToken(t, authorRefreshToken)
+	})
+}
+
+func TestHandlers_AdminUserManagement(t *testing.T) {
+	ctx := context.Background()
+	admin := randomUser()
+	target := randomUser()
+
+	helperCreatUser(t, ctx, admin)
+	helperCreatUser(t, ctx, target)
+
+	adminID, err := findUserIDByEmail(admin.Email)
+	if err != nil {
+		t.Fatalf("error finding user id: %v", err)
+	}
+	targetID, err := findUserIDByEmail(target.Email)
+	if err != nil {
+		t.Fatalf("error finding user id: %v", err)
+	}
+
+	if err = mockRepo.UpdateUserRole(ctx, adminID, domain.RoleAdmin); err != nil {
+		t.Fatalf("error updating role: %v", err)
+	}
+
+	adminToken, adminRefreshToken, err := mockServices.Auth.SignIn(ctx, admin)
+	if err != nil {
+		t.Fatalf("error generating accessToken: %v", err)
+	}
+	_, targetRefreshToken, err := mockServices.Auth.SignIn(ctx, target)
+	if err != nil {
+		t.Fatalf("error generating accessToken: %v", err)
+	}
+
+	r := gin.Default()
+	r.Use(sessions.Sessions("session", mockHandlers.store))
+	adminAPI := r.Group("/api/v1/admin", setSessionMiddleware(t, adminToken), mockHandlers.authMiddleware, mockHandlers.permissionMiddleware(policy.ManageUsers))
+	adminAPI.GET("/users", mockHandlers.SearchUsers)
+	adminAPI.POST("/users/:id/disable", mockHandlers.DisableUser)
+	adminAPI.POST("/users/:id/enable", mockHandlers.EnableUser)
+	adminAPI.POST("/users/:id/logout", mockHandlers.ForceLogout)
+	adminAPI.POST("/users/:id/password-reset", mockHandlers.noDelegationMiddleware, mockHandlers.ResetUserPassword)
+	adminAPI.POST("/users/:id/impersonate", mockHandlers.noDelegationMiddleware, mockHandlers.ImpersonateUser)
+	adminAPI.GET("/audit-log", mockHandlers.GetAuditLog)
+
+	post := func(t *testing.T, path, body string, status int) []byte {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/"+strconv.Itoa(targetID)+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != status {
+			t.Fatalf("%s: expected status %d, got %d: %s", path, status, w.Code, w.Body.String())
+		}
+
+		return w.Body.Bytes()
+	}
+
+	t.Run("Success: search users", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/users?q="+url.QueryEscape(target.Email), nil)
+		testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+			var users []domain.AdminUser
+			if err := json.Unmarshal(w.Body.Bytes(), &users); err != nil {
+				return false
+			}
+
+			return w.Code == http.StatusOK && len(users) == 1 && users[0].ID == targetID && users[0].Sessions == 1
+		})
+	})
+
+	t.Run("Error: disable without reason", func(t *testing.T) {
+		post(t, "/disable", `{}`, http.StatusBadRequest)
+	})
+
+	t.Run("Success: disabled user can't sign in", func(t *testing.T) {
+		post(t, "/disable", `{"reason": "spam"}`, http.StatusOK)
+
+		if _, _, err := mockServices.Auth.SignIn(ctx, target); !errors.Is(err, domain.ErrUserDisabled) {
+			t.Errorf("expected ErrUserDisabled, got %v", err)
+		}
+
+		if _, _, err := mockServices.Auth.GenerateAccessRefreshTokens(ctx, targetRefreshToken); err == nil {
+			t.Error("the refresh token of the disabled user is not deleted")
+		}
+
+		post(t, "/impersonate", `{"reason": "support"}`, http.StatusConflict)
+		post(t, "/enable", ``, http.StatusOK)
+	})
+
+	t.Run("Success: impersonation token can't manage the account", func(t *testing.T) {
+		var response domain.ImpersonationResponse
+		if err := json.Unmarshal(post(t, "/impersonate", `{"reason": "support ticket"}`, http.StatusOK), &response); err != nil {
+			t.Fatalf("error parsing response: %v", err)
+		}
+
+		payload, err := mockServices.Auth.VerifyToken(ctx, response.AccessToken)
+		if err != nil {
+			t.Fatalf("error verifying token: %v", err)
+		}
+
+		if payload.UserID != targetID || payload.ImpersonatorID != adminID {
+			t.Errorf("unexpected impersonation payload: %+v", payload)
+		}
+
+		me := gin.Default()
+		me.DELETE("/api/v1/me", mockHandlers.authMiddleware, mockHandlers.noDelegationMiddleware, mockHandlers.DeleteAccount)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/me", nil)
+		req.Header.Set(authorizationHeader, "Bearer "+response.AccessToken)
+		testHTTPResponse(t, me, req, func(w *httptest.ResponseRecorder) bool {
+			return w.Code == http.StatusForbidden
+		})
+	})
+
+	t.Run("Success: reset password", func(t *testing.T) {
+		var response domain.ResetPasswordResponse
+		if err := json.Unmarshal(post(t, "/password-reset", `{"reason": "locked out"}`, http.StatusOK), &response); err != nil {
+			t.Fatalf("error parsing response: %v", err)
+		}
+
+		_, refreshToken, err := mockServices.Auth.SignIn(ctx, domain.User{Email: target.Email, Password: response.TemporaryPassword})
+		if err != nil {
+			t.Fatalf("error signing in with the temporary password: %v", err)
+		}
+
+		var logout domain.ForceLogoutResponse
+		if err = json.Unmarshal(post(t, "/logout", ``, http.StatusOK), &logout); err != nil || logout.Sessions != 1 {
+			t.Errorf("expected 1 deleted session, got %+v, %v", logout, err)
+		}
+
+		helperDeleteRefreshTokenByToken(t, refreshToken)
+	})
+
+	t.Run("Success: actions are recorded in the audit log", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/audit-log?target_user_id="+strconv.Itoa(targetID), nil)
+		testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+			var entries []domain.AuditEntry
+			if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+				return false
+			}
+
+			actions := make([]domain.AuditAction, 0, len(entries))
+			for _, e := range entries {
+				actions = append(actions, e.Action)
+			}
+
+			want := []domain.AuditAction{
+				domain.AuditForceLogout, domain.AuditResetPassword, domain.AuditImpersonate, domain.AuditEnableUser, domain.AuditDisableUser,
+			}
+			if !reflect.DeepEqual(actions, want) {
+				t.Errorf("got actions %v, want %v", actions, want)
+				return false
+			}
+
+			return *entries[0].ActorID == adminID
+		})
+	})
+
+	t.Cleanup(func() {
+		if _, err := mockDB.Exec("DELETE FROM audit_log WHERE actor_id = $1", adminID); err != nil {
+			t.Errorf("error deleting audit log: %v", err)
+		}
+		helperDeleteUserByID(t, adminID)
+		helperDeleteUserByID(t, targetID)
+		helperDeleteRefreshTokenByToken(t, adminRefreshToken)
+		helperDeleteRefreshTokenByToken(t, targetRefreshToken)
+	})
+}
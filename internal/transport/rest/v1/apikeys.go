@@ -0,0 +1,108 @@
+// Package v1 defines the handlers for the 1 version.
+package v1
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/apperror"
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+// CreateAPIKey godoc
+// @Summary Create API key
+// @Security ApiKeyAuth
+// @Tags api-keys
+// @Description Create personal API key with scopes. The key is shown only once.
+// @ID create-api-key
+// @Accept  json
+// @Produce  json
+// @Param key body domain.CreateAPIKeyRequest true "api key"
+// @Success 201 {object} domain.CreatedAPIKey
+// @Failure 400,401,403 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /api-keys [post]
+func (h *Handlers) CreateAPIKey(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	var request domain.CreateAPIKeyRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	key, err := h.service.APIKeys.CreateAPIKey(c, userID, request)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// GetAPIKeys godoc
+// @Summary Get API keys
+// @Security ApiKeyAuth
+// @Tags api-keys
+// @Description Get all API keys of the user including revoked ones.
+// @ID get-api-keys
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} domain.APIKey
+// @Failure 401,403 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /api-keys [get]
+func (h *Handlers) GetAPIKeys(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	keys, err := h.service.APIKeys.GetAPIKeys(c, userID)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke API key
+// @Security ApiKeyAuth
+// @Tags api-keys
+// @Description Revoke API key. The key stops working immediately.
+// @ID revoke-api-key
+// @Accept  json
+// @Produce  json
+// @Param id path int true "api key id"
+// @Success 200
+// @Failure 400,401,403,404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /api-keys/{id} [delete]
+func (h *Handlers) RevokeAPIKey(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	keyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	if err = h.service.APIKeys.RevokeAPIKey(c, userID, keyID); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,162 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/policy"
+)
+
+func TestHandlers_CreateAPIKey(t *testing.T) {
+	ctx := context.Background()
+	u := randomUser()
+
+	helperCreatUser(t, ctx, u)
+	userID, err := findUserIDByEmail(u.Email)
+	if err != nil {
+		t.Fatalf("error finding user id: %v", err)
+	}
+
+	token, refreshToken, err := mockServices.Auth.SignIn(ctx, u)
+	if err != nil {
+		t.Fatalf("error generating accessToken: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		input  []byte
+		status int
+	}{
+		{
+			name:   "Success: create api key",
+			input:  []byte(`{"name": "ci", "scopes": ["tests:read"]}`),
+			status: http.StatusCreated,
+		},
+		{
+			name:   "Error: key can't manage keys",
+			input:  []byte(`{"name": "ci", "scopes": ["api-keys:manage"]}`),
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Error: without scopes",
+			input:  []byte(`{"name": "ci", "scopes": []}`),
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/api-keys", bytes.NewReader(tt.input))
+			req.Header.Set("Content-Type", "application/json")
+
+			r := gin.Default()
+			r.Use(sessions.Sessions("session", mockHandlers.store))
+			r.POST("/api/v1/api-keys", setSessionMiddleware(t, token), mockHandlers.authMiddleware, mockHandlers.permissionMiddleware(policy.ManageAPIKeys), mockHandlers.CreateAPIKey)
+
+			testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+				return w.Code == tt.status
+			})
+		})
+	}
+
+	t.Cleanup(func() {
+		helperDeleteUserByID(t, userID)
+		helperDeleteRefreshTokenByToken(t, refreshToken)
+	})
+}
+
+func TestHandlers_AuthenticateAPIKey(t *testing.T) {
+	ctx := context.Background()
+	u := randomUser()
+
+	helperCreatUser(t, ctx, u)
+	userID, err := findUserIDByEmail(u.Email)
+	if err != nil {
+		t.Fatalf("error finding user id: %v", err)
+	}
+
+	key, err := mockServices.APIKeys.CreateAPIKey(ctx, userID, domain.CreateAPIKeyRequest{
+		Name:   "ci",
+		Scopes: []string{string(policy.ReadTest)},
+	})
+	if err != nil {
+		t.Fatalf("error creating api key: %v", err)
+	}
+
+	newRouter := func() *gin.Engine {
+		r := gin.Default()
+		r.Use(sessions.Sessions("session", mockHandlers.store))
+		r.GET("/api/v1/tests", mockHandlers.authMiddleware, mockHandlers.permissionMiddleware(policy.ReadTest), mockHandlers.GetAllTestsByUserID)
+		r.POST("/api/v1/tests", mockHandlers.authMiddleware, mockHandlers.permissionMiddleware(policy.CreateTest), mockHandlers.CreateTest)
+		return r
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		header string
+		value  string
+		status int
+	}{
+		{
+			name:   "Success: X-API-Key header",
+			method: http.MethodGet,
+			header: "X-API-Key",
+			value:  key.Key,
+			status: http.StatusOK,
+		},
+		{
+			name:   "Success: bearer api key",
+			method: http.MethodGet,
+			header: "Authorization",
+			value:  "Bearer " + key.Key,
+			status: http.StatusOK,
+		},
+		{
+			name:   "Error: out of key scopes",
+			method: http.MethodPost,
+			header: "X-API-Key",
+			value:  key.Key,
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "Error: unknown key",
+			method: http.MethodGet,
+			header: "X-API-Key",
+			value:  "qna_unknown",
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/v1/tests", bytes.NewReader([]byte(`{"title": "test"}`)))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(tt.header, tt.value)
+
+			testHTTPResponse(t, newRouter(), req, func(w *httptest.ResponseRecorder) bool {
+				return w.Code == tt.status
+			})
+		})
+	}
+
+	if err = mockServices.APIKeys.RevokeAPIKey(ctx, userID, key.ID); err != nil {
+		t.Fatalf("error revoking api key: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tests", nil)
+	req.Header.Set("X-API-Key", key.Key)
+	testHTTPResponse(t, newRouter(), req, func(w *httptest.ResponseRecorder) bool {
+		return w.Code == http.StatusUnauthorized
+	})
+
+	t.Cleanup(func() {
+		helperDeleteUserByID(t, userID)
+	})
+}
@@ -0,0 +1,217 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/apperror"
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+// batchItemError is the problem of a failed operation of a batch.
+type batchItemError struct {
+	Code    string                 `json:"code"`
+	Detail  string                 `json:"detail"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// batchItem is the result of an operation of a batch. Status is the status the operation would have on its own.
+type batchItem struct {
+	Index    int              `json:"index"`
+	ID       int              `json:"id,omitempty"`
+	Status   int              `json:"status"`
+	Test     *domain.Test     `json:"test,omitempty"`
+	Question *domain.Question `json:"question,omitempty"`
+	Error    *batchItemError  `json:"error,omitempty"`
+}
+
+// batchResponse lists the results of the operations of a batch in the order of the request.
+type batchResponse struct {
+	Mode      domain.BatchMode `json:"mode"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Create    []batchItem      `json:"create"`
+	Update    []batchItem      `json:"update"`
+	Delete    []batchItem      `json:"delete"`
+}
+
+// newBatchItem is the result of the operation with the index, a failed operation has the status of its error.
+func newBatchItem(index, id, status int, err error) batchItem {
+	item := batchItem{Index: index, ID: id, Status: status}
+	if err != nil {
+		appErr := toAppError(err)
+		item.Status = appErr.Status
+		item.Error = &batchItemError{Code: appErr.Code, Detail: appErr.Message, Details: appErr.Details}
+	}
+
+	return item
+}
+
+// count sums up the succeeded and the failed operations.
+func (r *batchResponse) count() {
+	for _, items := range [][]batchItem{r.Create, r.Update, r.Delete} {
+		for _, item := range items {
+			if item.Error != nil {
+				r.Failed++
+			} else {
+				r.Succeeded++
+			}
+		}
+	}
+}
+
+// status is 200 if all the operations succeeded, 207 otherwise.
+func (r *batchResponse) status() int {
+	if r.Failed > 0 {
+		return http.StatusMultiStatus
+	}
+
+	return http.StatusOK
+}
+
+func newTestBatchResponse(mode domain.BatchMode, result domain.TestBatchResult) batchResponse {
+	response := batchResponse{
+		Mode:   mode,
+		Create: make([]batchItem, len(result.Create)),
+		Update: make([]batchItem, len(result.Update)),
+		Delete: make([]batchItem, len(result.Delete)),
+	}
+
+	for i, created := range result.Create {
+		response.Create[i] = newBatchItem(i, created.Test.ID, http.StatusCreated, created.Err)
+		if created.Err == nil {
+			test := created.Test
+			response.Create[i].Test = &test
+		}
+	}
+	for i, updated := range result.Update {
+		response.Update[i] = newBatchItem(i, updated.Test.ID, http.StatusOK, updated.Err)
+		if updated.Err == nil {
+			test := updated.Test
+			response.Update[i].Test = &test
+		}
+	}
+	for i, deleted := range result.Delete {
+		response.Delete[i] = newBatchItem(i, deleted.Test.ID, http.StatusOK, deleted.Err)
+	}
+	response.count()
+
+	return response
+}
+
+func newQuestionBatchResponse(mode domain.BatchMode, result domain.QuestionBatchResult) batchResponse {
+	response := batchResponse{
+		Mode:   mode,
+		Create: make([]batchItem, len(result.Create)),
+		Update: make([]batchItem, len(result.Update)),
+		Delete: make([]batchItem, len(result.Delete)),
+	}
+
+	for i, created := range result.Create {
+		response.Create[i] = newBatchItem(i, created.Question.ID, http.StatusCreated, created.Err)
+		if created.Err == nil {
+			question := created.Question
+			response.Create[i].Question = &question
+		}
+	}
+	for i, updated := range result.Update {
+		response.Update[i] = newBatchItem(i, updated.Question.ID, http.StatusOK, updated.Err)
+		if updated.Err == nil {
+			question := updated.Question
+			response.Update[i].Question = &question
+		}
+	}
+	for i, deleted := range result.Delete {
+		response.Delete[i] = newBatchItem(i, deleted.Question.ID, http.StatusOK, deleted.Err)
+	}
+	response.count()
+
+	return response
+}
+
+// ApplyTestBatch godoc
+// @Summary Create, update and delete tests at once
+// @Security ApiKeyAuth
+// @Tags tests
+// @Description Create, update and delete up to 500 tests at once. The atomic mode applies all the operations or none,
+// @Description the partial mode applies the operations that can be applied. The status of every operation is in the response.
+// @ID apply-test-batch
+// @Accept  json
+// @Produce  json
+// @Param batch body domain.TestBatchRequest true "operations"
+// @Param Idempotency-Key header string false "key of the request, its retries get the same response"
+// @Success 200,207 {object} batchResponse
+// @Failure 400,401,413,422 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /tests/batch [post]
+func (h *Handlers) ApplyTestBatch(c *gin.Context) {
+	subject, err := getSubject(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	var request domain.TestBatchRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	batch := request.Batch()
+	result, err := h.service.Tests.ApplyTestBatch(c, subject, batch)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	response := newTestBatchResponse(batch.Mode, result)
+	c.JSON(response.status(), response)
+}
+
+// ApplyQuestionBatch godoc
+// @Summary Create, update and delete questions of the test at once
+// @Security ApiKeyAuth
+// @Tags tests
+// @Description Create, update and delete up to 500 questions of the test at once, the answers of the deleted questions
+// @Description are deleted too. The atomic mode applies all the operations or none, the partial mode applies the operations
+// @Description that can be applied. The status of every operation is in the response.
+// @ID apply-question-batch
+// @Accept  json
+// @Produce  json
+// @Param id path int true "test id"
+// @Param batch body domain.QuestionBatchRequest true "operations"
+// @Param Idempotency-Key header string false "key of the request, its retries get the same response"
+// @Success 200,207 {object} batchResponse
+// @Failure 400,401,403,404,413,422 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /tests/{id}/questions/batch [post]
+func (h *Handlers) ApplyQuestionBatch(c *gin.Context) {
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	subject, err := getSubject(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	var request domain.QuestionBatchRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	batch := request.Batch(testID)
+	result, err := h.service.Tests.ApplyQuestionBatch(c, subject, batch)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	response := newQuestionBatchResponse(batch.Mode, result)
+	c.JSON(response.status(), response)
+}
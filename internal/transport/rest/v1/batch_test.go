@@ -0,0 +1,80 @@
+package v1
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/popeskul/qna-go/internal/apperror"
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/policy"
+	"github.com/popeskul/qna-go/internal/repository/tests"
+)
+
+func TestNewTestBatchResponse(t *testing.T) {
+	cases := []struct {
+		name      string
+		result    domain.TestBatchResult
+		status    int
+		succeeded int
+		failed    int
+		items     []batchItem
+	}{
+		{
+			name: "all succeeded",
+			result: domain.TestBatchResult{
+				Create: []domain.TestResult{{Test: domain.Test{ID: 3, Title: "created"}}},
+				Delete: []domain.TestResult{{Test: domain.Test{ID: 1}}},
+			},
+			status:    http.StatusOK,
+			succeeded: 2,
+			items:     []batchItem{{Index: 0, ID: 3, Status: http.StatusCreated}, {Index: 0, ID: 1, Status: http.StatusOK}},
+		},
+		{
+			name: "some failed",
+			result: domain.TestBatchResult{
+				Update: []domain.TestResult{
+					{Test: domain.Test{ID: 1, Title: "updated"}},
+					{Test: domain.Test{ID: 2}, Err: tests.ErrVersionConflict},
+				},
+				Delete: []domain.TestResult{{Test: domain.Test{ID: 4}, Err: policy.ErrForbidden}},
+			},
+			status:    http.StatusMultiStatus,
+			succeeded: 1,
+			failed:    2,
+			items: []batchItem{
+				{Index: 0, ID: 1, Status: http.StatusOK},
+				{Index: 1, ID: 2, Status: http.StatusPreconditionFailed, Error: &batchItemError{Code: codePreconditionFailed}},
+				{Index: 0, ID: 4, Status: http.StatusForbidden, Error: &batchItemError{Code: apperror.CodeForbidden}},
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			response := newTestBatchResponse(domain.BatchPartial, tt.result)
+
+			if response.status() != tt.status || response.Succeeded != tt.succeeded || response.Failed != tt.failed {
+				t.Errorf("response = %+v, want status %d, %d succeeded and %d failed", response, tt.status, tt.succeeded, tt.failed)
+			}
+
+			items := append(append(append([]batchItem{}, response.Create...), response.Update...), response.Delete...)
+			for _, item := range items[:len(response.Create)+len(response.Update)] {
+				if (item.Test == nil) != (item.Error != nil) {
+					t.Errorf("item = %+v, want the test of the succeeded operation only", item)
+				}
+			}
+			if len(items) != len(tt.items) {
+				t.Fatalf("items = %+v, want %+v", items, tt.items)
+			}
+			for i, want := range tt.items {
+				got := items[i]
+				if got.Index != want.Index || got.ID != want.ID || got.Status != want.Status {
+					t.Errorf("item %d = %+v, want %+v", i, got, want)
+				}
+				if (got.Error == nil) != (want.Error == nil) || (want.Error != nil && got.Error.Code != want.Error.Code) {
+					t.Errorf("item %d error = %+v, want %+v", i, got.Error, want.Error)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package v1
+
+import (
+	"crypto/subtle"
+	"errors"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/oidc"
+)
+
+const (
+	csrfTokenName   = "csrf-token"
+	csrfTokenHeader = "X-CSRF-Token"
+)
+
+var ErrInvalidCSRFToken = errors.New("the X-CSRF-Token header doesn't match the token of the session")
+
+// csrfToken returns the CSRF token of the session, the first call issues it.
+// The token lives as long as the session, signing out clears it.
+func csrfToken(c *gin.Context) (string, error) {
+	session := sessions.Default(c)
+	if token, ok := session.Get(csrfTokenName).(string); ok && token != "" {
+		return token, nil
+	}
+
+	token, err := oidc.RandomString(32)
+	if err != nil {
+		return "", err
+	}
+
+	session.Set(csrfTokenName, token)
+
+	return token, session.Save()
+}
+
+// checkCSRFToken returns ErrInvalidCSRFToken unless the X-CSRF-Token header is the token of the session.
+// The cross-site pages can send the session cookie but can't read the token to send it back.
+func checkCSRFToken(c *gin.Context) error {
+	expected, _ := sessions.Default(c).Get(csrfTokenName).(string)
+	if expected == "" || subtle.ConstantTimeCompare([]byte(c.GetHeader(csrfTokenHeader)), []byte(expected)) != 1 {
+		return ErrInvalidCSRFToken
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/logger"
+	"github.com/popeskul/qna-go/internal/services"
+	"github.com/popeskul/qna-go/internal/token"
+)
+
+func TestCSRFToken(t *testing.T) {
+	r := gin.New()
+	r.Use(sessions.Sessions("session", cookie.NewStore([]byte("secret"))))
+	r.GET("/token", func(c *gin.Context) {
+		token, err := csrfToken(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.String(http.StatusOK, token)
+	})
+	r.POST("/check", func(c *gin.Context) {
+		if err := checkCSRFToken(c); err != nil {
+			c.Status(http.StatusForbidden)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	issue := func(cookies []*http.Cookie) (string, []*http.Cookie) {
+		req := httptest.NewRequest(http.MethodGet, "/token", nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if len(w.Result().Cookies()) > 0 {
+			cookies = w.Result().Cookies()
+		}
+
+		return w.Body.String(), cookies
+	}
+
+	first, session := issue(nil)
+	if again, _ := issue(session); again != first {
+		t.Errorf("csrfToken() = %s, want the token of the session %s", again, first)
+	}
+	other, _ := issue(nil)
+
+	cases := []struct {
+		name    string
+		cookies []*http.Cookie
+		header  string
+		status  int
+	}{
+		{name: "Success: the token of the session", cookies: session, header: first, status: http.StatusOK},
+		{name: "Fail: without the header", cookies: session, status: http.StatusForbidden},
+		{name: "Fail: the token of another session", cookies: session, header: other, status: http.StatusForbidden},
+		{name: "Fail: without the session", header: first, status: http.StatusForbidden},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/check", nil)
+			for _, c := range tt.cookies {
+				req.AddCookie(c)
+			}
+			if tt.header != "" {
+				req.Header.Set(csrfTokenHeader, tt.header)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestHandlers_ApproveOAuthConsentCSRF(t *testing.T) {
+	h := NewHandler(&services.Service{}, nil, logger.GetLogger(), nil, nil, nil)
+
+	r := gin.New()
+	r.Use(sessions.Sessions("session", cookie.NewStore([]byte("secret"))))
+	r.POST("/api/v1/oauth/authorize", func(c *gin.Context) {
+		c.Set(authorizationPayloadKey, &token.Payload{UserID: 1})
+	}, h.ApproveOAuthConsent)
+
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		code        string
+	}{
+		{name: "Fail: the form of a cross-site page", contentType: "application/x-www-form-urlencoded", body: "approve=true", status: http.StatusUnsupportedMediaType, code: codeUnsupportedMediaType},
+		{name: "Fail: text/plain", contentType: "text/plain", body: `{"approve": true}`, status: http.StatusUnsupportedMediaType, code: codeUnsupportedMediaType},
+		{name: "Fail: JSON without the CSRF token", contentType: "application/json", body: `{"approve": true}`, status: http.StatusForbidden, code: codeCSRFTokenInvalid},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/oauth/authorize", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status || !strings.Contains(w.Body.String(), `"code":"`+tt.code+`"`) {
+				t.Errorf("response = %d %s, want %d with code %s", w.Code, w.Body.String(), tt.status, tt.code)
+			}
+		})
+	}
+}
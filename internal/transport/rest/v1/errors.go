@@ -0,0 +1,213 @@
+package v1
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/popeskul/qna-go/internal/apperror"
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/idempotency"
+	"github.com/popeskul/qna-go/internal/live"
+	"github.com/popeskul/qna-go/internal/lockout"
+	"github.com/popeskul/qna-go/internal/oidc"
+	"github.com/popeskul/qna-go/internal/policy"
+	"github.com/popeskul/qna-go/internal/repository/admin"
+	"github.com/popeskul/qna-go/internal/repository/apikeys"
+	"github.com/popeskul/qna-go/internal/repository/exports"
+	"github.com/popeskul/qna-go/internal/repository/identities"
+	"github.com/popeskul/qna-go/internal/repository/members"
+	"github.com/popeskul/qna-go/internal/repository/oauth"
+	"github.com/popeskul/qna-go/internal/repository/questions"
+	"github.com/popeskul/qna-go/internal/repository/tests"
+	"github.com/popeskul/qna-go/internal/repository/user"
+	"github.com/popeskul/qna-go/internal/services"
+	"github.com/popeskul/qna-go/internal/services/account"
+	adminService "github.com/popeskul/qna-go/internal/services/admin"
+	apikeysService "github.com/popeskul/qna-go/internal/services/apikeys"
+	"github.com/popeskul/qna-go/internal/services/auth"
+	exportsService "github.com/popeskul/qna-go/internal/services/exports"
+	oauthService "github.com/popeskul/qna-go/internal/services/oauth"
+	testsService "github.com/popeskul/qna-go/internal/services/tests"
+	"github.com/popeskul/qna-go/internal/token"
+)
+
+// The codes of the errors, the clients rely on them so they must never change.
+const (
+	codeTokenMissing              = "token_missing"
+	codeTokenInvalid              = "token_invalid"
+	codeTokenExpired              = "token_expired"
+	codeInvalidAuthHeader         = "invalid_authorization_header"
+	codeAPIKeyInvalid             = "api_key_invalid"
+	codeAPIKeyInactive            = "api_key_inactive"
+	codeInvalidCredentials        = "invalid_credentials"
+	codeRefreshTokenInvalid       = "refresh_token_invalid"
+	codeTwoFactorCodeInvalid      = "two_factor_code_invalid"
+	codeTwoFactorChallengeInvalid = "two_factor_challenge_invalid"
+	codeLoginStateInvalid         = "login_state_invalid"
+	codeIDTokenInvalid            = "id_token_invalid"
+	codeCodeExchangeFailed        = "code_exchange_failed"
+	codeProviderDenied            = "provider_denied"
+
+	codeDelegatedCredential = "delegated_credential"
+	codeUserDisabled        = "user_disabled"
+	codeWrongPassword       = "wrong_password"
+	codeEmailNotVerified    = "email_not_verified"
+	codeEmailRequired       = "email_required"
+	codeImpersonateAdmin    = "impersonate_admin"
+	codeDownloadLinkInvalid = "download_link_invalid"
+	codeCSRFTokenInvalid    = "csrf_token_invalid"
+
+	codeTestNotFound         = "test_not_found"
+	codeMemberNotFound       = "member_not_found"
+	codeQuestionNotFound     = "question_not_found"
+	codeUserNotFound         = "user_not_found"
+	codeEmailChangeNotFound  = "email_change_not_found"
+	codeProviderNotFound     = "provider_not_found"
+	codeIdentityNotFound     = "identity_not_found"
+	codeAPIKeyNotFound       = "api_key_not_found"
+	codeExportNotFound       = "export_not_found"
+	codeArchiveNotFound      = "export_archive_not_found"
+	codeOAuthClientNotFound  = "oauth_client_not_found"
+	codePublicKeysNotFound   = "public_keys_not_found"
+	codeEmailTaken           = "email_taken"
+	codeTwoFactorEnabled     = "two_factor_enabled"
+	codeIdentityLinked       = "identity_linked"
+	codeAccountExists        = "account_exists"
+	codeLastSignInMethod     = "last_sign_in_method"
+	codeImpersonateDisabled  = "impersonate_disabled"
+	codeTwoFactorNotEnrolled = "two_factor_not_enrolled"
+	codeTwoFactorNotEnabled  = "two_factor_not_enabled"
+
+	codeValidationFailed   = "validation_failed"
+	codePasswordPolicy     = "password_policy"
+	codeInvalidName        = "invalid_name"
+	codeInvalidEmail       = "invalid_email"
+	codeInvalidAvatarURL   = "invalid_avatar_url"
+	codeInvalidLocale      = "invalid_locale"
+	codeInvalidTimezone    = "invalid_timezone"
+	codeEmailChangeExpired = "email_change_expired"
+	codeInvalidRole        = "invalid_role"
+	codeSelfAction         = "self_action"
+	codeInvalidMemberRole  = "invalid_member_role"
+	codeOwnerAsMember      = "owner_as_member"
+	codeInvalidScope       = "invalid_scope"
+	codeExpiresInPast      = "expires_in_past"
+	codeInvalidRedirectURI = "invalid_redirect_uri"
+	codeTooManyAttempts    = "too_many_attempts"
+
+	codeUnsupportedMediaType = "unsupported_media_type"
+	codePreconditionFailed   = "precondition_failed"
+	codePreconditionRequired = "precondition_required"
+
+	codeIdempotencyKeyInProgress = "idempotency_key_in_progress"
+	codeIdempotencyKeyReused     = "idempotency_key_reused"
+
+	codeBatchEmpty         = "batch_empty"
+	codeBatchTooLarge      = "batch_too_large"
+	codeDuplicateBatchItem = "duplicate_batch_item"
+	codeBatchRolledBack    = "batch_rolled_back"
+
+	codeRoomNotFound    = "room_not_found"
+	codeRoomStarted     = "room_started"
+	codeRoomFull        = "room_full"
+	codeNicknameTaken   = "nickname_taken"
+	codeInvalidNickname = "invalid_nickname"
+	codeEmptyQuiz       = "empty_quiz"
+)
+
+// errorRules map the errors of the services and the repositories to the application errors,
+// the first matching rule wins and the errors without a rule are internal.
+var errorRules = []apperror.Rule{
+	{Err: services.ErrUserIdNotFound, Code: apperror.CodeUnauthorized, Status: http.StatusUnauthorized},
+	{Err: services.ErrTokenNotFound, Code: codeTokenMissing, Status: http.StatusUnauthorized},
+	{Err: services.ErrAuthEmptyToken, Code: codeTokenMissing, Status: http.StatusUnauthorized},
+	{Err: services.ErrInvalidAuthHeader, Code: codeInvalidAuthHeader, Status: http.StatusUnauthorized},
+	{Err: token.ErrExpiredToken, Code: codeTokenExpired, Status: http.StatusUnauthorized},
+	{Err: token.ErrInvalidToken, Code: codeTokenInvalid, Status: http.StatusUnauthorized},
+	{Err: apikeysService.ErrInvalidAPIKey, Code: codeAPIKeyInvalid, Status: http.StatusUnauthorized},
+	{Err: apikeysService.ErrAPIKeyNotActive, Code: codeAPIKeyInactive, Status: http.StatusUnauthorized},
+	{Err: auth.ErrSignIn, Code: codeInvalidCredentials, Status: http.StatusUnauthorized},
+	{Err: auth.ErrInvalidRefreshToken, Code: codeRefreshTokenInvalid, Status: http.StatusUnauthorized},
+	{Err: auth.ErrInvalidTwoFactorCode, Code: codeTwoFactorCodeInvalid, Status: http.StatusUnauthorized},
+	{Err: auth.ErrInvalidChallenge, Code: codeTwoFactorChallengeInvalid, Status: http.StatusUnauthorized},
+	{Err: auth.ErrInvalidLoginState, Code: codeLoginStateInvalid, Status: http.StatusUnauthorized},
+	{Err: oidc.ErrInvalidIDToken, Code: codeIDTokenInvalid, Status: http.StatusUnauthorized},
+	{Err: oidc.ErrExchange, Code: codeCodeExchangeFailed, Status: http.StatusUnauthorized},
+	{Err: ErrProviderDenied, Code: codeProviderDenied, Status: http.StatusUnauthorized},
+
+	{Err: policy.ErrForbidden, Code: apperror.CodeForbidden, Status: http.StatusForbidden},
+	{Err: ErrDelegatedCredential, Code: codeDelegatedCredential, Status: http.StatusForbidden},
+	{Err: domain.ErrUserDisabled, Code: codeUserDisabled, Status: http.StatusForbidden},
+	{Err: auth.ErrPassword, Code: codeWrongPassword, Status: http.StatusForbidden},
+	{Err: auth.ErrEmailNotVerified, Code: codeEmailNotVerified, Status: http.StatusForbidden},
+	{Err: auth.ErrEmailRequired, Code: codeEmailRequired, Status: http.StatusForbidden},
+	{Err: adminService.ErrImpersonateAdmin, Code: codeImpersonateAdmin, Status: http.StatusForbidden},
+	{Err: exportsService.ErrInvalidLink, Code: codeDownloadLinkInvalid, Status: http.StatusForbidden},
+	{Err: ErrInvalidCSRFToken, Code: codeCSRFTokenInvalid, Status: http.StatusForbidden},
+
+	{Err: tests.ErrTestNotFound, Code: codeTestNotFound, Status: http.StatusNotFound},
+	{Err: tests.ErrTest, Code: codeTestNotFound, Status: http.StatusNotFound, Message: tests.ErrTestNotFound.Error()},
+	{Err: members.ErrMemberNotFound, Code: codeMemberNotFound, Status: http.StatusNotFound},
+	{Err: questions.ErrQuestionNotFound, Code: codeQuestionNotFound, Status: http.StatusNotFound},
+	{Err: user.ErrUserNotFound, Code: codeUserNotFound, Status: http.StatusNotFound},
+	{Err: admin.ErrUserNotFound, Code: codeUserNotFound, Status: http.StatusNotFound},
+	{Err: user.ErrUpdateUser, Code: codeUserNotFound, Status: http.StatusNotFound, Message: user.ErrUserNotFound.Error()},
+	{Err: user.ErrDeleteUser, Code: codeUserNotFound, Status: http.StatusNotFound, Message: user.ErrUserNotFound.Error()},
+	{Err: user.ErrEmailChangeNotFound, Code: codeEmailChangeNotFound, Status: http.StatusNotFound},
+	{Err: auth.ErrUnknownProvider, Code: codeProviderNotFound, Status: http.StatusNotFound},
+	{Err: identities.ErrIdentityNotFound, Code: codeIdentityNotFound, Status: http.StatusNotFound},
+	{Err: apikeys.ErrAPIKeyNotFound, Code: codeAPIKeyNotFound, Status: http.StatusNotFound},
+	{Err: exports.ErrExportNotFound, Code: codeExportNotFound, Status: http.StatusNotFound},
+	{Err: exports.ErrArchiveNotFound, Code: codeArchiveNotFound, Status: http.StatusNotFound},
+	{Err: oauth.ErrClientNotFound, Code: codeOAuthClientNotFound, Status: http.StatusNotFound},
+	{Err: ErrNoPublicKeys, Code: codePublicKeysNotFound, Status: http.StatusNotFound},
+	{Err: sql.ErrNoRows, Code: apperror.CodeNotFound, Status: http.StatusNotFound, Message: apperror.ErrNotFound.Message},
+
+	{Err: user.ErrEmailTaken, Code: codeEmailTaken, Status: http.StatusConflict},
+	{Err: auth.ErrUserExists, Code: codeEmailTaken, Status: http.StatusConflict, Message: user.ErrEmailTaken.Error()},
+	{Err: auth.ErrTwoFactorAlreadyEnabled, Code: codeTwoFactorEnabled, Status: http.StatusConflict},
+	{Err: auth.ErrIdentityLinked, Code: codeIdentityLinked, Status: http.StatusConflict},
+	{Err: auth.ErrAccountExists, Code: codeAccountExists, Status: http.StatusConflict},
+	{Err: auth.ErrLastSignInMethod, Code: codeLastSignInMethod, Status: http.StatusConflict},
+	{Err: adminService.ErrImpersonateDisabled, Code: codeImpersonateDisabled, Status: http.StatusConflict},
+
+	{Err: auth.ErrTwoFactorNotEnrolled, Code: codeTwoFactorNotEnrolled, Status: http.StatusBadRequest},
+	{Err: auth.ErrTwoFactorNotEnabled, Code: codeTwoFactorNotEnabled, Status: http.StatusBadRequest},
+	{Err: account.ErrInvalidName, Code: codeInvalidName, Status: http.StatusBadRequest},
+	{Err: account.ErrInvalidEmail, Code: codeInvalidEmail, Status: http.StatusBadRequest},
+	{Err: account.ErrInvalidAvatarURL, Code: codeInvalidAvatarURL, Status: http.StatusBadRequest},
+	{Err: account.ErrInvalidLocale, Code: codeInvalidLocale, Status: http.StatusBadRequest},
+	{Err: account.ErrInvalidTimezone, Code: codeInvalidTimezone, Status: http.StatusBadRequest},
+	{Err: account.ErrEmailChangeExpired, Code: codeEmailChangeExpired, Status: http.StatusBadRequest},
+	{Err: adminService.ErrInvalidRole, Code: codeInvalidRole, Status: http.StatusBadRequest},
+	{Err: adminService.ErrSelfAction, Code: codeSelfAction, Status: http.StatusBadRequest},
+	{Err: testsService.ErrInvalidMemberRole, Code: codeInvalidMemberRole, Status: http.StatusBadRequest},
+	{Err: testsService.ErrOwnerAsMember, Code: codeOwnerAsMember, Status: http.StatusBadRequest},
+	{Err: apikeysService.ErrInvalidScope, Code: codeInvalidScope, Status: http.StatusBadRequest},
+	{Err: apikeysService.ErrExpiresInPast, Code: codeExpiresInPast, Status: http.StatusBadRequest},
+	{Err: oauthService.ErrInvalidScope, Code: codeInvalidScope, Status: http.StatusBadRequest},
+	{Err: oauthService.ErrInvalidRedirectURI, Code: codeInvalidRedirectURI, Status: http.StatusBadRequest},
+
+	{Err: lockout.ErrTooManyAttempts, Code: codeTooManyAttempts, Status: http.StatusTooManyRequests},
+
+	{Err: ErrUnsupportedPatch, Code: codeUnsupportedMediaType, Status: http.StatusUnsupportedMediaType},
+	{Err: ErrConsentNotJSON, Code: codeUnsupportedMediaType, Status: http.StatusUnsupportedMediaType},
+	{Err: tests.ErrVersionConflict, Code: codePreconditionFailed, Status: http.StatusPreconditionFailed},
+	{Err: ErrPreconditionFailed, Code: codePreconditionFailed, Status: http.StatusPreconditionFailed},
+	{Err: ErrPreconditionRequired, Code: codePreconditionRequired, Status: http.StatusPreconditionRequired},
+
+	{Err: idempotency.ErrKeyInProgress, Code: codeIdempotencyKeyInProgress, Status: http.StatusConflict},
+	{Err: idempotency.ErrKeyReused, Code: codeIdempotencyKeyReused, Status: http.StatusUnprocessableEntity},
+
+	{Err: testsService.ErrEmptyBatch, Code: codeBatchEmpty, Status: http.StatusBadRequest},
+	{Err: testsService.ErrBatchTooLarge, Code: codeBatchTooLarge, Status: http.StatusRequestEntityTooLarge},
+	{Err: testsService.ErrDuplicateBatchItem, Code: codeDuplicateBatchItem, Status: http.StatusConflict},
+	{Err: domain.ErrBatchRolledBack, Code: codeBatchRolledBack, Status: http.StatusFailedDependency},
+
+	{Err: live.ErrRoomNotFound, Code: codeRoomNotFound, Status: http.StatusNotFound},
+	{Err: live.ErrRoomStarted, Code: codeRoomStarted, Status: http.StatusConflict},
+	{Err: live.ErrRoomFull, Code: codeRoomFull, Status: http.StatusConflict},
+	{Err: live.ErrNicknameTaken, Code: codeNicknameTaken, Status: http.StatusConflict},
+	{Err: live.ErrInvalidNickname, Code: codeInvalidNickname, Status: http.StatusBadRequest},
+	{Err: live.ErrEmptyQuiz, Code: codeEmptyQuiz, Status: http.StatusBadRequest},
+}
@@ -0,0 +1,57 @@
+package v1
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+var (
+	ErrPreconditionRequired = errors.New("the If-Match header with the ETag of the test is required")
+	ErrPreconditionFailed   = errors.New("the If-Match header doesn't match the ETag of the test")
+)
+
+// testETag is the strong ETag of the version of the test.
+func testETag(test domain.Test) string {
+	return `"` + strconv.Itoa(test.Version) + `"`
+}
+
+// ifMatchVersion returns the version of the test the If-Match header requires, domain.AnyVersion for "*".
+// Only one ETag is accepted because the version is checked by the update itself.
+func ifMatchVersion(c *gin.Context) (int, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		return 0, ErrPreconditionRequired
+	}
+
+	if header == "*" {
+		return domain.AnyVersion, nil
+	}
+
+	// the weak ETags never match If-Match, the comparison is strong
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return 0, ErrPreconditionFailed
+	}
+
+	version, err := strconv.Atoi(header[1 : len(header)-1])
+	if err != nil || version <= domain.AnyVersion {
+		return 0, ErrPreconditionFailed
+	}
+
+	return version, nil
+}
+
+// noneMatch reports whether the If-None-Match header matches the etag, the comparison is weak.
+func noneMatch(c *gin.Context, etag string) bool {
+	for _, tag := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+
+	return false
+}
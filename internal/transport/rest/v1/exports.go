@@ -0,0 +1,119 @@
+// Package v1 defines the handlers for the 1 version.
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/apperror"
+)
+
+// downloadLinkRequest is the query of the download link of the export.
+type downloadLinkRequest struct {
+	Expires   int64  `form:"expires" binding:"required"`
+	Signature string `form:"signature" binding:"required"`
+}
+
+// CreateExport godoc
+// @Summary Export user data
+// @Security ApiKeyAuth
+// @Tags account
+// @Description Start the export of everything stored about the current user into a ZIP of JSON files.
+// @Description If the previous export is not finished yet, it is returned instead of a new one.
+// @ID create-export
+// @Produce  json
+// @Success 202 {object} domain.DataExport
+// @Header 202 {string} Location "status of the export"
+// @Failure 401,403 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /me/exports [post]
+func (h *Handlers) CreateExport(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	export, err := h.service.Exports.CreateExport(c, userID)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/me/exports/%d", export.ID))
+	c.JSON(http.StatusAccepted, export)
+}
+
+// GetExport godoc
+// @Summary Get export status
+// @Security ApiKeyAuth
+// @Tags account
+// @Description Get the status of the export. The ready export has the download link valid until link_expires_at,
+// @Description a new link is issued on every request until the archive expires.
+// @ID get-export
+// @Produce  json
+// @Param id path int true "export id"
+// @Success 200 {object} domain.DataExport
+// @Failure 400,401,403,404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /me/exports/{id} [get]
+func (h *Handlers) GetExport(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	exportID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	export, err := h.service.Exports.GetExport(c, userID, exportID)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, export)
+}
+
+// DownloadExport godoc
+// @Summary Download export
+// @Tags account
+// @Description Download the archive of the export by the signed link from the status of the export.
+// @ID download-export
+// @Produce  application/zip
+// @Param id path int true "export id"
+// @Param expires query int true "expiration time of the link"
+// @Param signature query string true "signature of the link"
+// @Success 200 {file} file
+// @Failure 400,403 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /me/exports/{id}/download [get]
+func (h *Handlers) DownloadExport(c *gin.Context) {
+	exportID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	var request downloadLinkRequest
+	if err = c.ShouldBindQuery(&request); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	archive, err := h.service.Exports.DownloadExport(c, exportID, request.Expires, request.Signature)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="qna-export-%d.zip"`, exportID))
+	c.Data(http.StatusOK, "application/zip", archive)
+}
@@ -0,0 +1,99 @@
+package v1
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/util"
+)
+
+func TestHandlers_ExportFlow(t *testing.T) {
+	ctx := context.Background()
+	u := randomUser()
+
+	helperCreatUser(t, ctx, u)
+	userID, err := findUserIDByEmail(u.Email)
+	if err != nil {
+		t.Fatalf("error finding user id: %v", err)
+	}
+
+	token, refreshToken, err := mockServices.Auth.SignIn(ctx, u)
+	if err != nil {
+		t.Fatalf("error generating accessToken: %v", err)
+	}
+
+	testID := helperCreateTest(t, userID, domain.Test{Title: util.RandomString(10)})
+
+	r := gin.Default()
+	r.Use(sessions.Sessions("session", mockHandlers.store))
+	r.POST("/api/v1/me/exports", setSessionMiddleware(t, token), mockHandlers.authMiddleware, mockHandlers.noDelegationMiddleware, mockHandlers.CreateExport)
+	r.GET("/api/v1/me/exports/:id", setSessionMiddleware(t, token), mockHandlers.authMiddleware, mockHandlers.noDelegationMiddleware, mockHandlers.GetExport)
+	r.GET("/api/v1/me/exports/:id/download", mockHandlers.DownloadExport)
+
+	var export domain.DataExport
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/me/exports", nil)
+	testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+		if w.Code != http.StatusAccepted {
+			t.Errorf("expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+			return false
+		}
+
+		return json.Unmarshal(w.Body.Bytes(), &export) == nil && w.Header().Get("Location") != ""
+	})
+
+	for i := 0; i < 100 && export.Status != domain.ExportReady && export.Status != domain.ExportFailed; i++ {
+		time.Sleep(50 * time.Millisecond)
+
+		req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/me/exports/%d", export.ID), nil)
+		testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+			return w.Code == http.StatusOK && json.Unmarshal(w.Body.Bytes(), &export) == nil
+		})
+	}
+
+	if export.Status != domain.ExportReady || export.DownloadURL == "" {
+		t.Fatalf("expected ready export with the download url, got %+v", export)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, export.DownloadURL+"0", nil)
+	testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+		return w.Code == http.StatusForbidden
+	})
+
+	req = httptest.NewRequest(http.MethodGet, export.DownloadURL, nil)
+	testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/zip" {
+			t.Errorf("expected the archive, got %d %s", w.Code, w.Header().Get("Content-Type"))
+			return false
+		}
+
+		zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		if err != nil {
+			t.Errorf("error reading archive: %v", err)
+			return false
+		}
+
+		for _, f := range zr.File {
+			if f.Name == "tests.json" {
+				return true
+			}
+		}
+
+		return false
+	})
+
+	t.Cleanup(func() {
+		helperDeleteTestByID(t, testID)
+		helperDeleteUserByID(t, userID)
+		helperDeleteRefreshTokenByToken(t, refreshToken)
+	})
+}
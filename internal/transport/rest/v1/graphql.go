@@ -0,0 +1,47 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/popeskul/qna-go/internal/apperror"
+	"github.com/popeskul/qna-go/internal/transport/graphql"
+)
+
+// GraphQL godoc
+// @Summary GraphQL query
+// @Security ApiKeyAuth
+// @Tags graphql
+// @Description Query the tests with their questions, answers and the attempts of the user in one request.
+// @Description The correct answers are returned only to the users who can edit the test.
+// @Description The queries deeper than graphql.max_depth or more complex than graphql.max_complexity are rejected.
+// @ID graphql
+// @Accept  json
+// @Produce  json
+// @Param request body graphql.Request true "GraphQL request"
+// @Success 200 {object} map[string]interface{} "data and the errors of the fields"
+// @Failure 400 {object} map[string]interface{} "errors of the query"
+// @Failure 401 {object} problemResponse
+// @Router /graphql [post]
+func (h *Handlers) GraphQL(c *gin.Context) {
+	subject, err := getSubject(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	var req graphql.Request
+	if err = c.ShouldBindJSON(&req); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	result := h.graphQL.Execute(c, subject, req)
+	if result.Data == nil {
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
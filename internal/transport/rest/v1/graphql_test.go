@@ -0,0 +1,155 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandlers_GraphQL(t *testing.T) {
+	ctx := context.Background()
+	owner := randomUser()
+	stranger := randomUser()
+
+	helperCreatUser(t, ctx, owner)
+	helperCreatUser(t, ctx, stranger)
+
+	ownerID, err := findUserIDByEmail(owner.Email)
+	if err != nil {
+		t.Fatalf("error finding user id: %v", err)
+	}
+	strangerID, err := findUserIDByEmail(stranger.Email)
+	if err != nil {
+		t.Fatalf("error finding user id: %v", err)
+	}
+
+	ownerToken, ownerRefreshToken, err := mockServices.Auth.SignIn(ctx, owner)
+	if err != nil {
+		t.Fatalf("error generating accessToken: %v", err)
+	}
+	strangerToken, strangerRefreshToken, err := mockServices.Auth.SignIn(ctx, stranger)
+	if err != nil {
+		t.Fatalf("error generating accessToken: %v", err)
+	}
+
+	testID := helperCreateTest(t, ownerID, randomTest())
+
+	var questionID int
+	if err = mockDB.QueryRow("INSERT INTO questions (body, test_id) VALUES ('2 + 2', $1) RETURNING id", testID).Scan(&questionID); err != nil {
+		t.Fatalf("error inserting question: %v", err)
+	}
+	if _, err = mockDB.Exec("INSERT INTO answers (title, correct, question_id) VALUES ('4', true, $1), ('5', false, $1)", questionID); err != nil {
+		t.Fatalf("error inserting answers: %v", err)
+	}
+	if _, err = mockDB.Exec("INSERT INTO test_passages (user_id, test_id, passed) VALUES ($1, $2, true)", ownerID, testID); err != nil {
+		t.Fatalf("error inserting passage: %v", err)
+	}
+
+	r := gin.Default()
+	r.Use(sessions.Sessions("session", mockHandlers.store))
+	r.Use(func(c *gin.Context) {
+		setSessionMiddleware(t, c.GetHeader("X-Test-Token"))(c)
+	})
+	r.POST("/api/v1/graphql", mockHandlers.authMiddleware, mockHandlers.GraphQL)
+
+	testQuery := `{"query": "{ test(id: ` + strconv.Itoa(testID) + `) { title questions { body answers { title correct } } attempts { passed } } }"}`
+	complexQuery := `{"query": "{ a: tests(pageSize: 10) { questions { answers { id } } } b: tests(pageSize: 10) { questions { answers { id } } } }"}`
+
+	tests := []struct {
+		name   string
+		token  string
+		input  string
+		status int
+		body   []string
+	}{
+		{
+			name:   "Success: owner reads the test with the questions, answers and attempts",
+			token:  ownerToken,
+			input:  testQuery,
+			status: http.StatusOK,
+			body:   []string{`"body":"2 + 2"`, `"correct":true`, `"passed":true`},
+		},
+		{
+			name:   "Fail: stranger reads the test",
+			token:  strangerToken,
+			input:  testQuery,
+			status: http.StatusOK,
+			body:   []string{`"test":null`, `not allowed`},
+		},
+		{
+			name:   "Fail: too complex query",
+			token:  ownerToken,
+			input:  complexQuery,
+			status: http.StatusBadRequest,
+			body:   []string{`query is too complex`},
+		},
+		{
+			name:   "Fail: invalid query",
+			token:  ownerToken,
+			input:  `{"query": "{ test { id } }"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Fail: without query",
+			token:  ownerToken,
+			input:  `{}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Fail: without token",
+			input:  testQuery,
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/graphql", bytes.NewReader([]byte(tt.input)))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Test-Token", tt.token)
+
+			testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+				if w.Code != tt.status || tt.status == http.StatusUnauthorized {
+					return w.Code == tt.status
+				}
+
+				if !json.Valid(w.Body.Bytes()) {
+					return false
+				}
+
+				for _, s := range tt.body {
+					if !strings.Contains(w.Body.String(), s) {
+						t.Errorf("expected %q in %s", s, w.Body.String())
+					}
+				}
+
+				return true
+			})
+		})
+	}
+
+	t.Cleanup(func() {
+		if _, err := mockDB.Exec("DELETE FROM answers WHERE question_id = $1", questionID); err != nil {
+			t.Errorf("error deleting answers: %v", err)
+		}
+		if _, err := mockDB.Exec("DELETE FROM questions WHERE id = $1", questionID); err != nil {
+			t.Errorf("error deleting question: %v", err)
+		}
+		if _, err := mockDB.Exec("DELETE FROM test_passages WHERE test_id = $1", testID); err != nil {
+			t.Errorf("error deleting passages: %v", err)
+		}
+		helperDeleteTestByID(t, testID)
+		helperDeleteUserByID(t, ownerID)
+		helperDeleteUserByID(t, strangerID)
+		helperDeleteRefreshTokenByToken(t, ownerRefreshToken)
+		helperDeleteRefreshTokenByToken(t, strangerRefreshToken)
+	})
+}
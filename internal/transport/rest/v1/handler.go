@@ -4,8 +4,12 @@ package v1
 import (
 	"github.com/gin-contrib/sessions/cookie"
 	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/idempotency"
 	"github.com/popeskul/qna-go/internal/logger"
+	"github.com/popeskul/qna-go/internal/notify"
+	"github.com/popeskul/qna-go/internal/policy"
 	"github.com/popeskul/qna-go/internal/services"
+	"github.com/popeskul/qna-go/internal/transport/graphql"
 )
 
 // Handlers defines handlers for v1
@@ -13,18 +17,27 @@ type Handlers struct {
 	service *services.Service
 	store   cookie.Store
 	logger  *logger.Logger
+	graphQL *graphql.Executor
+	events  notify.Broker
+	keys    *idempotency.Keys
 }
 
 // NewHandler creates a new Handlers with the necessary dependencies.
-func NewHandler(service *services.Service, store cookie.Store, log *logger.Logger) *Handlers {
+func NewHandler(service *services.Service, store cookie.Store, log *logger.Logger, graphQL *graphql.Executor, events notify.Broker,
+	keys *idempotency.Keys) *Handlers {
 	return &Handlers{
 		service: service,
 		store: store,
 		logger:  log,
+		graphQL: graphQL,
+		events:  events,
+		keys:    keys,
 	}
 }
 
 // Init initializes routes for v1
+// The responses issuing credentials (tokens, secrets, codes, passwords) must not be stored,
+// so their routes don't use idempotencyMiddleware.
 func (h *Handlers) Init(api *gin.RouterGroup) *gin.RouterGroup {
 	api.Use(h.loggingMiddleware)
 
@@ -33,15 +46,92 @@ func (h *Handlers) Init(api *gin.RouterGroup) *gin.RouterGroup {
 		authAPI.POST("/sign-up", h.SignUp)
 		authAPI.POST("/sign-in", h.SignIn)
 		authAPI.GET("/refresh", h.Refresh)
+		authAPI.POST("/2fa/verify", h.VerifyTwoFactor)
+		authAPI.PUT("/password", h.authMiddleware, h.noDelegationMiddleware, h.ChangePassword)
+		authAPI.GET("/jwks", h.GetJWKS)
+		authAPI.GET("/oidc/:provider/login", h.StartOIDCLogin)
+		authAPI.GET("/oidc/:provider/callback", h.FinishOIDCLogin)
+		authAPI.POST("/oidc/:provider/link", h.authMiddleware, h.noDelegationMiddleware, h.idempotencyMiddleware, h.LinkOIDCIdentity)
+	}
+
+	identitiesAPI := api.Group("/auth/identities", h.authMiddleware, h.noDelegationMiddleware)
+	{
+		identitiesAPI.GET("/", h.GetIdentities)
+		identitiesAPI.DELETE("/:id", h.UnlinkIdentity)
+	}
+
+	twoFactorAPI := api.Group("/auth/2fa", h.authMiddleware, h.noDelegationMiddleware)
+	{
+		twoFactorAPI.POST("/enroll", h.EnrollTwoFactor)
+		twoFactorAPI.POST("/activate", h.ActivateTwoFactor)
+		twoFactorAPI.POST("/disable", h.idempotencyMiddleware, h.DisableTwoFactor)
+	}
+
+	api.GET("/me/email/confirm", h.ConfirmEmailChange)
+	api.GET("/me/exports/:id/download", h.DownloadExport)
+
+	meAPI := api.Group("/me", h.authMiddleware)
+	{
+		meAPI.GET("", h.GetProfile)
+		meAPI.PATCH("", h.noDelegationMiddleware, h.UpdateProfile)
+		meAPI.DELETE("", h.noDelegationMiddleware, h.DeleteAccount)
+		meAPI.POST("/exports", h.noDelegationMiddleware, h.idempotencyMiddleware, h.CreateExport)
+		meAPI.GET("/exports/:id", h.noDelegationMiddleware, h.GetExport)
 	}
 
 	testsAPI := api.Group("/tests", h.authMiddleware)
 	{
-		testsAPI.POST("/", h.CreateTest)
-		testsAPI.GET("/", h.GetAllTestsByUserID)
+		testsAPI.POST("/", h.permissionMiddleware(policy.CreateTest), h.idempotencyMiddleware, h.CreateTest)
+		testsAPI.POST("/batch", h.idempotencyMiddleware, h.ApplyTestBatch)
+		testsAPI.GET("/", h.permissionMiddleware(policy.ReadTest), h.GetAllTestsByUserID)
 		testsAPI.GET("/:id", h.GetTestByID)
 		testsAPI.PUT("/:id", h.UpdateTestByID)
+		testsAPI.PATCH("/:id", h.PatchTestByID)
 		testsAPI.DELETE("/:id", h.DeleteTestByID)
+		testsAPI.GET("/:id/results", h.GetTestResults)
+		testsAPI.GET("/:id/results/stream", h.StreamTestResults)
+		testsAPI.POST("/:id/questions/batch", h.idempotencyMiddleware, h.ApplyQuestionBatch)
+		testsAPI.GET("/:id/members", h.GetTestMembers)
+		testsAPI.PUT("/:id/members", h.SaveTestMember)
+		testsAPI.DELETE("/:id/members/:user_id", h.DeleteTestMember)
+	}
+
+	api.POST("/graphql", h.authMiddleware, h.idempotencyMiddleware, h.GraphQL)
+
+	apiKeysAPI := api.Group("/api-keys", h.authMiddleware, h.permissionMiddleware(policy.ManageAPIKeys))
+	{
+		apiKeysAPI.POST("/", h.CreateAPIKey)
+		apiKeysAPI.GET("/", h.GetAPIKeys)
+		apiKeysAPI.DELETE("/:id", h.RevokeAPIKey)
+	}
+
+	oauthAPI := api.Group("/oauth")
+	{
+		oauthAPI.GET("/authorize", h.authMiddleware, h.noDelegationMiddleware, h.GetOAuthConsent)
+		oauthAPI.POST("/authorize", h.authMiddleware, h.noDelegationMiddleware, h.ApproveOAuthConsent)
+		oauthAPI.POST("/token", h.OAuthToken)
+		oauthAPI.POST("/introspect", h.IntrospectOAuthToken)
+	}
+
+	oauthClientsAPI := api.Group("/oauth/clients", h.authMiddleware, h.permissionMiddleware(policy.ManageOAuthClients))
+	{
+		oauthClientsAPI.POST("/", h.RegisterOAuthClient)
+		oauthClientsAPI.GET("/", h.GetOAuthClients)
+		oauthClientsAPI.DELETE("/:client_id", h.DeleteOAuthClient)
+	}
+
+	adminAPI := api.Group("/admin", h.authMiddleware, h.permissionMiddleware(policy.ManageUsers))
+	{
+		adminAPI.GET("/users", h.SearchUsers)
+		adminAPI.GET("/users/:id", h.GetUser)
+		adminAPI.DELETE("/users/:id", h.DeleteUser)
+		adminAPI.PUT("/users/:id/role", h.UpdateUserRole)
+		adminAPI.POST("/users/:id/disable", h.idempotencyMiddleware, h.DisableUser)
+		adminAPI.POST("/users/:id/enable", h.idempotencyMiddleware, h.EnableUser)
+		adminAPI.POST("/users/:id/logout", h.idempotencyMiddleware, h.ForceLogout)
+		adminAPI.POST("/users/:id/password-reset", h.noDelegationMiddleware, h.ResetUserPassword)
+		adminAPI.POST("/users/:id/impersonate", h.noDelegationMiddleware, h.ImpersonateUser)
+		adminAPI.GET("/audit-log", h.GetAuditLog)
 	}
 
 	return api
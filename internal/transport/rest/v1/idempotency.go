@@ -0,0 +1,156 @@
+package v1
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/apperror"
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/token"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+var ErrIdempotencyKeyTooLong = errors.New("the Idempotency-Key header must be at most 255 characters long")
+
+// replayedHeaders are the headers of the response replayed to the retries, the cookies are never stored.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// idempotencyMiddleware replays the response of the request with the same Idempotency-Key header
+// to the retries, so a retried POST doesn't create the resource twice. The keys of the users are
+// separate, it must follow the authentication middleware. The requests without the header or the user
+// are not recorded, neither are the server errors, the throttled requests and the panics, their retries are processed.
+// The response is stored as is, the routes issuing credentials must not use it.
+func (h *Handlers) idempotencyMiddleware(c *gin.Context) {
+	key := c.GetHeader(idempotencyKeyHeader)
+	scope, ok := idempotencyScope(c)
+	if key == "" || !ok || h.keys == nil {
+		c.Next()
+		return
+	}
+
+	if len(key) > maxIdempotencyKeyLength {
+		newErrorResponse(c, apperror.Invalid(ErrIdempotencyKeyTooLong))
+		c.Abort()
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		c.Abort()
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	replay, err := h.keys.Begin(c, scope, key, requestFingerprint(c, body))
+	if err != nil {
+		newErrorResponse(c, err)
+		c.Abort()
+		return
+	}
+
+	if replay != nil {
+		for name, values := range replay.Response.Header {
+			for _, value := range values {
+				c.Writer.Header().Add(name, value)
+			}
+		}
+		c.Header(idempotentReplayedHeader, "true")
+		c.Status(replay.Response.Status)
+		_, _ = c.Writer.Write(replay.Response.Body)
+		c.Abort()
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: c.Writer}
+	c.Writer = recorder
+
+	// the key of the panicking request is released before the recovery answers it, the retry must be processed again
+	defer func() {
+		if p := recover(); p != nil {
+			h.releaseIdempotencyKey(c, scope, key)
+			panic(p)
+		}
+	}()
+
+	c.Next()
+
+	// the server errors and the throttled requests are not final, the retry must be processed again
+	if status := c.Writer.Status(); status == http.StatusTooManyRequests || status >= http.StatusInternalServerError {
+		h.releaseIdempotencyKey(c, scope, key)
+		return
+	}
+
+	response := domain.IdempotentResponse{
+		Status: c.Writer.Status(),
+		Header: make(map[string][]string),
+		Body:   recorder.body.Bytes(),
+	}
+	for _, name := range replayedHeaders {
+		if values := c.Writer.Header().Values(name); len(values) > 0 {
+			response.Header[name] = values
+		}
+	}
+
+	if err = h.keys.Complete(c, scope, key, response); err != nil {
+		h.logger.Errorf("%s: [IDEMPOTENCY] - complete %s: %s", time.Now().Format(time.RFC3339), key, err)
+	}
+}
+
+// releaseIdempotencyKey forgets the key reserved for the request, the failure is only logged.
+func (h *Handlers) releaseIdempotencyKey(c *gin.Context, scope, key string) {
+	if err := h.keys.Release(c, scope, key); err != nil {
+		h.logger.Errorf("%s: [IDEMPOTENCY] - release %s: %s", time.Now().Format(time.RFC3339), key, err)
+	}
+}
+
+// idempotencyScope separates the keys of the users. The anonymous requests have no scope,
+// otherwise anyone could take the key before the client uses it.
+func idempotencyScope(c *gin.Context) (string, bool) {
+	if payload, ok := c.Get(authorizationPayloadKey); ok {
+		if authPayload, ok := payload.(*token.Payload); ok && authPayload != nil {
+			return "user:" + strconv.Itoa(authPayload.UserID), true
+		}
+	}
+
+	return "", false
+}
+
+// requestFingerprint is the hash of the method, the path and the body of the request.
+func requestFingerprint(c *gin.Context, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder keeps a copy of the body written to the client.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,177 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/idempotency"
+	"github.com/popeskul/qna-go/internal/logger"
+	"github.com/popeskul/qna-go/internal/services"
+	"github.com/popeskul/qna-go/internal/token"
+)
+
+func TestHandlers_IdempotencyMiddleware(t *testing.T) {
+	h := NewHandler(&services.Service{}, nil, logger.GetLogger(), nil, nil, idempotency.NewKeys(idempotency.NewMemoryStore(), time.Hour))
+
+	created, failures, panics := 0, 0, 0
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "" {
+			c.Set(authorizationPayloadKey, &token.Payload{UserID: 1})
+		}
+	})
+	r.POST("/items", h.idempotencyMiddleware, func(c *gin.Context) {
+		created++
+		c.Header("Location", "/items/"+strconv.Itoa(created))
+		c.JSON(http.StatusCreated, gin.H{"id": created})
+	})
+	r.POST("/flaky", h.idempotencyMiddleware, func(c *gin.Context) {
+		failures++
+		if failures == 1 {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+	r.POST("/panicky", gin.Recovery(), h.idempotencyMiddleware, func(c *gin.Context) {
+		panics++
+		if panics == 1 {
+			panic("handler failed")
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	steps := []struct {
+		name      string
+		path      string
+		key       string
+		anonymous bool
+		input     string
+		status    int
+		body      string
+		replayed  bool
+	}{
+		{name: "Success: create", path: "/items", key: "key-1", input: `{"title":"a"}`, status: http.StatusCreated, body: `{"id":1}`},
+		{name: "Success: retry replays the response", path: "/items", key: "key-1", input: `{"title":"a"}`, status: http.StatusCreated, body: `{"id":1}`, replayed: true},
+		{name: "Fail: the key with another body", path: "/items", key: "key-1", input: `{"title":"b"}`, status: http.StatusUnprocessableEntity},
+		{name: "Fail: the key on another path", path: "/flaky", key: "key-1", input: `{"title":"a"}`, status: http.StatusUnprocessableEntity},
+		{name: "Success: without the key", path: "/items", input: `{"title":"a"}`, status: http.StatusCreated, body: `{"id":2}`},
+		{name: "Success: another key", path: "/items", key: "key-2", input: `{"title":"a"}`, status: http.StatusCreated, body: `{"id":3}`},
+		{name: "Fail: the key is too long", path: "/items", key: strings.Repeat("k", maxIdempotencyKeyLength+1), status: http.StatusBadRequest},
+		{name: "Success: anonymous request is not recorded", path: "/items", key: "key-1", input: `{"title":"a"}`, anonymous: true, status: http.StatusCreated, body: `{"id":4}`},
+		{name: "Fail: server error", path: "/flaky", key: "key-3", status: http.StatusInternalServerError},
+		{name: "Success: retry after server error", path: "/flaky", key: "key-3", status: http.StatusNoContent},
+		{name: "Success: retry replays no content", path: "/flaky", key: "key-3", status: http.StatusNoContent, replayed: true},
+		{name: "Fail: panic", path: "/panicky", key: "key-4", status: http.StatusInternalServerError},
+		{name: "Success: retry after panic", path: "/panicky", key: "key-4", status: http.StatusNoContent},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, step.path, strings.NewReader(step.input))
+			req.Header.Set("Content-Type", "application/json")
+			if step.key != "" {
+				req.Header.Set(idempotencyKeyHeader, step.key)
+			}
+			if !step.anonymous {
+				req.Header.Set("Authorization", "Bearer token")
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != step.status {
+				t.Fatalf("expected status %d, got %d: %s", step.status, w.Code, w.Body.String())
+			}
+			if step.body != "" && w.Body.String() != step.body {
+				t.Errorf("expected body %s, got %s", step.body, w.Body.String())
+			}
+			if replayed := w.Header().Get(idempotentReplayedHeader) == "true"; replayed != step.replayed {
+				t.Errorf("expected replayed %v, got %v", step.replayed, replayed)
+			}
+			if step.replayed && step.status == http.StatusCreated && w.Header().Get("Location") != "/items/1" {
+				t.Errorf("expected the replayed Location /items/1, got %s", w.Header().Get("Location"))
+			}
+		})
+	}
+
+	if created != 4 {
+		t.Errorf("expected 4 created items, got %d", created)
+	}
+}
+
+func TestHandlers_CreateTestIdempotency(t *testing.T) {
+	ctx := context.Background()
+	user := randomUser()
+
+	helperCreatUser(t, ctx, user)
+
+	userID, err := findUserIDByEmail(user.Email)
+	if err != nil {
+		t.Fatalf("error finding user id: %v", err)
+	}
+
+	accessToken, refreshToken, err := mockServices.Auth.SignIn(ctx, user)
+	if err != nil {
+		t.Fatalf("error generating accessToken: %v", err)
+	}
+
+	r := gin.Default()
+	r.Use(sessions.Sessions("session", mockHandlers.store))
+	r.POST("/api/v1/tests", setSessionMiddleware(t, accessToken), mockHandlers.authMiddleware, mockHandlers.idempotencyMiddleware, mockHandlers.CreateTest)
+
+	title := randomTest().Title
+	createTest := func(input string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/tests", strings.NewReader(input))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(idempotencyKeyHeader, "create-"+title)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		return w
+	}
+
+	first := createTest(`{"title": "` + title + `"}`)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, first.Code, first.Body.String())
+	}
+
+	var test domain.Test
+	if err = json.Unmarshal(first.Body.Bytes(), &test); err != nil {
+		t.Fatalf("error unmarshalling response: %v", err)
+	}
+
+	retry := createTest(`{"title": "` + title + `"}`)
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() ||
+		retry.Header().Get("Location") != "/api/v1/tests/"+strconv.Itoa(test.ID) || retry.Header().Get(idempotentReplayedHeader) != "true" {
+		t.Errorf("expected the replayed response, got %d %v: %s", retry.Code, retry.Header(), retry.Body.String())
+	}
+
+	if mismatch := createTest(`{"title": "other ` + title + `"}`); mismatch.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d, got %d: %s", http.StatusUnprocessableEntity, mismatch.Code, mismatch.Body.String())
+	}
+
+	var count int
+	if err = mockDB.QueryRow("SELECT count(*) FROM tests WHERE author_id = $1", userID).Scan(&count); err != nil {
+		t.Fatalf("error counting tests: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 created test, got %d", count)
+	}
+
+	t.Cleanup(func() {
+		helperDeleteTestByID(t, test.ID)
+		helperDeleteUserByID(t, userID)
+		helperDeleteRefreshTokenByToken(t, refreshToken)
+	})
+}
@@ -0,0 +1,34 @@
+// Package v1 defines the handlers for the 1 version.
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/token"
+)
+
+var (
+	ErrNoPublicKeys = errors.New("tokens are not signed with public keys")
+)
+
+// GetJWKS godoc
+// @Summary Get token public keys
+// @Tags auth
+// @Description Get the public keys to verify access tokens as a JSON Web Key Set. The key is chosen by the kid of the token.
+// @ID get-jwks
+// @Produce  json
+// @Success 200 {object} token.JWKS
+// @Failure 404 {object} problemResponse
+// @Router /auth/jwks [get]
+func (h *Handlers) GetJWKS(c *gin.Context) {
+	keySet, ok := h.service.TokenMaker.(token.KeySet)
+	if !ok {
+		newErrorResponse(c, ErrNoPublicKeys)
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, keySet.JWKS())
+}
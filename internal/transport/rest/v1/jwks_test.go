@@ -0,0 +1,71 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/logger"
+	"github.com/popeskul/qna-go/internal/services"
+	"github.com/popeskul/qna-go/internal/token"
+)
+
+func TestHandlers_GetJWKS(t *testing.T) {
+	key, err := token.GenerateKey("key-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := token.NewKeyRing(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicMaker, err := token.NewPasetoPublicManager(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		handlers *Handlers
+		status   int
+		keys     int
+	}{
+		{
+			name:     "Success: public keys of asymmetric tokens",
+			handlers: NewHandler(&services.Service{TokenMaker: publicMaker}, mockHandlers.store, logger.GetLogger(), nil, nil, nil),
+			status:   http.StatusOK,
+			keys:     1,
+		},
+		{
+			name:     "Error: symmetric tokens",
+			handlers: mockHandlers,
+			status:   http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/jwks", nil)
+
+			r := gin.Default()
+			r.GET("/api/v1/auth/jwks", tt.handlers.GetJWKS)
+
+			testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+				if w.Code != tt.status {
+					return false
+				}
+				if tt.status != http.StatusOK {
+					return true
+				}
+
+				var set token.JWKS
+				if err := json.Unmarshal(w.Body.Bytes(), &set); err != nil {
+					return false
+				}
+				return len(set.Keys) == tt.keys && set.Keys[0].KeyID == "key-1"
+			})
+		})
+	}
+}
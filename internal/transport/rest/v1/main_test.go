@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	sessionsPostgres "github.com/gin-contrib/sessions/postgres"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/joho/godotenv"
 	"github.com/popeskul/cache"
 	"github.com/popeskul/qna-go/internal/config"
@@ -12,16 +13,26 @@ import (
 	"github.com/popeskul/qna-go/internal/db/postgres"
 	"github.com/popeskul/qna-go/internal/domain"
 	"github.com/popeskul/qna-go/internal/hash"
+	"github.com/popeskul/qna-go/internal/idempotency"
+	"github.com/popeskul/qna-go/internal/lockout"
 	"github.com/popeskul/qna-go/internal/logger"
+	"github.com/popeskul/qna-go/internal/mail"
+	"github.com/popeskul/qna-go/internal/notify"
+	"github.com/popeskul/qna-go/internal/password"
 	"github.com/popeskul/qna-go/internal/repository"
 	"github.com/popeskul/qna-go/internal/repository/sessions"
 	"github.com/popeskul/qna-go/internal/services"
+	"github.com/popeskul/qna-go/internal/services/account"
+	"github.com/popeskul/qna-go/internal/services/exports"
 	"github.com/popeskul/qna-go/internal/token"
+	"github.com/popeskul/qna-go/internal/transport/graphql"
 	"github.com/popeskul/qna-go/internal/util"
+	"github.com/popeskul/qna-go/internal/validation"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sync"
 	"testing"
 	"time"
 )
@@ -31,9 +42,36 @@ var (
 	mockRepo     *repository.Repository
 	mockHandlers *Handlers
 	mockServices *services.Service
+	mockEvents   *notify.Hub
+	mockKeys     *idempotency.Keys
+	mockMailer   = &testMailer{messages: make(map[string]mail.Message)}
 	cfg          *config.Config
 )
 
+// testMailer keeps the last message sent to every recipient.
+type testMailer struct {
+	mu       sync.Mutex
+	messages map[string]mail.Message
+}
+
+func (m *testMailer) Send(_ context.Context, msg mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages[msg.To] = msg
+
+	return nil
+}
+
+func (m *testMailer) lastMessage(to string) (mail.Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	msg, ok := m.messages[to]
+
+	return msg, ok
+}
+
 func TestMain(m *testing.M) {
 	if err := util.ChangeDir("../../"); err != nil {
 		log.Fatal(err)
@@ -75,10 +113,24 @@ func TestMain(m *testing.M) {
 	sessionManager := sessions.NewRepoSessions(db)
 
 	mockRepo = repository.NewRepository(db)
-	mockServices = services.NewService(mockRepo, pasetoMaker, hashManager, cache, sessionManager)
-	mockHandlers = NewHandler(mockServices, store, logger.GetLogger())
+	loginGuard := lockout.NewGuard(lockout.NewMemoryStore(), lockout.Config{
+		MaxAttempts:   5,
+		MaxIPAttempts: 100,
+		Window:        time.Minute,
+		Duration:      time.Minute,
+	})
+	mockServices = services.NewService(mockRepo, pasetoMaker, hashManager, password.NewValidator(password.DefaultPolicy, nil), cache, sessionManager, loginGuard, nil,
+		mockMailer, account.Config{DeletionPolicy: account.DeleteData}, exports.Config{})
+	graphQL, err := graphql.NewExecutor(mockServices, graphql.DefaultConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mockEvents = notify.NewHub()
+	mockKeys = idempotency.NewKeys(idempotency.NewMemoryStore(), time.Hour)
+	mockHandlers = NewHandler(mockServices, store, logger.GetLogger(), graphQL, mockEvents, mockKeys)
 
 	gin.SetMode(gin.TestMode)
+	binding.Validator = validation.New()
 
 	os.Exit(m.Run())
 }
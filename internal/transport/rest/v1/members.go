@@ -0,0 +1,163 @@
+// Package v1 defines the handlers for the 1 version.
+package v1
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/apperror"
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+// GetTestResults godoc
+// @Summary Get results of the test
+// @Tags tests
+// @Security ApiKeyAuth
+// @Description Get results of the test. Allowed for the owner, editors and reviewers of the test.
+// @ID get-test-results
+// @Accept  json
+// @Produce  json
+// @Param id path int true "id"
+// @Success 200 {object} []domain.TestPassage
+// @Failure 400,401,403,404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /tests/{id}/results [get]
+func (h *Handlers) GetTestResults(c *gin.Context) {
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	subject, err := getSubject(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	results, err := h.service.Tests.GetTestResults(c, subject, testID)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// GetTestMembers godoc
+// @Summary Get collaborators of the test
+// @Tags members
+// @Security ApiKeyAuth
+// @Description Get collaborators of the test
+// @ID get-test-members
+// @Accept  json
+// @Produce  json
+// @Param id path int true "id"
+// @Success 200 {object} []domain.TestMember
+// @Failure 400,401,403,404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /tests/{id}/members [get]
+func (h *Handlers) GetTestMembers(c *gin.Context) {
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	subject, err := getSubject(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	testMembers, err := h.service.Tests.GetTestMembers(c, subject, testID)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, testMembers)
+}
+
+// SaveTestMember godoc
+// @Summary Invite user to the test
+// @Tags members
+// @Security ApiKeyAuth
+// @Description Invite user to the test as editor, reviewer or viewer. Changes the role if the user is already a member.
+// @ID save-test-member
+// @Accept  json
+// @Produce  json
+// @Param id path int true "id"
+// @Param member body domain.SaveMemberRequest true "member"
+// @Success 200
+// @Failure 400,401,403,404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /tests/{id}/members [put]
+func (h *Handlers) SaveTestMember(c *gin.Context) {
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	var request domain.SaveMemberRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	subject, err := getSubject(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	if err = h.service.Tests.SaveTestMember(c, subject, request.Member(testID)); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// DeleteTestMember godoc
+// @Summary Remove user from the test
+// @Tags members
+// @Security ApiKeyAuth
+// @Description Remove user from the collaborators of the test
+// @ID delete-test-member
+// @Accept  json
+// @Produce  json
+// @Param id path int true "id"
+// @Param user_id path int true "user id"
+// @Success 200
+// @Failure 400,401,403,404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /tests/{id}/members/{user_id} [delete]
+func (h *Handlers) DeleteTestMember(c *gin.Context) {
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	subject, err := getSubject(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	if err = h.service.Tests.DeleteTestMember(c, subject, testID, userID); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
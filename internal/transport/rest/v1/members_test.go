@@ -0,0 +1,197 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandlers_TestMembers(t *testing.T) {
+	ctx := context.Background()
+	owner := randomUser()
+	collaborator := randomUser()
+
+	helperCreatUser(t, ctx, owner)
+	helperCreatUser(t, ctx, collaborator)
+
+	ownerID, err := findUserIDByEmail(owner.Email)
+	if err != nil {
+		t.Fatalf("error finding user id: %v", err)
+	}
+	collaboratorID, err := findUserIDByEmail(collaborator.Email)
+	if err != nil {
+		t.Fatalf("error finding user id: %v", err)
+	}
+
+	ownerToken, ownerRefreshToken, err := mockServices.Auth.SignIn(ctx, owner)
+	if err != nil {
+		t.Fatalf("error generating accessToken: %v", err)
+	}
+	collaboratorToken, collaboratorRefreshToken, err := mockServices.Auth.SignIn(ctx, collaborator)
+	if err != nil {
+		t.Fatalf("error generating accessToken: %v", err)
+	}
+
+	testID := helperCreateTest(t, ownerID, randomTest())
+	testPath := "/api/v1/tests/" + strconv.Itoa(testID)
+	membersPath := testPath + "/members"
+	memberJSON := func(role string) []byte {
+		return []byte(`{"user_id": ` + strconv.Itoa(collaboratorID) + `, "role": "` + role + `"}`)
+	}
+
+	r := gin.Default()
+	r.Use(sessions.Sessions("session", mockHandlers.store))
+	r.Use(func(c *gin.Context) {
+		setSessionMiddleware(t, c.GetHeader("X-Test-Token"))(c)
+	})
+	api := r.Group("/api/v1", mockHandlers.authMiddleware)
+	api.GET("/tests/:id", mockHandlers.GetTestByID)
+	api.PUT("/tests/:id", mockHandlers.UpdateTestByID)
+	api.DELETE("/tests/:id", mockHandlers.DeleteTestByID)
+	api.GET("/tests/:id/results", mockHandlers.GetTestResults)
+	api.PUT("/tests/:id/members", mockHandlers.SaveTestMember)
+	api.DELETE("/tests/:id/members/:user_id", mockHandlers.DeleteTestMember)
+
+	steps := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		input  []byte
+		status int
+	}{
+		{
+			name:   "Fail: stranger reads the test",
+			method: http.MethodGet,
+			path:   testPath,
+			token:  collaboratorToken,
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "Fail: stranger invites themselves",
+			method: http.MethodPut,
+			path:   membersPath,
+			token:  collaboratorToken,
+			input:  memberJSON("editor"),
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "Fail: owner invites with unknown role",
+			method: http.MethodPut,
+			path:   membersPath,
+			token:  ownerToken,
+			input:  memberJSON("owner"),
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Fail: owner invites unknown user",
+			method: http.MethodPut,
+			path:   membersPath,
+			token:  ownerToken,
+			input:  []byte(`{"user_id": 2147483647, "role": "viewer"}`),
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "Success: owner invites viewer",
+			method: http.MethodPut,
+			path:   membersPath,
+			token:  ownerToken,
+			input:  memberJSON("viewer"),
+			status: http.StatusOK,
+		},
+		{
+			name:   "Success: viewer reads the test",
+			method: http.MethodGet,
+			path:   testPath,
+			token:  collaboratorToken,
+			status: http.StatusOK,
+		},
+		{
+			name:   "Fail: viewer updates the test",
+			method: http.MethodPut,
+			path:   testPath,
+			token:  collaboratorToken,
+			input:  []byte(`{"title": "updated by viewer"}`),
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "Fail: viewer sees results",
+			method: http.MethodGet,
+			path:   testPath + "/results",
+			token:  collaboratorToken,
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "Success: owner promotes viewer to editor",
+			method: http.MethodPut,
+			path:   membersPath,
+			token:  ownerToken,
+			input:  memberJSON("editor"),
+			status: http.StatusOK,
+		},
+		{
+			name:   "Success: editor updates the test",
+			method: http.MethodPut,
+			path:   testPath,
+			token:  collaboratorToken,
+			input:  []byte(`{"title": "updated by editor"}`),
+			status: http.StatusOK,
+		},
+		{
+			name:   "Success: editor sees results",
+			method: http.MethodGet,
+			path:   testPath + "/results",
+			token:  collaboratorToken,
+			status: http.StatusOK,
+		},
+		{
+			name:   "Fail: editor deletes the test",
+			method: http.MethodDelete,
+			path:   testPath,
+			token:  collaboratorToken,
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "Success: owner removes editor",
+			method: http.MethodDelete,
+			path:   membersPath + "/" + strconv.Itoa(collaboratorID),
+			token:  ownerToken,
+			status: http.StatusOK,
+		},
+		{
+			name:   "Fail: removed editor updates the test",
+			method: http.MethodPut,
+			path:   testPath,
+			token:  collaboratorToken,
+			input:  []byte(`{"title": "updated after removal"}`),
+			status: http.StatusForbidden,
+		},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			req := httptest.NewRequest(step.method, step.path, bytes.NewReader(step.input))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Test-Token", step.token)
+			req.Header.Set("If-Match", "*")
+
+			testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+				return w.Code == step.status
+			})
+		})
+	}
+
+	t.Cleanup(func() {
+		helperDeleteTestByID(t, testID)
+		helperDeleteUserByID(t, ownerID)
+		helperDeleteUserByID(t, collaboratorID)
+		helperDeleteRefreshTokenByToken(t, ownerRefreshToken)
+		helperDeleteRefreshTokenByToken(t, collaboratorRefreshToken)
+	})
+}
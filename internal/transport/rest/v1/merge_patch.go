@@ -0,0 +1,74 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/popeskul/qna-go/internal/apperror"
+	"github.com/popeskul/qna-go/internal/validation"
+)
+
+const mergePatchContentType = "application/merge-patch+json"
+
+var (
+	ErrUnsupportedPatch = errors.New("the patch must be application/merge-patch+json")
+	ErrPatchNotObject   = errors.New("the patch must be a JSON object")
+)
+
+// bindMergePatch decodes the JSON Merge Patch (RFC 7396) of the request into patch and validates it.
+// The patch must be an object, its unknown members are ignored. The fields of patch can't be removed,
+// so null is rejected for them.
+func bindMergePatch(c *gin.Context, patch interface{}) error {
+	if contentType := c.ContentType(); contentType != mergePatchContentType && contentType != binding.MIMEJSON {
+		return ErrUnsupportedPatch
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return apperror.Invalid(err)
+	}
+
+	var members map[string]json.RawMessage
+	if err = json.Unmarshal(body, &members); err != nil {
+		return apperror.Invalid(ErrPatchNotObject)
+	}
+
+	var removed validation.Errors
+	for _, name := range jsonFields(patch) {
+		if value, ok := members[name]; ok && string(value) == "null" {
+			removed = append(removed, validation.FieldError{Field: name, Rule: "required", Message: "can't be removed"})
+		}
+	}
+	if len(removed) > 0 {
+		return removed
+	}
+
+	if err = binding.JSON.BindBody(body, patch); err != nil {
+		return apperror.Invalid(err)
+	}
+
+	return nil
+}
+
+// jsonFields returns the json names of the fields of the struct v points to.
+func jsonFields(v interface{}) []string {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.SplitN(t.Field(i).Tag.Get("json"), ",", 2)[0]
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
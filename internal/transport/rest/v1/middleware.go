@@ -5,49 +5,103 @@ import (
 	"errors"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/logger"
+	"github.com/popeskul/qna-go/internal/policy"
+	"github.com/popeskul/qna-go/internal/services"
 	"github.com/popeskul/qna-go/internal/token"
-	"net/http"
 	"time"
 )
 
 const (
 	authorizationPayloadKey = "authorization_payload"
+	apiKeyHeader            = "X-API-Key"
+	authorizationHeader     = "Authorization"
+	wwwAuthenticateHeader   = "WWW-Authenticate"
+	bearerChallenge         = `Bearer realm="qna"`
 )
 
-var (
-	ErrUserIdNotFound    = errors.New("user id not found")
-	ErrTokenNotFound     = errors.New("accessToken not found")
-	ErrAuthEmptyToken    = errors.New("empty auth header")
-	ErrInvalidAuthHeader = errors.New("authorization header is invalid")
-)
+var ErrDelegatedCredential = errors.New("the action is not allowed with an api key, an oauth token or an impersonation token")
 
-// authMiddleware is a middleware that authenticates the user based on the accessToken in the request.
+// authMiddleware is a middleware that authenticates the user. The credential is taken in this order:
+//  1. Authorization: Bearer <access token or API key>
+//  2. X-API-Key: <API key>
+//  3. the access token stored in the session cookie
+//
+// A credential sent in a header takes precedence over the session and is never mixed with it,
+// if it is invalid the request is rejected with 401.
 func (h *Handlers) authMiddleware(c *gin.Context) {
+	payload, err := h.authenticate(c)
+	if err != nil {
+		c.Header(wwwAuthenticateHeader, bearerChallenge)
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.Set(authorizationPayloadKey, payload)
+	c.Next()
+}
+
+// authenticate finds the credential of the request and verifies it with the service like the gRPC interceptor does.
+// The session is used only if there is no credential in the headers.
+func (h *Handlers) authenticate(c *gin.Context) (*token.Payload, error) {
+	header, key := c.GetHeader(authorizationHeader), c.GetHeader(apiKeyHeader)
+	if header != "" || key != "" {
+		return h.service.Authenticate(c, header, key)
+	}
+
 	session := sessions.Default(c)
-	token, ok := session.Get(accessTokenName).(string)
+	accessToken, ok := session.Get(accessTokenName).(string)
 	if !ok {
-		newErrorResponse(c, http.StatusUnauthorized, ErrTokenNotFound.Error())
-		return
+		return nil, services.ErrTokenNotFound
+	}
+
+	if accessToken == "" {
+		return nil, services.ErrAuthEmptyToken
 	}
 
-	if token == "" {
-		newErrorResponse(c, http.StatusUnauthorized, ErrAuthEmptyToken.Error())
+	return h.service.VerifyCredential(c, accessToken)
+}
+
+// permissionMiddleware returns a middleware that allows the request only if the user's role has the permission.
+// Ownership of a concrete resource is checked by the handler.
+func (h *Handlers) permissionMiddleware(perm policy.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subject, err := getSubject(c)
+		if err != nil {
+			newErrorResponse(c, err)
+			return
+		}
+
+		if !policy.Can(subject, perm) {
+			newErrorResponse(c, policy.ErrForbidden)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// noDelegationMiddleware is a middleware that rejects the delegated credentials: API keys,
+// the tokens issued to OAuth2 clients and the tokens of the operators impersonating the user.
+// Managing the account and granting access to other apps stay with the user themself.
+func (h *Handlers) noDelegationMiddleware(c *gin.Context) {
+	authPayload, ok := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !ok || authPayload == nil {
+		newErrorResponse(c, services.ErrUserIdNotFound)
 		return
 	}
 
-	payload, err := h.service.Auth.VerifyToken(c, token)
-	if err != nil {
-		newErrorResponse(c, http.StatusUnauthorized, ErrInvalidAuthHeader.Error())
+	if len(authPayload.Scopes) > 0 || authPayload.ClientID != "" || authPayload.ImpersonatorID != 0 {
+		newErrorResponse(c, ErrDelegatedCredential)
 		return
 	}
 
-	c.Set(authorizationPayloadKey, payload)
 	c.Next()
 }
 
 // loggingMiddleware is a middleware that logs the request.
 func (h *Handlers) loggingMiddleware(c *gin.Context) {
-	h.logger.Infof("%s: [%s] - %s ", time.Now().Format(time.RFC3339), c.Request.Method, c.Request.URL.String())
+	h.logger.Infof("%s: [%s] - %s ", time.Now().Format(time.RFC3339), c.Request.Method, logger.RedactURL(c.Request.URL))
 
 	c.Next()
 }
@@ -56,8 +110,18 @@ func (h *Handlers) loggingMiddleware(c *gin.Context) {
 func getUserId(c *gin.Context) (int, error) {
 	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
 	if authPayload == nil {
-		return 0, ErrUserIdNotFound
+		return 0, services.ErrUserIdNotFound
 	}
 
 	return authPayload.UserID, nil
 }
+
+// getSubject get the user id and role from the context and returns them and an error if they are not found.
+func getSubject(c *gin.Context) (policy.Subject, error) {
+	authPayload, ok := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !ok || authPayload == nil {
+		return policy.Subject{}, services.ErrUserIdNotFound
+	}
+
+	return services.SubjectOf(authPayload), nil
+}
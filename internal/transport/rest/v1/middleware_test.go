@@ -0,0 +1,99 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandlers_AuthMiddleware(t *testing.T) {
+	ctx := context.Background()
+	u := randomUser()
+
+	helperCreatUser(t, ctx, u)
+	userID, err := findUserIDByEmail(u.Email)
+	if err != nil {
+		t.Fatalf("error finding user id: %v", err)
+	}
+
+	token, refreshToken, err := mockServices.Auth.SignIn(ctx, u)
+	if err != nil {
+		t.Fatalf("error generating accessToken: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		sessionToken  string
+		status        int
+	}{
+		{
+			name:          "Success: bearer token",
+			authorization: "Bearer " + token,
+			status:        http.StatusOK,
+		},
+		{
+			name:          "Success: scheme is case-insensitive",
+			authorization: "bearer " + token,
+			status:        http.StatusOK,
+		},
+		{
+			name:         "Success: session cookie",
+			sessionToken: token,
+			status:       http.StatusOK,
+		},
+		{
+			name:          "Error: invalid bearer token takes precedence over session",
+			authorization: "Bearer invalid",
+			sessionToken:  token,
+			status:        http.StatusUnauthorized,
+		},
+		{
+			name:          "Error: wrong scheme",
+			authorization: "Basic " + token,
+			status:        http.StatusUnauthorized,
+		},
+		{
+			name:          "Error: empty bearer token",
+			authorization: "Bearer",
+			status:        http.StatusUnauthorized,
+		},
+		{
+			name:   "Error: without credentials",
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/tests", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			r := gin.Default()
+			r.Use(sessions.Sessions("session", mockHandlers.store))
+			handlers := []gin.HandlerFunc{mockHandlers.authMiddleware, mockHandlers.GetAllTestsByUserID}
+			if tt.sessionToken != "" {
+				handlers = append([]gin.HandlerFunc{setSessionMiddleware(t, tt.sessionToken)}, handlers...)
+			}
+			r.GET("/api/v1/tests", handlers...)
+
+			testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+				if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+					return false
+				}
+				return w.Code == tt.status
+			})
+		})
+	}
+
+	t.Cleanup(func() {
+		helperDeleteUserByID(t, userID)
+		helperDeleteRefreshTokenByToken(t, refreshToken)
+	})
+}
@@ -0,0 +1,345 @@
+// Package v1 defines the handlers for the 1 version.
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/popeskul/qna-go/internal/apperror"
+	"github.com/popeskul/qna-go/internal/domain"
+	oauthService "github.com/popeskul/qna-go/internal/services/oauth"
+)
+
+// oauthErrorResponse is the error of the token and introspection endpoints from RFC 6749 section 5.2.
+type oauthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+var ErrConsentNotJSON = errors.New("the decision must be application/json")
+
+// consentResponse is the consent screen with the CSRF token the decision must be sent with.
+type consentResponse struct {
+	domain.ConsentRequest
+	CSRFToken string `json:"csrf_token"`
+}
+
+// consentRedirectResponse is returned when the user answers on the consent screen.
+type consentRedirectResponse struct {
+	RedirectTo string `json:"redirect_to"`
+}
+
+// introspectionRequest is the body of the introspection request.
+type introspectionRequest struct {
+	Token        string `form:"token" binding:"required"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+// RegisterOAuthClient godoc
+// @Summary Register OAuth2 client
+// @Security ApiKeyAuth
+// @Tags oauth
+// @Description Register a third-party app. The secret of a confidential client is shown only once.
+// @ID register-oauth-client
+// @Accept  json
+// @Produce  json
+// @Param client body domain.CreateOAuthClientRequest true "oauth client"
+// @Success 201 {object} domain.RegisteredOAuthClient
+// @Failure 400,401,403 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /oauth/clients [post]
+func (h *Handlers) RegisterOAuthClient(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	var request domain.CreateOAuthClientRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	client, err := h.service.OAuth.RegisterClient(c, userID, request)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, client)
+}
+
+// GetOAuthClients godoc
+// @Summary Get OAuth2 clients
+// @Security ApiKeyAuth
+// @Tags oauth
+// @Description Get all OAuth2 clients registered by the user.
+// @ID get-oauth-clients
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} domain.OAuthClient
+// @Failure 401,403 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /oauth/clients [get]
+func (h *Handlers) GetOAuthClients(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	clients, err := h.service.OAuth.GetClients(c, userID)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, clients)
+}
+
+// DeleteOAuthClient godoc
+// @Summary Delete OAuth2 client
+// @Security ApiKeyAuth
+// @Tags oauth
+// @Description Delete OAuth2 client. Its refresh tokens stop working immediately.
+// @ID delete-oauth-client
+// @Accept  json
+// @Produce  json
+// @Param client_id path string true "client id"
+// @Success 200
+// @Failure 401,403,404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /oauth/clients/{client_id} [delete]
+func (h *Handlers) DeleteOAuthClient(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	if err = h.service.OAuth.DeleteClient(c, userID, c.Param("client_id")); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// GetOAuthConsent godoc
+// @Summary OAuth2 authorization request
+// @Security ApiKeyAuth
+// @Tags oauth
+// @Description Validate the authorization request of the client and get the consent screen.
+// @Description If the user already granted the scopes, redirect to the client with the authorization code.
+// @ID get-oauth-consent
+// @Produce  json
+// @Param response_type query string true "code"
+// @Param client_id query string true "client id"
+// @Param redirect_uri query string true "registered redirect uri"
+// @Param scope query string false "space-delimited scopes"
+// @Param state query string false "state"
+// @Param code_challenge query string true "PKCE code challenge"
+// @Param code_challenge_method query string true "S256"
+// @Success 200 {object} consentResponse
+// @Success 302
+// @Failure 400,401,403 {object} oauthErrorResponse
+// @Failure 500 {object} problemResponse
+// @Router /oauth/authorize [get]
+func (h *Handlers) GetOAuthConsent(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	var request domain.AuthorizationRequest
+	if err = c.ShouldBindQuery(&request); err != nil {
+		c.JSON(http.StatusBadRequest, oauthErrorResponse{Error: oauthService.CodeInvalidRequest, ErrorDescription: err.Error()})
+		return
+	}
+
+	consent, err := h.service.OAuth.Authorize(c, userID, request)
+	if err != nil {
+		oauthErrorResponseFrom(c, err)
+		return
+	}
+
+	if !consent.Granted {
+		token, err := csrfToken(c)
+		if err != nil {
+			newErrorResponse(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, consentResponse{ConsentRequest: consent, CSRFToken: token})
+		return
+	}
+
+	redirectTo, err := h.service.OAuth.Approve(c, userID, domain.ConsentDecision{AuthorizationRequest: request, Approve: true})
+	if err != nil {
+		oauthErrorResponseFrom(c, err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, redirectTo)
+}
+
+// ApproveOAuthConsent godoc
+// @Summary OAuth2 consent
+// @Security ApiKeyAuth
+// @Tags oauth
+// @Description Approve or deny the authorization request and get the redirect URL of the client
+// @Description with the authorization code or the access_denied error.
+// @Description The decision is sent as JSON with the csrf_token of the consent screen in the X-CSRF-Token header.
+// @ID approve-oauth-consent
+// @Accept  json
+// @Produce  json
+// @Param X-CSRF-Token header string true "csrf_token of the consent screen"
+// @Param decision body domain.ConsentDecision true "decision of the user"
+// @Success 200 {object} consentRedirectResponse
+// @Failure 400,401,403 {object} oauthErrorResponse
+// @Failure 403,415 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /oauth/authorize [post]
+func (h *Handlers) ApproveOAuthConsent(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	// the forms of the cross-site pages can't send JSON without a CORS preflight
+	if c.ContentType() != binding.MIMEJSON {
+		newErrorResponse(c, ErrConsentNotJSON)
+		return
+	}
+
+	if err = checkCSRFToken(c); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	var decision domain.ConsentDecision
+	if err = c.ShouldBindJSON(&decision); err != nil {
+		c.JSON(http.StatusBadRequest, oauthErrorResponse{Error: oauthService.CodeInvalidRequest, ErrorDescription: err.Error()})
+		return
+	}
+
+	redirectTo, err := h.service.OAuth.Approve(c, userID, decision)
+	if err != nil {
+		oauthErrorResponseFrom(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, consentRedirectResponse{RedirectTo: redirectTo})
+}
+
+// OAuthToken godoc
+// @Summary OAuth2 token endpoint
+// @Tags oauth
+// @Description Issue tokens for the authorization_code, refresh_token and client_credentials grants.
+// @Description The client authenticates with HTTP Basic or with client_id and client_secret in the body.
+// @ID oauth-token
+// @Accept  x-www-form-urlencoded
+// @Produce  json
+// @Param grant_type formData string true "grant type"
+// @Param code formData string false "authorization code"
+// @Param redirect_uri formData string false "redirect uri of the authorization request"
+// @Param code_verifier formData string false "PKCE code verifier"
+// @Param refresh_token formData string false "refresh token"
+// @Param scope formData string false "space-delimited scopes"
+// @Param client_id formData string false "client id"
+// @Param client_secret formData string false "client secret"
+// @Success 200 {object} domain.TokenResponse
+// @Failure 400,401 {object} oauthErrorResponse
+// @Failure 500 {object} problemResponse
+// @Router /oauth/token [post]
+func (h *Handlers) OAuthToken(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var request domain.TokenRequest
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, oauthErrorResponse{Error: oauthService.CodeInvalidRequest, ErrorDescription: err.Error()})
+		return
+	}
+
+	clientID, clientSecret := clientCredentials(c, request.ClientID, request.ClientSecret)
+
+	tokens, err := h.service.OAuth.Exchange(c, clientID, clientSecret, request)
+	if err != nil {
+		oauthErrorResponseFrom(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// IntrospectOAuthToken godoc
+// @Summary OAuth2 token introspection
+// @Tags oauth
+// @Description Get the state of the access token issued to the confidential client.
+// @ID oauth-introspect
+// @Accept  x-www-form-urlencoded
+// @Produce  json
+// @Param token formData string true "access token"
+// @Param client_id formData string false "client id"
+// @Param client_secret formData string false "client secret"
+// @Success 200 {object} domain.Introspection
+// @Failure 400,401 {object} oauthErrorResponse
+// @Failure 500 {object} problemResponse
+// @Router /oauth/introspect [post]
+func (h *Handlers) IntrospectOAuthToken(c *gin.Context) {
+	var request introspectionRequest
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, oauthErrorResponse{Error: oauthService.CodeInvalidRequest, ErrorDescription: err.Error()})
+		return
+	}
+
+	clientID, clientSecret := clientCredentials(c, request.ClientID, request.ClientSecret)
+
+	introspection, err := h.service.OAuth.Introspect(c, clientID, clientSecret, request.Token)
+	if err != nil {
+		oauthErrorResponseFrom(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, introspection)
+}
+
+// clientCredentials returns the credentials of the client from HTTP Basic or from the body.
+// The credentials in HTTP Basic are form-urlencoded as RFC 6749 section 2.3.1 requires.
+func clientCredentials(c *gin.Context, formClientID, formClientSecret string) (string, string) {
+	if clientID, clientSecret, ok := c.Request.BasicAuth(); ok {
+		id, idErr := url.QueryUnescape(clientID)
+		secret, secretErr := url.QueryUnescape(clientSecret)
+		if idErr != nil || secretErr != nil {
+			return "", ""
+		}
+
+		return id, secret
+	}
+
+	return formClientID, formClientSecret
+}
+
+func oauthErrorResponseFrom(c *gin.Context, err error) {
+	var oauthErr *oauthService.Error
+	if !errors.As(err, &oauthErr) {
+		newErrorResponse(c, err)
+		return
+	}
+
+	status := http.StatusBadRequest
+	if oauthErr.Code == oauthService.CodeInvalidClient {
+		status = http.StatusUnauthorized
+		c.Header(wwwAuthenticateHeader, `Basic realm="qna"`)
+	}
+
+	c.AbortWithStatusJSON(status, oauthErrorResponse{Error: oauthErr.Code, ErrorDescription: oauthErr.Description})
+}
@@ -0,0 +1,345 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/oidc"
+	"github.com/popeskul/qna-go/internal/policy"
+)
+
+const oauthRedirectURI = "https://quiz.example.com/callback"
+
+func TestHandlers_RegisterOAuthClient(t *testing.T) {
+	ctx := context.Background()
+	u := randomUser()
+
+	helperCreatUser(t, ctx, u)
+	userID, err := findUserIDByEmail(u.Email)
+	if err != nil {
+		t.Fatalf("error finding user id: %v", err)
+	}
+
+	token, refreshToken, err := mockServices.Auth.SignIn(ctx, u)
+	if err != nil {
+		t.Fatalf("error generating accessToken: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		input  []byte
+		status int
+	}{
+		{
+			name:   "Success: register confidential client",
+			input:  []byte(`{"name": "quiz", "redirect_uris": ["https://quiz.example.com/callback"], "scopes": ["tests:read"], "confidential": true}`),
+			status: http.StatusCreated,
+		},
+		{
+			name:   "Error: client can't manage api keys",
+			input:  []byte(`{"name": "quiz", "redirect_uris": ["https://quiz.example.com/callback"], "scopes": ["api-keys:manage"]}`),
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Error: relative redirect uri",
+			input:  []byte(`{"name": "quiz", "redirect_uris": ["/callback"], "scopes": ["tests:read"]}`),
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Error: without redirect uris",
+			input:  []byte(`{"name": "quiz", "redirect_uris": [], "scopes": ["tests:read"]}`),
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/oauth/clients", bytes.NewReader(tt.input))
+			req.Header.Set("Content-Type", "application/json")
+
+			r := gin.Default()
+			r.Use(sessions.Sessions("session", mockHandlers.store))
+			r.POST("/api/v1/oauth/clients", setSessionMiddleware(t, token), mockHandlers.authMiddleware, mockHandlers.permissionMiddleware(policy.ManageOAuthClients), mockHandlers.RegisterOAuthClient)
+
+			testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+				return w.Code == tt.status
+			})
+		})
+	}
+
+	t.Cleanup(func() {
+		helperDeleteUserByID(t, userID)
+		helperDeleteRefreshTokenByToken(t, refreshToken)
+	})
+}
+
+func TestHandlers_OAuthAuthorizationCodeFlow(t *testing.T) {
+	ctx := context.Background()
+	u := randomUser()
+
+	helperCreatUser(t, ctx, u)
+	userID, err := findUserIDByEmail(u.Email)
+	if err != nil {
+		t.Fatalf("error finding user id: %v", err)
+	}
+
+	accessToken, sessionRefreshToken, err := mockServices.Auth.SignIn(ctx, u)
+	if err != nil {
+		t.Fatalf("error generating accessToken: %v", err)
+	}
+
+	client, err := mockServices.OAuth.RegisterClient(ctx, userID, domain.CreateOAuthClientRequest{
+		Name:         "quiz",
+		RedirectURIs: []string{oauthRedirectURI},
+		Scopes:       []string{string(policy.ReadTest), string(policy.CreateTest)},
+		Confidential: true,
+	})
+	if err != nil {
+		t.Fatalf("error registering client: %v", err)
+	}
+
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.Default()
+	r.Use(sessions.Sessions("session", mockHandlers.store))
+	r.GET("/api/v1/oauth/authorize", setSessionMiddleware(t, accessToken), mockHandlers.authMiddleware, mockHandlers.noDelegationMiddleware, mockHandlers.GetOAuthConsent)
+	r.POST("/api/v1/oauth/authorize", setSessionMiddleware(t, accessToken), mockHandlers.authMiddleware, mockHandlers.noDelegationMiddleware, mockHandlers.ApproveOAuthConsent)
+	r.POST("/api/v1/oauth/token", mockHandlers.OAuthToken)
+	r.POST("/api/v1/oauth/introspect", mockHandlers.IntrospectOAuthToken)
+	r.GET("/api/v1/tests", mockHandlers.authMiddleware, mockHandlers.permissionMiddleware(policy.ReadTest), mockHandlers.GetAllTestsByUserID)
+	r.POST("/api/v1/tests", mockHandlers.authMiddleware, mockHandlers.permissionMiddleware(policy.CreateTest), mockHandlers.CreateTest)
+
+	authRequest := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {client.ClientID},
+		"redirect_uri":          {oauthRedirectURI},
+		"scope":                 {string(policy.ReadTest)},
+		"state":                 {"xyz"},
+		"code_challenge":        {oidc.CodeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	// The consent screen is shown on the first authorization with the CSRF token of the session.
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/oauth/authorize?"+authRequest.Encode(), nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var consent consentResponse
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &consent) != nil || consent.Granted || consent.CSRFToken == "" {
+		t.Fatalf("expected the consent screen, got %d: %s", w.Code, w.Body.String())
+	}
+	cookies := w.Result().Cookies()
+	session := cookies[len(cookies)-1]
+
+	decision, _ := json.Marshal(map[string]interface{}{
+		"response_type":         "code",
+		"client_id":             client.ClientID,
+		"redirect_uri":          oauthRedirectURI,
+		"scope":                 string(policy.ReadTest),
+		"state":                 "xyz",
+		"code_challenge":        oidc.CodeChallenge(verifier),
+		"code_challenge_method": "S256",
+		"approve":               true,
+	})
+	// The decision of a cross-site page comes without the CSRF token.
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/oauth/authorize", bytes.NewReader(decision))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(session)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403 without the CSRF token, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/oauth/authorize", bytes.NewReader(decision))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(csrfTokenHeader, consent.CSRFToken)
+	req.AddCookie(session)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var redirect consentRedirectResponse
+	if err = json.Unmarshal(w.Body.Bytes(), &redirect); err != nil {
+		t.Fatal(err)
+	}
+	location, err := url.Parse(redirect.RedirectTo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if location.Query().Get("state") != "xyz" || location.Query().Get("code") == "" {
+		t.Fatalf("unexpected redirect: %s", redirect.RedirectTo)
+	}
+	code := location.Query().Get("code")
+
+	// The consent is remembered, the next authorization redirects at once.
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/oauth/authorize?"+authRequest.Encode(), nil)
+	testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+		return w.Code == http.StatusFound && strings.HasPrefix(w.Header().Get("Location"), oauthRedirectURI)
+	})
+
+	exchange := func(form url.Values, basicAuth bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if basicAuth {
+			req.SetBasicAuth(client.ClientID, client.ClientSecret)
+		}
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	codeForm := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {oauthRedirectURI},
+		"code_verifier": {"wrong-verifier-wrong-verifier-wrong-verifier"},
+	}
+	if w = exchange(codeForm, true); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid_grant for wrong verifier, got %d", w.Code)
+	}
+
+	// The code is single-use, so the failed exchange burned it.
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/oauth/authorize", bytes.NewReader(decision))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if err = json.Unmarshal(w.Body.Bytes(), &redirect); err != nil {
+		t.Fatal(err)
+	}
+	location, _ = url.Parse(redirect.RedirectTo)
+	codeForm.Set("code", location.Query().Get("code"))
+	codeForm.Set("code_verifier", verifier)
+
+	if w = exchange(codeForm, false); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected invalid_client without credentials, got %d", w.Code)
+	}
+
+	if w = exchange(codeForm, true); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var tokens domain.TokenResponse
+	if err = json.Unmarshal(w.Body.Bytes(), &tokens); err != nil {
+		t.Fatal(err)
+	}
+	if tokens.TokenType != "Bearer" || tokens.RefreshToken == "" || tokens.Scope != string(policy.ReadTest) {
+		t.Fatalf("unexpected token response: %+v", tokens)
+	}
+
+	if w = exchange(codeForm, true); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid_grant for the reused code, got %d", w.Code)
+	}
+
+	t.Run("scopes are enforced", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/tests", nil)
+		req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+		testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+			return w.Code == http.StatusOK
+		})
+
+		req = httptest.NewRequest(http.MethodPost, "/api/v1/tests", bytes.NewReader([]byte(`{"title": "test"}`)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+		testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+			return w.Code == http.StatusForbidden
+		})
+	})
+
+	t.Run("token can't approve other clients", func(t *testing.T) {
+		r := gin.Default()
+		r.GET("/api/v1/oauth/authorize", mockHandlers.authMiddleware, mockHandlers.noDelegationMiddleware, mockHandlers.GetOAuthConsent)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/oauth/authorize?"+authRequest.Encode(), nil)
+		req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+		testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+			return w.Code == http.StatusForbidden
+		})
+	})
+
+	t.Run("introspect", func(t *testing.T) {
+		form := url.Values{"token": {tokens.AccessToken}}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/oauth/introspect", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(client.ClientID, client.ClientSecret)
+
+		testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+			var introspection domain.Introspection
+			return w.Code == http.StatusOK && json.Unmarshal(w.Body.Bytes(), &introspection) == nil &&
+				introspection.Active && introspection.UserID == userID && introspection.ClientID == client.ClientID
+		})
+
+		form = url.Values{"token": {accessToken}}
+		req = httptest.NewRequest(http.MethodPost, "/api/v1/oauth/introspect", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(client.ClientID, client.ClientSecret)
+
+		testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+			var introspection domain.Introspection
+			return w.Code == http.StatusOK && json.Unmarshal(w.Body.Bytes(), &introspection) == nil && !introspection.Active
+		})
+	})
+
+	t.Run("refresh", func(t *testing.T) {
+		form := url.Values{"grant_type": {"refresh_token"}, "refresh_token": {tokens.RefreshToken}}
+		w := exchange(form, true)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var refreshed domain.TokenResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &refreshed); err != nil {
+			t.Fatal(err)
+		}
+
+		if w = exchange(form, true); w.Code != http.StatusBadRequest {
+			t.Errorf("expected invalid_grant for the reused refresh token, got %d", w.Code)
+		}
+
+		if _, _, err := mockServices.Auth.GenerateAccessRefreshTokens(ctx, refreshed.RefreshToken); err == nil {
+			t.Error("client refresh token must not refresh the session of the application")
+		}
+	})
+
+	t.Run("client credentials", func(t *testing.T) {
+		form := url.Values{"grant_type": {"client_credentials"}, "scope": {string(policy.CreateTest)}}
+		w := exchange(form, true)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var tokens domain.TokenResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &tokens); err != nil {
+			t.Fatal(err)
+		}
+		if tokens.RefreshToken != "" || tokens.Scope != string(policy.CreateTest) {
+			t.Errorf("unexpected token response: %+v", tokens)
+		}
+
+		form.Set("scope", string(policy.ManageUsers))
+		if w = exchange(form, true); w.Code != http.StatusBadRequest {
+			t.Errorf("expected invalid_scope, got %d", w.Code)
+		}
+	})
+
+	t.Cleanup(func() {
+		helperDeleteUserByID(t, userID)
+		helperDeleteRefreshTokenByToken(t, sessionRefreshToken)
+	})
+}
@@ -0,0 +1,195 @@
+// Package v1 defines the handlers for the 1 version.
+package v1
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/apperror"
+	"github.com/popeskul/qna-go/internal/services/auth"
+)
+
+// loginStateCookie binds the login with the identity provider to the browser that started it.
+const loginStateCookie = "oidc-state"
+
+var (
+	ErrProviderDenied = errors.New("identity provider denied the authorization")
+)
+
+// authorizationURLResponse is returned when the identity is linked to the signed-in user.
+type authorizationURLResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// StartOIDCLogin godoc
+// @Summary Sign in with identity provider
+// @Tags auth
+// @Description Redirect to the identity provider to sign in with the authorization code flow and PKCE.
+// @ID start-oidc-login
+// @Param provider path string true "identity provider"
+// @Success 302
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /auth/oidc/{provider}/login [get]
+func (h *Handlers) StartOIDCLogin(c *gin.Context) {
+	authURL, state, err := h.service.Auth.StartOIDCLogin(c, c.Param("provider"), 0)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	setLoginStateCookie(c, state, int(auth.LoginStateTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// LinkOIDCIdentity godoc
+// @Summary Link identity provider account
+// @Security ApiKeyAuth
+// @Tags auth
+// @Description Get the URL of the identity provider to link its account to the signed-in user.
+// @ID link-oidc-identity
+// @Produce  json
+// @Param provider path string true "identity provider"
+// @Success 200 {object} authorizationURLResponse
+// @Failure 401,404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /auth/oidc/{provider}/link [post]
+func (h *Handlers) LinkOIDCIdentity(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	authURL, state, err := h.service.Auth.StartOIDCLogin(c, c.Param("provider"), userID)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	setLoginStateCookie(c, state, int(auth.LoginStateTTL.Seconds()))
+	c.JSON(http.StatusOK, authorizationURLResponse{AuthorizationURL: authURL})
+}
+
+// FinishOIDCLogin godoc
+// @Summary Identity provider callback
+// @Tags auth
+// @Description Complete the sign in with the identity provider. The account is linked or created on the first sign in.
+// @Description The callback is accepted only in the browser that started the login, the linking only for the signed-in user.
+// @ID finish-oidc-login
+// @Produce  json
+// @Param provider path string true "identity provider"
+// @Param code query string true "authorization code"
+// @Param state query string true "state"
+// @Success 200 {string} string "access_token"
+// @Success 202 {object} twoFactorChallengeResponse
+// @Failure 401,403,404,409 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /auth/oidc/{provider}/callback [get]
+func (h *Handlers) FinishOIDCLogin(c *gin.Context) {
+	if c.Query("error") != "" {
+		newErrorResponse(c, ErrProviderDenied)
+		return
+	}
+
+	state := c.Query("state")
+	cookie, _ := c.Cookie(loginStateCookie)
+	setLoginStateCookie(c, "", -1)
+	if state == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(state)) != 1 {
+		newErrorResponse(c, auth.ErrInvalidLoginState)
+		return
+	}
+
+	// the linking is finished only by the user that started it
+	userID := 0
+	if payload, err := h.authenticate(c); err == nil {
+		userID = payload.UserID
+	}
+
+	accessToken, refreshToken, err := h.service.Auth.FinishOIDCLogin(c, c.Param("provider"), state, c.Query("code"), userID)
+	if err != nil {
+		var challenge *auth.ChallengeError
+		if errors.As(err, &challenge) {
+			c.JSON(http.StatusAccepted, twoFactorChallengeResponse{
+				ChallengeToken: challenge.Token,
+				ExpiresAt:      challenge.ExpiresAt,
+			})
+			return
+		}
+
+		newErrorResponse(c, err)
+		return
+	}
+
+	respondWithTokens(c, accessToken, refreshToken)
+}
+
+// setLoginStateCookie stores the state for the callback next to the login and the link paths, maxAge < 0 deletes it.
+func setLoginStateCookie(c *gin.Context, state string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(loginStateCookie, state, maxAge, path.Dir(c.Request.URL.Path), "", c.Request.TLS != nil, true)
+}
+
+// GetIdentities godoc
+// @Summary Get linked identities
+// @Security ApiKeyAuth
+// @Tags auth
+// @Description Get the identity provider accounts linked to the user.
+// @ID get-identities
+// @Produce  json
+// @Success 200 {array} domain.LinkedIdentity
+// @Failure 401 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /auth/identities [get]
+func (h *Handlers) GetIdentities(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	linked, err := h.service.Auth.GetIdentities(c, userID)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, linked)
+}
+
+// UnlinkIdentity godoc
+// @Summary Unlink identity
+// @Security ApiKeyAuth
+// @Tags auth
+// @Description Unlink the identity provider account. The only sign in method of the user can't be unlinked.
+// @ID unlink-identity
+// @Produce  json
+// @Param id path int true "identity id"
+// @Success 200
+// @Failure 400,401,404,409 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /auth/identities/{id} [delete]
+func (h *Handlers) UnlinkIdentity(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	identityID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	if err = h.service.Auth.UnlinkIdentity(c, userID, identityID); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
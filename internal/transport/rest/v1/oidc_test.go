@@ -0,0 +1,49 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/logger"
+	"github.com/popeskul/qna-go/internal/services"
+)
+
+func TestHandlers_FinishOIDCLoginState(t *testing.T) {
+	h := NewHandler(&services.Service{}, nil, logger.GetLogger(), nil, nil, nil)
+
+	r := gin.New()
+	r.GET("/api/v1/auth/oidc/:provider/callback", h.FinishOIDCLogin)
+
+	cases := []struct {
+		name   string
+		query  string
+		cookie string
+	}{
+		{name: "Fail: without the cookie", query: "?state=started&code=code"},
+		{name: "Fail: the state of another browser", query: "?state=forged&code=code", cookie: "started"},
+		{name: "Fail: without the state", query: "?code=code", cookie: "started"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/oidc/company/callback"+tt.query, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: loginStateCookie, Value: tt.cookie})
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d: %s", w.Code, http.StatusUnauthorized, w.Body.String())
+			}
+
+			cookies := w.Result().Cookies()
+			if len(cookies) != 1 || cookies[0].Name != loginStateCookie || cookies[0].MaxAge >= 0 || cookies[0].Path != "/api/v1/auth/oidc/company" {
+				t.Errorf("cookies = %v, want the state cookie deleted", cookies)
+			}
+		})
+	}
+}
@@ -1,8 +1,14 @@
 package v1
 
 import (
+	"errors"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/apperror"
 	"github.com/popeskul/qna-go/internal/logger"
+	"github.com/popeskul/qna-go/internal/password"
+	"github.com/popeskul/qna-go/internal/validation"
 	"github.com/sirupsen/logrus"
 )
 
@@ -10,18 +16,67 @@ var (
 	accessTokenName = "access-token"
 )
 
-// errorResponse is the error response
-type errorResponse struct {
-	Message string `json:"message"`
+const problemContentType = "application/problem+json"
+
+// problemResponse is the error response, the problem details of RFC 7807 with the stable code of the error.
+type problemResponse struct {
+	Type     string                 `json:"type"`
+	Title    string                 `json:"title"`
+	Status   int                    `json:"status"`
+	Detail   string                 `json:"detail"`
+	Instance string                 `json:"instance"`
+	Code     string                 `json:"code"`
+	Details  map[string]interface{} `json:"details,omitempty"`
 }
 
-// newErrorResponse creates a new error response and logs the error message
-func newErrorResponse(c *gin.Context, statusCode int, message string) {
-	logger := logger.GetLogger()
-	logger.WithFields(logrus.Fields{
-		"url":    c.Request.URL.String(),
+// newErrorResponse maps the error to the application error, logs it with the cause and writes the problem.
+// The client sees only the safe message of the application error.
+func newErrorResponse(c *gin.Context, err error) {
+	appErr := toAppError(err)
+
+	entry := logger.GetLogger().WithFields(logrus.Fields{
+		"url":    logger.RedactURL(c.Request.URL),
 		"method": c.Request.Method,
-	}).Error(message)
+		"code":   appErr.Code,
+		"status": appErr.Status,
+	})
+	if appErr.Status >= http.StatusInternalServerError {
+		entry.Error(err)
+	} else {
+		entry.Info(err)
+	}
+
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(appErr.Status, problemResponse{
+		Type:     "about:blank",
+		Title:    http.StatusText(appErr.Status),
+		Status:   appErr.Status,
+		Detail:   appErr.Message,
+		Instance: c.Request.URL.Path,
+		Code:     appErr.Code,
+		Details:  appErr.Details,
+	})
+}
+
+// WriteError writes the error like the v1 handlers do. It is used by the transports served under /api/v1
+// by their own packages, so all of them answer with the same problems and codes.
+func WriteError(c *gin.Context, err error) {
+	newErrorResponse(c, err)
+}
+
+// toAppError maps the errors of the services and the repositories to the application errors.
+func toAppError(err error) *apperror.Error {
+	var fieldErrs validation.Errors
+	if errors.As(err, &fieldErrs) {
+		return apperror.New(codeValidationFailed, http.StatusBadRequest, "the request is invalid").Wrap(err).
+			WithDetails(map[string]interface{}{"errors": fieldErrs})
+	}
+
+	var validationErr *password.ValidationError
+	if errors.As(err, &validationErr) {
+		return apperror.New(codePasswordPolicy, http.StatusBadRequest, validationErr.Error()).Wrap(err).
+			WithDetails(map[string]interface{}{"violations": validationErr.Violations})
+	}
 
-	c.AbortWithStatusJSON(statusCode, errorResponse{message})
+	return apperror.Map(err, errorRules)
 }
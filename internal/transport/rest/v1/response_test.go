@@ -0,0 +1,84 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/apperror"
+	"github.com/popeskul/qna-go/internal/password"
+	"github.com/popeskul/qna-go/internal/repository/tests"
+	"github.com/popeskul/qna-go/internal/services/auth"
+	"github.com/popeskul/qna-go/internal/validation"
+)
+
+func TestNewErrorResponse(t *testing.T) {
+	cases := []struct {
+		name       string
+		err        error
+		status     int
+		code       string
+		detail     string
+		violations bool
+		fields     int
+	}{
+		{name: "not found", err: tests.ErrTestNotFound, status: http.StatusNotFound, code: codeTestNotFound, detail: tests.ErrTestNotFound.Error()},
+		{name: "conflict", err: auth.ErrUserExists, status: http.StatusConflict, code: codeEmailTaken, detail: "email is already taken"},
+		{name: "invalid request", err: ErrTestIDRequired, status: http.StatusBadRequest, code: "invalid_request", detail: "the request is invalid"},
+		{
+			name:   "validation failed",
+			err:    apperror.Invalid(validation.Errors{{Field: "title", Rule: "required", Message: "is required"}}),
+			status: http.StatusBadRequest,
+			code:   codeValidationFailed,
+			fields: 1,
+		},
+		{
+			name:       "password policy",
+			err:        &password.ValidationError{Violations: []password.Violation{{Code: password.CodeTooShort, Message: "too short"}}},
+			status:     http.StatusBadRequest,
+			code:       codePasswordPolicy,
+			violations: true,
+		},
+		{name: "internal error hides the cause", err: errors.New("pq: relation does not exist"), status: http.StatusInternalServerError, code: "internal", detail: "internal server error"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/api/v1/tests/1", func(c *gin.Context) {
+				newErrorResponse(c, tt.err)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/tests/1", nil))
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != problemContentType {
+				t.Errorf("Content-Type = %q, want %q", ct, problemContentType)
+			}
+
+			var problem problemResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("error decoding the problem: %v", err)
+			}
+
+			if problem.Code != tt.code || problem.Status != tt.status || problem.Instance != "/api/v1/tests/1" {
+				t.Errorf("problem = %+v", problem)
+			}
+			if tt.detail != "" && problem.Detail != tt.detail {
+				t.Errorf("detail = %q, want %q", problem.Detail, tt.detail)
+			}
+			if _, ok := problem.Details["violations"]; ok != tt.violations {
+				t.Errorf("details = %v", problem.Details)
+			}
+			if fields, _ := problem.Details["errors"].([]interface{}); len(fields) != tt.fields {
+				t.Errorf("details = %v", problem.Details)
+			}
+		})
+	}
+}
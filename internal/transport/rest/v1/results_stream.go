@@ -0,0 +1,131 @@
+package v1
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/apperror"
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/policy"
+)
+
+const (
+	streamWriteWait    = 10 * time.Second
+	streamPingInterval = 15 * time.Second
+)
+
+// StreamTestResults godoc
+// @Summary Stream results of the test
+// @Tags tests
+// @Security ApiKeyAuth
+// @Description Stream the results of the test as Server-Sent Events. The "finished" event is sent when a user
+// @Description finishes the test and the "scored" event when the result is changed. Allowed for the owner, editors and reviewers of the test.
+// @ID stream-test-results
+// @Produce  text/event-stream
+// @Param id path int true "id"
+// @Success 200 {object} domain.PassageEvent
+// @Failure 400,401,403,404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /tests/{id}/results/stream [get]
+func (h *Handlers) StreamTestResults(c *gin.Context) {
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	subject, err := getSubject(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	if _, err = h.service.Tests.AuthorizeTest(c, subject, testID, policy.ViewResults); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	sub := h.events.Subscribe(testID)
+	defer sub.Close()
+
+	// the connection is hijacked because the write timeout of the server would end the stream
+	conn, rw, err := c.Writer.Hijack()
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	defer conn.Close()
+
+	// the deadlines of the server are not needed anymore, every write sets its own
+	if err = conn.SetDeadline(time.Time{}); err != nil {
+		return
+	}
+
+	// the client doesn't send anything else, the read ends when it goes away
+	gone := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.Discard, rw)
+		close(gone)
+	}()
+
+	if err = writeStreamHeader(conn, rw.Writer); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(streamPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				// the client is too slow, it has to reconnect
+				return
+			}
+			if err = writeStreamEvent(conn, rw.Writer, event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err = writeStream(conn, rw.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
+
+func writeStreamHeader(conn net.Conn, w *bufio.Writer) error {
+	return writeStream(conn, w, "HTTP/1.1 200 OK\r\n"+
+		"Content-Type: text/event-stream\r\n"+
+		"Cache-Control: no-cache\r\n"+
+		"Connection: close\r\n\r\n")
+}
+
+func writeStreamEvent(conn net.Conn, w *bufio.Writer, event domain.PassageEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return writeStream(conn, w, fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", event.PassageID, event.Type, data))
+}
+
+// writeStream writes s to the hijacked connection within streamWriteWait.
+func writeStream(conn net.Conn, w *bufio.Writer, s string) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(streamWriteWait)); err != nil {
+		return err
+	}
+
+	if _, err := w.WriteString(s); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,150 @@
+package v1
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+func TestHandlers_StreamTestResults(t *testing.T) {
+	ctx := context.Background()
+	owner := randomUser()
+	stranger := randomUser()
+
+	helperCreatUser(t, ctx, owner)
+	helperCreatUser(t, ctx, stranger)
+
+	ownerID, err := findUserIDByEmail(owner.Email)
+	if err != nil {
+		t.Fatalf("error finding user id: %v", err)
+	}
+	strangerID, err := findUserIDByEmail(stranger.Email)
+	if err != nil {
+		t.Fatalf("error finding user id: %v", err)
+	}
+
+	ownerToken, ownerRefreshToken, err := mockServices.Auth.SignIn(ctx, owner)
+	if err != nil {
+		t.Fatalf("error generating accessToken: %v", err)
+	}
+	strangerToken, strangerRefreshToken, err := mockServices.Auth.SignIn(ctx, stranger)
+	if err != nil {
+		t.Fatalf("error generating accessToken: %v", err)
+	}
+
+	testID := helperCreateTest(t, ownerID, randomTest())
+
+	r := gin.Default()
+	r.Use(sessions.Sessions("session", mockHandlers.store))
+	r.Use(func(c *gin.Context) {
+		setSessionMiddleware(t, c.GetHeader("X-Test-Token"))(c)
+	})
+	r.GET("/api/v1/tests/:id/results/stream", mockHandlers.authMiddleware, mockHandlers.StreamTestResults)
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	url := srv.URL + "/api/v1/tests/" + strconv.Itoa(testID) + "/results/stream"
+
+	tests := []struct {
+		name   string
+		url    string
+		token  string
+		status int
+	}{
+		{name: "Fail: stranger streams the results", url: url, token: strangerToken, status: http.StatusForbidden},
+		{name: "Fail: test not found", url: srv.URL + "/api/v1/tests/0/results/stream", token: ownerToken, status: http.StatusNotFound},
+		{name: "Fail: invalid id", url: srv.URL + "/api/v1/tests/abc/results/stream", token: ownerToken, status: http.StatusBadRequest},
+		{name: "Fail: without token", url: url, status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+			req.Header.Set("X-Test-Token", tt.token)
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("error requesting the stream: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, resp.StatusCode)
+			}
+		})
+	}
+
+	t.Run("Success: owner receives the events of the test", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("X-Test-Token", ownerToken)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("error requesting the stream: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("unexpected response: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+
+		events := []domain.PassageEvent{
+			{Type: domain.PassageFinished, PassageID: 1, TestID: testID + 1, UserID: strangerID},
+			{Type: domain.PassageFinished, PassageID: 2, TestID: testID, UserID: strangerID, Passed: true},
+		}
+		for _, event := range events {
+			if err = mockEvents.Publish(ctx, event); err != nil {
+				t.Fatalf("error publishing: %v", err)
+			}
+		}
+
+		lines := make(chan string, 16)
+		go func() {
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				lines <- scanner.Text()
+			}
+			close(lines)
+		}()
+
+		var received []string
+		for len(received) < 3 {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					t.Fatalf("the stream is closed, received %v", received)
+				}
+				if line != "" {
+					received = append(received, line)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("no event, received %v", received)
+			}
+		}
+
+		expected := []string{"id: 2", "event: finished", `data: {"type":"finished","passage_id":2`}
+		for i, s := range expected {
+			if !strings.HasPrefix(received[i], s) {
+				t.Errorf("expected %q, got %q", s, received[i])
+			}
+		}
+	})
+
+	t.Cleanup(func() {
+		helperDeleteTestByID(t, testID)
+		helperDeleteUserByID(t, ownerID)
+		helperDeleteUserByID(t, strangerID)
+		helperDeleteRefreshTokenByToken(t, ownerRefreshToken)
+		helperDeleteRefreshTokenByToken(t, strangerRefreshToken)
+	})
+}
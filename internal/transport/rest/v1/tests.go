@@ -3,22 +3,28 @@ package v1
 
 import (
 	"context"
-	"database/sql"
-	"errors"
 	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/apperror"
 	"github.com/popeskul/qna-go/internal/domain"
-	"github.com/popeskul/qna-go/internal/repository/tests"
+	"github.com/popeskul/qna-go/internal/policy"
 	"net/http"
 	"strconv"
+	"strings"
+)
+
+var (
+	ErrUserIDRequired = apperror.ErrInvalidRequest.WithDetails(map[string]interface{}{"reason": "user id is required"})
+	ErrTestIDRequired = apperror.ErrInvalidRequest.WithDetails(map[string]interface{}{"reason": "test id is required"})
 )
 
 // Tests interface is implemented by the service.
 type Tests interface {
-	CreateTest(ctx context.Context, test domain.Test) error
+	CreateTest(ctx context.Context, userID int, test domain.Test) (domain.Test, error)
 	GetTestByID(ctx context.Context, id int) (domain.Test, error)
 	GetAllTestsByUserID(ctx context.Context, userID int, params domain.GetAllTestsParams) ([]domain.Test, error)
-	UpdateTestByID(ctx context.Context, id int, test domain.Test) error
-	DeleteTestByID(ctx context.Context, id int) error
+	UpdateTestByID(ctx context.Context, id, version int, test domain.Test) (domain.Test, error)
+	PatchTestByID(ctx context.Context, id, version int, patch domain.TestPatch) (domain.Test, error)
+	DeleteTestByID(ctx context.Context, id, version int) error
 }
 
 // CreateTest godoc
@@ -29,39 +35,40 @@ type Tests interface {
 // @ID create-test
 // @Accept  json
 // @Produce  json
-// @Param test body domain.Test true "test"
-// @Success 201
-// @Failure 400,401 {object} errorResponse
-// @Failure 500 {object} errorResponse
+// @Param test body domain.TestRequest true "test"
+// @Param Idempotency-Key header string false "key of the request, its retries get the same response"
+// @Success 201 {object} domain.Test
+// @Header 201 {string} Location "url of the created test"
+// @Header 201 {string} ETag "version of the test"
+// @Failure 400,401,409,422 {object} problemResponse
+// @Failure 500 {object} problemResponse
 // @Router /tests [post]
 func (h *Handlers) CreateTest(c *gin.Context) {
 	userId, err := getUserId(c)
 	if err != nil {
-		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		newErrorResponse(c, err)
 		return
 	}
 	if userId == 0 {
-		newErrorResponse(c, http.StatusBadRequest, "user id is required")
+		newErrorResponse(c, ErrUserIDRequired)
 		return
 	}
 
-	var test domain.Test
-	if err = c.ShouldBindJSON(&test); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	var request domain.TestRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
 		return
 	}
 
-	if test.Title == "" {
-		newErrorResponse(c, http.StatusBadRequest, "title is required")
-		return
-	}
-
-	if err = h.service.Tests.CreateTest(c, userId, test); err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	test, err := h.service.Tests.CreateTest(c, userId, request.Test())
+	if err != nil {
+		newErrorResponse(c, err)
 		return
 	}
 
-	c.Status(http.StatusCreated)
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+strconv.Itoa(test.ID))
+	c.Header("ETag", testETag(test))
+	c.JSON(http.StatusCreated, test)
 }
 
 // GetTestByID godoc
@@ -73,36 +80,36 @@ func (h *Handlers) CreateTest(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param id path int true "id"
+// @Param If-None-Match header string false "ETag of the cached test"
 // @Success 200 {object} domain.Test
-// @Failure 400,401,404 {object} errorResponse
-// @Failure 500 {object} errorResponse
+// @Success 304
+// @Header 200,304 {string} ETag "version of the test"
+// @Failure 400,401,403,404 {object} problemResponse
+// @Failure 500 {object} problemResponse
 // @Router /tests/{id} [get]
 func (h *Handlers) GetTestByID(c *gin.Context) {
-	userId, err := getUserId(c)
+	testID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		newErrorResponse(c, apperror.Invalid(err))
 		return
 	}
 
-	testID, err := strconv.Atoi(c.Param("id"))
+	subject, err := getSubject(c)
 	if err != nil {
-		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		newErrorResponse(c, err)
 		return
 	}
 
-	test, err := h.service.Tests.GetTest(c, testID)
+	test, err := h.service.Tests.AuthorizeTest(c, subject, testID, policy.ReadTest)
 	if err != nil {
-		if err == tests.ErrTestNotFound {
-			newErrorResponse(c, http.StatusNotFound, err.Error())
-			return
-		}
-
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		newErrorResponse(c, err)
 		return
 	}
 
-	if test.AuthorID != userId {
-		newErrorResponse(c, http.StatusUnauthorized, "you are not allowed to get this test")
+	etag := testETag(test)
+	c.Header("ETag", etag)
+	if noneMatch(c, etag) {
+		c.Status(http.StatusNotModified)
 		return
 	}
 
@@ -120,23 +127,23 @@ func (h *Handlers) GetTestByID(c *gin.Context) {
 // @Param page_id query int false "page id"
 // @Param page_size query int false "page size"
 // @Success 200 {object} []domain.Test
-// @Failure 400,401,404 {object} errorResponse
-// @Failure 500 {object} errorResponse
+// @Failure 400,401,404 {object} problemResponse
+// @Failure 500 {object} problemResponse
 // @Router /tests [get]
 func (h *Handlers) GetAllTestsByUserID(c *gin.Context) {
 	userID, err := getUserId(c)
 	if err != nil {
-		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		newErrorResponse(c, err)
 		return
 	}
 	if userID == 0 {
-		newErrorResponse(c, http.StatusBadRequest, "user id is required")
+		newErrorResponse(c, ErrUserIDRequired)
 		return
 	}
 
 	var request domain.GetAllTestsRequest
 	if err = c.ShouldBindQuery(&request); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		newErrorResponse(c, apperror.Invalid(err))
 		return
 	}
 
@@ -147,12 +154,7 @@ func (h *Handlers) GetAllTestsByUserID(c *gin.Context) {
 
 	tests, err := h.service.Tests.GetAllTestsByUserID(c, userID, args)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			newErrorResponse(c, http.StatusNotFound, "tests not found")
-			return
-		}
-
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		newErrorResponse(c, err)
 		return
 	}
 
@@ -163,69 +165,145 @@ func (h *Handlers) GetAllTestsByUserID(c *gin.Context) {
 // @Summary Update test by id
 // @Tags tests
 // @Security ApiKeyAuth
-// @Description Update test by id
+// @Description Update test by id. The If-Match header must have the ETag of the test, "*" updates any version.
 // @ID update-test-by-id
 // @Accept  json
 // @Produce  json
 // @Param id path int true "id"
-// @Param test body domain.Test true "test"
+// @Param If-Match header string true "ETag of the test"
+// @Param test body domain.TestRequest true "test"
 // @Success 200
-// @Failure 401 {object} errorResponse
-// @Failure 500 {object} errorResponse
+// @Header 200 {string} ETag "new version of the test"
+// @Failure 400,401,403,404,412,428 {object} problemResponse
+// @Failure 500 {object} problemResponse
 // @Router /tests/{id} [put]
 func (h *Handlers) UpdateTestByID(c *gin.Context) {
 	testID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		newErrorResponse(c, err)
 		return
 	}
 
-	var test domain.Test
-	if err := c.ShouldBindJSON(&test); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	var request domain.TestRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
 		return
 	}
 
-	if err := h.service.Tests.UpdateTestByID(c, testID, test); err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	subject, err := getSubject(c)
+	if err != nil {
+		newErrorResponse(c, err)
 		return
 	}
 
+	test, err := h.service.Tests.UpdateTestByID(c, subject, testID, version, request.Test())
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.Header("ETag", testETag(test))
 	c.Status(http.StatusOK)
 }
 
+// PatchTestByID godoc
+// @Summary Patch test by id
+// @Tags tests
+// @Security ApiKeyAuth
+// @Description Change the fields of the test set in the JSON Merge Patch (RFC 7396), the other fields are kept.
+// @Description The If-Match header must have the ETag of the test, "*" patches any version.
+// @ID patch-test-by-id
+// @Accept  application/merge-patch+json
+// @Produce  json
+// @Param id path int true "id"
+// @Param If-Match header string true "ETag of the test"
+// @Param patch body domain.TestPatch true "patch"
+// @Success 200 {object} domain.Test
+// @Header 200 {string} ETag "new version of the test"
+// @Failure 400,401,403,404,412,415,428 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /tests/{id} [patch]
+func (h *Handlers) PatchTestByID(c *gin.Context) {
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	var patch domain.TestPatch
+	if err = bindMergePatch(c, &patch); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	subject, err := getSubject(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	test, err := h.service.Tests.PatchTestByID(c, subject, testID, version, patch)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.Header("ETag", testETag(test))
+	c.JSON(http.StatusOK, test)
+}
+
 // DeleteTestByID godoc
 // @Summary Delete test by id
 // @Tags tests
-// @Description Delete test by id
+// @Description Delete test by id. The If-Match header must have the ETag of the test, "*" deletes any version.
 // @ID delete-test-by-id
 // @Security ApiKeyAuth
 // @Accept  json
 // @Produce  json
 // @Param id path int true "id"
+// @Param If-Match header string true "ETag of the test"
 // @Success 200
-// @Failure 400,401,404 {object} errorResponse
-// @Failure 500 {object} errorResponse
+// @Failure 400,401,403,404,412,428 {object} problemResponse
+// @Failure 500 {object} problemResponse
 // @Router /tests/{id} [delete]
 func (h *Handlers) DeleteTestByID(c *gin.Context) {
 	testID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		newErrorResponse(c, apperror.Invalid(err))
 		return
 	}
 
 	if testID == 0 {
-		newErrorResponse(c, http.StatusBadRequest, "test id is required")
+		newErrorResponse(c, ErrTestIDRequired)
 		return
 	}
 
-	if err = h.service.Tests.DeleteTestByID(c, testID); err != nil {
-		if errors.Unwrap(err) == tests.ErrTest {
-			newErrorResponse(c, http.StatusNotFound, tests.ErrTest.Error())
-			return
-		}
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	subject, err := getSubject(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
 
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	if err = h.service.Tests.DeleteTestByID(c, subject, testID, version); err != nil {
+		newErrorResponse(c, err)
 		return
 	}
 
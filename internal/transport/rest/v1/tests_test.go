@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -72,7 +73,18 @@ func TestHandlers_CreateTests(t *testing.T) {
 					}
 				})
 
-				return w.Code == tt.status
+				if w.Code != http.StatusCreated {
+					return w.Code == tt.status
+				}
+
+				var created domain.Test
+				if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+					t.Errorf("error unmarshalling response: %v", err)
+					return false
+				}
+
+				return w.Code == tt.status && created.Title == test.Title && created.AuthorID == userID &&
+					w.Header().Get("Location") == "/api/v1/tests/"+strconv.Itoa(created.ID) && w.Header().Get("ETag") == testETag(created)
 			})
 		})
 	}
@@ -107,7 +119,7 @@ func TestHandlers_GetTestByID(t *testing.T) {
 		t.Fatalf("error generating accessToken: %v", err)
 	}
 
-	if err = mockRepo.CreateTest(ctx, user.ID, test); err != nil {
+	if _, err = mockRepo.CreateTest(ctx, user.ID, test); err != nil {
 		t.Fatalf("error creating test: %v", err)
 	}
 
@@ -432,6 +444,7 @@ func TestHandlers_UpdateTestByID(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPut, "/api/v1/tests/"+strconv.Itoa(tt.args.id), bytes.NewReader(tt.args.input))
 			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("If-Match", "*")
 
 			r := gin.Default()
 			r.Use(sessions.Sessions("session", mockHandlers.store))
@@ -451,6 +464,95 @@ func TestHandlers_UpdateTestByID(t *testing.T) {
 	})
 }
 
+func TestHandlers_PatchTestByID(t *testing.T) {
+	ctx := context.Background()
+	user := randomUser()
+
+	helperCreatUser(t, ctx, user)
+
+	userID, err := findUserIDByEmail(user.Email)
+	if err != nil {
+		t.Fatalf("error finding user id: %v", err)
+	}
+
+	accessToken, refreshToken, err := mockServices.Auth.SignIn(ctx, user)
+	if err != nil {
+		t.Fatalf("error generating accessToken: %v", err)
+	}
+
+	test := randomTest()
+	testID := helperCreateTest(t, userID, test)
+	newTitle := util.RandomString(10)
+
+	// the test is cached by the read, the patch must invalidate it
+	if _, err = mockServices.Tests.GetTest(ctx, testID); err != nil {
+		t.Fatalf("error getting test: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		id          int
+		contentType string
+		input       string
+		token       string
+		status      int
+		title       string
+	}{
+		{name: "Success: empty patch keeps the test", id: testID, contentType: mergePatchContentType, input: `{}`, token: accessToken, status: http.StatusOK, title: test.Title},
+		{name: "Success: patch the title", id: testID, contentType: mergePatchContentType, input: `{"title": "` + newTitle + `", "unknown": 1}`, token: accessToken, status: http.StatusOK, title: newTitle},
+		{name: "Error: remove the title", id: testID, contentType: mergePatchContentType, input: `{"title": null}`, token: accessToken, status: http.StatusBadRequest},
+		{name: "Error: blank title", id: testID, contentType: mergePatchContentType, input: `{"title": "   "}`, token: accessToken, status: http.StatusBadRequest},
+		{name: "Error: patch is not an object", id: testID, contentType: mergePatchContentType, input: `["title"]`, token: accessToken, status: http.StatusBadRequest},
+		{name: "Error: unsupported content type", id: testID, contentType: "text/plain", input: `{}`, token: accessToken, status: http.StatusUnsupportedMediaType},
+		{name: "Error: test not found", id: 0, contentType: mergePatchContentType, input: `{}`, token: accessToken, status: http.StatusNotFound},
+		{name: "Error: invalid accessToken", id: testID, contentType: mergePatchContentType, input: `{}`, status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/api/v1/tests/"+strconv.Itoa(tt.id), strings.NewReader(tt.input))
+			req.Header.Set("Content-Type", tt.contentType)
+			req.Header.Set("If-Match", "*")
+
+			r := gin.Default()
+			r.Use(sessions.Sessions("session", mockHandlers.store))
+			r.PATCH("/api/v1/tests/:id", setSessionMiddleware(t, tt.token), mockHandlers.authMiddleware, mockHandlers.PatchTestByID)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var patched domain.Test
+			if err := json.Unmarshal(w.Body.Bytes(), &patched); err != nil {
+				t.Fatalf("error decoding test: %v", err)
+			}
+			if patched.Title != tt.title {
+				t.Errorf("expected title %s, got %s", tt.title, patched.Title)
+			}
+
+			cached, err := mockServices.Tests.GetTest(ctx, testID)
+			if err != nil {
+				t.Fatalf("error getting test: %v", err)
+			}
+			if cached.Title != tt.title {
+				t.Errorf("expected the read title %s, got %s", tt.title, cached.Title)
+			}
+		})
+	}
+
+	t.Cleanup(func() {
+		helperDeleteTestByID(t, testID)
+		helperDeleteUserByID(t, userID)
+		helperDeleteRefreshTokenByToken(t, refreshToken)
+	})
+}
+
 func TestHandlers_DeleteTestByID(t *testing.T) {
 	ctx := context.Background()
 	user := randomUser()
@@ -520,6 +622,7 @@ func TestHandlers_DeleteTestByID(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			req := httptest.NewRequest(http.MethodDelete, "/api/v1/tests/"+strconv.Itoa(tt.args.id), nil)
 			req.Header.Set("Authorization", "Bearer "+tt.args.token)
+			req.Header.Set("If-Match", "*")
 
 			r := gin.Default()
 			r.Use(sessions.Sessions("session", mockHandlers.store))
@@ -548,3 +651,80 @@ func setSessionMiddleware(t *testing.T, token string) gin.HandlerFunc {
 		}
 	}
 }
+
+func TestHandlers_TestConditionalRequests(t *testing.T) {
+	ctx := context.Background()
+	user := randomUser()
+
+	helperCreatUser(t, ctx, user)
+
+	userID, err := findUserIDByEmail(user.Email)
+	if err != nil {
+		t.Fatalf("error finding user id: %v", err)
+	}
+
+	accessToken, refreshToken, err := mockServices.Auth.SignIn(ctx, user)
+	if err != nil {
+		t.Fatalf("error generating accessToken: %v", err)
+	}
+
+	testID := helperCreateTest(t, userID, randomTest())
+	path := "/api/v1/tests/" + strconv.Itoa(testID)
+
+	r := gin.Default()
+	r.Use(sessions.Sessions("session", mockHandlers.store))
+	api := r.Group("/api/v1/tests", setSessionMiddleware(t, accessToken), mockHandlers.authMiddleware)
+	api.GET("/:id", mockHandlers.GetTestByID)
+	api.PUT("/:id", mockHandlers.UpdateTestByID)
+	api.PATCH("/:id", mockHandlers.PatchTestByID)
+	api.DELETE("/:id", mockHandlers.DeleteTestByID)
+
+	// two authors read the first version, the second one edits it after the first one
+	steps := []struct {
+		name    string
+		method  string
+		header  string
+		value   string
+		input   string
+		status  int
+		version string
+	}{
+		{name: "Success: get the ETag", method: http.MethodGet, status: http.StatusOK, version: `"1"`},
+		{name: "Success: not modified", method: http.MethodGet, header: "If-None-Match", value: `W/"1"`, status: http.StatusNotModified, version: `"1"`},
+		{name: "Fail: update without If-Match", method: http.MethodPut, input: `{"title": "first author"}`, status: http.StatusPreconditionRequired},
+		{name: "Success: first author updates", method: http.MethodPut, header: "If-Match", value: `"1"`, input: `{"title": "first author"}`, status: http.StatusOK, version: `"2"`},
+		{name: "Fail: second author updates the stale version", method: http.MethodPut, header: "If-Match", value: `"1"`, input: `{"title": "second author"}`, status: http.StatusPreconditionFailed},
+		{name: "Fail: second author patches the stale version", method: http.MethodPatch, header: "If-Match", value: `"1"`, input: `{"title": "second author"}`, status: http.StatusPreconditionFailed},
+		{name: "Fail: weak ETag never matches", method: http.MethodPatch, header: "If-Match", value: `W/"2"`, input: `{"title": "second author"}`, status: http.StatusPreconditionFailed},
+		{name: "Success: modified since", method: http.MethodGet, header: "If-None-Match", value: `"1"`, status: http.StatusOK, version: `"2"`},
+		{name: "Success: second author patches the new version", method: http.MethodPatch, header: "If-Match", value: `"2"`, input: `{"title": "second author"}`, status: http.StatusOK, version: `"3"`},
+		{name: "Fail: delete the stale version", method: http.MethodDelete, header: "If-Match", value: `"2"`, status: http.StatusPreconditionFailed},
+		{name: "Success: delete the current version", method: http.MethodDelete, header: "If-Match", value: `"3"`, status: http.StatusOK},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			req := httptest.NewRequest(step.method, path, strings.NewReader(step.input))
+			req.Header.Set("Content-Type", "application/json")
+			if step.header != "" {
+				req.Header.Set(step.header, step.value)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != step.status {
+				t.Fatalf("expected status %d, got %d: %s", step.status, w.Code, w.Body.String())
+			}
+			if etag := w.Header().Get("ETag"); etag != step.version {
+				t.Errorf("expected ETag %s, got %s", step.version, etag)
+			}
+		})
+	}
+
+	t.Cleanup(func() {
+		helperDeleteTestByID(t, testID)
+		helperDeleteUserByID(t, userID)
+		helperDeleteRefreshTokenByToken(t, refreshToken)
+	})
+}
@@ -0,0 +1,147 @@
+// Package v1 defines the handlers for the 1 version.
+package v1
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/apperror"
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+// twoFactorChallengeResponse is returned by sign-in when the TOTP code is required.
+type twoFactorChallengeResponse struct {
+	ChallengeToken string    `json:"challenge_token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+// recoveryCodesResponse is returned once when two-factor authentication is activated.
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// EnrollTwoFactor godoc
+// @Summary Enroll two-factor authentication
+// @Security ApiKeyAuth
+// @Tags auth
+// @Description Generate TOTP secret and otpauth URI. Two-factor authentication is enabled after activation.
+// @ID enroll-two-factor
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} domain.TwoFactorEnrollment
+// @Failure 401,409 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /auth/2fa/enroll [post]
+func (h *Handlers) EnrollTwoFactor(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	enrollment, err := h.service.Auth.EnrollTwoFactor(c, userID)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// ActivateTwoFactor godoc
+// @Summary Activate two-factor authentication
+// @Security ApiKeyAuth
+// @Tags auth
+// @Description Activate two-factor authentication with a TOTP code and get recovery codes. Recovery codes are shown only once.
+// @ID activate-two-factor
+// @Accept  json
+// @Produce  json
+// @Param code body domain.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} recoveryCodesResponse
+// @Failure 400,401,409 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /auth/2fa/activate [post]
+func (h *Handlers) ActivateTwoFactor(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	var request domain.TwoFactorCodeRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	codes, err := h.service.Auth.ActivateTwoFactor(c, userID, request.Code)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable two-factor authentication
+// @Security ApiKeyAuth
+// @Tags auth
+// @Description Disable two-factor authentication with a TOTP or recovery code
+// @ID disable-two-factor
+// @Accept  json
+// @Produce  json
+// @Param code body domain.TwoFactorCodeRequest true "TOTP or recovery code"
+// @Success 200
+// @Failure 400,401,409 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /auth/2fa/disable [post]
+func (h *Handlers) DisableTwoFactor(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	var request domain.TwoFactorCodeRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	if err = h.service.Auth.DisableTwoFactor(c, userID, request.Code); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// VerifyTwoFactor godoc
+// @Summary Complete sign in with two-factor code
+// @Tags auth
+// @Description Exchange the challenge token returned by sign in and a TOTP or recovery code for the tokens
+// @ID verify-two-factor
+// @Accept  json
+// @Produce  json
+// @Param request body domain.TwoFactorVerifyRequest true "challenge and code"
+// @Success 200 {string} string "access_token"
+// @Failure 400,401 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /auth/2fa/verify [post]
+func (h *Handlers) VerifyTwoFactor(c *gin.Context) {
+	var request domain.TwoFactorVerifyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	accessToken, refreshToken, err := h.service.Auth.VerifyTwoFactor(c, request.ChallengeToken, request.Code)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	respondWithTokens(c, accessToken, refreshToken)
+}
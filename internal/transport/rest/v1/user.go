@@ -3,12 +3,18 @@ package v1
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/apperror"
 	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/lockout"
+	"github.com/popeskul/qna-go/internal/services/auth"
+	"math"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 )
 
@@ -22,30 +28,65 @@ type Auth interface {
 // SignUp godoc
 // @Summary Sign up
 // @Tags auth
-// @Description Sign up
+// @Description Sign up. The password must follow the password policy and must not be found in a data breach.
 // @ID sign-up
 // @Accept  json
 // @Produce  json
-// @Param user body domain.User true "user"
+// @Param user body domain.SignUpRequest true "user"
 // @Success 201
-// @Failure 400 {object} errorResponse
-// @Failure 500 {object} errorResponse
+// @Failure 400 {object} problemResponse
+// @Failure 500 {object} problemResponse
 // @Router /sign-up [post]
 func (h *Handlers) SignUp(c *gin.Context) {
-	var user domain.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	var request domain.SignUpRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
 		return
 	}
 
-	if err := h.service.Auth.CreateUser(c, user); err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	if err := h.service.Auth.CreateUser(c, request.User()); err != nil {
+		newErrorResponse(c, err)
 		return
 	}
 
 	c.Status(http.StatusCreated)
 }
 
+// ChangePassword godoc
+// @Summary Change password
+// @Security ApiKeyAuth
+// @Tags auth
+// @Description Change the password of the user. The new password must follow the password policy.
+// @ID change-password
+// @Accept  json
+// @Produce  json
+// @Param password body domain.ChangePasswordRequest true "passwords"
+// @Success 200
+// @Failure 400 {object} problemResponse
+// @Failure 401,403 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Router /auth/password [put]
+func (h *Handlers) ChangePassword(c *gin.Context) {
+	userID, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	var request domain.ChangePasswordRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
+		return
+	}
+
+	if err = h.service.Auth.ChangePassword(c, userID, request.CurrentPassword, request.NewPassword); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
 // SignIn godoc
 // @Summary Sign in
 // @Tags auth
@@ -53,52 +94,67 @@ func (h *Handlers) SignUp(c *gin.Context) {
 // @ID sign-in
 // @Accept  json
 // @Produce  json
-// @Param user body domain.User true "user"
+// @Param user body domain.SignInRequest true "user"
 // @Success 200 {string} string "access_token"
-// @Failure 400 {object} errorResponse
-// @Failure 500 {object} errorResponse
+// @Success 202 {object} twoFactorChallengeResponse
+// @Failure 400,429 {object} problemResponse
+// @Failure 500 {object} problemResponse
 // @Router /sign-in [post]
 func (h *Handlers) SignIn(c *gin.Context) {
-	var user domain.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	var request domain.SignInRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		newErrorResponse(c, apperror.Invalid(err))
 		return
 	}
 
-	accessToken, refreshToken, err := h.service.Auth.SignIn(c, user)
+	ctx := lockout.WithClientIP(c.Request.Context(), c.ClientIP())
+	accessToken, refreshToken, err := h.service.Auth.SignIn(ctx, request.User())
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
-		return
-	}
-
-	c.Header("Set-Cookie", fmt.Sprintf("refresh-accessToken='%s'; HttpOnly", refreshToken))
-	if err = updateSession(c, accessToken); err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		var retry *lockout.RetryError
+		if errors.As(err, &retry) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retry.RetryAfter.Seconds()))))
+			newErrorResponse(c, err)
+			return
+		}
+
+		var challenge *auth.ChallengeError
+		if errors.As(err, &challenge) {
+			c.JSON(http.StatusAccepted, twoFactorChallengeResponse{
+				ChallengeToken: challenge.Token,
+				ExpiresAt:      challenge.ExpiresAt,
+			})
+			return
+		}
+
+		newErrorResponse(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, map[string]interface{}{
-		"accessToken": accessToken,
-	})
+	respondWithTokens(c, accessToken, refreshToken)
 }
 
 func (h *Handlers) Refresh(c *gin.Context) {
 	token, err := c.Cookie("refresh-accessToken")
 	if err != nil {
-		newErrorResponse(c, http.StatusUnauthorized, "empty auth header")
+		newErrorResponse(c, auth.ErrInvalidRefreshToken)
 		return
 	}
 
 	accessToken, refreshToken, err := h.service.GenerateAccessRefreshTokens(c, token)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		newErrorResponse(c, err)
 		return
 	}
 
-	c.Header("Set-Cookie", fmt.Sprintf("refresh-accessToken='%s'; HttpOnly", refreshToken))
+	respondWithTokens(c, accessToken, refreshToken)
+}
+
+// respondWithTokens stores the tokens in the cookies and the session and writes the access token to the body.
+func respondWithTokens(c *gin.Context, accessToken, refreshToken string) {
+	c.Header("Set-Cookie", fmt.Sprintf("refresh-accessToken=%s; Path=/; HttpOnly", refreshToken))
 
-	if err = updateSession(c, accessToken); err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	if err := updateSession(c, accessToken); err != nil {
+		newErrorResponse(c, err)
 		return
 	}
 
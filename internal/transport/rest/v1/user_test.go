@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
+	"github.com/popeskul/qna-go/internal/domain"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -24,6 +25,14 @@ func TestAuth_SignUp(t *testing.T) {
 	invalidUniqueEmailJSON, _ := json.Marshal(u)
 	badJSON := []byte(`bad request`)
 
+	weak := randomUser()
+	weak.Password = "short"
+	weakPasswordJSON, _ := json.Marshal(weak)
+
+	personal := randomUser()
+	personal.Password = "my-" + personal.Name
+	personalPasswordJSON, _ := json.Marshal(personal)
+
 	tests := []struct {
 		name   string
 		user   []byte
@@ -37,13 +46,23 @@ func TestAuth_SignUp(t *testing.T) {
 		{
 			name:   "Error: with existing email",
 			user:   invalidUniqueEmailJSON,
-			status: http.StatusInternalServerError,
+			status: http.StatusConflict,
 		},
 		{
 			name:   "Error: with invalid json",
 			user:   badJSON,
 			status: http.StatusBadRequest,
 		},
+		{
+			name:   "Error: with too short password",
+			user:   weakPasswordJSON,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Error: with name in password",
+			user:   personalPasswordJSON,
+			status: http.StatusBadRequest,
+		},
 	}
 
 	for _, tt := range tests {
@@ -106,7 +125,7 @@ func TestAuth_SignIn(t *testing.T) {
 		{
 			name:   "Error: with invalid email",
 			user:   invalidJSON,
-			status: http.StatusInternalServerError,
+			status: http.StatusUnauthorized,
 		},
 	}
 
@@ -129,3 +148,71 @@ func TestAuth_SignIn(t *testing.T) {
 		helperDeleteUserByID(t, userID)
 	})
 }
+
+func TestAuth_ChangePassword(t *testing.T) {
+	ctx := context.Background()
+	u := randomUser()
+
+	helperCreatUser(t, ctx, u)
+	userID, err := findUserIDByEmail(u.Email)
+	if err != nil {
+		t.Fatalf("error finding user id: %v", err)
+	}
+
+	token, refreshToken, err := mockServices.Auth.SignIn(ctx, u)
+	if err != nil {
+		t.Fatalf("error generating accessToken: %v", err)
+	}
+
+	newPassword := "correct horse battery"
+
+	tests := []struct {
+		name   string
+		input  domain.ChangePasswordRequest
+		status int
+	}{
+		{
+			name:   "Error: wrong current password",
+			input:  domain.ChangePasswordRequest{CurrentPassword: "wrong password", NewPassword: newPassword},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "Error: new password breaks the policy",
+			input:  domain.ChangePasswordRequest{CurrentPassword: u.Password, NewPassword: "short"},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Success: change password",
+			input:  domain.ChangePasswordRequest{CurrentPassword: u.Password, NewPassword: newPassword},
+			status: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, _ := json.Marshal(tt.input)
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/auth/password", bytes.NewReader(input))
+			req.Header.Set("Content-Type", "application/json")
+
+			r := gin.Default()
+			r.Use(sessions.Sessions("session", mockHandlers.store))
+			r.PUT("/api/v1/auth/password", setSessionMiddleware(t, token), mockHandlers.authMiddleware, mockHandlers.noDelegationMiddleware, mockHandlers.ChangePassword)
+
+			testHTTPResponse(t, r, req, func(w *httptest.ResponseRecorder) bool {
+				return w.Code == tt.status
+			})
+		})
+	}
+
+	u.Password = newPassword
+	_, newRefreshToken, err := mockServices.Auth.SignIn(ctx, u)
+	if err != nil {
+		t.Errorf("error signing in with the new password: %v", err)
+	}
+
+	t.Cleanup(func() {
+		helperDeleteUserByID(t, userID)
+		helperDeleteRefreshTokenByToken(t, refreshToken)
+		helperDeleteRefreshTokenByToken(t, newRefreshToken)
+	})
+}
@@ -0,0 +1,244 @@
+// Package ws serves the live quiz sessions of the live package over WebSocket.
+// The clients are authenticated with the access token or the API key in the authorization header,
+// or with the access token in the access_token query parameter because the browsers can't set the headers of WebSocket.
+package ws
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/popeskul/qna-go/internal/apperror"
+	"github.com/popeskul/qna-go/internal/live"
+	"github.com/popeskul/qna-go/internal/logger"
+	"github.com/popeskul/qna-go/internal/policy"
+	"github.com/popeskul/qna-go/internal/services"
+	"github.com/popeskul/qna-go/internal/token"
+	"github.com/popeskul/qna-go/internal/transport/rest/v1"
+)
+
+const (
+	authorizationHeader = "Authorization"
+	accessTokenParam    = "access_token"
+	bearerScheme        = "Bearer"
+
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = pongWait * 9 / 10
+	maxMessageSize = 1024
+)
+
+var ErrInvalidTestID = errors.New("invalid test id")
+
+// Handler upgrades the requests of the hosts and the players to WebSocket and connects them to the rooms.
+type Handler struct {
+	service  *services.Service
+	rooms    *live.Manager
+	logger   *logger.Logger
+	upgrader websocket.Upgrader
+}
+
+// NewHandler creates a new Handler with the necessary dependencies.
+func NewHandler(service *services.Service, rooms *live.Manager, log *logger.Logger) *Handler {
+	return &Handler{
+		service: service,
+		rooms:   rooms,
+		logger:  log,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+		},
+	}
+}
+
+// Init initializes the routes of the live sessions.
+func (h *Handler) Init(api *gin.RouterGroup) *gin.RouterGroup {
+	api.GET("/tests/:id/host", h.Host)
+	api.GET("/rooms/:pin", h.Join)
+
+	return api
+}
+
+// Host godoc
+// @Summary Host a live session
+// @Security ApiKeyAuth
+// @Tags live
+// @Description Open the room for the test and connect the host to it over WebSocket.
+// @Description The first message is "room" with the PIN for the players. The host sends {"type": "next"}
+// @Description to start the quiz and to advance the questions and {"type": "end"} to finish it.
+// @ID live-host
+// @Param id path int true "test id"
+// @Param access_token query string false "access token if the authorization header can't be set"
+// @Success 101
+// @Failure 400,401,403,404 {object} v1.problemResponse
+// @Failure 500 {object} v1.problemResponse
+// @Router /live/tests/{id}/host [get]
+func (h *Handler) Host(c *gin.Context) {
+	payload, err := h.authenticate(c)
+	if err != nil {
+		v1.WriteError(c, err)
+		return
+	}
+
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || testID <= 0 {
+		v1.WriteError(c, apperror.Invalid(ErrInvalidTestID))
+		return
+	}
+
+	quiz, err := h.loadQuiz(c, services.SubjectOf(payload), testID)
+	if err != nil {
+		v1.WriteError(c, err)
+		return
+	}
+
+	host, err := h.rooms.Open(payload.UserID, quiz)
+	if err != nil {
+		v1.WriteError(c, err)
+		return
+	}
+
+	h.serve(c, host)
+}
+
+// Join godoc
+// @Summary Join a live session
+// @Security ApiKeyAuth
+// @Tags live
+// @Description Join the room with the PIN and connect the player to it over WebSocket.
+// @Description The player answers the question with {"type": "answer", "answer_id": 1}.
+// @ID live-join
+// @Param pin path string true "PIN of the room"
+// @Param nickname query string true "nickname in the leaderboard"
+// @Param access_token query string false "access token if the authorization header can't be set"
+// @Success 101
+// @Failure 400,401,404,409 {object} v1.problemResponse
+// @Failure 500 {object} v1.problemResponse
+// @Router /live/rooms/{pin} [get]
+func (h *Handler) Join(c *gin.Context) {
+	payload, err := h.authenticate(c)
+	if err != nil {
+		v1.WriteError(c, err)
+		return
+	}
+
+	player, err := h.rooms.Join(c.Param("pin"), payload.UserID, strings.TrimSpace(c.Query("nickname")))
+	if err != nil {
+		v1.WriteError(c, err)
+		return
+	}
+
+	h.serve(c, player)
+}
+
+// loadQuiz returns the quiz of the test if the subject can edit the test, the host sees the correct answers.
+func (h *Handler) loadQuiz(c *gin.Context, subject policy.Subject, testID int) (live.Quiz, error) {
+	test, err := h.service.Tests.AuthorizeTest(c, subject, testID, policy.UpdateTest)
+	if err != nil {
+		return live.Quiz{}, err
+	}
+
+	questions, err := h.service.Tests.GetQuestions(c, []int{testID})
+	if err != nil {
+		return live.Quiz{}, err
+	}
+
+	questionIDs := make([]int, 0, len(questions))
+	for _, q := range questions {
+		questionIDs = append(questionIDs, q.ID)
+	}
+
+	answers, err := h.service.Tests.GetAnswers(c, questionIDs)
+	if err != nil {
+		return live.Quiz{}, err
+	}
+
+	return live.NewQuiz(test, questions, answers)
+}
+
+// serve upgrades the connection and pumps the messages between it and the room.
+// The client leaves the room when the connection is closed.
+func (h *Handler) serve(c *gin.Context, client *live.Client) {
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// the upgrader has already replied with the error
+		client.Leave()
+		return
+	}
+
+	go h.write(conn, client)
+	h.read(conn, client)
+}
+
+// read passes the messages of the connection to the room until the connection is closed.
+func (h *Handler) read(conn *websocket.Conn, client *live.Client) {
+	defer func() {
+		client.Leave()
+		conn.Close()
+	}()
+
+	conn.SetReadLimit(maxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		var msg live.ClientMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				h.logger.Infof("%s: [WS] - %s", time.Now().Format(time.RFC3339), err)
+			}
+			return
+		}
+
+		client.Handle(msg)
+	}
+}
+
+// write sends the messages of the room to the connection and pings it.
+// The connection is closed when the room closes the messages of the client.
+func (h *Handler) write(conn *websocket.Conn, client *live.Client) {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+
+	for {
+		select {
+		case msg, ok := <-client.Messages():
+			_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+
+			if err := conn.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// authenticate verifies the credential of the authorization header or the access token of the access_token
+// query parameter with the service, like the REST and gRPC transports do. The header takes precedence.
+func (h *Handler) authenticate(c *gin.Context) (*token.Payload, error) {
+	header := c.GetHeader(authorizationHeader)
+	if header == "" {
+		if accessToken := c.Query(accessTokenParam); accessToken != "" {
+			header = bearerScheme + " " + accessToken
+		}
+	}
+
+	return h.service.Authenticate(c, header, "")
+}
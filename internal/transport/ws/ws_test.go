@@ -0,0 +1,273 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/popeskul/qna-go/internal/domain"
+	"github.com/popeskul/qna-go/internal/live"
+	"github.com/popeskul/qna-go/internal/logger"
+	"github.com/popeskul/qna-go/internal/policy"
+	"github.com/popeskul/qna-go/internal/repository/tests"
+	"github.com/popeskul/qna-go/internal/services"
+	"github.com/popeskul/qna-go/internal/token"
+	"github.com/popeskul/qna-go/internal/util"
+)
+
+const (
+	authorID  = 1
+	learnerID = 2
+	quizID    = 1
+	emptyID   = 2
+	brokenID  = 3
+)
+
+// errDatabase is the internal cause the clients must never see.
+var errDatabase = errors.New("pq: connection refused")
+
+// fakeTests has the quiz of the author and the test without questions, only the author can edit them.
+type fakeTests struct {
+	services.Tests
+}
+
+func (t *fakeTests) AuthorizeTest(_ context.Context, subject policy.Subject, testID int, _ policy.Permission) (domain.Test, error) {
+	if testID != quizID && testID != emptyID && testID != brokenID {
+		return domain.Test{}, tests.ErrTestNotFound
+	}
+
+	test := domain.Test{ID: testID, Title: "Go basics", AuthorID: authorID}
+	if subject.UserID != test.AuthorID {
+		return test, policy.ErrForbidden
+	}
+
+	return test, nil
+}
+
+func (t *fakeTests) GetQuestions(_ context.Context, testIDs []int) ([]domain.Question, error) {
+	if testIDs[0] == brokenID {
+		return nil, errDatabase
+	}
+	if testIDs[0] != quizID {
+		return nil, nil
+	}
+
+	return []domain.Question{{ID: 1, TestID: quizID, Body: "2 + 2"}}, nil
+}
+
+func (t *fakeTests) GetAnswers(_ context.Context, _ []int) ([]domain.Answer, error) {
+	return []domain.Answer{
+		{ID: 1, QuestionID: 1, Title: "4", Correct: true},
+		{ID: 2, QuestionID: 1, Title: "5"},
+	}, nil
+}
+
+// fakeAuth verifies the access tokens with the token manager.
+type fakeAuth struct {
+	services.Auth
+	tokenManager token.Manager
+}
+
+func (a *fakeAuth) VerifyToken(_ context.Context, accessToken string) (*token.Payload, error) {
+	return a.tokenManager.VerifyToken(accessToken)
+}
+
+type testServer struct {
+	url          string
+	tokenManager token.Manager
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	tokenManager, err := token.NewPasetoManager(util.RandomString(32))
+	if err != nil {
+		t.Fatalf("error creating token manager: %v", err)
+	}
+
+	service := &services.Service{Auth: &fakeAuth{tokenManager: tokenManager}, Tests: &fakeTests{}, TokenMaker: tokenManager}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewHandler(service, live.NewManager(live.Config{}), logger.GetLogger()).Init(router.Group("/live"))
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	return &testServer{url: "ws" + strings.TrimPrefix(srv.URL, "http"), tokenManager: tokenManager}
+}
+
+func (s *testServer) token(t *testing.T, userID int) string {
+	t.Helper()
+
+	accessToken, err := s.tokenManager.CreateToken(token.Claims{UserID: userID, Role: string(domain.RoleAuthor)}, time.Minute)
+	if err != nil {
+		t.Fatalf("error creating token: %v", err)
+	}
+
+	return accessToken
+}
+
+// dial connects to the path, the response is returned for the rejected upgrade.
+func (s *testServer) dial(t *testing.T, path string, header http.Header) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+
+	conn, resp, err := websocket.DefaultDialer.Dial(s.url+path, header)
+	if conn != nil {
+		t.Cleanup(func() { conn.Close() })
+	}
+
+	return conn, resp, err
+}
+
+// readMessage returns the next message of the type skipping the others.
+func readMessage(t *testing.T, conn *websocket.Conn, messageType string) map[string]interface{} {
+	t.Helper()
+
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		var msg struct {
+			Type    string                 `json:"type"`
+			Payload map[string]interface{} `json:"payload"`
+		}
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("error reading %s message: %v", messageType, err)
+		}
+
+		if msg.Type == messageType {
+			return msg.Payload
+		}
+	}
+}
+
+func TestHandler_Game(t *testing.T) {
+	s := newTestServer(t)
+
+	host, _, err := s.dial(t, "/live/tests/1/host", http.Header{authorizationHeader: {"Bearer " + s.token(t, authorID)}})
+	if err != nil {
+		t.Fatalf("error connecting the host: %v", err)
+	}
+	pin := readMessage(t, host, live.MessageRoom)["pin"].(string)
+
+	query := url.Values{"nickname": {"alice"}, accessTokenParam: {s.token(t, learnerID)}}
+	player, _, err := s.dial(t, "/live/rooms/"+pin+"?"+query.Encode(), nil)
+	if err != nil {
+		t.Fatalf("error connecting the player: %v", err)
+	}
+	if room := readMessage(t, player, live.MessageRoom); room["nickname"] != "alice" || room["title"] != "Go basics" {
+		t.Errorf("unexpected room: %v", room)
+	}
+
+	if err = host.WriteJSON(live.ClientMessage{Type: live.CommandNext}); err != nil {
+		t.Fatalf("error starting the quiz: %v", err)
+	}
+	question := readMessage(t, player, live.MessageQuestion)
+	for _, answer := range question["answers"].([]interface{}) {
+		if _, ok := answer.(map[string]interface{})["correct"]; ok {
+			t.Errorf("the correct answer is sent to the player: %v", answer)
+		}
+	}
+
+	if err = player.WriteJSON(live.ClientMessage{Type: live.CommandAnswer, AnswerID: 1}); err != nil {
+		t.Fatalf("error answering: %v", err)
+	}
+	result := readMessage(t, player, live.MessageResult)
+	if you := result["you"].(map[string]interface{}); you["correct"] != true || you["rank"].(float64) != 1 {
+		t.Errorf("unexpected result: %v", you)
+	}
+
+	if err = host.WriteJSON(live.ClientMessage{Type: live.CommandNext}); err != nil {
+		t.Fatalf("error finishing the quiz: %v", err)
+	}
+	readMessage(t, host, live.MessageFinished)
+
+	// the room closes the connections when the quiz is finished
+	_ = player.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		if _, _, err = player.ReadMessage(); err != nil {
+			break
+		}
+	}
+	if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Errorf("expected the normal closure, got %v", err)
+	}
+}
+
+func TestHandler_Errors(t *testing.T) {
+	s := newTestServer(t)
+
+	host, _, err := s.dial(t, "/live/tests/1/host?"+accessTokenParam+"="+s.token(t, authorID), nil)
+	if err != nil {
+		t.Fatalf("error connecting the host: %v", err)
+	}
+	pin := readMessage(t, host, live.MessageRoom)["pin"].(string)
+
+	if _, _, err = s.dial(t, "/live/rooms/"+pin+"?nickname=alice&"+accessTokenParam+"="+s.token(t, learnerID), nil); err != nil {
+		t.Fatalf("error connecting the player: %v", err)
+	}
+
+	authorToken := http.Header{authorizationHeader: {"Bearer " + s.token(t, authorID)}}
+	learnerToken := http.Header{authorizationHeader: {"Bearer " + s.token(t, learnerID)}}
+
+	tests := []struct {
+		name       string
+		path       string
+		header     http.Header
+		statusCode int
+		code       string
+	}{
+		{name: "Error: without token", path: "/live/tests/1/host", statusCode: http.StatusUnauthorized, code: "token_missing"},
+		{name: "Error: invalid token", path: "/live/rooms/" + pin + "?nickname=bob", header: http.Header{authorizationHeader: {"Bearer invalid"}}, statusCode: http.StatusUnauthorized, code: "token_invalid"},
+		{name: "Error: invalid authorization header", path: "/live/tests/1/host", header: http.Header{authorizationHeader: {"invalid"}}, statusCode: http.StatusUnauthorized, code: "invalid_authorization_header"},
+		{name: "Error: invalid test id", path: "/live/tests/abc/host", header: authorToken, statusCode: http.StatusBadRequest, code: "invalid_request"},
+		{name: "Error: test not found", path: "/live/tests/100/host", header: authorToken, statusCode: http.StatusNotFound, code: "test_not_found"},
+		{name: "Error: not the author", path: "/live/tests/1/host", header: learnerToken, statusCode: http.StatusForbidden, code: "forbidden"},
+		{name: "Error: test without questions", path: "/live/tests/2/host", header: authorToken, statusCode: http.StatusBadRequest, code: "empty_quiz"},
+		{name: "Error: internal", path: "/live/tests/3/host", header: authorToken, statusCode: http.StatusInternalServerError, code: "internal"},
+		{name: "Error: room not found", path: "/live/rooms/pin?nickname=bob", header: learnerToken, statusCode: http.StatusNotFound, code: "room_not_found"},
+		{name: "Error: without nickname", path: "/live/rooms/" + pin, header: learnerToken, statusCode: http.StatusBadRequest, code: "invalid_nickname"},
+		{name: "Error: nickname taken", path: "/live/rooms/" + pin + "?nickname=alice", header: learnerToken, statusCode: http.StatusConflict, code: "nickname_taken"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, resp, err := s.dial(t, tt.path, tt.header)
+			if err == nil {
+				t.Fatal("expected the upgrade to be rejected")
+			}
+
+			if resp == nil || resp.StatusCode != tt.statusCode {
+				t.Fatalf("expected status %d, got %v", tt.statusCode, resp)
+			}
+
+			if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/problem+json") {
+				t.Errorf("Content-Type = %q, want application/problem+json", ct)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var problem struct {
+				Code string `json:"code"`
+			}
+			if err = json.Unmarshal(body, &problem); err != nil || problem.Code != tt.code {
+				t.Errorf("code = %q, want %q: %s", problem.Code, tt.code, body)
+			}
+			if strings.Contains(string(body), errDatabase.Error()) {
+				t.Errorf("the internal cause is returned: %s", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+// Package validation checks the request DTOs against the rules of their `binding` tags.
+// It replaces the validator of gin, so every bound request is checked and the broken rules
+// are reported per field with the names the clients send.
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// TagName is the tag of the rules, the same gin uses.
+const TagName = "binding"
+
+// The custom rules.
+const (
+	// RuleNotBlank requires the string to have other characters than the spaces.
+	RuleNotBlank = "notblank"
+	// RuleValid requires the value to report itself valid by its Valid method, e.g. a role.
+	RuleValid = "valid"
+)
+
+// FieldError is a rule the field breaks.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Errors lists all the rules the request breaks.
+type Errors []FieldError
+
+// Error joins the messages of the fields.
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Field + " " + fieldErr.Message
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// validatable is implemented by the enumerations checked with RuleValid.
+type validatable interface {
+	Valid() bool
+}
+
+// Validator checks the structs, it implements binding.StructValidator of gin.
+type Validator struct {
+	validate *validator.Validate
+}
+
+// New creates a new Validator with the custom rules.
+func New() *Validator {
+	validate := validator.New()
+	validate.SetTagName(TagName)
+	validate.RegisterTagNameFunc(fieldName)
+
+	// the rules are static, the registration fails only on a programming error
+	if err := validate.RegisterValidation(RuleNotBlank, notBlank); err != nil {
+		panic(err)
+	}
+	if err := validate.RegisterValidation(RuleValid, valid); err != nil {
+		panic(err)
+	}
+
+	return &Validator{validate: validate}
+}
+
+// ValidateStruct checks the struct or the pointer to it, the other values are not checked.
+// The broken rules are returned as Errors.
+func (v *Validator) ValidateStruct(obj interface{}) error {
+	if obj == nil {
+		return nil
+	}
+
+	value := reflect.ValueOf(obj)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	err := v.validate.Struct(value.Interface())
+	if err == nil {
+		return nil
+	}
+
+	validationErrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return err
+	}
+
+	errs := make(Errors, len(validationErrs))
+	for i, fieldErr := range validationErrs {
+		errs[i] = FieldError{
+			Field:   fieldPath(fieldErr.Namespace()),
+			Rule:    fieldErr.Tag(),
+			Message: message(fieldErr),
+		}
+	}
+
+	return errs
+}
+
+// Engine returns the underlying validator to register more rules.
+func (v *Validator) Engine() interface{} {
+	return v.validate
+}
+
+// fieldName is the name of the field in the json, the form or the query, the struct field name otherwise.
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+// fieldPath drops the name of the struct from the namespace: "SignUpRequest.email" becomes "email".
+func fieldPath(namespace string) string {
+	if i := strings.IndexByte(namespace, '.'); i >= 0 {
+		return namespace[i+1:]
+	}
+
+	return namespace
+}
+
+func notBlank(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	if field.Kind() != reflect.String {
+		return true
+	}
+
+	return strings.IndexFunc(field.String(), func(r rune) bool { return !unicode.IsSpace(r) }) >= 0
+}
+
+func valid(fl validator.FieldLevel) bool {
+	v, ok := fl.Field().Interface().(validatable)
+
+	return !ok || v.Valid()
+}
+
+// message describes the broken rule for the client.
+func message(fieldErr validator.FieldError) string {
+	param := fieldErr.Param()
+
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case RuleNotBlank:
+		return "must not be blank"
+	case RuleValid:
+		return fmt.Sprintf("%v is not a valid value", fieldErr.Value())
+	case "email":
+		return "must be a valid email"
+	case "url":
+		return "must be a valid URL"
+	case "oneof":
+		return "must be one of: " + param
+	case "required_without":
+		return "is required unless " + strings.ToLower(param) + " is set"
+	case "excluded_with":
+		return "must not be set with " + strings.ToLower(param)
+	case "min", "max", "len":
+		return lengthMessage(fieldErr.Tag(), fieldErr.Kind(), param)
+	case "gt", "gte", "lt", "lte":
+		return fmt.Sprintf("must be %s %s", comparisons[fieldErr.Tag()], param)
+	default:
+		return "is invalid"
+	}
+}
+
+var comparisons = map[string]string{
+	"gt":  "greater than",
+	"gte": "at least",
+	"lt":  "less than",
+	"lte": "at most",
+	"min": "at least",
+	"max": "at most",
+	"len": "exactly",
+}
+
+// lengthMessage describes the rules limiting the length of the strings and the slices or the numbers.
+func lengthMessage(tag string, kind reflect.Kind, param string) string {
+	switch kind {
+	case reflect.String:
+		return fmt.Sprintf("must be %s %s characters long", comparisons[tag], param)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return fmt.Sprintf("must contain %s %s items", comparisons[tag], param)
+	default:
+		return fmt.Sprintf("must be %s %s", comparisons[tag], param)
+	}
+}
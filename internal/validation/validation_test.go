@@ -0,0 +1,105 @@
+package validation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/popeskul/qna-go/internal/domain"
+)
+
+type request struct {
+	Title  string        `json:"title" binding:"required,notblank,min=3,max=10"`
+	Email  string        `json:"email" binding:"omitempty,email"`
+	Tags   []string      `json:"tags" binding:"max=2,dive,notblank"`
+	Page   int           `form:"page" binding:"omitempty,min=1"`
+	Role   domain.Role   `json:"role" binding:"omitempty,valid"`
+	Hidden string        `json:"-" binding:"max=1"`
+	Nested nestedRequest `json:"nested"`
+}
+
+type nestedRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+func TestValidator_ValidateStruct(t *testing.T) {
+	v := New()
+
+	tests := []struct {
+		name    string
+		request interface{}
+		errs    Errors
+	}{
+		{
+			name:    "valid request",
+			request: &request{Title: "title", Email: "a@b.co", Tags: []string{"go"}, Page: 1, Role: domain.RoleAuthor, Nested: nestedRequest{Name: "n"}},
+		},
+		{
+			name:    "all rules broken",
+			request: request{Title: "   ", Email: "email", Tags: []string{"a", " ", "c"}, Role: "root"},
+			errs: Errors{
+				{Field: "title", Rule: "notblank", Message: "must not be blank"},
+				{Field: "email", Rule: "email", Message: "must be a valid email"},
+				{Field: "tags", Rule: "max", Message: "must contain at most 2 items"},
+				{Field: "role", Rule: "valid", Message: "root is not a valid value"},
+				{Field: "nested.name", Rule: "required", Message: "is required"},
+			},
+		},
+		{
+			name:    "lengths and numbers",
+			request: &request{Title: "ab", Tags: []string{" "}, Page: -1, Nested: nestedRequest{Name: "n"}},
+			errs: Errors{
+				{Field: "title", Rule: "min", Message: "must be at least 3 characters long"},
+				{Field: "tags[0]", Rule: "notblank", Message: "must not be blank"},
+				{Field: "page", Rule: "min", Message: "must be at least 1"},
+			},
+		},
+		{
+			name: "version of the batch",
+			request: domain.TestBatchRequest{
+				Update: []domain.TestUpdate{{ID: 1, Title: "title"}, {ID: 2, Any: true, Title: "title"}, {ID: 3, Version: 2, Any: true, Title: "title"}},
+				Delete: []domain.TestDelete{{ID: 1, Version: 1}, {ID: 2}},
+			},
+			errs: Errors{
+				{Field: "update[0].version", Rule: "required_without", Message: "is required unless any is set"},
+				{Field: "update[2].version", Rule: "excluded_with", Message: "must not be set with any"},
+				{Field: "delete[1].version", Rule: "required_without", Message: "is required unless any is set"},
+			},
+		},
+		{
+			name:    "not a struct",
+			request: []request{{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.ValidateStruct(tt.request)
+
+			if tt.errs == nil {
+				if err != nil {
+					t.Fatalf("ValidateStruct() = %v, want nil", err)
+				}
+				return
+			}
+
+			errs, ok := err.(Errors)
+			if !ok {
+				t.Fatalf("ValidateStruct() = %T %v, want Errors", err, err)
+			}
+			if !reflect.DeepEqual(errs, tt.errs) {
+				t.Errorf("ValidateStruct() = %+v, want %+v", errs, tt.errs)
+			}
+		})
+	}
+}
+
+func TestErrors_Error(t *testing.T) {
+	errs := Errors{
+		{Field: "title", Rule: "required", Message: "is required"},
+		{Field: "email", Rule: "email", Message: "must be a valid email"},
+	}
+
+	if got, want := errs.Error(), "title is required; email must be a valid email"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,10 @@
+CREATE TABLE test_members
+(
+    id SERIAL NOT NULL UNIQUE,
+    test_id BIGINT NOT NULL REFERENCES tests (id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at TIMESTAMP NOT NULL DEFAULT (now()),
+    UNIQUE (test_id, user_id)
+);
@@ -0,0 +1,16 @@
+CREATE TABLE login_attempts
+(
+    key VARCHAR(320) NOT NULL UNIQUE,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP NOT NULL
+);
+
+CREATE TABLE lockout_events
+(
+    id SERIAL NOT NULL UNIQUE,
+    key VARCHAR(320) NOT NULL,
+    failures INT NOT NULL,
+    locked_until TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (now())
+);
@@ -0,0 +1,15 @@
+CREATE TABLE data_exports
+(
+    id SERIAL NOT NULL UNIQUE,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    error TEXT NOT NULL DEFAULT '',
+    archive BYTEA,
+    link_secret BYTEA NOT NULL,
+    expires_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at TIMESTAMP NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX data_exports_user_id_idx ON data_exports (user_id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS audit_log;
+
+ALTER TABLE users DROP COLUMN IF EXISTS disabled_reason;
+ALTER TABLE users DROP COLUMN IF EXISTS disabled_at;
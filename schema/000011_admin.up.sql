@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN disabled_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN disabled_reason TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE audit_log
+(
+    id BIGSERIAL NOT NULL UNIQUE,
+    actor_id BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    action VARCHAR(64) NOT NULL,
+    target_user_id BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX audit_log_actor_id_idx ON audit_log (actor_id);
+CREATE INDEX audit_log_target_user_id_idx ON audit_log (target_user_id);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS test_passages_test_id_user_id_idx;
+DROP INDEX IF EXISTS answers_question_id_idx;
+DROP INDEX IF EXISTS questions_test_id_idx;
+
+ALTER TABLE test_passages ADD CONSTRAINT test_passages_test_id_key UNIQUE (test_id);
+ALTER TABLE test_passages ADD CONSTRAINT test_passages_user_id_key UNIQUE (user_id);
+ALTER TABLE answers ADD CONSTRAINT answers_question_id_key UNIQUE (question_id);
+ALTER TABLE questions ADD CONSTRAINT questions_test_id_key UNIQUE (test_id);
@@ -0,0 +1,8 @@
+ALTER TABLE questions DROP CONSTRAINT IF EXISTS questions_test_id_key;
+ALTER TABLE answers DROP CONSTRAINT IF EXISTS answers_question_id_key;
+ALTER TABLE test_passages DROP CONSTRAINT IF EXISTS test_passages_user_id_key;
+ALTER TABLE test_passages DROP CONSTRAINT IF EXISTS test_passages_test_id_key;
+
+CREATE INDEX questions_test_id_idx ON questions (test_id);
+CREATE INDEX answers_question_id_idx ON answers (question_id);
+CREATE INDEX test_passages_test_id_user_id_idx ON test_passages (test_id, user_id);
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS test_passages_notify ON test_passages;
+DROP FUNCTION IF EXISTS notify_test_passage();
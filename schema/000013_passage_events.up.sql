@@ -0,0 +1,17 @@
+CREATE OR REPLACE FUNCTION notify_test_passage() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('test_passages', json_build_object(
+        'type', CASE WHEN TG_OP = 'INSERT' THEN 'finished' ELSE 'scored' END,
+        'passage_id', NEW.id,
+        'test_id', NEW.test_id,
+        'user_id', NEW.user_id,
+        'passed', NEW.passed,
+        'at', NEW.updated_at
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER test_passages_notify
+    AFTER INSERT OR UPDATE OF passed ON test_passages
+    FOR EACH ROW EXECUTE FUNCTION notify_test_passage();
@@ -0,0 +1 @@
+ALTER TABLE tests ADD COLUMN version INT NOT NULL DEFAULT 1;